@@ -0,0 +1,144 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pub
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RolloutAnalysis defines the metrics to be analyzed after each update batch of a workload.
+// The next batch will be held until all metrics have passed, and the rollout will be paused
+// if any of them fails.
+type RolloutAnalysis struct {
+	// Metrics is the list of metrics to be analyzed.
+	Metrics []AnalysisMetric `json:"metrics"`
+	// InitialDelaySeconds is the number of seconds to wait after all pods of the batch are ready,
+	// before the first measurement of the metrics.
+	// +optional
+	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// IntervalSeconds is the number of seconds between two measurements of the metrics.
+	// Defaults to 60.
+	// +optional
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty"`
+	// Count is the number of successful measurements required for each metric to pass.
+	// Defaults to 1.
+	// +optional
+	Count *int32 `json:"count,omitempty"`
+}
+
+// AnalysisMetric defines a metric to be measured by one of the providers.
+// Exactly one of the providers should be set.
+type AnalysisMetric struct {
+	// Name is the unique name of the metric.
+	Name string `json:"name"`
+	// Prometheus measures the metric by a Prometheus query.
+	// +optional
+	Prometheus *PrometheusAnalysisMetric `json:"prometheus,omitempty"`
+	// Web measures the metric by calling an HTTP webhook.
+	// +optional
+	Web *WebAnalysisMetric `json:"web,omitempty"`
+}
+
+// PrometheusAnalysisMetric measures the metric by a Prometheus query. Each sample of the query result
+// should be within [min, max], otherwise the metric fails. An empty result is considered as an error
+// and will be measured again later.
+type PrometheusAnalysisMetric struct {
+	// Address is the address of Prometheus server, such as http://prometheus.monitoring:9090.
+	// The loopback and link-local addresses are not allowed. Private addresses are only allowed within the networks configured by the manager.
+	Address string `json:"address"`
+	// Query is the PromQL to evaluate. The placeholders {{namespace}}, {{name}} and {{revision}} will
+	// be replaced with the namespace, name and update revision of the workload.
+	Query string `json:"query"`
+	// Min is the minimum value of each sample in decimal format, such as "0.99".
+	// +optional
+	Min *string `json:"min,omitempty"`
+	// Max is the maximum value of each sample in decimal format, such as "0.05".
+	// +optional
+	Max *string `json:"max,omitempty"`
+}
+
+// WebAnalysisMetric measures the metric by posting the information of the workload to an HTTP webhook.
+// A 2xx response means the metric passed, a 5xx response or an unreachable webhook will be measured
+// again later, and any other response means the metric failed.
+type WebAnalysisMetric struct {
+	// URL is the address of the webhook.
+	// The loopback and link-local addresses are not allowed. Private addresses are only allowed within the networks configured by the manager.
+	URL string `json:"url"`
+	// TimeoutSeconds is the timeout of each request. Defaults to 10.
+	// It should be no more than 30.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// AnalysisPhase is the phase of an analysis run or a metric.
+type AnalysisPhase string
+
+const (
+	// AnalysisPhasePending means the batch is not ready to be analyzed yet.
+	AnalysisPhasePending AnalysisPhase = "Pending"
+	// AnalysisPhaseRunning means the metrics are being measured.
+	AnalysisPhaseRunning AnalysisPhase = "Running"
+	// AnalysisPhaseSuccessful means all the metrics have passed.
+	AnalysisPhaseSuccessful AnalysisPhase = "Successful"
+	// AnalysisPhaseFailed means some of the metrics have failed.
+	AnalysisPhaseFailed AnalysisPhase = "Failed"
+	// AnalysisPhaseError means some of the metrics can not be measured, and they will be measured again later.
+	AnalysisPhaseError AnalysisPhase = "Error"
+)
+
+// AnalysisRunStatus is the status of the analysis of an update batch.
+type AnalysisRunStatus struct {
+	// UpdateRevision is the revision being analyzed.
+	UpdateRevision string `json:"updateRevision"`
+	// UpdatedReplicas is the number of updated replicas in the batch being analyzed.
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// Phase is the phase of the analysis run.
+	Phase AnalysisPhase `json:"phase"`
+	// Message is a human-readable message indicating details about the phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// MetricResults contains the results of each metric.
+	// +optional
+	MetricResults []AnalysisMetricResult `json:"metricResults,omitempty"`
+	// StartTime is the time when all pods of the batch became ready and the analysis started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// LastMeasureTime is the time of the last measurement.
+	// +optional
+	LastMeasureTime *metav1.Time `json:"lastMeasureTime,omitempty"`
+	// ObservedGeneration is the generation of the workload when the analysis run finished.
+	// A failed analysis run will be restarted if the workload has been resumed after that.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// AnalysisMetricResult is the result of measurements of a metric.
+type AnalysisMetricResult struct {
+	// Name is the name of the metric.
+	Name string `json:"name"`
+	// Phase is the phase of the metric.
+	Phase AnalysisPhase `json:"phase"`
+	// Value is the value of the last measurement.
+	// +optional
+	Value string `json:"value,omitempty"`
+	// Message is a human-readable message of the last measurement.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Successful is the number of successful measurements.
+	// +optional
+	Successful int32 `json:"successful,omitempty"`
+}
@@ -73,6 +73,16 @@ type InPlaceUpdateState struct {
 
 	// ContainerBatchesRecord records the update batches that have patched in this revision.
 	ContainerBatchesRecord []InPlaceUpdateContainerBatch `json:"containerBatchesRecord,omitempty"`
+
+	// UpdateResources indicates there are container resources that should be in-place resized.
+	UpdateResources bool `json:"updateResources,omitempty"`
+
+	// NextContainerResources is the containers with lower priority that waiting for in-place resize resources in next batch.
+	NextContainerResources map[string]v1.ResourceRequirements `json:"nextContainerResources,omitempty"`
+
+	// ResizeStatus is the latest observed resize status of Pod during in-place resize,
+	// which can be Proposed, InProgress, Deferred or Infeasible.
+	ResizeStatus v1.PodResizeStatus `json:"resizeStatus,omitempty"`
 }
 
 // InPlaceUpdatePreCheckBeforeNext contains the pre-check that must pass before the next containers can be in-place update.
@@ -92,6 +102,11 @@ type InPlaceUpdateContainerBatch struct {
 // to determine whether the InPlaceUpdate is completed.
 type InPlaceUpdateContainerStatus struct {
 	ImageID string `json:"imageID,omitempty"`
+	// Resources is the resources of container in spec before in-place resize.
+	Resources *v1.ResourceRequirements `json:"resources,omitempty"`
+	// RestartCount is the restart count of container before in-place resize, which is used to check
+	// whether the container has been restarted for the resources whose resizePolicy is RestartContainer.
+	RestartCount int32 `json:"restartCount,omitempty"`
 }
 
 // InPlaceUpdateStrategy defines the strategies for in-place update.
@@ -19,6 +19,8 @@ package pub
 const (
 	LifecycleStateKey     = "lifecycle.apps.kruise.io/state"
 	LifecycleTimestampKey = "lifecycle.apps.kruise.io/timestamp"
+	// LifecycleUpdateApprovedKey is the annotation which approves the Pod to be updated to the revision in its value.
+	LifecycleUpdateApprovedKey = "lifecycle.apps.kruise.io/update-approved"
 
 	// LifecycleStatePreparingNormal means the Pod is created but unavailable.
 	// It will translate to Normal state if Lifecycle.PreNormal is hooked.
@@ -51,6 +53,27 @@ type Lifecycle struct {
 	PreNormal *LifecycleHook `json:"preNormal,omitempty"`
 }
 
+// LifecycleApprovalHook is the hook to approve each Pod before it is updated.
+// A Pod is approved if it has the annotation lifecycle.apps.kruise.io/update-approved whose value is the
+// update revision, which could be set by an external controller.
+type LifecycleApprovalHook struct {
+	// Web is the webhook to approve the Pod. If set, the workload posts the Pod to the webhook asynchronously and
+	// annotates the Pod as approved once the webhook responds with a 2xx status code. Any other response or an
+	// unreachable webhook will be retried later.
+	// +optional
+	Web *LifecycleWebApproval `json:"web,omitempty"`
+}
+
+// LifecycleWebApproval approves the Pod by posting it to an HTTP webhook.
+type LifecycleWebApproval struct {
+	// URL is the address of the webhook. Loopback and link-local addresses are not allowed.
+	// Private addresses are only allowed within the networks configured by the manager.
+	URL string `json:"url"`
+	// TimeoutSeconds is the timeout of each request. Defaults to 10, and must not be greater than 30.
+	// +optional
+	TimeoutSeconds *int32 `json:"timeoutSeconds,omitempty"`
+}
+
 type LifecycleHook struct {
 	LabelsHandler     map[string]string `json:"labelsHandler,omitempty"`
 	FinalizersHandler []string          `json:"finalizersHandler,omitempty"`
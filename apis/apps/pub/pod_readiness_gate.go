@@ -27,4 +27,9 @@ const (
 	// the condition status will be considered as "True" only when all these writers
 	// set it to "True".
 	KruisePodReadyConditionType v1.PodConditionType = "KruisePodReady"
+
+	// BlueGreenHoldAnnotationKey is the annotation key of the new revision Pods created for BlueGreen update,
+	// which will be kept not ready until the workload switches to all of them. The podreadiness controller will not
+	// initialize the KruisePodReady condition of the Pods with this annotation.
+	BlueGreenHoldAnnotationKey = "apps.kruise.io/cloneset-blue-green-hold"
 )
@@ -34,6 +34,11 @@ const (
 	// CloneSetScalingExcludePreparingDeleteKey is the label key that enables scalingExcludePreparingDelete
 	// only for this CloneSet, which means it will calculate scale number excluding Pods in PreparingDelete state.
 	CloneSetScalingExcludePreparingDeleteKey = "apps.kruise.io/cloneset-scaling-exclude-preparing-delete"
+
+	// CloneSetApproveUpdateStepAnnotation is the annotation key to approve the update step that is waiting for
+	// manual approval, and its value should be the updateRevision and the index of the step in updateStrategy.steps,
+	// joined by "/" (e.g. "demo-7c9f8b6d5/1"), so that the approval will not be reused by the later rollouts.
+	CloneSetApproveUpdateStepAnnotation = "apps.kruise.io/cloneset-approve-update-step"
 )
 
 // CloneSetSpec defines the desired state of CloneSet
@@ -60,6 +65,10 @@ type CloneSetSpec struct {
 	// +kubebuilder:validation:Schemaless
 	VolumeClaimTemplates []v1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"`
 
+	// VolumeClaimUpdateStrategy indicates how the PVCs of existing pods are updated
+	// when VolumeClaimTemplates change.
+	VolumeClaimUpdateStrategy CloneSetVolumeClaimUpdateStrategy `json:"volumeClaimUpdateStrategy,omitempty"`
+
 	// ScaleStrategy indicates the ScaleStrategy that will be employed to
 	// create and delete Pods in the CloneSet.
 	ScaleStrategy CloneSetScaleStrategy `json:"scaleStrategy,omitempty"`
@@ -81,6 +90,34 @@ type CloneSetSpec struct {
 
 	// Lifecycle defines the lifecycle hooks for Pods pre-available(pre-normal), pre-delete, in-place update.
 	Lifecycle *appspub.Lifecycle `json:"lifecycle,omitempty"`
+
+	// ProgressDeadlineSeconds is the maximum time in seconds for a CloneSet to make progress before it
+	// is considered to be failed progressing. The time during which the CloneSet is paused, or has reached
+	// the expected updated replicas of partition, is not counted.
+	// The controller will add a Progressing condition with ProgressDeadlineExceeded reason to the status once
+	// the deadline has been exceeded.
+	// If unspecified, the Progressing condition will not be maintained.
+	ProgressDeadlineSeconds *int32 `json:"progressDeadlineSeconds,omitempty"`
+}
+
+// CloneSetVolumeClaimUpdateStrategyType defines how the PVCs of existing pods are updated.
+type CloneSetVolumeClaimUpdateStrategyType string
+
+const (
+	// OnDeleteCloneSetVolumeClaimUpdateStrategyType is the default type, which leaves the existing PVCs unchanged.
+	// Only the PVCs created for new pods will use the updated VolumeClaimTemplates.
+	OnDeleteCloneSetVolumeClaimUpdateStrategyType CloneSetVolumeClaimUpdateStrategyType = "OnDelete"
+	// OnlineResizeCloneSetVolumeClaimUpdateStrategyType expands the existing PVCs online without recreating pods,
+	// when storage requests increase in VolumeClaimTemplates. The storage class should allow volume expansion.
+	// Like updating pods, it respects the paused and partition of updateStrategy.
+	OnlineResizeCloneSetVolumeClaimUpdateStrategyType CloneSetVolumeClaimUpdateStrategyType = "OnlineResize"
+)
+
+// CloneSetVolumeClaimUpdateStrategy defines the strategy for updating the PVCs of existing pods.
+type CloneSetVolumeClaimUpdateStrategy struct {
+	// Type indicates the type of the CloneSetVolumeClaimUpdateStrategy.
+	// Default is OnDelete.
+	Type CloneSetVolumeClaimUpdateStrategyType `json:"type,omitempty"`
 }
 
 // CloneSetScaleStrategy defines strategies for pods scale.
@@ -97,6 +134,50 @@ type CloneSetScaleStrategy struct {
 	// Indicate if cloneSet will reuse already existed pvc to
 	// rebuild a new pod
 	DisablePVCReuse bool `json:"disablePVCReuse,omitempty"`
+
+	// ScaleDownDryRun indicates that the pods chosen to be deleted when scaling in will only be recorded
+	// in status.scaleDownPlan and an event with the reason of each pick, instead of being actually deleted.
+	// Operators can review the plan and then turn it off to let CloneSet delete the pods.
+	ScaleDownDryRun bool `json:"scaleDownDryRun,omitempty"`
+
+	// ScaleDownPolicy limits the rate of deleting pods when scaling in.
+	// Pods chosen beyond the allowed number will be held in PreparingDelete until they can be deleted.
+	ScaleDownPolicy *CloneSetScaleDownPolicy `json:"scaleDownPolicy,omitempty"`
+
+	// InstanceIDPolicy indicates how to generate instance-id for new pods, which is also the suffix of pod name.
+	// Default is Random.
+	InstanceIDPolicy CloneSetInstanceIDPolicyType `json:"instanceIDPolicy,omitempty"`
+}
+
+// CloneSetInstanceIDPolicyType defines how to generate instance-id for new pods.
+type CloneSetInstanceIDPolicyType string
+
+const (
+	// RandomCloneSetInstanceIDPolicyType generates random strings as instance-id,
+	// unless there is any free PVC whose instance-id can be reused.
+	RandomCloneSetInstanceIDPolicyType CloneSetInstanceIDPolicyType = "Random"
+	// LowestAvailableOrdinalCloneSetInstanceIDPolicyType uses the lowest numbers that are not used by existing pods
+	// as instance-id, like the ordinals of StatefulSet. The PVCs of the same instance-id will be reused if they exist.
+	LowestAvailableOrdinalCloneSetInstanceIDPolicyType CloneSetInstanceIDPolicyType = "LowestAvailableOrdinal"
+)
+
+// CloneSetScaleDownPolicy defines the rate limit and stabilization window for scaling in.
+type CloneSetScaleDownPolicy struct {
+	// MaxPodsPerInterval is the maximum number of pods that can be deleted for scaling in during each interval.
+	// Value can be an absolute number (ex: 5) or a percentage of current pods (ex: 10%).
+	// Absolute number is calculated from percentage by rounding up.
+	// Defaults to no limit.
+	// +optional
+	MaxPodsPerInterval *intstr.IntOrString `json:"maxPodsPerInterval,omitempty"`
+	// IntervalSeconds is the length of the interval that maxPodsPerInterval applies to.
+	// Defaults to 60.
+	// +optional
+	IntervalSeconds *int32 `json:"intervalSeconds,omitempty"`
+	// StabilizationWindowSeconds is the number of seconds after the last scale-up
+	// during which no pod will be deleted for scaling in.
+	// Defaults to 0.
+	// +optional
+	StabilizationWindowSeconds int32 `json:"stabilizationWindowSeconds,omitempty"`
 }
 
 // CloneSetUpdateStrategy defines strategies for pods update.
@@ -124,6 +205,10 @@ type CloneSetUpdateStrategy struct {
 	// Paused indicates that the CloneSet is paused.
 	// Default value is false
 	Paused bool `json:"paused,omitempty"`
+	// PauseOnProgressDeadlineExceeded indicates that the controller will set Paused to true
+	// once the CloneSet has exceeded its ProgressDeadlineSeconds.
+	// Default value is false
+	PauseOnProgressDeadlineExceeded bool `json:"pauseOnProgressDeadlineExceeded,omitempty"`
 	// Priorities are the rules for calculating the priority of updating pods.
 	// Each pod to be updated, will pass through these terms and get a sum of weights.
 	PriorityStrategy *appspub.UpdatePriorityStrategy `json:"priorityStrategy,omitempty"`
@@ -134,6 +219,50 @@ type CloneSetUpdateStrategy struct {
 	ScatterStrategy UpdateScatterStrategy `json:"scatterStrategy,omitempty"`
 	// InPlaceUpdateStrategy contains strategies for in-place update.
 	InPlaceUpdateStrategy *appspub.InPlaceUpdateStrategy `json:"inPlaceUpdateStrategy,omitempty"`
+	// RollbackPolicy indicates that the controller will automatically roll back the template to
+	// the current revision when the updated pods are unhealthy.
+	// Automatic rollback is disabled if it is nil, and it takes no effect when the CloneSet is paused.
+	RollbackPolicy *CloneSetRollbackPolicy `json:"rollbackPolicy,omitempty"`
+	// Steps defines the batches to update pods. If it is not empty, the controller will take over the partition
+	// and advance it step by step, so that users should not modify the partition by hand.
+	// The replicas of the last step must be 100%, so that all pods are updated even if the CloneSet is scaled.
+	Steps []CloneSetUpdateStep `json:"steps,omitempty"`
+	// Analysis defines the metrics to be analyzed after each update batch. If it is set, the controller
+	// will not update more pods than the analyzed batch until the analysis has passed, and it will pause
+	// the CloneSet once the analysis fails. The last batch that updates all pods is analyzed as well.
+	Analysis *appspub.RolloutAnalysis `json:"analysis,omitempty"`
+}
+
+// CloneSetUpdateStep defines a batch to update pods.
+type CloneSetUpdateStep struct {
+	// Replicas is the desired number of updated pods in this step.
+	// Value can be an absolute number (ex: 5) or a percentage of desired pods (ex: 10%).
+	// Absolute number is calculated from percentage by rounding up.
+	Replicas intstr.IntOrString `json:"replicas"`
+	// Pause indicates to pause after the pods of this step have been updated and ready.
+	// The controller moves to the next step immediately if it is nil.
+	Pause *CloneSetUpdateStepPause `json:"pause,omitempty"`
+}
+
+// CloneSetUpdateStepPause defines how to pause in an update step.
+type CloneSetUpdateStepPause struct {
+	// Duration is the seconds to pause before moving to the next step.
+	// If it is nil, the step has to be approved manually by setting the update revision and the step index
+	// like "{updateRevision}/{index}" into the apps.kruise.io/cloneset-approve-update-step annotation.
+	Duration *int32 `json:"duration,omitempty"`
+}
+
+// CloneSetRollbackPolicy defines the failure thresholds of updated pods to roll back the CloneSet.
+// The CloneSet will be rolled back once any of the thresholds has been exceeded.
+type CloneSetRollbackPolicy struct {
+	// MaxUnavailableUpdated is the maximum number of updated pods that can be unavailable,
+	// including the ones being updated or starting, so it should be no less than the maxUnavailable of update.
+	// Value can be an absolute number (ex: 5) or a percentage of desired pods (ex: 10%).
+	// Absolute number is calculated from percentage by rounding up, and it is at least 1.
+	MaxUnavailableUpdated *intstr.IntOrString `json:"maxUnavailableUpdated,omitempty"`
+	// NotReadyTimeoutSeconds is the maximum seconds that an updated pod can stay not ready
+	// since it has been created or in-place updated.
+	NotReadyTimeoutSeconds *int32 `json:"notReadyTimeoutSeconds,omitempty"`
 }
 
 // CloneSetUpdateStrategyType defines strategies for pods in-place update.
@@ -144,13 +273,19 @@ const (
 	// during Pod update, which is the default behavior.
 	RecreateCloneSetUpdateStrategyType CloneSetUpdateStrategyType = "ReCreate"
 	// InPlaceIfPossibleCloneSetUpdateStrategyType indicates that we try to in-place update Pod instead of
-	// recreating Pod when possible. Currently, only image update of pod spec is allowed. Any other changes to the pod
+	// recreating Pod when possible. Currently, only image update of pod spec is allowed, as well as
+	// resources update when InPlaceWorkloadVerticalScaling feature-gate is enabled. Any other changes to the pod
 	// spec will fall back to ReCreate CloneSetUpdateStrategyType where pod will be recreated.
 	InPlaceIfPossibleCloneSetUpdateStrategyType CloneSetUpdateStrategyType = "InPlaceIfPossible"
 	// InPlaceOnlyCloneSetUpdateStrategyType indicates that we will in-place update Pod instead of
 	// recreating pod. Currently we only allow image update for pod spec. Any other changes to the pod spec will be
 	// rejected by kube-apiserver
 	InPlaceOnlyCloneSetUpdateStrategyType CloneSetUpdateStrategyType = "InPlaceOnly"
+	// BlueGreenCloneSetUpdateStrategyType indicates that we create a full set of new revision Pods as if maxSurge
+	// is 100%, and keep them not ready through the KruisePodReady readiness gate until all of them are available.
+	// Then they will be turned ready and all the old revision Pods will be deleted at once.
+	// Partition and steps can not be used with this type.
+	BlueGreenCloneSetUpdateStrategyType CloneSetUpdateStrategyType = "BlueGreen"
 )
 
 // CloneSetStatus defines the observed state of CloneSet
@@ -200,8 +335,137 @@ type CloneSetStatus struct {
 
 	// LabelSelector is label selectors for query over pods that should match the replica count used by HPA.
 	LabelSelector string `json:"labelSelector,omitempty"`
+
+	// UpdateStepStatus records the progress of updateStrategy.steps.
+	UpdateStepStatus *CloneSetUpdateStepStatus `json:"updateStepStatus,omitempty"`
+
+	// AnalysisRun records the analysis of the latest update batch.
+	AnalysisRun *appspub.AnalysisRunStatus `json:"analysisRun,omitempty"`
+
+	// RolledBack records the revisions of the last automatic rollback, along with the RolledBack condition.
+	RolledBack *CloneSetRolledBackStatus `json:"rolledBack,omitempty"`
+
+	// ScaleDownPlan records the pods planned to be deleted for scaling in when scaleStrategy.scaleDownDryRun is enabled.
+	ScaleDownPlan *CloneSetScaleDownPlan `json:"scaleDownPlan,omitempty"`
+
+	// ScaleDownThrottle records the throttle state of scaleStrategy.scaleDownPolicy.
+	ScaleDownThrottle *CloneSetScaleDownThrottle `json:"scaleDownThrottle,omitempty"`
+
+	// VolumeClaims represents the compatibility between the PVCs of existing pods and their
+	// respective VolumeClaimTemplates.
+	VolumeClaims []CloneSetVolumeClaimStatus `json:"volumeClaims,omitempty"`
+}
+
+// CloneSetRolledBackStatus records the revisions of an automatic rollback.
+type CloneSetRolledBackStatus struct {
+	// FromRevision is the update revision which has been rolled back.
+	FromRevision string `json:"fromRevision"`
+
+	// ToRevision is the current revision which the CloneSet has been rolled back to.
+	ToRevision string `json:"toRevision"`
+}
+
+// CloneSetVolumeClaimStatus describes the status of a volume claim template.
+type CloneSetVolumeClaimStatus struct {
+	// VolumeClaimName is the name of the volume claim template.
+	VolumeClaimName string `json:"volumeClaimName"`
+
+	// CompatibleReplicas is the number of PVCs whose storage requests are no less than the template.
+	CompatibleReplicas int32 `json:"compatibleReplicas"`
+
+	// CompatibleReadyReplicas is the number of compatible PVCs whose capacity has reached their storage requests.
+	CompatibleReadyReplicas int32 `json:"compatibleReadyReplicas"`
+}
+
+// CloneSetScaleDownThrottle records the throttle state of scaling in.
+type CloneSetScaleDownThrottle struct {
+	// LastScaleUpTime is the last time that the replicas of CloneSet increased.
+	LastScaleUpTime *metav1.Time `json:"lastScaleUpTime,omitempty"`
+	// ObservedReplicas is the replicas of CloneSet observed by the throttle, to find out the increase of replicas.
+	ObservedReplicas *int32 `json:"observedReplicas,omitempty"`
+	// IntervalStartTime is the start time of the current interval.
+	IntervalStartTime *metav1.Time `json:"intervalStartTime,omitempty"`
+	// DeletedReplicas is the number of pods deleted for scaling in during the current interval.
+	DeletedReplicas int32 `json:"deletedReplicas"`
+	// HeldReplicas is the number of pods held in PreparingDelete because of the throttle.
+	HeldReplicas int32 `json:"heldReplicas"`
+	// Reason is the reason why scaling in is throttled, which is empty if it is not throttled.
+	Reason string `json:"reason,omitempty"`
+	// Message is a human-readable explanation of the throttle.
+	Message string `json:"message,omitempty"`
+}
+
+const (
+	// CloneSetScaleDownThrottleStabilizing means scaling in is blocked by the stabilization window.
+	CloneSetScaleDownThrottleStabilizing = "Stabilizing"
+	// CloneSetScaleDownThrottleRateLimited means the pods deleted in the current interval have reached the limit.
+	CloneSetScaleDownThrottleRateLimited = "RateLimited"
+)
+
+// CloneSetScaleDownPlan is the planned deletion set for scaling in.
+type CloneSetScaleDownPlan struct {
+	// Replicas is the desired replicas that the plan scales in to.
+	Replicas int32 `json:"replicas"`
+	// Pods is the list of pods planned to be deleted, in the order of deletion preference.
+	Pods []CloneSetScaleDownPlanPod `json:"pods,omitempty"`
 }
 
+// CloneSetScaleDownPlanPod describes a pod planned to be deleted and why it was picked.
+type CloneSetScaleDownPlanPod struct {
+	// Name of the pod.
+	Name string `json:"name"`
+	// Revision of the pod.
+	Revision string `json:"revision,omitempty"`
+	// Reason is the main reason why the pod was picked.
+	Reason CloneSetScaleDownReason `json:"reason"`
+	// Message is a human-readable explanation of the pick.
+	Message string `json:"message,omitempty"`
+}
+
+// CloneSetScaleDownReason is the reason why a pod was picked to be deleted when scaling in.
+type CloneSetScaleDownReason string
+
+const (
+	// CloneSetScaleDownReasonNotReady means the pod is unscheduled, pending, unavailable or not ready.
+	CloneSetScaleDownReasonNotReady CloneSetScaleDownReason = "NotReady"
+	// CloneSetScaleDownReasonDeletionCost means the pod has a lower pod-deletion-cost.
+	CloneSetScaleDownReasonDeletionCost CloneSetScaleDownReason = "DeletionCost"
+	// CloneSetScaleDownReasonTopologyImbalance means the pod is in a more crowded node or topology domain.
+	CloneSetScaleDownReasonTopologyImbalance CloneSetScaleDownReason = "TopologyImbalance"
+	// CloneSetScaleDownReasonRevision means the pod is picked because of its revision.
+	CloneSetScaleDownReasonRevision CloneSetScaleDownReason = "Revision"
+	// CloneSetScaleDownReasonOther means the pod is picked by ready time, restart count or creation time.
+	CloneSetScaleDownReasonOther CloneSetScaleDownReason = "Other"
+)
+
+// CloneSetUpdateStepStatus records the progress of update steps.
+type CloneSetUpdateStepStatus struct {
+	// UpdateRevision is the revision that the steps are updating to.
+	UpdateRevision string `json:"updateRevision,omitempty"`
+	// CurrentStepIndex is the index of the current step in updateStrategy.steps.
+	CurrentStepIndex int32 `json:"currentStepIndex"`
+	// CurrentStepState is the state of the current step.
+	CurrentStepState CloneSetUpdateStepState `json:"currentStepState,omitempty"`
+	// CurrentStepStartTime is the time when the current step started.
+	CurrentStepStartTime metav1.Time `json:"currentStepStartTime,omitempty"`
+	// LastUpdateTime is the time when the state of the current step changed.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+}
+
+// CloneSetUpdateStepState is the state of an update step.
+type CloneSetUpdateStepState string
+
+const (
+	// CloneSetUpdateStepStateUpgrading means the pods of the current step are being updated.
+	CloneSetUpdateStepStateUpgrading CloneSetUpdateStepState = "Upgrading"
+	// CloneSetUpdateStepStatePaused means the pods of the current step are ready, and it is pausing for a duration.
+	CloneSetUpdateStepStatePaused CloneSetUpdateStepState = "Paused"
+	// CloneSetUpdateStepStateWaitingApproval means the pods of the current step are ready, and it is waiting for manual approval.
+	CloneSetUpdateStepStateWaitingApproval CloneSetUpdateStepState = "WaitingApproval"
+	// CloneSetUpdateStepStateCompleted means all the steps have been finished.
+	CloneSetUpdateStepStateCompleted CloneSetUpdateStepState = "Completed"
+)
+
 // CloneSetConditionType is type for CloneSet conditions.
 type CloneSetConditionType string
 
@@ -210,6 +474,30 @@ const (
 	CloneSetConditionFailedScale CloneSetConditionType = "FailedScale"
 	// CloneSetConditionFailedUpdate indicates cloneset controller failed to update pods.
 	CloneSetConditionFailedUpdate CloneSetConditionType = "FailedUpdate"
+	// CloneSetConditionProgressing indicates whether the CloneSet is progressing, which is only maintained
+	// when progressDeadlineSeconds has been set.
+	CloneSetConditionProgressing CloneSetConditionType = "Progressing"
+	// CloneSetConditionRolledBack indicates the CloneSet has been automatically rolled back
+	// to the current revision according to its rollbackPolicy.
+	CloneSetConditionRolledBack CloneSetConditionType = "RolledBack"
+)
+
+const (
+	// CloneSetProgressUpdatedReason means the CloneSet has made progress since the last observation.
+	CloneSetProgressUpdatedReason = "CloneSetUpdated"
+	// CloneSetProgressPausedReason means the CloneSet has been paused.
+	CloneSetProgressPausedReason = "CloneSetPaused"
+	// CloneSetProgressPartitionAvailableReason means the CloneSet has reached the expected updated replicas of partition.
+	CloneSetProgressPartitionAvailableReason = "CloneSetPartitionAvailable"
+	// CloneSetProgressAvailableReason means all replicas of the CloneSet have been updated and available.
+	CloneSetProgressAvailableReason = "CloneSetAvailable"
+	// CloneSetProgressDeadlineExceededReason means the CloneSet has failed to make progress within progressDeadlineSeconds.
+	CloneSetProgressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+	// CloneSetRollbackUnavailableReason means the CloneSet has been rolled back for too many unavailable updated pods.
+	CloneSetRollbackUnavailableReason = "TooManyUnavailableUpdated"
+	// CloneSetRollbackNotReadyTimeoutReason means the CloneSet has been rolled back for updated pods not ready in time.
+	CloneSetRollbackNotReadyTimeoutReason = "UpdatedNotReadyTimeout"
 )
 
 // CloneSetCondition describes the state of a CloneSet at a certain point.
@@ -218,6 +506,8 @@ type CloneSetCondition struct {
 	Type CloneSetConditionType `json:"type"`
 	// Status of the condition, one of True, False, Unknown.
 	Status v1.ConditionStatus `json:"status"`
+	// The last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
 	// Last time the condition transitioned from one status to another.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
 	// The reason for the condition's last transition.
@@ -57,6 +57,23 @@ const (
 	DeprecatedSurgingRollingUpdateType RollingUpdateType = "Surging"
 )
 
+// SurgeFallbackStrategyType defines how to update the pod on a node where a surge pod can not run.
+type SurgeFallbackStrategyType string
+
+const (
+	// RecreateSurgeFallbackStrategyType deletes the old pod before creating the new one on the node.
+	RecreateSurgeFallbackStrategyType SurgeFallbackStrategyType = "Recreate"
+
+	// InPlaceIfPossibleSurgeFallbackStrategyType updates the old pod in-place if possible, otherwise recreates it.
+	InPlaceIfPossibleSurgeFallbackStrategyType SurgeFallbackStrategyType = "InPlaceIfPossible"
+)
+
+const (
+	// DaemonSetSurgeDisabled means surge has been turned off on some nodes, because the surge pods
+	// can not run together with the old pods on them.
+	DaemonSetSurgeDisabled appsv1.DaemonSetConditionType = "SurgeDisabled"
+)
+
 // Spec to control the desired behavior of daemon set rolling update.
 type RollingUpdateDaemonSet struct {
 	// Type is to specify which kind of rollingUpdate.
@@ -98,9 +115,18 @@ type RollingUpdateDaemonSet struct {
 	// so resource intensive daemonsets should take into account that they may
 	// cause evictions during disruption.
 	// This is beta field and enabled/disabled by DaemonSetUpdateSurge feature gate.
+	// For InPlaceIfPossible type, the pods that can be updated in-place are updated in-place
+	// instead of surging, and they are also counted against maxSurge until they become available.
 	// +optional
 	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
 
+	// SurgeFallbackStrategy is how to update the pod on a node where the surge pod can not run together
+	// with the old one, because both of them use hostNetwork or their hostPorts conflict.
+	// It can be Recreate or InPlaceIfPossible, and defaults to Recreate. It only works when maxSurge is not 0.
+	// As these pods are unavailable while updated, only one of them is updated at a time.
+	// +optional
+	SurgeFallbackStrategy SurgeFallbackStrategyType `json:"surgeFallbackStrategy,omitempty"`
+
 	// A label query over nodes that are managed by the daemon set RollingUpdate.
 	// Must match in order to be controlled.
 	// It must match the node's labels.
@@ -116,6 +142,61 @@ type RollingUpdateDaemonSet struct {
 	// daemon set controller.
 	// +optional
 	Paused *bool `json:"paused,omitempty"`
+
+	// Waves is an ordered list of node pools to update one after another.
+	// The next wave begins only after all pods in the previous wave are updated and available,
+	// and its soak time has passed. A node belongs to the first wave whose selector matches it,
+	// and nodes matched by none of the waves will not be updated.
+	// It can not be used together with Selector.
+	// +optional
+	Waves []DaemonSetUpdateWave `json:"waves,omitempty"`
+
+	// DrainingNodeStrategy defers the update of the nodes being drained. If it is set, the pods on
+	// the draining nodes are neither updated nor counted against maxUnavailable, and they will be
+	// updated after the nodes come back.
+	// +optional
+	DrainingNodeStrategy *DaemonSetDrainingNodeStrategy `json:"drainingNodeStrategy,omitempty"`
+
+	// PinnedRevisionLabelKey is the key of the node label whose value is the controller-revision-hash
+	// of a revision that the daemon pod on the node is pinned to, such as canary nodes staying on a newer
+	// revision or problem nodes held back on an older one. The pods on the pinned nodes are recreated
+	// with their pinned revisions regardless of partition, selector and waves, but still within maxUnavailable
+	// or maxSurge. A label naming a revision which does not exist is ignored.
+	// +optional
+	PinnedRevisionLabelKey string `json:"pinnedRevisionLabelKey,omitempty"`
+}
+
+// DaemonSetDrainingNodeStrategy defines how to detect the nodes being drained.
+// A node is considered draining if it is unschedulable, or has any of the taints or the label.
+type DaemonSetDrainingNodeStrategy struct {
+	// TaintKeys are the keys of the additional taints that mark a node being drained,
+	// such as ToBeDeletedByClusterAutoscaler.
+	// +optional
+	TaintKeys []string `json:"taintKeys,omitempty"`
+
+	// LabelKey is the key of the label that marks a node being drained, regardless of its value.
+	// +optional
+	LabelKey string `json:"labelKey,omitempty"`
+}
+
+// DaemonSetUpdateWave is a node pool updated in one step of the rolling update.
+type DaemonSetUpdateWave struct {
+	// Name is the unique name of the wave.
+	Name string `json:"name"`
+
+	// A label query over nodes that belong to the wave.
+	Selector *metav1.LabelSelector `json:"selector"`
+
+	// The maximum number of DaemonSet pods that can be unavailable during the update of the wave.
+	// Value can be an absolute number (ex: 5) or a percentage of the nodes in the wave (ex: 10%).
+	// Defaults to the maxUnavailable of the rolling update.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// SoakSeconds is the time to wait after all pods in the wave are updated and available
+	// before the next wave begins. Defaults to 0.
+	// +optional
+	SoakSeconds int32 `json:"soakSeconds,omitempty"`
 }
 
 // DaemonSetSpec defines the desired state of DaemonSet
@@ -159,8 +240,7 @@ type DaemonSetSpec struct {
 	// +optional
 	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
 
-	// Lifecycle defines the lifecycle hooks for Pods pre-delete, in-place update.
-	// Currently, we only support pre-delete hook for Advanced DaemonSet.
+	// Lifecycle defines the lifecycle hooks for Pods pre-available(pre-normal), pre-delete, in-place update.
 	// +optional
 	Lifecycle *appspub.Lifecycle `json:"lifecycle,omitempty"`
 }
@@ -193,7 +273,8 @@ type DaemonSetStatus struct {
 	// +optional
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 
-	// The total number of nodes that are running updated daemon pod
+	// The total number of nodes that are running updated daemon pod,
+	// including the pinned nodes running the daemon pod of their pinned revisions.
 	UpdatedNumberScheduled int32 `json:"updatedNumberScheduled"`
 
 	// The number of nodes that should be running the
@@ -222,6 +303,48 @@ type DaemonSetStatus struct {
 
 	// DaemonSetHash is the controller-revision-hash, which represents the latest version of the DaemonSet.
 	DaemonSetHash string `json:"daemonSetHash"`
+
+	// CurrentWave is the wave of the rolling update that is being updated or soaking.
+	// +optional
+	CurrentWave *DaemonSetUpdateWaveStatus `json:"currentWave,omitempty"`
+
+	// NumberDeferred is the number of the draining nodes whose daemon pods are deferred to update.
+	// +optional
+	NumberDeferred int32 `json:"numberDeferred,omitempty"`
+
+	// DeferredNodes are the names of the draining nodes whose daemon pods are deferred to update,
+	// sorted by name and limited to the first 10 of them.
+	// +optional
+	DeferredNodes []string `json:"deferredNodes,omitempty"`
+
+	// PinnedRevisions are the nodes pinned to revisions by the node label, grouped by the revisions.
+	// +optional
+	PinnedRevisions []DaemonSetPinnedRevision `json:"pinnedRevisions,omitempty"`
+}
+
+// DaemonSetPinnedRevision is a revision that the daemon pods on some nodes are pinned to.
+type DaemonSetPinnedRevision struct {
+	// Revision is the controller-revision-hash of the pinned revision.
+	Revision string `json:"revision"`
+
+	// NumberNodes is the number of the nodes pinned to the revision.
+	NumberNodes int32 `json:"numberNodes"`
+
+	// Nodes are the names of the nodes pinned to the revision, sorted by name and limited to the first 10 of them.
+	Nodes []string `json:"nodes"`
+}
+
+// DaemonSetUpdateWaveStatus is the observed state of a wave of the rolling update.
+type DaemonSetUpdateWaveStatus struct {
+	// Name is the name of the wave.
+	Name string `json:"name"`
+
+	// Index is the index of the wave in spec.updateStrategy.rollingUpdate.waves.
+	Index int32 `json:"index"`
+
+	// CompletedTime is the time when all pods in the wave were found updated and available.
+	// +optional
+	CompletedTime *metav1.Time `json:"completedTime,omitempty"`
 }
 
 // +genclient
@@ -49,6 +49,8 @@ type SidecarSetSpec struct {
 	// InitContainers is the list of init containers to be injected into the selected pod
 	// We will inject those containers by their name in ascending order
 	// We only inject init containers when a new pod is created, it does not apply to any existing pod
+	// The init containers with restartPolicy Always are native sidecar containers, which can be
+	// in-place updated and hot upgraded by the SidecarSet just like the containers
 	// +patchMergeKey=name
 	// +patchStrategy=merge
 	InitContainers []SidecarContainer `json:"initContainers,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
@@ -121,13 +123,19 @@ type SidecarContainer struct {
 	// +kubebuilder:validation:Schemaless
 	corev1.Container `json:",inline"`
 
-	// The rules that injected SidecarContainer into Pod.spec.containers,
-	// not takes effect in initContainers
+	// The rules that injected SidecarContainer into Pod.spec.containers or Pod.spec.initContainers
 	// If BeforeAppContainer, the SidecarContainer will be injected in front of the pod.spec.containers
 	// otherwise it will be injected into the back.
-	// default BeforeAppContainerType
+	// For initContainers, BeforeAppContainer injects the SidecarContainer in front of the pod's own
+	// initContainers, so that a native sidecar is started before them.
+	// default BeforeAppContainerType for containers, and AfterAppContainerType for initContainers
 	PodInjectPolicy PodInjectPolicyType `json:"podInjectPolicy,omitempty"`
 
+	// InjectAfterInitContainer is the name of the pod's own initContainer, right after which this
+	// initContainer is injected. It takes precedence over PodInjectPolicy, which still works for the
+	// pods without such initContainer. It only takes effect in initContainers.
+	InjectAfterInitContainer string `json:"injectAfterInitContainer,omitempty"`
+
 	// sidecarContainer upgrade strategy, include: ColdUpgrade, HotUpgrade
 	UpgradeStrategy SidecarContainerUpgradeStrategy `json:"upgradeStrategy,omitempty"`
 
@@ -275,6 +283,54 @@ type SidecarSetUpdateStrategy struct {
 	// - Note that pods will be scattered after priority sort. So, although priority strategy and scatter strategy can be applied together, we suggest to use either one of them.
 	// - If scatterStrategy is used, we suggest to just use one term. Otherwise, the update order can be hard to understand.
 	ScatterStrategy UpdateScatterStrategy `json:"scatterStrategy,omitempty"`
+
+	// Stages is an ordered list of the canary stages to update one after another, each targeting some namespaces
+	// or workloads. The next stage begins only after all pods in the previous stage are updated and ready,
+	// and its soak time has passed. A pod belongs to the first stage that matches it, and the pods matched by
+	// none of the stages are updated after all the stages are completed and soaked.
+	// It can not be used together with Selector or Partition, and MaxUnavailable is calculated against all the
+	// matched pods rather than the pods in the current stage.
+	// +optional
+	Stages []SidecarSetUpdateStage `json:"stages,omitempty"`
+}
+
+// SidecarSetUpdateStage is a set of pods updated in one stage of the rolling update.
+// A pod matches the stage if it matches any of namespaces, namespaceSelector and workloads.
+type SidecarSetUpdateStage struct {
+	// Name is the unique name of the stage.
+	Name string `json:"name"`
+
+	// Namespaces are the namespaces of the pods in the stage.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// A label query over the namespaces of the pods in the stage.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// Workloads are the workloads owning the pods in the stage.
+	// +optional
+	Workloads []SidecarSetUpdateStageWorkload `json:"workloads,omitempty"`
+
+	// SoakSeconds is the time to wait after all pods in the stage are updated and ready, before the next stage begins.
+	// +optional
+	SoakSeconds int32 `json:"soakSeconds,omitempty"`
+}
+
+// SidecarSetUpdateStageWorkload is a workload owning the pods, such as the Deployment owning the pods by its ReplicaSets.
+type SidecarSetUpdateStageWorkload struct {
+	// APIVersion is the API version of the workload, such as apps/v1 or apps.kruise.io/v1alpha1.
+	// Only its group is compared with the owner of the pods.
+	APIVersion string `json:"apiVersion"`
+
+	// Kind is the kind of the workload, such as Deployment or CloneSet.
+	Kind string `json:"kind"`
+
+	// Namespace is the namespace of the workload.
+	Namespace string `json:"namespace"`
+
+	// Name is the name of the workload.
+	Name string `json:"name"`
 }
 
 type SidecarSetUpdateStrategyType string
@@ -309,6 +365,23 @@ type SidecarSetStatus struct {
 	// uses this field as a collision avoidance mechanism when it needs to create the name for the
 	// newest ControllerRevision.
 	CollisionCount *int32 `json:"collisionCount,omitempty"`
+
+	// CurrentStage is the stage of the rolling update that is being updated or soaking.
+	// +optional
+	CurrentStage *SidecarSetUpdateStageStatus `json:"currentStage,omitempty"`
+}
+
+// SidecarSetUpdateStageStatus is the observed state of a stage of the rolling update.
+type SidecarSetUpdateStageStatus struct {
+	// Name is the name of the stage.
+	Name string `json:"name"`
+
+	// Index is the index of the stage in spec.updateStrategy.stages.
+	Index int32 `json:"index"`
+
+	// CompletedTime is the time when all pods in the stage were found updated and ready.
+	// +optional
+	CompletedTime *metav1.Time `json:"completedTime,omitempty"`
 }
 
 // +genclient
@@ -100,7 +100,8 @@ const (
 	// during Pod update, which is the default behavior
 	RecreatePodUpdateStrategyType PodUpdateStrategyType = "ReCreate"
 	// InPlaceIfPossiblePodUpdateStrategyType indicates that we try to in-place update Pod instead of
-	// recreating Pod when possible. Currently, only image update of pod spec is allowed. Any other changes to the pod
+	// recreating Pod when possible. Currently, only image update of pod spec is allowed, as well as
+	// resources update when InPlaceWorkloadVerticalScaling feature-gate is enabled. Any other changes to the pod
 	// spec will fall back to ReCreate PodUpdateStrategyType where pod will be recreated.
 	InPlaceIfPossiblePodUpdateStrategyType PodUpdateStrategyType = "InPlaceIfPossible"
 	// InPlaceOnlyPodUpdateStrategyType indicates that we will in-place update Pod instead of
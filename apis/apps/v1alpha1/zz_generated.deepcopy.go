@@ -375,6 +375,7 @@ func (in *CloneSet) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloneSetCondition) DeepCopyInto(out *CloneSetCondition) {
 	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
 	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
 }
 
@@ -420,6 +421,134 @@ func (in *CloneSetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetRollbackPolicy) DeepCopyInto(out *CloneSetRollbackPolicy) {
+	*out = *in
+	if in.MaxUnavailableUpdated != nil {
+		in, out := &in.MaxUnavailableUpdated, &out.MaxUnavailableUpdated
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.NotReadyTimeoutSeconds != nil {
+		in, out := &in.NotReadyTimeoutSeconds, &out.NotReadyTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetRollbackPolicy.
+func (in *CloneSetRollbackPolicy) DeepCopy() *CloneSetRollbackPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetRollbackPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetRolledBackStatus) DeepCopyInto(out *CloneSetRolledBackStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetRolledBackStatus.
+func (in *CloneSetRolledBackStatus) DeepCopy() *CloneSetRolledBackStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetRolledBackStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetScaleDownPlan) DeepCopyInto(out *CloneSetScaleDownPlan) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]CloneSetScaleDownPlanPod, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetScaleDownPlan.
+func (in *CloneSetScaleDownPlan) DeepCopy() *CloneSetScaleDownPlan {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetScaleDownPlan)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetScaleDownPlanPod) DeepCopyInto(out *CloneSetScaleDownPlanPod) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetScaleDownPlanPod.
+func (in *CloneSetScaleDownPlanPod) DeepCopy() *CloneSetScaleDownPlanPod {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetScaleDownPlanPod)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetScaleDownPolicy) DeepCopyInto(out *CloneSetScaleDownPolicy) {
+	*out = *in
+	if in.MaxPodsPerInterval != nil {
+		in, out := &in.MaxPodsPerInterval, &out.MaxPodsPerInterval
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.IntervalSeconds != nil {
+		in, out := &in.IntervalSeconds, &out.IntervalSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetScaleDownPolicy.
+func (in *CloneSetScaleDownPolicy) DeepCopy() *CloneSetScaleDownPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetScaleDownPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetScaleDownThrottle) DeepCopyInto(out *CloneSetScaleDownThrottle) {
+	*out = *in
+	if in.LastScaleUpTime != nil {
+		in, out := &in.LastScaleUpTime, &out.LastScaleUpTime
+		*out = (*in).DeepCopy()
+	}
+	if in.ObservedReplicas != nil {
+		in, out := &in.ObservedReplicas, &out.ObservedReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.IntervalStartTime != nil {
+		in, out := &in.IntervalStartTime, &out.IntervalStartTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetScaleDownThrottle.
+func (in *CloneSetScaleDownThrottle) DeepCopy() *CloneSetScaleDownThrottle {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetScaleDownThrottle)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloneSetScaleStrategy) DeepCopyInto(out *CloneSetScaleStrategy) {
 	*out = *in
@@ -433,6 +562,11 @@ func (in *CloneSetScaleStrategy) DeepCopyInto(out *CloneSetScaleStrategy) {
 		*out = new(intstr.IntOrString)
 		**out = **in
 	}
+	if in.ScaleDownPolicy != nil {
+		in, out := &in.ScaleDownPolicy, &out.ScaleDownPolicy
+		*out = new(CloneSetScaleDownPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetScaleStrategy.
@@ -466,6 +600,7 @@ func (in *CloneSetSpec) DeepCopyInto(out *CloneSetSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	out.VolumeClaimUpdateStrategy = in.VolumeClaimUpdateStrategy
 	in.ScaleStrategy.DeepCopyInto(&out.ScaleStrategy)
 	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
 	if in.RevisionHistoryLimit != nil {
@@ -478,6 +613,11 @@ func (in *CloneSetSpec) DeepCopyInto(out *CloneSetSpec) {
 		*out = new(pub.Lifecycle)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.ProgressDeadlineSeconds != nil {
+		in, out := &in.ProgressDeadlineSeconds, &out.ProgressDeadlineSeconds
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetSpec.
@@ -505,6 +645,36 @@ func (in *CloneSetStatus) DeepCopyInto(out *CloneSetStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.UpdateStepStatus != nil {
+		in, out := &in.UpdateStepStatus, &out.UpdateStepStatus
+		*out = new(CloneSetUpdateStepStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AnalysisRun != nil {
+		in, out := &in.AnalysisRun, &out.AnalysisRun
+		*out = new(pub.AnalysisRunStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RolledBack != nil {
+		in, out := &in.RolledBack, &out.RolledBack
+		*out = new(CloneSetRolledBackStatus)
+		**out = **in
+	}
+	if in.ScaleDownPlan != nil {
+		in, out := &in.ScaleDownPlan, &out.ScaleDownPlan
+		*out = new(CloneSetScaleDownPlan)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ScaleDownThrottle != nil {
+		in, out := &in.ScaleDownThrottle, &out.ScaleDownThrottle
+		*out = new(CloneSetScaleDownThrottle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeClaims != nil {
+		in, out := &in.VolumeClaims, &out.VolumeClaims
+		*out = make([]CloneSetVolumeClaimStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetStatus.
@@ -534,6 +704,64 @@ func (in *CloneSetTemplateSpec) DeepCopy() *CloneSetTemplateSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetUpdateStep) DeepCopyInto(out *CloneSetUpdateStep) {
+	*out = *in
+	out.Replicas = in.Replicas
+	if in.Pause != nil {
+		in, out := &in.Pause, &out.Pause
+		*out = new(CloneSetUpdateStepPause)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetUpdateStep.
+func (in *CloneSetUpdateStep) DeepCopy() *CloneSetUpdateStep {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetUpdateStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetUpdateStepPause) DeepCopyInto(out *CloneSetUpdateStepPause) {
+	*out = *in
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetUpdateStepPause.
+func (in *CloneSetUpdateStepPause) DeepCopy() *CloneSetUpdateStepPause {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetUpdateStepPause)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetUpdateStepStatus) DeepCopyInto(out *CloneSetUpdateStepStatus) {
+	*out = *in
+	in.CurrentStepStartTime.DeepCopyInto(&out.CurrentStepStartTime)
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetUpdateStepStatus.
+func (in *CloneSetUpdateStepStatus) DeepCopy() *CloneSetUpdateStepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetUpdateStepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CloneSetUpdateStrategy) DeepCopyInto(out *CloneSetUpdateStrategy) {
 	*out = *in
@@ -567,6 +795,23 @@ func (in *CloneSetUpdateStrategy) DeepCopyInto(out *CloneSetUpdateStrategy) {
 		*out = new(pub.InPlaceUpdateStrategy)
 		**out = **in
 	}
+	if in.RollbackPolicy != nil {
+		in, out := &in.RollbackPolicy, &out.RollbackPolicy
+		*out = new(CloneSetRollbackPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]CloneSetUpdateStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(pub.RolloutAnalysis)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetUpdateStrategy.
@@ -579,6 +824,36 @@ func (in *CloneSetUpdateStrategy) DeepCopy() *CloneSetUpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetVolumeClaimStatus) DeepCopyInto(out *CloneSetVolumeClaimStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetVolumeClaimStatus.
+func (in *CloneSetVolumeClaimStatus) DeepCopy() *CloneSetVolumeClaimStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetVolumeClaimStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CloneSetVolumeClaimUpdateStrategy) DeepCopyInto(out *CloneSetVolumeClaimUpdateStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CloneSetVolumeClaimUpdateStrategy.
+func (in *CloneSetVolumeClaimUpdateStrategy) DeepCopy() *CloneSetVolumeClaimUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(CloneSetVolumeClaimUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CompletionPolicy) DeepCopyInto(out *CompletionPolicy) {
 	*out = *in
@@ -925,6 +1200,26 @@ func (in *DaemonSet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DaemonSetDrainingNodeStrategy) DeepCopyInto(out *DaemonSetDrainingNodeStrategy) {
+	*out = *in
+	if in.TaintKeys != nil {
+		in, out := &in.TaintKeys, &out.TaintKeys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetDrainingNodeStrategy.
+func (in *DaemonSetDrainingNodeStrategy) DeepCopy() *DaemonSetDrainingNodeStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(DaemonSetDrainingNodeStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DaemonSetList) DeepCopyInto(out *DaemonSetList) {
 	*out = *in
@@ -957,6 +1252,26 @@ func (in *DaemonSetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DaemonSetPinnedRevision) DeepCopyInto(out *DaemonSetPinnedRevision) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetPinnedRevision.
+func (in *DaemonSetPinnedRevision) DeepCopy() *DaemonSetPinnedRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(DaemonSetPinnedRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DaemonSetSpec) DeepCopyInto(out *DaemonSetSpec) {
 	*out = *in
@@ -1009,6 +1324,23 @@ func (in *DaemonSetStatus) DeepCopyInto(out *DaemonSetStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.CurrentWave != nil {
+		in, out := &in.CurrentWave, &out.CurrentWave
+		*out = new(DaemonSetUpdateWaveStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DeferredNodes != nil {
+		in, out := &in.DeferredNodes, &out.DeferredNodes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PinnedRevisions != nil {
+		in, out := &in.PinnedRevisions, &out.PinnedRevisions
+		*out = make([]DaemonSetPinnedRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetStatus.
@@ -1041,6 +1373,50 @@ func (in *DaemonSetUpdateStrategy) DeepCopy() *DaemonSetUpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DaemonSetUpdateWave) DeepCopyInto(out *DaemonSetUpdateWave) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetUpdateWave.
+func (in *DaemonSetUpdateWave) DeepCopy() *DaemonSetUpdateWave {
+	if in == nil {
+		return nil
+	}
+	out := new(DaemonSetUpdateWave)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DaemonSetUpdateWaveStatus) DeepCopyInto(out *DaemonSetUpdateWaveStatus) {
+	*out = *in
+	if in.CompletedTime != nil {
+		in, out := &in.CompletedTime, &out.CompletedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetUpdateWaveStatus.
+func (in *DaemonSetUpdateWaveStatus) DeepCopy() *DaemonSetUpdateWaveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DaemonSetUpdateWaveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DeploymentTemplateSpec) DeepCopyInto(out *DeploymentTemplateSpec) {
 	*out = *in
@@ -2609,6 +2985,18 @@ func (in *RollingUpdateDaemonSet) DeepCopyInto(out *RollingUpdateDaemonSet) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Waves != nil {
+		in, out := &in.Waves, &out.Waves
+		*out = make([]DaemonSetUpdateWave, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.DrainingNodeStrategy != nil {
+		in, out := &in.DrainingNodeStrategy, &out.DrainingNodeStrategy
+		*out = new(DaemonSetDrainingNodeStrategy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateDaemonSet.
@@ -2944,6 +3332,11 @@ func (in *SidecarSetStatus) DeepCopyInto(out *SidecarSetStatus) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.CurrentStage != nil {
+		in, out := &in.CurrentStage, &out.CurrentStage
+		*out = new(SidecarSetUpdateStageStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarSetStatus.
@@ -2956,6 +3349,70 @@ func (in *SidecarSetStatus) DeepCopy() *SidecarSetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SidecarSetUpdateStage) DeepCopyInto(out *SidecarSetUpdateStage) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]SidecarSetUpdateStageWorkload, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarSetUpdateStage.
+func (in *SidecarSetUpdateStage) DeepCopy() *SidecarSetUpdateStage {
+	if in == nil {
+		return nil
+	}
+	out := new(SidecarSetUpdateStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SidecarSetUpdateStageStatus) DeepCopyInto(out *SidecarSetUpdateStageStatus) {
+	*out = *in
+	if in.CompletedTime != nil {
+		in, out := &in.CompletedTime, &out.CompletedTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarSetUpdateStageStatus.
+func (in *SidecarSetUpdateStageStatus) DeepCopy() *SidecarSetUpdateStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SidecarSetUpdateStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SidecarSetUpdateStageWorkload) DeepCopyInto(out *SidecarSetUpdateStageWorkload) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarSetUpdateStageWorkload.
+func (in *SidecarSetUpdateStageWorkload) DeepCopy() *SidecarSetUpdateStageWorkload {
+	if in == nil {
+		return nil
+	}
+	out := new(SidecarSetUpdateStageWorkload)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SidecarSetUpdateStrategy) DeepCopyInto(out *SidecarSetUpdateStrategy) {
 	*out = *in
@@ -2984,6 +3441,13 @@ func (in *SidecarSetUpdateStrategy) DeepCopyInto(out *SidecarSetUpdateStrategy)
 		*out = make(UpdateScatterStrategy, len(*in))
 		copy(*out, *in)
 	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]SidecarSetUpdateStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SidecarSetUpdateStrategy.
@@ -44,8 +44,19 @@ const (
 	// This strategy places full control of the update timing in the hands of the user, typically executed after ensuring data has been backed up or there are no data security concerns,
 	// allowing for storage resource management that aligns with specific user requirements and security policies.
 	OnPVCDeleteVolumeClaimUpdateStrategyType VolumeClaimUpdateStrategyType = "OnDelete"
+
+	// MigrateVolumeClaimUpdateStrategyType indicates that volume claims whose storageClassName or accessModes differ from the templates
+	// are migrated one ordinal at a time during pod rolling updates. The Pod is deleted first, then the data of each claim is backed up
+	// into a VolumeSnapshot, and the claim is rebuilt from the template restoring the snapshot before the Pod is recreated.
+	// It requires the VolumeSnapshot CRDs installed, and the new storage class to have the same provisioner as the old one,
+	// because a VolumeSnapshot can only be restored by the CSI driver which took it.
+	MigrateVolumeClaimUpdateStrategyType VolumeClaimUpdateStrategyType = "Migrate"
 )
 
+// AbandonPVCResizeAnnotationKey can be set to "true" on a volume claim whose resize has failed, to abandon the resize
+// and let the rolling update of the StatefulSet continue with the old size.
+const AbandonPVCResizeAnnotationKey = "apps.kruise.io/abandon-pvc-resize"
+
 // VolumeClaimStatus describes the status of a volume claim template.
 // It provides details about the compatibility and readiness of the volume claim.
 type VolumeClaimStatus struct {
@@ -61,6 +72,33 @@ type VolumeClaimStatus struct {
 	// Compatibility is determined by whether the pvc spec storage requests are greater than or equal to the template spec storage requests
 	// The "ready" status is determined by whether the PVC status capacity is greater than or equal to the PVC spec storage requests.
 	CompatibleReadyReplicas int32 `json:"compatibleReadyReplicas"`
+	// MigratingReplicas is the number of replicas whose volume claim is being migrated to the template.
+	// It is only used with the Migrate volume claim update strategy.
+	// +optional
+	MigratingReplicas int32 `json:"migratingReplicas,omitempty"`
+	// FailedReplicas is the number of replicas whose volume claim failed to be migrated to the template.
+	// +optional
+	FailedReplicas int32 `json:"failedReplicas,omitempty"`
+	// Message describes the latest pending or failed backup and restore of the volume claim migration.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ResizeFailures records the volume claims of this template whose resize has failed, sorted by ordinal.
+	// +optional
+	ResizeFailures []VolumeClaimResizeFailure `json:"resizeFailures,omitempty"`
+}
+
+// VolumeClaimResizeFailure describes a volume claim whose resize has failed.
+type VolumeClaimResizeFailure struct {
+	// Ordinal is the ordinal of the Pod which the volume claim belongs to.
+	Ordinal int32 `json:"ordinal"`
+	// ClaimName is the name of the volume claim.
+	ClaimName string `json:"claimName"`
+	// Status is the allocated resource status of the volume claim, ControllerResizeFailed or NodeResizeFailed.
+	Status v1.ClaimResourceStatus `json:"status"`
+	// Abandoned indicates the resize has been abandoned by the AbandonPVCResizeAnnotationKey annotation on the volume claim,
+	// so the rolling update continues with the old size.
+	// +optional
+	Abandoned bool `json:"abandoned,omitempty"`
 }
 
 // StatefulSetUpdateStrategy indicates the strategy that the StatefulSet
@@ -82,6 +120,7 @@ type VolumeClaimUpdateStrategy struct {
 	// Type specifies the type of update strategy, possible values include:
 	// OnPodRollingUpdateVolumeClaimUpdateStrategyType: Apply the update strategy during pod rolling updates.
 	// OnPVCDeleteVolumeClaimUpdateStrategyType: Apply the update strategy when a PersistentVolumeClaim is deleted.
+	// MigrateVolumeClaimUpdateStrategyType: Migrate the PersistentVolumeClaims to the changed storage class of the same provisioner or access modes during pod rolling updates.
 	Type VolumeClaimUpdateStrategyType `json:"type,omitempty"`
 }
 
@@ -124,6 +163,11 @@ type RollingUpdateStatefulSetStrategy struct {
 	// Default value is 0, max is 300.
 	// +optional
 	MinReadySeconds *int32 `json:"minReadySeconds,omitempty"`
+	// Analysis defines the metrics to be analyzed after each update batch. If it is set, the controller
+	// will not update more pods than the analyzed batch until the analysis has passed, and it will pause
+	// the StatefulSet once the analysis fails. The last batch that updates all pods is analyzed as well.
+	// +optional
+	Analysis *appspub.RolloutAnalysis `json:"analysis,omitempty"`
 }
 
 // UnorderedUpdateStrategy defines strategies for non-ordered update.
@@ -132,6 +176,48 @@ type UnorderedUpdateStrategy struct {
 	// Each pod to be updated, will pass through these terms and get a sum of weights.
 	// +optional
 	PriorityStrategy *appspub.UpdatePriorityStrategy `json:"priorityStrategy,omitempty"`
+	// TopologyDomainStrategy groups the pods by the topology domain of their nodes, and updates the pods domain by domain.
+	// All pods in one domain are updated and become available before moving to the next domain,
+	// and the maxUnavailable is enforced both per domain and across all domains.
+	// +optional
+	TopologyDomainStrategy *TopologyDomainUpdateStrategy `json:"topologyDomainStrategy,omitempty"`
+	// RolePriority makes the pods of the leader role updated after all the pods of the other roles have been
+	// updated and become available, regardless of the priorityStrategy.
+	// For workloads such as primary/replica databases, the replicas will be updated first and the leader last.
+	// +optional
+	RolePriority *UpdatePriorityRoleTerm `json:"rolePriority,omitempty"`
+}
+
+// UpdatePriorityRoleTerm defines role priority.
+type UpdatePriorityRoleTerm struct {
+	// RoleKey is the key of pod's label which indicates the role of the pod.
+	RoleKey string `json:"roleKey"`
+	// LeaderValues are the values of the role label of leader pods.
+	LeaderValues []string `json:"leaderValues"`
+}
+
+// IsLeader returns whether the pod with the given labels is of the leader role.
+func (term *UpdatePriorityRoleTerm) IsLeader(podLabels map[string]string) bool {
+	if term == nil {
+		return false
+	}
+	value, ok := podLabels[term.RoleKey]
+	if !ok {
+		return false
+	}
+	for _, v := range term.LeaderValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TopologyDomainUpdateStrategy defines how to group the pods by topology domain when updating.
+type TopologyDomainUpdateStrategy struct {
+	// TopologyKey is the key of node labels, whose value is the topology domain of the pods on the node,
+	// such as topology.kubernetes.io/zone. Pods which have not been scheduled are in an empty domain.
+	TopologyKey string `json:"topologyKey"`
 }
 
 // PodUpdateStrategyType is a string enumeration type that enumerates
@@ -143,7 +229,8 @@ const (
 	// during Pod update, which is the default behavior
 	RecreatePodUpdateStrategyType PodUpdateStrategyType = "ReCreate"
 	// InPlaceIfPossiblePodUpdateStrategyType indicates that we try to in-place update Pod instead of
-	// recreating Pod when possible. Currently, only image update of pod spec is allowed. Any other changes to the pod
+	// recreating Pod when possible. Currently, only image update of pod spec is allowed, as well as
+	// resources update when InPlaceWorkloadVerticalScaling feature-gate is enabled. Any other changes to the pod
 	// spec will fall back to ReCreate PodUpdateStrategyType where pod will be recreated.
 	InPlaceIfPossiblePodUpdateStrategyType PodUpdateStrategyType = "InPlaceIfPossible"
 	// InPlaceOnlyPodUpdateStrategyType indicates that we will in-place update Pod instead of
@@ -168,6 +255,13 @@ const (
 	// will be deleted in the scenario specified in
 	// StatefulSetPersistentVolumeClaimPolicy.
 	DeletePersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Delete"
+	// SnapshotPersistentVolumeClaimRetentionPolicyType specifies that a
+	// VolumeSnapshot will be created for each PersistentVolumeClaim before it
+	// is deleted. It is only supported by WhenScaled, and requires the
+	// VolumeSnapshot CRDs and a CSI driver supporting snapshots. The scale-down
+	// is blocked until the snapshots are ready to use, which never happens if the
+	// snapshots failed or the CRDs are missing, until the policy is changed.
+	SnapshotPersistentVolumeClaimRetentionPolicyType PersistentVolumeClaimRetentionPolicyType = "Snapshot"
 )
 
 // StatefulSetPersistentVolumeClaimRetentionPolicy describes the policy used for PVCs
@@ -182,8 +276,36 @@ type StatefulSetPersistentVolumeClaimRetentionPolicy struct {
 	// VolumeClaimTemplates when the StatefulSet is scaled down. The default
 	// policy of `Retain` causes PVCs to not be affected by a scaledown. The
 	// `Delete` policy causes the associated PVCs for any excess pods above
-	// the replica count to be deleted.
+	// the replica count to be deleted. The `Snapshot` policy causes a
+	// VolumeSnapshot to be created for each of those PVCs before they are deleted,
+	// and the scale-down waits for the snapshots to be ready to use.
+	WhenScaled PersistentVolumeClaimRetentionPolicyType `json:"whenScaled,omitempty"`
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshots created
+	// for the `Snapshot` policy. The default class is used if it is empty.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
+	// Templates overrides the policies for the PVCs created from the specific
+	// VolumeClaimTemplates, e.g. to retain the data PVCs while deleting the cache
+	// PVCs on scale-down.
+	// +optional
+	Templates []VolumeClaimTemplateRetentionPolicy `json:"templates,omitempty"`
+}
+
+// VolumeClaimTemplateRetentionPolicy describes the policy used for PVCs created
+// from a VolumeClaimTemplate. The policies that are empty inherit the ones of the StatefulSet.
+type VolumeClaimTemplateRetentionPolicy struct {
+	// Name is the name of the VolumeClaimTemplate.
+	Name string `json:"name"`
+	// WhenDeleted specifies what happens to the PVCs when the StatefulSet is deleted.
+	// +optional
+	WhenDeleted PersistentVolumeClaimRetentionPolicyType `json:"whenDeleted,omitempty"`
+	// WhenScaled specifies what happens to the PVCs when the StatefulSet is scaled down.
+	// +optional
 	WhenScaled PersistentVolumeClaimRetentionPolicyType `json:"whenScaled,omitempty"`
+	// VolumeSnapshotClassName is the VolumeSnapshotClass of the snapshots created
+	// for the `Snapshot` policy.
+	// +optional
+	VolumeSnapshotClassName *string `json:"volumeSnapshotClassName,omitempty"`
 }
 
 // StatefulSetOrdinals describes the policy used for replica ordinal assignment
@@ -199,6 +321,25 @@ type StatefulSetOrdinals struct {
 	//   [0, .spec.replicas).
 	// +optional
 	Start int32 `json:"start" protobuf:"varint,1,opt,name=start"`
+
+	// end is the number representing the last replica's index (inclusive) that
+	// this StatefulSet may own. Together with start, it allows StatefulSets in
+	// different clusters to own disjoint ranges of ordinals, so that replicas
+	// can be migrated between them one ordinal at a time.
+	// If set, replica indices will be in the range:
+	//   [.spec.ordinals.start, .spec.ordinals.end],
+	// and replicas beyond the range will not be created.
+	// The range can span at most 100000 ordinals.
+	// +optional
+	End *int32 `json:"end,omitempty"`
+
+	// list is an explicit list of replica indices that this StatefulSet may own.
+	// Replicas are assigned to the listed indices in ascending order, skipping
+	// the reserveOrdinals. It can not be set together with start or end.
+	// The partition counts the replicas in this order, and the listed indices
+	// can span at most 100000 ordinals from the smallest to the largest.
+	// +optional
+	List []int32 `json:"list,omitempty"`
 }
 
 // StatefulSetSpec defines the desired state of StatefulSet
@@ -282,6 +423,10 @@ type StatefulSetSpec struct {
 	// Lifecycle defines the lifecycle hooks for Pods pre-delete, in-place update.
 	Lifecycle *appspub.Lifecycle `json:"lifecycle,omitempty"`
 
+	// PreUpdateApproval is the hook to approve each Pod before it moves to PreparingUpdate or is recreated for update.
+	// +optional
+	PreUpdateApproval *appspub.LifecycleApprovalHook `json:"preUpdateApproval,omitempty"`
+
 	// scaleStrategy indicates the StatefulSetScaleStrategy that will be
 	// employed to scale Pods in the StatefulSet.
 	ScaleStrategy *StatefulSetScaleStrategy `json:"scaleStrategy,omitempty"`
@@ -299,6 +444,42 @@ type StatefulSetSpec struct {
 	// enabled, which is beta.
 	// +optional
 	Ordinals *StatefulSetOrdinals `json:"ordinals,omitempty"`
+
+	// maintenanceOrdinals lists the replicas taken out of service for maintenance.
+	// Unlike reserveOrdinals, the replicas keep their ordinals and PVCs, and no other
+	// replica will be created in place of them. Pods under maintenance are excluded
+	// from scaling, updates and availability accounting until they are removed from the list.
+	// +optional
+	MaintenanceOrdinals []MaintenanceOrdinal `json:"maintenanceOrdinals,omitempty"`
+
+	// ordinalStatusLimit is the maximum number of replicas reported in status.ordinalStatuses,
+	// which are listed from the lowest ordinal. Defaults to 0, which disables the per-ordinal status.
+	// +optional
+	OrdinalStatusLimit *int32 `json:"ordinalStatusLimit,omitempty"`
+}
+
+// MaintenancePodPolicyType defines what happens to the Pod of a replica under maintenance.
+type MaintenancePodPolicyType string
+
+const (
+	// KeepMaintenancePodPolicyType keeps the Pod running and marks it not-ready,
+	// so that it will be removed from the endpoints of services.
+	// It relies on the KruisePodReady readiness gate, which is injected into the Pods by the Kruise pod webhook
+	// on creation. The Pods created without the readiness gate are kept running but not marked not-ready.
+	KeepMaintenancePodPolicyType MaintenancePodPolicyType = "Keep"
+	// DeleteMaintenancePodPolicyType deletes the Pod and does not recreate it until the maintenance ends.
+	DeleteMaintenancePodPolicyType MaintenancePodPolicyType = "Delete"
+)
+
+// MaintenanceOrdinal describes a replica under maintenance.
+type MaintenanceOrdinal struct {
+	// Ordinal is the ordinal of the replica under maintenance.
+	Ordinal int32 `json:"ordinal"`
+
+	// PodPolicy indicates what happens to the Pod of the replica during maintenance.
+	// Default to Keep, which requires the Pod to have the KruisePodReady readiness gate to be marked not-ready.
+	// +optional
+	PodPolicy MaintenancePodPolicyType `json:"podPolicy,omitempty"`
 }
 
 // StatefulSetScaleStrategy defines strategies for pods scale.
@@ -342,6 +523,9 @@ type StatefulSetStatus struct {
 	//for atleast minReadySeconds.
 	UpdatedAvailableReplicas int32 `json:"updatedAvailableReplicas,omitempty"`
 
+	// maintenanceReplicas is the number of replicas under maintenance.
+	MaintenanceReplicas int32 `json:"maintenanceReplicas,omitempty"`
+
 	// currentRevision, if not empty, indicates the version of the StatefulSet used to generate Pods in the
 	// sequence [0,currentReplicas).
 	CurrentRevision string `json:"currentRevision,omitempty"`
@@ -370,6 +554,74 @@ type StatefulSetStatus struct {
 	// to match any changes made to the volumeClaimTemplates, ensuring synchronization
 	// between the defined templates and the actual PersistentVolumeClaims in use.
 	VolumeClaims []VolumeClaimStatus `json:"volumeClaims,omitempty"`
+
+	// AnalysisRun records the analysis of the latest update batch.
+	AnalysisRun *appspub.AnalysisRunStatus `json:"analysisRun,omitempty"`
+
+	// ordinalStatuses reports the state of each replica in the order of ordinals,
+	// at most spec.ordinalStatusLimit of them.
+	// +optional
+	OrdinalStatuses []StatefulSetOrdinalStatus `json:"ordinalStatuses,omitempty"`
+
+	// TopologyDomainUpdate reports the topology domain being updated by the topologyDomainStrategy,
+	// while it has unavailable pods or the later domains are waiting for it.
+	// +optional
+	TopologyDomainUpdate *TopologyDomainUpdateStatus `json:"topologyDomainUpdate,omitempty"`
+}
+
+// TopologyDomainUpdateStatus is the observed state of the topology domain being updated.
+type TopologyDomainUpdateStatus struct {
+	// Domain is the topology domain being updated.
+	Domain string `json:"domain"`
+
+	// UnavailableReplicas is the number of the unavailable pods in the domain.
+	// The later domains will not be updated until all pods in the domain are updated and available.
+	// +optional
+	UnavailableReplicas int32 `json:"unavailableReplicas,omitempty"`
+
+	// UnavailablePods are the names of the unavailable pods in the domain, sorted by name and limited to the first 10 of them.
+	// +optional
+	UnavailablePods []string `json:"unavailablePods,omitempty"`
+
+	// PendingDomains is the number of the later domains waiting for the domain to be updated and available.
+	// +optional
+	PendingDomains int32 `json:"pendingDomains,omitempty"`
+}
+
+// OrdinalInPlaceUpdateStateType is the in-place update state of a replica.
+type OrdinalInPlaceUpdateStateType string
+
+const (
+	// InPlaceUpdatingOrdinalState means the Pod is being updated in-place.
+	InPlaceUpdatingOrdinalState OrdinalInPlaceUpdateStateType = "Updating"
+	// InPlaceUpdatedOrdinalState means the last in-place update of the Pod has completed.
+	InPlaceUpdatedOrdinalState OrdinalInPlaceUpdateStateType = "Updated"
+)
+
+// StatefulSetOrdinalStatus is the observed state of the Pod of a replica.
+type StatefulSetOrdinalStatus struct {
+	// Ordinal is the ordinal of the replica.
+	Ordinal int32 `json:"ordinal"`
+
+	// Revision is the revision of the Pod.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// LifecycleState is the lifecycle state of the Pod.
+	// +optional
+	LifecycleState appspub.LifecycleStateType `json:"lifecycleState,omitempty"`
+
+	// InPlaceUpdateState is the in-place update state of the Pod, empty if it has never been updated in-place.
+	// +optional
+	InPlaceUpdateState OrdinalInPlaceUpdateStateType `json:"inPlaceUpdateState,omitempty"`
+
+	// ClaimsCompatible indicates whether the PVCs of the Pod are compatible with the volumeClaimTemplates.
+	// It is only reported when the StatefulSetAutoResizePVCGate feature is enabled.
+	// +optional
+	ClaimsCompatible *bool `json:"claimsCompatible,omitempty"`
+
+	// Ready indicates whether the Pod is running and ready.
+	Ready bool `json:"ready"`
 }
 
 // These are valid conditions of a statefulset.
@@ -29,6 +29,21 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaintenanceOrdinal) DeepCopyInto(out *MaintenanceOrdinal) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaintenanceOrdinal.
+func (in *MaintenanceOrdinal) DeepCopy() *MaintenanceOrdinal {
+	if in == nil {
+		return nil
+	}
+	out := new(MaintenanceOrdinal)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RollingUpdateStatefulSetStrategy) DeepCopyInto(out *RollingUpdateStatefulSetStrategy) {
 	*out = *in
@@ -57,6 +72,11 @@ func (in *RollingUpdateStatefulSetStrategy) DeepCopyInto(out *RollingUpdateState
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Analysis != nil {
+		in, out := &in.Analysis, &out.Analysis
+		*out = new(pub.RolloutAnalysis)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatefulSetStrategy.
@@ -128,9 +148,39 @@ func (in *StatefulSetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StatefulSetOrdinalStatus) DeepCopyInto(out *StatefulSetOrdinalStatus) {
+	*out = *in
+	if in.ClaimsCompatible != nil {
+		in, out := &in.ClaimsCompatible, &out.ClaimsCompatible
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSetOrdinalStatus.
+func (in *StatefulSetOrdinalStatus) DeepCopy() *StatefulSetOrdinalStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(StatefulSetOrdinalStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatefulSetOrdinals) DeepCopyInto(out *StatefulSetOrdinals) {
 	*out = *in
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = new(int32)
+		**out = **in
+	}
+	if in.List != nil {
+		in, out := &in.List, &out.List
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSetOrdinals.
@@ -146,6 +196,18 @@ func (in *StatefulSetOrdinals) DeepCopy() *StatefulSetOrdinals {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatefulSetPersistentVolumeClaimRetentionPolicy) DeepCopyInto(out *StatefulSetPersistentVolumeClaimRetentionPolicy) {
 	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]VolumeClaimTemplateRetentionPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StatefulSetPersistentVolumeClaimRetentionPolicy.
@@ -216,6 +278,11 @@ func (in *StatefulSetSpec) DeepCopyInto(out *StatefulSetSpec) {
 		*out = new(pub.Lifecycle)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.PreUpdateApproval != nil {
+		in, out := &in.PreUpdateApproval, &out.PreUpdateApproval
+		*out = new(pub.LifecycleApprovalHook)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.ScaleStrategy != nil {
 		in, out := &in.ScaleStrategy, &out.ScaleStrategy
 		*out = new(StatefulSetScaleStrategy)
@@ -224,11 +291,21 @@ func (in *StatefulSetSpec) DeepCopyInto(out *StatefulSetSpec) {
 	if in.PersistentVolumeClaimRetentionPolicy != nil {
 		in, out := &in.PersistentVolumeClaimRetentionPolicy, &out.PersistentVolumeClaimRetentionPolicy
 		*out = new(StatefulSetPersistentVolumeClaimRetentionPolicy)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Ordinals != nil {
 		in, out := &in.Ordinals, &out.Ordinals
 		*out = new(StatefulSetOrdinals)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaintenanceOrdinals != nil {
+		in, out := &in.MaintenanceOrdinals, &out.MaintenanceOrdinals
+		*out = make([]MaintenanceOrdinal, len(*in))
+		copy(*out, *in)
+	}
+	if in.OrdinalStatusLimit != nil {
+		in, out := &in.OrdinalStatusLimit, &out.OrdinalStatusLimit
+		*out = new(int32)
 		**out = **in
 	}
 }
@@ -261,7 +338,26 @@ func (in *StatefulSetStatus) DeepCopyInto(out *StatefulSetStatus) {
 	if in.VolumeClaims != nil {
 		in, out := &in.VolumeClaims, &out.VolumeClaims
 		*out = make([]VolumeClaimStatus, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnalysisRun != nil {
+		in, out := &in.AnalysisRun, &out.AnalysisRun
+		*out = new(pub.AnalysisRunStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OrdinalStatuses != nil {
+		in, out := &in.OrdinalStatuses, &out.OrdinalStatuses
+		*out = make([]StatefulSetOrdinalStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.TopologyDomainUpdate != nil {
+		in, out := &in.TopologyDomainUpdate, &out.TopologyDomainUpdate
+		*out = new(TopologyDomainUpdateStatus)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -295,6 +391,41 @@ func (in *StatefulSetUpdateStrategy) DeepCopy() *StatefulSetUpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyDomainUpdateStrategy) DeepCopyInto(out *TopologyDomainUpdateStrategy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyDomainUpdateStrategy.
+func (in *TopologyDomainUpdateStrategy) DeepCopy() *TopologyDomainUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyDomainUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyDomainUpdateStatus) DeepCopyInto(out *TopologyDomainUpdateStatus) {
+	*out = *in
+	if in.UnavailablePods != nil {
+		in, out := &in.UnavailablePods, &out.UnavailablePods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyDomainUpdateStatus.
+func (in *TopologyDomainUpdateStatus) DeepCopy() *TopologyDomainUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyDomainUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnorderedUpdateStrategy) DeepCopyInto(out *UnorderedUpdateStrategy) {
 	*out = *in
@@ -303,6 +434,16 @@ func (in *UnorderedUpdateStrategy) DeepCopyInto(out *UnorderedUpdateStrategy) {
 		*out = new(pub.UpdatePriorityStrategy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.TopologyDomainStrategy != nil {
+		in, out := &in.TopologyDomainStrategy, &out.TopologyDomainStrategy
+		*out = new(TopologyDomainUpdateStrategy)
+		**out = **in
+	}
+	if in.RolePriority != nil {
+		in, out := &in.RolePriority, &out.RolePriority
+		*out = new(UpdatePriorityRoleTerm)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnorderedUpdateStrategy.
@@ -315,9 +456,49 @@ func (in *UnorderedUpdateStrategy) DeepCopy() *UnorderedUpdateStrategy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdatePriorityRoleTerm) DeepCopyInto(out *UpdatePriorityRoleTerm) {
+	*out = *in
+	if in.LeaderValues != nil {
+		in, out := &in.LeaderValues, &out.LeaderValues
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdatePriorityRoleTerm.
+func (in *UpdatePriorityRoleTerm) DeepCopy() *UpdatePriorityRoleTerm {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdatePriorityRoleTerm)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeClaimResizeFailure) DeepCopyInto(out *VolumeClaimResizeFailure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeClaimResizeFailure.
+func (in *VolumeClaimResizeFailure) DeepCopy() *VolumeClaimResizeFailure {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeClaimResizeFailure)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeClaimStatus) DeepCopyInto(out *VolumeClaimStatus) {
 	*out = *in
+	if in.ResizeFailures != nil {
+		in, out := &in.ResizeFailures, &out.ResizeFailures
+		*out = make([]VolumeClaimResizeFailure, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeClaimStatus.
@@ -330,6 +511,26 @@ func (in *VolumeClaimStatus) DeepCopy() *VolumeClaimStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeClaimTemplateRetentionPolicy) DeepCopyInto(out *VolumeClaimTemplateRetentionPolicy) {
+	*out = *in
+	if in.VolumeSnapshotClassName != nil {
+		in, out := &in.VolumeSnapshotClassName, &out.VolumeSnapshotClassName
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeClaimTemplateRetentionPolicy.
+func (in *VolumeClaimTemplateRetentionPolicy) DeepCopy() *VolumeClaimTemplateRetentionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeClaimTemplateRetentionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VolumeClaimUpdateStrategy) DeepCopyInto(out *VolumeClaimUpdateStrategy) {
 	*out = *in
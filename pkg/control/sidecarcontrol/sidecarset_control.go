@@ -76,13 +76,13 @@ func (c *commonControl) IsPodReady(pod *v1.Pod) bool {
 	// check whether hot upgrade is complete
 	// map[string]string: {empty container name}->{sidecarSet.spec.containers[x].upgradeStrategy.HotUpgradeEmptyImage}
 	emptyContainers := map[string]string{}
-	for _, sidecarContainer := range sidecarSet.Spec.Containers {
+	for _, sidecarContainer := range GetUpdatableSidecarContainers(sidecarSet) {
 		if IsHotUpgradeContainer(&sidecarContainer) {
 			_, emptyContainer := GetPodHotUpgradeContainers(sidecarContainer.Name, pod)
 			emptyContainers[emptyContainer] = sidecarContainer.UpgradeStrategy.HotUpgradeEmptyImage
 		}
 	}
-	for _, container := range pod.Spec.Containers {
+	for _, container := range GetPodRunningContainers(pod) {
 		// If container is empty container, then its image must be empty image
 		if emptyImage := emptyContainers[container.Name]; emptyImage != "" && container.Image != emptyImage {
 			klog.V(5).InfoS("Pod sidecar empty container image wasn't empty image", "pod", klog.KObj(pod),
@@ -120,9 +120,10 @@ func (c *commonControl) UpdatePodAnnotationsInUpgrade(changedContainers []string
 		inPlaceUpdateState.LastContainerStatuses = make(map[string]pub.InPlaceUpdateContainerStatus)
 	}
 
-	cStatus := make(map[string]string, len(pod.Status.ContainerStatuses))
-	for i := range pod.Status.ContainerStatuses {
-		c := &pod.Status.ContainerStatuses[i]
+	containerStatuses := GetPodRunningContainerStatuses(pod)
+	cStatus := make(map[string]string, len(containerStatuses))
+	for i := range containerStatuses {
+		c := &containerStatuses[i]
 		cStatus[c.Name] = c.ImageID
 	}
 	for _, cName := range changedContainers {
@@ -141,7 +142,8 @@ func (c *commonControl) UpdatePodAnnotationsInUpgrade(changedContainers []string
 
 // only check sidecar container is consistent
 func (c *commonControl) IsPodStateConsistent(pod *v1.Pod, sidecarContainers sets.String) bool {
-	if len(pod.Spec.Containers) != len(pod.Status.ContainerStatuses) {
+	containers := GetPodRunningContainers(pod)
+	if len(containers) != len(GetPodRunningContainerStatuses(pod)) {
 		return false
 	}
 
@@ -152,7 +154,7 @@ func (c *commonControl) IsPodStateConsistent(pod *v1.Pod, sidecarContainers sets
 
 	allDigestImage := true
 	cImageIDs := util.GetPodContainerImageIDs(pod)
-	for _, container := range pod.Spec.Containers {
+	for _, container := range containers {
 		// only check whether sidecar container is consistent
 		if !sidecarContainers.Has(container.Name) {
 			continue
@@ -192,7 +194,7 @@ func (c *commonControl) IsSidecarSetUpgradable(pod *v1.Pod) (canUpgrade, consist
 
 	// cStatus: container.name -> containerStatus.Ready
 	cStatus := map[string]bool{}
-	for _, status := range pod.Status.ContainerStatuses {
+	for _, status := range GetPodRunningContainerStatuses(pod) {
 		cStatus[status.Name] = status.Ready
 	}
 	sidecarContainerList := GetSidecarContainersInPod(sidecarSet)
@@ -240,13 +242,14 @@ func IsSidecarContainerUpdateCompleted(pod *v1.Pod, sidecarSets, containers sets
 		}
 	}
 
-	containerImages := make(map[string]string, len(pod.Spec.Containers))
-	for i := range pod.Spec.Containers {
-		c := &pod.Spec.Containers[i]
+	podContainers := GetPodRunningContainers(pod)
+	containerImages := make(map[string]string, len(podContainers))
+	for i := range podContainers {
+		c := &podContainers[i]
 		containerImages[c.Name] = c.Image
 	}
 
-	for _, cs := range pod.Status.ContainerStatuses {
+	for _, cs := range GetPodRunningContainerStatuses(pod) {
 		// only check containers set
 		if !containers.Has(cs.Name) {
 			continue
@@ -256,7 +256,7 @@ func UpdatePodSidecarSetHash(pod *corev1.Pod, sidecarSet *appsv1alpha1.SidecarSe
 
 func GetSidecarContainersInPod(sidecarSet *appsv1alpha1.SidecarSet) sets.String {
 	names := sets.NewString()
-	for _, sidecarContainer := range sidecarSet.Spec.Containers {
+	for _, sidecarContainer := range GetUpdatableSidecarContainers(sidecarSet) {
 		if IsHotUpgradeContainer(&sidecarContainer) {
 			name1, name2 := GetHotUpgradeContainerName(sidecarContainer.Name)
 			names.Insert(name2)
@@ -285,8 +285,9 @@ func IsPodInjectedSidecarSet(pod *corev1.Pod, sidecarSet *appsv1alpha1.SidecarSe
 }
 
 func IsPodConsistentWithSidecarSet(pod *corev1.Pod, sidecarSet *appsv1alpha1.SidecarSet) bool {
-	for i := range sidecarSet.Spec.Containers {
-		container := &sidecarSet.Spec.Containers[i]
+	sidecarContainers := GetUpdatableSidecarContainers(sidecarSet)
+	for i := range sidecarContainers {
+		container := &sidecarContainers[i]
 		switch container.UpgradeStrategy.UpgradeType {
 		case appsv1alpha1.SidecarContainerHotUpgrade:
 			_, exist := GetPodHotUpgradeInfoInAnnotations(pod)[container.Name]
@@ -569,6 +570,47 @@ func IsSidecarContainer(container corev1.Container) bool {
 	return false
 }
 
+// GetUpdatableSidecarContainers returns the native sidecar initContainers and the containers of the sidecarSet,
+// both of which can be in-place updated in the injected pods.
+func GetUpdatableSidecarContainers(sidecarSet *appsv1alpha1.SidecarSet) []appsv1alpha1.SidecarContainer {
+	sidecarContainers := make([]appsv1alpha1.SidecarContainer, 0, len(sidecarSet.Spec.InitContainers)+len(sidecarSet.Spec.Containers))
+	for i := range sidecarSet.Spec.InitContainers {
+		if IsSidecarContainer(sidecarSet.Spec.InitContainers[i].Container) {
+			sidecarContainers = append(sidecarContainers, sidecarSet.Spec.InitContainers[i])
+		}
+	}
+	return append(sidecarContainers, sidecarSet.Spec.Containers...)
+}
+
+// GetPodRunningContainers returns the native sidecar initContainers and the containers of the pod,
+// which keep running during the whole lifetime of the pod.
+func GetPodRunningContainers(pod *corev1.Pod) []corev1.Container {
+	containers := make([]corev1.Container, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+	for i := range pod.Spec.InitContainers {
+		if IsSidecarContainer(pod.Spec.InitContainers[i]) {
+			containers = append(containers, pod.Spec.InitContainers[i])
+		}
+	}
+	return append(containers, pod.Spec.Containers...)
+}
+
+// GetPodRunningContainerStatuses returns the statuses of the native sidecar initContainers and the containers of the pod.
+func GetPodRunningContainerStatuses(pod *corev1.Pod) []corev1.ContainerStatus {
+	sidecarNames := sets.NewString()
+	for i := range pod.Spec.InitContainers {
+		if IsSidecarContainer(pod.Spec.InitContainers[i]) {
+			sidecarNames.Insert(pod.Spec.InitContainers[i].Name)
+		}
+	}
+	statuses := make([]corev1.ContainerStatus, 0, sidecarNames.Len()+len(pod.Status.ContainerStatuses))
+	for i := range pod.Status.InitContainerStatuses {
+		if sidecarNames.Has(pod.Status.InitContainerStatuses[i].Name) {
+			statuses = append(statuses, pod.Status.InitContainerStatuses[i])
+		}
+	}
+	return append(statuses, pod.Status.ContainerStatuses...)
+}
+
 // listSidecarNameInSidecarSet list always init containers and sidecar containers
 func listSidecarNameInSidecarSet(sidecarSet *appsv1alpha1.SidecarSet) sets.String {
 	sidecarList := sets.NewString()
@@ -283,6 +283,55 @@ func TestIsSidecarContainerUpdateCompleted(t *testing.T) {
 	}
 }
 
+func TestGetPodRunningContainers(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{
+				{Name: "init"},
+				{Name: "native-sidecar", RestartPolicy: &always},
+			},
+			Containers: []corev1.Container{{Name: "main"}},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{{Name: "init"}, {Name: "native-sidecar"}},
+			ContainerStatuses:     []corev1.ContainerStatus{{Name: "main"}},
+		},
+	}
+
+	var names []string
+	for _, container := range GetPodRunningContainers(pod) {
+		names = append(names, container.Name)
+	}
+	if expect := []string{"native-sidecar", "main"}; !reflect.DeepEqual(names, expect) {
+		t.Fatalf("expect running containers %v, but got %v", expect, names)
+	}
+	names = nil
+	for _, status := range GetPodRunningContainerStatuses(pod) {
+		names = append(names, status.Name)
+	}
+	if expect := []string{"native-sidecar", "main"}; !reflect.DeepEqual(names, expect) {
+		t.Fatalf("expect running container statuses %v, but got %v", expect, names)
+	}
+
+	sidecarSet := &appsv1alpha1.SidecarSet{
+		Spec: appsv1alpha1.SidecarSetSpec{
+			InitContainers: []appsv1alpha1.SidecarContainer{
+				{Container: pod.Spec.InitContainers[0]},
+				{Container: pod.Spec.InitContainers[1]},
+			},
+			Containers: []appsv1alpha1.SidecarContainer{{Container: corev1.Container{Name: "sidecar"}}},
+		},
+	}
+	names = nil
+	for _, container := range GetUpdatableSidecarContainers(sidecarSet) {
+		names = append(names, container.Name)
+	}
+	if expect := []string{"native-sidecar", "sidecar"}; !reflect.DeepEqual(names, expect) {
+		t.Fatalf("expect updatable sidecar containers %v, but got %v", expect, names)
+	}
+}
+
 func TestGetPodSidecarSetRevision(t *testing.T) {
 	cases := []struct {
 		name   string
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"context"
+	"time"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// calculateAnalysisRun maintains the analysis run of the current update batch in newStatus.
+// It returns the duration after which the metrics should be measured again.
+func (r *realStatusUpdater) calculateAnalysisRun(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, now metav1.Time) time.Duration {
+	rollout := analysis.Rollout{
+		Args: analysis.Args{
+			Namespace: cs.Namespace,
+			Name:      cs.Name,
+			Kind:      clonesetutils.ControllerKind.Kind,
+			UID:       cs.UID,
+			Revision:  newStatus.UpdateRevision,
+		},
+		Generation:              cs.Generation,
+		Paused:                  cs.Spec.UpdateStrategy.Paused,
+		CurrentRevision:         newStatus.CurrentRevision,
+		Replicas:                *cs.Spec.Replicas,
+		ExpectedUpdatedReplicas: newStatus.ExpectedUpdatedReplicas,
+		UpdatedReadyReplicas:    newStatus.UpdatedReadyReplicas,
+	}
+	run, requeueDuration := r.analyzer.Sync(context.TODO(), cs.Spec.UpdateStrategy.Analysis, cs.Status.AnalysisRun, rollout, now)
+	newStatus.AnalysisRun = run
+	return requeueDuration
+}
+
+// holdPartitionForAnalysis raises the partition of the given CloneSet copy, so that no more pods will
+// be updated than the batch whose analysis has not passed yet.
+func holdPartitionForAnalysis(cs *appsv1alpha1.CloneSet, updateRevision string) {
+	held, ok := analysis.GetHeldUpdatedReplicas(cs.Spec.UpdateStrategy.Analysis, cs.Status.AnalysisRun, updateRevision)
+	if !ok {
+		return
+	}
+	partition := *cs.Spec.Replicas - held
+	if partition < 0 {
+		partition = 0
+	}
+	if current, err := util.CalculatePartitionReplicas(cs.Spec.UpdateStrategy.Partition, cs.Spec.Replicas); err == nil && int32(current) >= partition {
+		return
+	}
+	klog.V(4).InfoS("CloneSet held partition for analysis", "cloneSet", klog.KObj(cs), "partition", partition)
+	p := intstrutil.FromInt(int(partition))
+	cs.Spec.UpdateStrategy.Partition = &p
+}
+
+// pauseOnAnalysisFailed pauses the CloneSet if the analysis of the current batch has just failed.
+func (r *ReconcileCloneSet) pauseOnAnalysisFailed(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus) error {
+	if cs.Spec.UpdateStrategy.Paused || !analysis.IsNewlyFailed(newStatus.AnalysisRun, cs.Generation) {
+		return nil
+	}
+
+	body := `{"spec":{"updateStrategy":{"paused":true}}}`
+	if err := r.Patch(context.TODO(), cs.DeepCopy(), client.RawPatch(types.MergePatchType, []byte(body))); err != nil {
+		r.recorder.Eventf(cs, v1.EventTypeWarning, "FailedPause", "failed to pause CloneSet for failed analysis: %v", err)
+		return err
+	}
+	klog.InfoS("Paused CloneSet for failed analysis", "cloneSet", klog.KObj(cs), "updatedReplicas", newStatus.AnalysisRun.UpdatedReplicas)
+	r.recorder.Eventf(cs, v1.EventTypeWarning, "AnalysisFailed", "paused CloneSet for failed analysis of %d updated replicas: %s",
+		newStatus.AnalysisRun.UpdatedReplicas, newStatus.AnalysisRun.Message)
+	return nil
+}
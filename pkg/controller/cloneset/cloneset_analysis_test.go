@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"testing"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+func TestHoldPartitionForAnalysis(t *testing.T) {
+	cases := []struct {
+		name              string
+		partition         intstr.IntOrString
+		run               *appspub.AnalysisRunStatus
+		expectedPartition intstr.IntOrString
+	}{
+		{
+			name:              "no analysis run",
+			partition:         intstr.FromInt(5),
+			expectedPartition: intstr.FromInt(5),
+		},
+		{
+			name:              "analysis running",
+			partition:         intstr.FromString("50%"),
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning},
+			expectedPartition: intstr.FromInt(8),
+		},
+		{
+			name:              "analysis failed",
+			partition:         intstr.FromInt(0),
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 5, Phase: appspub.AnalysisPhaseFailed},
+			expectedPartition: intstr.FromInt(5),
+		},
+		{
+			name:              "analysis passed",
+			partition:         intstr.FromInt(5),
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseSuccessful},
+			expectedPartition: intstr.FromInt(5),
+		},
+		{
+			name:              "partition larger than batch",
+			partition:         intstr.FromInt(9),
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending},
+			expectedPartition: intstr.FromInt(9),
+		},
+		{
+			name:              "analysis of old revision",
+			partition:         intstr.FromInt(0),
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v1", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseFailed},
+			expectedPartition: intstr.FromInt(0),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			partition := tc.partition
+			cs := &appsv1alpha1.CloneSet{
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas: utilpointer.Int32(10),
+					UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+						Partition: &partition,
+						Analysis: &appspub.RolloutAnalysis{
+							Metrics: []appspub.AnalysisMetric{{Name: "stub", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}},
+						},
+					},
+				},
+				Status: appsv1alpha1.CloneSetStatus{AnalysisRun: tc.run},
+			}
+			holdPartitionForAnalysis(cs, "v2")
+			if *cs.Spec.UpdateStrategy.Partition != tc.expectedPartition {
+				t.Fatalf("expected partition %v, got %v", tc.expectedPartition.String(), cs.Spec.UpdateStrategy.Partition.String())
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ import (
 	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
 	"github.com/openkruise/kruise/pkg/features"
 	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
 	utilclient "github.com/openkruise/kruise/pkg/util/client"
 	utildiscovery "github.com/openkruise/kruise/pkg/util/discovery"
 	"github.com/openkruise/kruise/pkg/util/expectations"
@@ -99,11 +100,13 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 		recorder = eventBroadcaster.NewRecorder(mgr.GetScheme(), v1.EventSource{Component: "cloneset-controller"})
 	}
 	cli := utilclient.NewClientFromManager(mgr, "cloneset-controller")
+	analyzer := analysis.NewDefaultAnalyzer()
 	reconciler := &ReconcileCloneSet{
 		Client:            cli,
 		scheme:            mgr.GetScheme(),
 		recorder:          recorder,
-		statusUpdater:     newStatusUpdater(cli),
+		analyzer:          analyzer,
+		statusUpdater:     newStatusUpdater(cli, recorder, analyzer),
 		controllerHistory: historyutil.NewHistory(cli),
 		revisionControl:   revisioncontrol.NewRevisionControl(),
 	}
@@ -162,6 +165,7 @@ type ReconcileCloneSet struct {
 	reconcileFunc func(request reconcile.Request) (reconcile.Result, error)
 
 	recorder          record.EventRecorder
+	analyzer          *analysis.Analyzer
 	controllerHistory history.Interface
 	statusUpdater     StatusUpdater
 	revisionControl   revisioncontrol.Interface
@@ -172,6 +176,7 @@ type ReconcileCloneSet struct {
 // +kubebuilder:rbac:groups=core,resources=pods/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.kruise.io,resources=clonesets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.kruise.io,resources=clonesets/status,verbs=get;update;patch
@@ -208,6 +213,9 @@ func (r *ReconcileCloneSet) doReconcile(request reconcile.Request) (res reconcil
 			// For additional cleanup logic use finalizers.
 			klog.V(3).InfoS("CloneSet has been deleted", "cloneSet", request)
 			clonesetutils.ScaleExpectations.DeleteExpectations(request.String())
+			if r.analyzer != nil {
+				r.analyzer.Forget(clonesetutils.ControllerKind.Kind, request.Namespace, request.Name)
+			}
 			return reconcile.Result{}, nil
 		}
 		return reconcile.Result{}, err
@@ -335,14 +343,37 @@ func (r *ReconcileCloneSet) doReconcile(request reconcile.Request) (res reconcil
 		}
 	}
 
+	// move partition to the current update step before updating pods
+	if patched, err := r.syncUpdateStepPartition(instance, instance.Status.UpdateStepStatus, currentRevision.Name, updateRevision.Name); err != nil {
+		return reconcile.Result{}, err
+	} else if patched {
+		return reconcile.Result{}, nil
+	}
+
+	// roll back to the current revision if the updated pods are unhealthy
+	if rolledBack, err := r.rollbackIfNeeded(instance, currentRevision, updateRevision, filteredPods); err != nil {
+		return reconcile.Result{}, err
+	} else if rolledBack {
+		return reconcile.Result{}, nil
+	}
+
 	// scale and update pods
 	syncErr := r.syncCloneSet(instance, &newStatus, currentRevision, updateRevision, revisions, filteredPods, filteredPVCs)
+	calculateVolumeClaimStatus(instance, &newStatus, filteredPods, filteredPVCs)
 
 	// update new status
 	if err = r.statusUpdater.UpdateCloneSetStatus(instance, &newStatus, filteredPods); err != nil {
 		return reconcile.Result{}, err
 	}
 
+	if err = r.pauseOnAnalysisFailed(instance, &newStatus); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if _, err = r.syncUpdateStepPartition(instance, newStatus.UpdateStepStatus, newStatus.CurrentRevision, newStatus.UpdateRevision); err != nil {
+		return reconcile.Result{}, err
+	}
+
 	if err = r.truncatePodsToDelete(instance, filteredPods); err != nil {
 		klog.ErrorS(err, "Failed to truncate podsToDelete for CloneSet", "cloneSet", request)
 	}
@@ -375,12 +406,13 @@ func (r *ReconcileCloneSet) syncCloneSet(
 	if err != nil {
 		return err
 	}
+	holdPartitionForAnalysis(updateSet, updateRevision.Name)
 
 	var scaling bool
 	var podsScaleErr error
 	var podsUpdateErr error
 
-	scaling, podsScaleErr = r.syncControl.Scale(currentSet, updateSet, currentRevision.Name, updateRevision.Name, filteredPods, filteredPVCs)
+	scaling, podsScaleErr = r.syncControl.Scale(currentSet, updateSet, currentRevision.Name, updateRevision.Name, filteredPods, filteredPVCs, newStatus)
 	if podsScaleErr != nil {
 		newStatus.Conditions = append(newStatus.Conditions, appsv1alpha1.CloneSetCondition{
 			Type:               appsv1alpha1.CloneSetConditionFailedScale,
@@ -454,6 +486,7 @@ func (r *ReconcileCloneSet) getActiveRevisions(cs *appsv1alpha1.CloneSet, revisi
 		if !VCTHashEqual(lastEqualRevision, updateRevision) {
 			klog.InfoS("Revision vct hash will be updated", "revisionName", lastEqualRevision.Name, "lastRevisionVCTHash", lastEqualRevision.Annotations[volumeclaimtemplate.HashAnnotation], "updateRevisionVCTHash", updateRevision.Annotations[volumeclaimtemplate.HashAnnotation])
 			lastEqualRevision.Annotations[volumeclaimtemplate.HashAnnotation] = updateRevision.Annotations[volumeclaimtemplate.HashAnnotation]
+			lastEqualRevision.Annotations[volumeclaimtemplate.StorageExcludedHashAnnotation] = updateRevision.Annotations[volumeclaimtemplate.StorageExcludedHashAnnotation]
 		}
 		// if the equivalent revision is not immediately prior we will roll back by incrementing the
 		// Revision of the equivalent revision
@@ -44,9 +44,10 @@ func (r *ReconcileCloneSet) createImagePullJobsForInPlaceUpdate(cs *appsv1alpha1
 		return nil
 	}
 
-	// ignore if update type is ReCreate
-	if cs.Spec.UpdateStrategy.Type == appsv1alpha1.RecreateCloneSetUpdateStrategyType {
-		klog.V(4).InfoS("CloneSet skipped to create ImagePullJob for update type is ReCreate", "cloneSet", klog.KObj(cs))
+	// ignore if update type is ReCreate or BlueGreen
+	if cs.Spec.UpdateStrategy.Type == appsv1alpha1.RecreateCloneSetUpdateStrategyType ||
+		cs.Spec.UpdateStrategy.Type == appsv1alpha1.BlueGreenCloneSetUpdateStrategyType {
+		klog.V(4).InfoS("CloneSet skipped to create ImagePullJob for update type", "cloneSet", klog.KObj(cs), "type", cs.Spec.UpdateStrategy.Type)
 		return r.patchControllerRevisionLabels(updateRevision, appsv1alpha1.ImagePreDownloadIgnoredKey, "true")
 	}
 
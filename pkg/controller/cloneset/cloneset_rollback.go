@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetcore "github.com/openkruise/kruise/pkg/controller/cloneset/core"
+	"github.com/openkruise/kruise/pkg/controller/cloneset/sync"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util/specifieddelete"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// rollbackIfNeeded sets the template of CloneSet back to the current revision, if the updated pods have
+// exceeded any of the thresholds in rollbackPolicy. It returns true if the CloneSet has been rolled back.
+func (r *ReconcileCloneSet) rollbackIfNeeded(cs *appsv1alpha1.CloneSet, currentRevision, updateRevision *apps.ControllerRevision, pods []*v1.Pod) (bool, error) {
+	if cs.Spec.UpdateStrategy.RollbackPolicy == nil || cs.Spec.UpdateStrategy.Paused || currentRevision.Name == updateRevision.Name {
+		return false, nil
+	}
+
+	reason, message, requeueDuration := checkRollbackThresholds(cs, updateRevision.Name, pods, time.Now())
+	if reason == "" {
+		if requeueDuration > 0 {
+			clonesetutils.DurationStore.Push(clonesetutils.GetControllerKey(cs), requeueDuration)
+		}
+		return false, nil
+	}
+
+	currentSet, err := r.revisionControl.ApplyRevision(cs, currentRevision)
+	if err != nil {
+		return false, err
+	}
+
+	var rolledBack bool
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		clone := &appsv1alpha1.CloneSet{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name}, clone); err != nil {
+			return err
+		}
+		// the spec has been modified since we checked the thresholds, so leave it to the next reconcile
+		if clone.Generation != cs.Generation {
+			return nil
+		}
+		clone.Spec.Template = currentSet.Spec.Template
+		if err := r.Update(context.TODO(), clone); err != nil {
+			return err
+		}
+		rolledBack = true
+		return nil
+	})
+	if err != nil {
+		r.recorder.Eventf(cs, v1.EventTypeWarning, "FailedRollback", "failed to roll back CloneSet to revision %s: %v", currentRevision.Name, err)
+		return false, err
+	} else if !rolledBack {
+		return false, nil
+	}
+
+	klog.InfoS("Rolled back CloneSet to current revision", "cloneSet", klog.KObj(cs), "currentRevision", currentRevision.Name,
+		"updateRevision", updateRevision.Name, "reason", reason)
+	r.recorder.Eventf(cs, v1.EventTypeWarning, "RolledBack", "rolled back CloneSet from revision %s to %s: %s",
+		updateRevision.Name, currentRevision.Name, message)
+
+	err = retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		clone := &appsv1alpha1.CloneSet{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name}, clone); err != nil {
+			return err
+		}
+		cond := clonesetutils.NewCloneSetCondition(appsv1alpha1.CloneSetConditionRolledBack, v1.ConditionTrue, reason,
+			fmt.Sprintf("Rolled back from revision %s to %s: %s", updateRevision.Name, currentRevision.Name, message))
+		clonesetutils.SetCloneSetCondition(&clone.Status, cond)
+		clone.Status.RolledBack = &appsv1alpha1.CloneSetRolledBackStatus{FromRevision: updateRevision.Name, ToRevision: currentRevision.Name}
+		return r.Status().Update(context.TODO(), clone)
+	})
+	return true, err
+}
+
+// checkRollbackThresholds checks the updated pods against the thresholds in rollbackPolicy.
+// It returns the reason and message if any threshold has been exceeded, otherwise it returns the duration
+// after which the not-ready updated pods should be checked again.
+func checkRollbackThresholds(cs *appsv1alpha1.CloneSet, updateRevision string, pods []*v1.Pod, now time.Time) (string, string, time.Duration) {
+	policy := cs.Spec.UpdateStrategy.RollbackPolicy
+	coreControl := clonesetcore.New(cs)
+
+	var unavailableUpdated int
+	var requeueDuration time.Duration
+	for _, pod := range pods {
+		if !clonesetutils.EqualToRevisionHash("", pod, updateRevision) || pod.DeletionTimestamp != nil || specifieddelete.IsSpecifiedDelete(pod) {
+			continue
+		}
+		// any updated pod not available counts, including the ones being updated or starting
+		if !sync.IsPodAvailable(coreControl, pod, cs.Spec.MinReadySeconds) {
+			unavailableUpdated++
+		}
+
+		ready := coreControl.IsPodUpdateReady(pod, 0)
+		if policy.NotReadyTimeoutSeconds == nil || ready {
+			continue
+		}
+		timeout := time.Duration(*policy.NotReadyTimeoutSeconds) * time.Second
+		remaining := getPodUpdateTime(pod).Add(timeout).Sub(now)
+		if remaining <= 0 {
+			return appsv1alpha1.CloneSetRollbackNotReadyTimeoutReason,
+				fmt.Sprintf("updated pod %s has not been ready for %ds", pod.Name, *policy.NotReadyTimeoutSeconds), 0
+		}
+		if requeueDuration == 0 || remaining < requeueDuration {
+			requeueDuration = remaining
+		}
+	}
+
+	if policy.MaxUnavailableUpdated != nil {
+		maxUnavailableUpdated, err := clonesetutils.GetMaxUnavailableUpdated(policy.MaxUnavailableUpdated, int(*cs.Spec.Replicas))
+		if err == nil && unavailableUpdated > maxUnavailableUpdated {
+			return appsv1alpha1.CloneSetRollbackUnavailableReason,
+				fmt.Sprintf("%d updated pods are unavailable, exceeding maxUnavailableUpdated %d", unavailableUpdated, maxUnavailableUpdated), 0
+		}
+	}
+	return "", "", requeueDuration
+}
+
+// getPodUpdateTime returns the time when the pod has been created or in-place updated to its current revision.
+func getPodUpdateTime(pod *v1.Pod) time.Time {
+	updateTime := pod.CreationTimestamp.Time
+	if stateStr, ok := appspub.GetInPlaceUpdateState(pod); ok {
+		state := appspub.InPlaceUpdateState{}
+		if err := json.Unmarshal([]byte(stateStr), &state); err == nil && state.UpdateTimestamp.After(updateTime) {
+			updateTime = state.UpdateTimestamp.Time
+		}
+	}
+	return updateTime
+}
@@ -0,0 +1,244 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+func TestCheckRollbackThresholds(t *testing.T) {
+	now := time.Now()
+	newPod := func(i int, revision string, ready bool, age time.Duration) *v1.Pod {
+		status := v1.ConditionFalse
+		if ready {
+			status = v1.ConditionTrue
+		}
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              fmt.Sprintf("pod-%d", i),
+				Labels:            map[string]string{apps.ControllerRevisionHashLabelKey: revision},
+				CreationTimestamp: metav1.NewTime(now.Add(-age)),
+			},
+			Status: v1.PodStatus{
+				Phase:      v1.PodRunning,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: status}},
+			},
+		}
+	}
+
+	cases := []struct {
+		name            string
+		policy          appsv1alpha1.CloneSetRollbackPolicy
+		pods            []*v1.Pod
+		expectedReason  string
+		expectedRequeue time.Duration
+	}{
+		{
+			name:   "unavailable updated pods not exceeded",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: &intstr.IntOrString{Type: intstr.Int, IntVal: 1}},
+			pods: []*v1.Pod{
+				newPod(0, "v2", false, time.Minute),
+				newPod(1, "v1", false, time.Minute),
+				newPod(2, "v1", false, time.Minute),
+			},
+		},
+		{
+			name:   "unavailable updated pods exceeded",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: &intstr.IntOrString{Type: intstr.String, StrVal: "20%"}},
+			pods: []*v1.Pod{
+				newPod(0, "v2", false, time.Minute),
+				newPod(1, "v2", false, time.Minute),
+				newPod(2, "v1", true, time.Minute),
+			},
+			expectedReason: appsv1alpha1.CloneSetRollbackUnavailableReason,
+		},
+		{
+			name:   "pending and not started pods counted",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: &intstr.IntOrString{Type: intstr.Int, IntVal: 1}},
+			pods: func() []*v1.Pod {
+				pending := newPod(0, "v2", false, time.Minute)
+				pending.Status.Phase = v1.PodPending
+				notStarted := newPod(1, "v2", false, time.Minute)
+				notStarted.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "main", Started: utilpointer.Bool(false)}}
+				return []*v1.Pod{pending, notStarted}
+			}(),
+			expectedReason: appsv1alpha1.CloneSetRollbackUnavailableReason,
+		},
+		{
+			name:   "in-place updating pod counted",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: &intstr.IntOrString{Type: intstr.Int, IntVal: 1}},
+			pods: func() []*v1.Pod {
+				updating := newPod(0, "v2", false, time.Minute)
+				updating.Status.Conditions = append(updating.Status.Conditions, v1.PodCondition{Type: appspub.InPlaceUpdateReady, Status: v1.ConditionFalse})
+				available := newPod(1, "v2", true, time.Minute)
+				return []*v1.Pod{updating, available}
+			}(),
+		},
+		{
+			name:   "percent rounded up to at least one pod",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: &intstr.IntOrString{Type: intstr.String, StrVal: "1%"}},
+			pods: []*v1.Pod{
+				newPod(0, "v2", false, time.Minute),
+				newPod(1, "v2", true, time.Minute),
+			},
+		},
+		{
+			name:   "crashing pods counted",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: &intstr.IntOrString{Type: intstr.Int, IntVal: 1}},
+			pods: func() []*v1.Pod {
+				var pods []*v1.Pod
+				for i := 0; i < 2; i++ {
+					pod := newPod(i, "v2", false, time.Minute)
+					pod.Status.ContainerStatuses = []v1.ContainerStatus{{Name: "main", Started: utilpointer.Bool(false), RestartCount: 3}}
+					pods = append(pods, pod)
+				}
+				return pods
+			}(),
+			expectedReason: appsv1alpha1.CloneSetRollbackUnavailableReason,
+		},
+		{
+			name:   "not ready updated pod before timeout",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{NotReadyTimeoutSeconds: utilpointer.Int32(300)},
+			pods: []*v1.Pod{
+				newPod(0, "v2", false, time.Minute),
+				newPod(1, "v2", false, 3*time.Minute),
+				newPod(2, "v1", false, time.Hour),
+			},
+			expectedRequeue: 2 * time.Minute,
+		},
+		{
+			name:   "not ready updated pod timeout",
+			policy: appsv1alpha1.CloneSetRollbackPolicy{NotReadyTimeoutSeconds: utilpointer.Int32(300)},
+			pods: []*v1.Pod{
+				newPod(0, "v2", true, time.Hour),
+				newPod(1, "v2", false, 10*time.Minute),
+			},
+			expectedReason: appsv1alpha1.CloneSetRollbackNotReadyTimeoutReason,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas:       utilpointer.Int32(5),
+					UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{RollbackPolicy: &tc.policy},
+				},
+			}
+			reason, _, requeue := checkRollbackThresholds(cs, "v2", tc.pods, now)
+			if reason != tc.expectedReason {
+				t.Fatalf("expected reason %q, got %q", tc.expectedReason, reason)
+			}
+			if requeue.Round(time.Second) != tc.expectedRequeue {
+				t.Fatalf("expected requeue %v, got %v", tc.expectedRequeue, requeue)
+			}
+		})
+	}
+}
+
+func TestIsRolledBackResolved(t *testing.T) {
+	rolledBack := &appsv1alpha1.CloneSetRolledBackStatus{FromRevision: "v2", ToRevision: "v1"}
+	cases := []struct {
+		name           string
+		rolledBack     *appsv1alpha1.CloneSetRolledBackStatus
+		updateRevision string
+		available      int32
+		expected       bool
+	}{
+		{
+			name:           "rolled back revision completed",
+			rolledBack:     rolledBack,
+			updateRevision: "v1",
+			available:      5,
+		},
+		{
+			name:           "revision sharing the prefix of rolled back revision completed",
+			rolledBack:     rolledBack,
+			updateRevision: "v",
+			available:      5,
+			expected:       true,
+		},
+		{
+			name:           "new revision not completed",
+			rolledBack:     rolledBack,
+			updateRevision: "v3",
+			available:      4,
+		},
+		{
+			name:           "new revision completed",
+			rolledBack:     rolledBack,
+			updateRevision: "v3",
+			available:      5,
+			expected:       true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				Spec:   appsv1alpha1.CloneSetSpec{Replicas: utilpointer.Int32(5)},
+				Status: appsv1alpha1.CloneSetStatus{RolledBack: tc.rolledBack},
+			}
+			newStatus := &appsv1alpha1.CloneSetStatus{
+				CurrentRevision:          tc.updateRevision,
+				UpdateRevision:           tc.updateRevision,
+				UpdatedAvailableReplicas: tc.available,
+			}
+			if resolved := isRolledBackResolved(cs, newStatus); resolved != tc.expected {
+				t.Fatalf("expected resolved %v, got %v", tc.expected, resolved)
+			}
+		})
+	}
+}
+
+func TestGetMaxUnavailableUpdated(t *testing.T) {
+	cases := []struct {
+		value       intstr.IntOrString
+		replicas    int
+		expected    int
+		expectedErr bool
+	}{
+		{value: intstr.FromInt(2), replicas: 10, expected: 2},
+		{value: intstr.FromString("15%"), replicas: 10, expected: 2},
+		{value: intstr.FromString("1%"), replicas: 0, expected: 1},
+		{value: intstr.FromInt(0), replicas: 10, expectedErr: true},
+		{value: intstr.FromString("0%"), replicas: 10, expectedErr: true},
+		{value: intstr.FromString("abc"), replicas: 10, expectedErr: true},
+	}
+
+	for _, tc := range cases {
+		value, err := clonesetutils.GetMaxUnavailableUpdated(&tc.value, tc.replicas)
+		if (err != nil) != tc.expectedErr {
+			t.Fatalf("%s: expected error %v, got %v", tc.value.String(), tc.expectedErr, err)
+		}
+		if err == nil && value != tc.expected {
+			t.Fatalf("%s: expected %d, got %d", tc.value.String(), tc.expected, value)
+		}
+	}
+}
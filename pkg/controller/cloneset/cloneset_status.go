@@ -19,14 +19,20 @@ package cloneset
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
 	clonesetcore "github.com/openkruise/kruise/pkg/controller/cloneset/core"
 	"github.com/openkruise/kruise/pkg/controller/cloneset/sync"
 	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
 	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
+	"github.com/openkruise/kruise/pkg/util/pvc"
 	v1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,12 +43,14 @@ type StatusUpdater interface {
 	UpdateCloneSetStatus(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, pods []*v1.Pod) error
 }
 
-func newStatusUpdater(c client.Client) StatusUpdater {
-	return &realStatusUpdater{Client: c}
+func newStatusUpdater(c client.Client, recorder record.EventRecorder, analyzer *analysis.Analyzer) StatusUpdater {
+	return &realStatusUpdater{Client: c, recorder: recorder, analyzer: analyzer}
 }
 
 type realStatusUpdater struct {
 	client.Client
+	recorder record.EventRecorder
+	analyzer *analysis.Analyzer
 }
 
 func (r *realStatusUpdater) UpdateCloneSetStatus(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, pods []*v1.Pod) error {
@@ -50,6 +58,22 @@ func (r *realStatusUpdater) UpdateCloneSetStatus(cs *appsv1alpha1.CloneSet, newS
 	if err := clonesetcore.New(cs).ExtraStatusCalculation(newStatus, pods); err != nil {
 		return fmt.Errorf("failed to calculate extra status for cloneSet %s/%s: %v", cs.Namespace, cs.Name, err)
 	}
+	if requeueDuration := calculateProgressingCondition(cs, newStatus, metav1.Now()); requeueDuration > 0 {
+		clonesetutils.DurationStore.Push(clonesetutils.GetControllerKey(cs), requeueDuration)
+	}
+	if cond := clonesetutils.GetCloneSetCondition(cs.Status, appsv1alpha1.CloneSetConditionRolledBack); cond != nil && !isRolledBackResolved(cs, newStatus) {
+		clonesetutils.SetCloneSetCondition(newStatus, cond)
+		newStatus.RolledBack = cs.Status.RolledBack
+	}
+	if requeueDuration := r.calculateAnalysisRun(cs, newStatus, metav1.Now()); requeueDuration > 0 {
+		clonesetutils.DurationStore.Push(clonesetutils.GetControllerKey(cs), requeueDuration)
+	}
+	if requeueDuration := calculateUpdateStepStatus(cs, newStatus, metav1.Now()); requeueDuration > 0 {
+		clonesetutils.DurationStore.Push(clonesetutils.GetControllerKey(cs), requeueDuration)
+	}
+	if err := r.pauseOnProgressDeadlineExceeded(cs, newStatus); err != nil {
+		return err
+	}
 	if !r.inconsistentStatus(cs, newStatus) {
 		return nil
 	}
@@ -58,6 +82,32 @@ func (r *realStatusUpdater) UpdateCloneSetStatus(cs *appsv1alpha1.CloneSet, newS
 	return r.updateStatus(cs, newStatus)
 }
 
+// pauseOnProgressDeadlineExceeded sets updateStrategy.paused to true when the CloneSet has just exceeded its
+// progress deadline and pauseOnProgressDeadlineExceeded is enabled. The spec is patched before the condition is
+// written into status, so that it will be retried until the CloneSet has been paused.
+func (r *realStatusUpdater) pauseOnProgressDeadlineExceeded(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus) error {
+	if !cs.Spec.UpdateStrategy.PauseOnProgressDeadlineExceeded || cs.Spec.UpdateStrategy.Paused {
+		return nil
+	}
+	newCond := clonesetutils.GetCloneSetCondition(*newStatus, appsv1alpha1.CloneSetConditionProgressing)
+	if newCond == nil || newCond.Reason != appsv1alpha1.CloneSetProgressDeadlineExceededReason {
+		return nil
+	}
+	if oldCond := clonesetutils.GetCloneSetCondition(cs.Status, appsv1alpha1.CloneSetConditionProgressing); oldCond != nil &&
+		oldCond.Reason == appsv1alpha1.CloneSetProgressDeadlineExceededReason {
+		return nil
+	}
+
+	body := `{"spec":{"updateStrategy":{"paused":true}}}`
+	if err := r.Patch(context.TODO(), cs.DeepCopy(), client.RawPatch(types.MergePatchType, []byte(body))); err != nil {
+		r.recorder.Eventf(cs, v1.EventTypeWarning, "FailedPause", "failed to pause CloneSet for exceeding progress deadline: %v", err)
+		return err
+	}
+	klog.InfoS("Paused CloneSet for exceeding progress deadline", "cloneSet", klog.KObj(cs))
+	r.recorder.Eventf(cs, v1.EventTypeWarning, "ProgressDeadlineExceeded", "paused CloneSet for exceeding progress deadline %ds", *cs.Spec.ProgressDeadlineSeconds)
+	return nil
+}
+
 func (r *realStatusUpdater) updateStatus(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus) error {
 	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 		clone := &appsv1alpha1.CloneSet{}
@@ -81,7 +131,28 @@ func (r *realStatusUpdater) inconsistentStatus(cs *appsv1alpha1.CloneSet, newSta
 		newStatus.ExpectedUpdatedReplicas != oldStatus.ExpectedUpdatedReplicas ||
 		newStatus.UpdateRevision != oldStatus.UpdateRevision ||
 		newStatus.CurrentRevision != oldStatus.CurrentRevision ||
-		newStatus.LabelSelector != oldStatus.LabelSelector
+		newStatus.LabelSelector != oldStatus.LabelSelector ||
+		!apiequality.Semantic.DeepEqual(newStatus.UpdateStepStatus, oldStatus.UpdateStepStatus) ||
+		!apiequality.Semantic.DeepEqual(newStatus.AnalysisRun, oldStatus.AnalysisRun) ||
+		!apiequality.Semantic.DeepEqual(newStatus.RolledBack, oldStatus.RolledBack) ||
+		!apiequality.Semantic.DeepEqual(newStatus.ScaleDownPlan, oldStatus.ScaleDownPlan) ||
+		!apiequality.Semantic.DeepEqual(newStatus.ScaleDownThrottle, oldStatus.ScaleDownThrottle) ||
+		!apiequality.Semantic.DeepEqual(newStatus.VolumeClaims, oldStatus.VolumeClaims) ||
+		!apiequality.Semantic.DeepEqual(
+			clonesetutils.GetCloneSetCondition(*newStatus, appsv1alpha1.CloneSetConditionProgressing),
+			clonesetutils.GetCloneSetCondition(oldStatus, appsv1alpha1.CloneSetConditionProgressing)) ||
+		!apiequality.Semantic.DeepEqual(
+			clonesetutils.GetCloneSetCondition(*newStatus, appsv1alpha1.CloneSetConditionRolledBack),
+			clonesetutils.GetCloneSetCondition(oldStatus, appsv1alpha1.CloneSetConditionRolledBack))
+}
+
+// isRolledBackResolved returns true if a revision other than the ones in the rollback has been rolled out
+// and all its pods are available, so that the RolledBack condition is no longer relevant.
+func isRolledBackResolved(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus) bool {
+	rolledBack := cs.Status.RolledBack
+	return newStatus.CurrentRevision == newStatus.UpdateRevision &&
+		newStatus.UpdatedAvailableReplicas == *cs.Spec.Replicas &&
+		(rolledBack == nil || (newStatus.UpdateRevision != rolledBack.FromRevision && newStatus.UpdateRevision != rolledBack.ToRevision))
 }
 
 func (r *realStatusUpdater) calculateStatus(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, pods []*v1.Pod) {
@@ -113,3 +184,105 @@ func (r *realStatusUpdater) calculateStatus(cs *appsv1alpha1.CloneSet, newStatus
 		newStatus.ExpectedUpdatedReplicas = *cs.Spec.Replicas - int32(partition)
 	}
 }
+
+// calculateProgressingCondition maintains the Progressing condition when progressDeadlineSeconds has been set.
+// The time during which the CloneSet is paused or has reached the partition is not counted into the deadline,
+// so the timer restarts once the CloneSet leaves these states. It returns the duration after which the deadline
+// should be checked again.
+func calculateProgressingCondition(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, now metav1.Time) time.Duration {
+	if cs.Spec.ProgressDeadlineSeconds == nil {
+		return 0
+	}
+
+	oldCond := clonesetutils.GetCloneSetCondition(cs.Status, appsv1alpha1.CloneSetConditionProgressing)
+	if oldCond != nil {
+		clonesetutils.SetCloneSetCondition(newStatus, oldCond)
+	}
+	setCondition := func(status v1.ConditionStatus, reason, message string) {
+		cond := clonesetutils.NewCloneSetCondition(appsv1alpha1.CloneSetConditionProgressing, status, reason, message)
+		cond.LastUpdateTime = now
+		cond.LastTransitionTime = now
+		clonesetutils.SetCloneSetCondition(newStatus, cond)
+	}
+	oldReason := ""
+	if oldCond != nil {
+		oldReason = oldCond.Reason
+	}
+	deadline := time.Duration(*cs.Spec.ProgressDeadlineSeconds) * time.Second
+
+	switch {
+	case cs.Spec.UpdateStrategy.Paused:
+		// keep the ProgressDeadlineExceeded condition, so that users know why the CloneSet has been paused
+		if oldReason != appsv1alpha1.CloneSetProgressPausedReason && oldReason != appsv1alpha1.CloneSetProgressDeadlineExceededReason {
+			setCondition(v1.ConditionUnknown, appsv1alpha1.CloneSetProgressPausedReason, "CloneSet is paused")
+		}
+
+	case newStatus.Replicas == *cs.Spec.Replicas && newStatus.UpdatedReplicas == newStatus.Replicas &&
+		newStatus.UpdatedAvailableReplicas == newStatus.Replicas:
+		if oldReason != appsv1alpha1.CloneSetProgressAvailableReason {
+			setCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressAvailableReason, "CloneSet has been updated and available")
+		}
+
+	case newStatus.Replicas == *cs.Spec.Replicas && newStatus.UpdatedAvailableReplicas >= newStatus.ExpectedUpdatedReplicas:
+		if oldReason != appsv1alpha1.CloneSetProgressPartitionAvailableReason {
+			setCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressPartitionAvailableReason, "CloneSet has reached the expected updated replicas of partition")
+		}
+
+	case oldReason != appsv1alpha1.CloneSetProgressUpdatedReason && oldReason != appsv1alpha1.CloneSetProgressDeadlineExceededReason,
+		hasCloneSetProgressed(&cs.Status, newStatus):
+		// (re)start the timer, because the CloneSet has just left the stable states or made some progress
+		setCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressUpdatedReason, "CloneSet is progressing")
+		return deadline
+
+	case oldReason == appsv1alpha1.CloneSetProgressUpdatedReason:
+		if remaining := oldCond.LastUpdateTime.Add(deadline).Sub(now.Time); remaining > 0 {
+			return remaining
+		}
+		setCondition(v1.ConditionFalse, appsv1alpha1.CloneSetProgressDeadlineExceededReason,
+			fmt.Sprintf("CloneSet has timed out progressing after %ds", *cs.Spec.ProgressDeadlineSeconds))
+	}
+	return 0
+}
+
+func hasCloneSetProgressed(oldStatus, newStatus *appsv1alpha1.CloneSetStatus) bool {
+	return newStatus.UpdateRevision != oldStatus.UpdateRevision ||
+		newStatus.Replicas != oldStatus.Replicas ||
+		newStatus.UpdatedReplicas > oldStatus.UpdatedReplicas ||
+		newStatus.UpdatedAvailableReplicas > oldStatus.UpdatedAvailableReplicas ||
+		newStatus.AvailableReplicas > oldStatus.AvailableReplicas
+}
+
+// calculateVolumeClaimStatus counts the PVCs of existing pods that are compatible with each volume claim template.
+func calculateVolumeClaimStatus(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, pods []*v1.Pod, pvcs []*v1.PersistentVolumeClaim) {
+	templates := cs.Spec.VolumeClaimTemplates
+	if len(templates) == 0 {
+		newStatus.VolumeClaims = nil
+		return
+	}
+
+	claims := make(map[string]*v1.PersistentVolumeClaim, len(pvcs))
+	for _, claim := range pvcs {
+		claims[claim.Name] = claim
+	}
+	newStatus.VolumeClaims = make([]appsv1alpha1.CloneSetVolumeClaimStatus, len(templates))
+	templateStatuses := make(map[string]*appsv1alpha1.CloneSetVolumeClaimStatus, len(templates))
+	for i := range templates {
+		newStatus.VolumeClaims[i].VolumeClaimName = templates[i].Name
+		templateStatuses[templates[i].Name] = &newStatus.VolumeClaims[i]
+	}
+
+	for _, pod := range pods {
+		for templateName, template := range clonesetutils.GetPersistentVolumeClaims(cs, pod) {
+			claim, ok := claims[template.Name]
+			if !ok || claim.DeletionTimestamp != nil {
+				continue
+			}
+			if compatible, ready := pvc.IsPVCCompatibleAndReady(claim, &template); compatible {
+				templateStatuses[templateName].CompatibleReplicas++
+				if ready {
+					templateStatuses[templateName].CompatibleReadyReplicas++
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,323 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util/analysis"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestCalculateProgressingCondition(t *testing.T) {
+	now := metav1.Now()
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+	progressingCondition := func(status v1.ConditionStatus, reason string, lastUpdateTime metav1.Time) appsv1alpha1.CloneSetCondition {
+		return appsv1alpha1.CloneSetCondition{
+			Type:               appsv1alpha1.CloneSetConditionProgressing,
+			Status:             status,
+			Reason:             reason,
+			LastUpdateTime:     lastUpdateTime,
+			LastTransitionTime: lastUpdateTime,
+		}
+	}
+
+	cases := []struct {
+		name              string
+		deadline          *int32
+		paused            bool
+		oldStatus         appsv1alpha1.CloneSetStatus
+		newStatus         appsv1alpha1.CloneSetStatus
+		expectedCondition *appsv1alpha1.CloneSetCondition
+		expectedRequeue   time.Duration
+	}{
+		{
+			name:      "progressDeadlineSeconds not set",
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdateRevision: "v2"},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdateRevision: "v2"},
+		},
+		{
+			name:      "start progressing",
+			deadline:  utilpointer.Int32(60),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 5, UpdatedAvailableReplicas: 5, UpdateRevision: "v1"},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionTrue, Reason: appsv1alpha1.CloneSetProgressUpdatedReason, LastUpdateTime: now,
+			},
+			expectedRequeue: time.Minute,
+		},
+		{
+			name:     "progressing without progress before deadline",
+			deadline: utilpointer.Int32(7200),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressUpdatedReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionTrue, Reason: appsv1alpha1.CloneSetProgressUpdatedReason, LastUpdateTime: longAgo,
+			},
+			expectedRequeue: time.Hour,
+		},
+		{
+			name:     "progressing with progress",
+			deadline: utilpointer.Int32(60),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressUpdatedReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 2, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionTrue, Reason: appsv1alpha1.CloneSetProgressUpdatedReason, LastUpdateTime: now,
+			},
+			expectedRequeue: time.Minute,
+		},
+		{
+			name:     "progress deadline exceeded",
+			deadline: utilpointer.Int32(60),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressUpdatedReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionFalse, Reason: appsv1alpha1.CloneSetProgressDeadlineExceededReason, LastUpdateTime: now,
+			},
+		},
+		{
+			name:     "paused keeps deadline exceeded",
+			deadline: utilpointer.Int32(60),
+			paused:   true,
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionFalse, appsv1alpha1.CloneSetProgressDeadlineExceededReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionFalse, Reason: appsv1alpha1.CloneSetProgressDeadlineExceededReason, LastUpdateTime: longAgo,
+			},
+		},
+		{
+			name:     "paused",
+			deadline: utilpointer.Int32(60),
+			paused:   true,
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressUpdatedReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionUnknown, Reason: appsv1alpha1.CloneSetProgressPausedReason, LastUpdateTime: now,
+			},
+		},
+		{
+			name:     "resumed from paused restarts the timer",
+			deadline: utilpointer.Int32(60),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionUnknown, appsv1alpha1.CloneSetProgressPausedReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 1, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionTrue, Reason: appsv1alpha1.CloneSetProgressUpdatedReason, LastUpdateTime: now,
+			},
+			expectedRequeue: time.Minute,
+		},
+		{
+			name:     "partition available",
+			deadline: utilpointer.Int32(60),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 2, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionTrue, appsv1alpha1.CloneSetProgressUpdatedReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 2, UpdatedAvailableReplicas: 2, UpdateRevision: "v2", ExpectedUpdatedReplicas: 2},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionTrue, Reason: appsv1alpha1.CloneSetProgressPartitionAvailableReason, LastUpdateTime: now,
+			},
+		},
+		{
+			name:     "all updated and available",
+			deadline: utilpointer.Int32(60),
+			oldStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 5, UpdateRevision: "v2", Conditions: []appsv1alpha1.CloneSetCondition{
+				progressingCondition(v1.ConditionFalse, appsv1alpha1.CloneSetProgressDeadlineExceededReason, longAgo),
+			}},
+			newStatus: appsv1alpha1.CloneSetStatus{Replicas: 5, UpdatedReplicas: 5, UpdatedAvailableReplicas: 5, UpdateRevision: "v2", ExpectedUpdatedReplicas: 5},
+			expectedCondition: &appsv1alpha1.CloneSetCondition{
+				Type: appsv1alpha1.CloneSetConditionProgressing, Status: v1.ConditionTrue, Reason: appsv1alpha1.CloneSetProgressAvailableReason, LastUpdateTime: now,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas:                utilpointer.Int32(5),
+					ProgressDeadlineSeconds: tc.deadline,
+					UpdateStrategy:          appsv1alpha1.CloneSetUpdateStrategy{Paused: tc.paused},
+				},
+				Status: tc.oldStatus,
+			}
+			newStatus := tc.newStatus.DeepCopy()
+			requeue := calculateProgressingCondition(cs, newStatus, now)
+			if requeue.Round(time.Minute) != tc.expectedRequeue {
+				t.Fatalf("expected requeue %v, got %v", tc.expectedRequeue, requeue)
+			}
+
+			cond := clonesetutils.GetCloneSetCondition(*newStatus, appsv1alpha1.CloneSetConditionProgressing)
+			if tc.expectedCondition == nil {
+				if cond != nil {
+					t.Fatalf("expected no Progressing condition, got %v", cond)
+				}
+				return
+			}
+			if cond == nil {
+				t.Fatalf("expected Progressing condition, got nil")
+			}
+			if cond.Status != tc.expectedCondition.Status || cond.Reason != tc.expectedCondition.Reason ||
+				!cond.LastUpdateTime.Equal(&tc.expectedCondition.LastUpdateTime) {
+				t.Fatalf("expected condition %v, got %v", tc.expectedCondition, cond)
+			}
+		})
+	}
+}
+
+func TestCalculateVolumeClaimStatus(t *testing.T) {
+	newClaim := func(name, storage, capacity string) *v1.PersistentVolumeClaim {
+		claim := &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1.PersistentVolumeClaimSpec{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)},
+				},
+			},
+		}
+		if capacity != "" {
+			claim.Status.Capacity = v1.ResourceList{v1.ResourceStorage: resource.MustParse(capacity)}
+		}
+		return claim
+	}
+	newPod := func(id string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:   "sample-" + id,
+			Labels: map[string]string{appsv1alpha1.CloneSetInstanceID: id},
+		}}
+	}
+
+	cs := &appsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample"},
+		Spec: appsv1alpha1.CloneSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "sample"}},
+			VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+				*newClaim("data", "2Gi", ""),
+				*newClaim("log", "1Gi", ""),
+			},
+		},
+	}
+	pods := []*v1.Pod{newPod("a"), newPod("b"), newPod("c")}
+	pvcs := []*v1.PersistentVolumeClaim{
+		newClaim("data-sample-a", "2Gi", "2Gi"),
+		newClaim("data-sample-b", "2Gi", "1Gi"),
+		newClaim("data-sample-c", "1Gi", "1Gi"),
+		newClaim("log-sample-a", "1Gi", "1Gi"),
+		newClaim("log-sample-b", "1Gi", "1Gi"),
+	}
+
+	newStatus := &appsv1alpha1.CloneSetStatus{}
+	calculateVolumeClaimStatus(cs, newStatus, pods, pvcs)
+	expected := []appsv1alpha1.CloneSetVolumeClaimStatus{
+		{VolumeClaimName: "data", CompatibleReplicas: 2, CompatibleReadyReplicas: 1},
+		{VolumeClaimName: "log", CompatibleReplicas: 2, CompatibleReadyReplicas: 2},
+	}
+	if !reflect.DeepEqual(newStatus.VolumeClaims, expected) {
+		t.Fatalf("expected volume claims %+v, got %+v", expected, newStatus.VolumeClaims)
+	}
+
+	cs.Spec.VolumeClaimTemplates = nil
+	calculateVolumeClaimStatus(cs, newStatus, pods, pvcs)
+	if newStatus.VolumeClaims != nil {
+		t.Fatalf("expected no volume claims, got %+v", newStatus.VolumeClaims)
+	}
+}
+
+func TestPauseOnProgressDeadlineExceeded(t *testing.T) {
+	testScheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(testScheme))
+	utilruntime.Must(appsv1alpha1.AddToScheme(testScheme))
+	cs := &appsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sample"},
+		Spec: appsv1alpha1.CloneSetSpec{
+			Replicas:                utilpointer.Int32(1),
+			ProgressDeadlineSeconds: utilpointer.Int32(60),
+			UpdateStrategy:          appsv1alpha1.CloneSetUpdateStrategy{PauseOnProgressDeadlineExceeded: true},
+		},
+		Status: appsv1alpha1.CloneSetStatus{
+			UpdateRevision: "v2",
+			Conditions: []appsv1alpha1.CloneSetCondition{{
+				Type:           appsv1alpha1.CloneSetConditionProgressing,
+				Status:         v1.ConditionTrue,
+				Reason:         appsv1alpha1.CloneSetProgressUpdatedReason,
+				LastUpdateTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+			}},
+		},
+	}
+	getCloneSet := func(c client.Client) *appsv1alpha1.CloneSet {
+		got := &appsv1alpha1.CloneSet{}
+		if err := c.Get(context.TODO(), client.ObjectKeyFromObject(cs), got); err != nil {
+			t.Fatalf("failed to get CloneSet: %v", err)
+		}
+		return got
+	}
+
+	// the exceeded condition should not be written until the CloneSet has been paused
+	failingClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(cs.DeepCopy()).WithStatusSubresource(cs).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				return fmt.Errorf("connection refused")
+			},
+		}).Build()
+	updater := newStatusUpdater(failingClient, record.NewFakeRecorder(10), analysis.NewDefaultAnalyzer())
+	if err := updater.UpdateCloneSetStatus(getCloneSet(failingClient), &appsv1alpha1.CloneSetStatus{UpdateRevision: "v2"}, nil); err == nil {
+		t.Fatalf("expected error when failed to pause")
+	}
+	if cond := clonesetutils.GetCloneSetCondition(getCloneSet(failingClient).Status, appsv1alpha1.CloneSetConditionProgressing); cond.Reason != appsv1alpha1.CloneSetProgressUpdatedReason {
+		t.Fatalf("expected condition not updated before paused, got %v", cond.Reason)
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(cs.DeepCopy()).WithStatusSubresource(cs).Build()
+	updater = newStatusUpdater(fakeClient, record.NewFakeRecorder(10), analysis.NewDefaultAnalyzer())
+	if err := updater.UpdateCloneSetStatus(getCloneSet(fakeClient), &appsv1alpha1.CloneSetStatus{UpdateRevision: "v2"}, nil); err != nil {
+		t.Fatalf("failed to update status: %v", err)
+	}
+	got := getCloneSet(fakeClient)
+	if !got.Spec.UpdateStrategy.Paused {
+		t.Fatalf("expected CloneSet paused")
+	}
+	if cond := clonesetutils.GetCloneSetCondition(got.Status, appsv1alpha1.CloneSetConditionProgressing); cond.Reason != appsv1alpha1.CloneSetProgressDeadlineExceededReason {
+		t.Fatalf("expected condition %s, got %v", appsv1alpha1.CloneSetProgressDeadlineExceededReason, cond.Reason)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// syncUpdateStepPartition patches the partition of CloneSet to match the current update step during rollout.
+// It returns true if the partition has been patched.
+func (r *ReconcileCloneSet) syncUpdateStepPartition(cs *appsv1alpha1.CloneSet, stepStatus *appsv1alpha1.CloneSetUpdateStepStatus,
+	currentRevision, updateRevision string) (bool, error) {
+	if len(cs.Spec.UpdateStrategy.Steps) == 0 || currentRevision == updateRevision {
+		return false, nil
+	}
+
+	var index int32
+	if stepStatus != nil && stepStatus.UpdateRevision == updateRevision {
+		index = stepStatus.CurrentStepIndex
+	}
+	partition := *cs.Spec.Replicas - getUpdateStepReplicas(cs, index)
+	if partition < 0 {
+		partition = 0
+	}
+	if p := cs.Spec.UpdateStrategy.Partition; p != nil && p.Type == intstr.Int && p.IntVal == partition {
+		return false, nil
+	}
+
+	body := fmt.Sprintf(`{"spec":{"updateStrategy":{"partition":%d}}}`, partition)
+	if err := r.Patch(context.TODO(), cs.DeepCopy(), client.RawPatch(types.MergePatchType, []byte(body))); err != nil {
+		r.recorder.Eventf(cs, v1.EventTypeWarning, "FailedUpdateStep", "failed to set partition to %d for update step %d: %v", partition, index, err)
+		return false, err
+	}
+	klog.InfoS("Set CloneSet partition for update step", "cloneSet", klog.KObj(cs), "step", index, "partition", partition)
+	r.recorder.Eventf(cs, v1.EventTypeNormal, "UpdateStep", "set partition to %d for update step %d", partition, index)
+	return true, nil
+}
+
+// calculateUpdateStepStatus maintains the progress of updateStrategy.steps in newStatus, according to the
+// updated ready replicas, the pause duration and the manual approval. It returns the duration after which
+// the paused step should be checked again.
+func calculateUpdateStepStatus(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, now metav1.Time) time.Duration {
+	steps := cs.Spec.UpdateStrategy.Steps
+	if len(steps) == 0 {
+		return 0
+	}
+
+	var stepStatus *appsv1alpha1.CloneSetUpdateStepStatus
+	if old := cs.Status.UpdateStepStatus; old != nil && old.UpdateRevision == newStatus.UpdateRevision {
+		stepStatus = old.DeepCopy()
+	} else if newStatus.UpdateRevision != newStatus.CurrentRevision {
+		stepStatus = &appsv1alpha1.CloneSetUpdateStepStatus{
+			UpdateRevision:       newStatus.UpdateRevision,
+			CurrentStepState:     appsv1alpha1.CloneSetUpdateStepStateUpgrading,
+			CurrentStepStartTime: now,
+			LastUpdateTime:       now,
+		}
+	} else {
+		return 0
+	}
+	newStatus.UpdateStepStatus = stepStatus
+
+	// steps might have been shortened by users
+	if int(stepStatus.CurrentStepIndex) >= len(steps) {
+		stepStatus.CurrentStepIndex = int32(len(steps) - 1)
+	}
+	if cs.Spec.UpdateStrategy.Paused {
+		return 0
+	}
+
+	setState := func(state appsv1alpha1.CloneSetUpdateStepState) {
+		stepStatus.CurrentStepState = state
+		stepStatus.LastUpdateTime = now
+	}
+	nextStep := func() {
+		if int(stepStatus.CurrentStepIndex) >= len(steps)-1 {
+			setState(appsv1alpha1.CloneSetUpdateStepStateCompleted)
+			return
+		}
+		stepStatus.CurrentStepIndex++
+		stepStatus.CurrentStepStartTime = now
+		setState(appsv1alpha1.CloneSetUpdateStepStateUpgrading)
+	}
+
+	step := steps[stepStatus.CurrentStepIndex]
+	switch stepStatus.CurrentStepState {
+	case appsv1alpha1.CloneSetUpdateStepStateUpgrading:
+		stepReplicas := getUpdateStepReplicas(cs, stepStatus.CurrentStepIndex)
+		if newStatus.UpdatedReadyReplicas < stepReplicas {
+			return 0
+		}
+		// wait for the analysis of this step to pass
+		if !analysis.IsBatchPassed(cs.Spec.UpdateStrategy.Analysis, newStatus.AnalysisRun, newStatus.UpdateRevision, stepReplicas) {
+			return 0
+		}
+		if step.Pause == nil || (step.Pause.Duration != nil && *step.Pause.Duration <= 0) {
+			nextStep()
+		} else if step.Pause.Duration == nil {
+			setState(appsv1alpha1.CloneSetUpdateStepStateWaitingApproval)
+		} else {
+			setState(appsv1alpha1.CloneSetUpdateStepStatePaused)
+			return time.Duration(*step.Pause.Duration) * time.Second
+		}
+
+	case appsv1alpha1.CloneSetUpdateStepStatePaused:
+		var duration time.Duration
+		if step.Pause != nil && step.Pause.Duration != nil {
+			duration = time.Duration(*step.Pause.Duration) * time.Second
+		}
+		if remaining := stepStatus.LastUpdateTime.Add(duration).Sub(now.Time); remaining > 0 {
+			return remaining
+		}
+		nextStep()
+
+	case appsv1alpha1.CloneSetUpdateStepStateWaitingApproval:
+		if cs.Annotations[appsv1alpha1.CloneSetApproveUpdateStepAnnotation] == getUpdateStepApproval(stepStatus) {
+			nextStep()
+		}
+	}
+	return 0
+}
+
+// getUpdateStepApproval returns the expected value of the approval annotation for the current step,
+// which contains the update revision to avoid approving the same step of the later rollouts.
+func getUpdateStepApproval(stepStatus *appsv1alpha1.CloneSetUpdateStepStatus) string {
+	return stepStatus.UpdateRevision + "/" + strconv.Itoa(int(stepStatus.CurrentStepIndex))
+}
+
+// getUpdateStepReplicas returns the desired number of updated pods in the given step.
+func getUpdateStepReplicas(cs *appsv1alpha1.CloneSet, index int32) int32 {
+	replicas := int(*cs.Spec.Replicas)
+	stepReplicas, err := util.GetScaledValueFromIntOrPercent(&cs.Spec.UpdateStrategy.Steps[index].Replicas, replicas, true)
+	if err != nil || stepReplicas > replicas {
+		return int32(replicas)
+	}
+	return int32(stepReplicas)
+}
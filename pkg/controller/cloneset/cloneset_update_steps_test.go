@@ -0,0 +1,194 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cloneset
+
+import (
+	"testing"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+func TestCalculateUpdateStepStatus(t *testing.T) {
+	now := metav1.Now()
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+	steps := []appsv1alpha1.CloneSetUpdateStep{
+		{Replicas: intstr.FromString("20%"), Pause: &appsv1alpha1.CloneSetUpdateStepPause{}},
+		{Replicas: intstr.FromString("50%"), Pause: &appsv1alpha1.CloneSetUpdateStepPause{Duration: utilpointer.Int32(600)}},
+		{Replicas: intstr.FromString("100%")},
+	}
+	stepStatus := func(index int32, state appsv1alpha1.CloneSetUpdateStepState, lastUpdateTime metav1.Time) *appsv1alpha1.CloneSetUpdateStepStatus {
+		return &appsv1alpha1.CloneSetUpdateStepStatus{
+			UpdateRevision:       "v2",
+			CurrentStepIndex:     index,
+			CurrentStepState:     state,
+			CurrentStepStartTime: longAgo,
+			LastUpdateTime:       lastUpdateTime,
+		}
+	}
+
+	cases := []struct {
+		name               string
+		annotations        map[string]string
+		paused             bool
+		analysisRun        *appspub.AnalysisRunStatus
+		oldStepStatus      *appsv1alpha1.CloneSetUpdateStepStatus
+		newStatus          appsv1alpha1.CloneSetStatus
+		expectedStepStatus *appsv1alpha1.CloneSetUpdateStepStatus
+		expectedRequeue    time.Duration
+	}{
+		{
+			name:      "no rollout",
+			newStatus: appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v1", UpdatedReadyReplicas: 10},
+		},
+		{
+			name:          "start the first step for new revision",
+			oldStepStatus: &appsv1alpha1.CloneSetUpdateStepStatus{UpdateRevision: "v1", CurrentStepIndex: 2, CurrentStepState: appsv1alpha1.CloneSetUpdateStepStateCompleted},
+			newStatus:     appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2"},
+			expectedStepStatus: &appsv1alpha1.CloneSetUpdateStepStatus{
+				UpdateRevision: "v2", CurrentStepIndex: 0, CurrentStepState: appsv1alpha1.CloneSetUpdateStepStateUpgrading, CurrentStepStartTime: now, LastUpdateTime: now,
+			},
+		},
+		{
+			name:               "upgrading not ready",
+			oldStepStatus:      stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 1},
+			expectedStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+		},
+		{
+			name:               "upgrading ready and wait for approval",
+			oldStepStatus:      stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 2},
+			expectedStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, now),
+		},
+		{
+			name:               "approval of another step",
+			annotations:        map[string]string{appsv1alpha1.CloneSetApproveUpdateStepAnnotation: "v2/1"},
+			oldStepStatus:      stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 2},
+			expectedStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, longAgo),
+		},
+		{
+			name:               "upgrading ready and wait for analysis",
+			oldStepStatus:      stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+			analysisRun:        &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning},
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 2},
+			expectedStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+		},
+		{
+			name:               "upgrading ready and analysis passed",
+			oldStepStatus:      stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+			analysisRun:        &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseSuccessful},
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 2},
+			expectedStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, now),
+		},
+		{
+			name:               "approval of another revision",
+			annotations:        map[string]string{appsv1alpha1.CloneSetApproveUpdateStepAnnotation: "v1/0"},
+			oldStepStatus:      stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 2},
+			expectedStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, longAgo),
+		},
+		{
+			name:          "approved",
+			annotations:   map[string]string{appsv1alpha1.CloneSetApproveUpdateStepAnnotation: "v2/0"},
+			oldStepStatus: stepStatus(0, appsv1alpha1.CloneSetUpdateStepStateWaitingApproval, longAgo),
+			newStatus:     appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 2},
+			expectedStepStatus: &appsv1alpha1.CloneSetUpdateStepStatus{
+				UpdateRevision: "v2", CurrentStepIndex: 1, CurrentStepState: appsv1alpha1.CloneSetUpdateStepStateUpgrading, CurrentStepStartTime: now, LastUpdateTime: now,
+			},
+		},
+		{
+			name:               "upgrading ready and pause",
+			oldStepStatus:      stepStatus(1, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 5},
+			expectedStepStatus: stepStatus(1, appsv1alpha1.CloneSetUpdateStepStatePaused, now),
+			expectedRequeue:    10 * time.Minute,
+		},
+		{
+			name:               "paused in duration",
+			oldStepStatus:      stepStatus(1, appsv1alpha1.CloneSetUpdateStepStatePaused, metav1.NewTime(now.Add(-5*time.Minute))),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 5},
+			expectedStepStatus: stepStatus(1, appsv1alpha1.CloneSetUpdateStepStatePaused, metav1.NewTime(now.Add(-5*time.Minute))),
+			expectedRequeue:    5 * time.Minute,
+		},
+		{
+			name:          "paused duration passed",
+			oldStepStatus: stepStatus(1, appsv1alpha1.CloneSetUpdateStepStatePaused, longAgo),
+			newStatus:     appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 5},
+			expectedStepStatus: &appsv1alpha1.CloneSetUpdateStepStatus{
+				UpdateRevision: "v2", CurrentStepIndex: 2, CurrentStepState: appsv1alpha1.CloneSetUpdateStepStateUpgrading, CurrentStepStartTime: now, LastUpdateTime: now,
+			},
+		},
+		{
+			name:               "cloneset paused",
+			paused:             true,
+			oldStepStatus:      stepStatus(1, appsv1alpha1.CloneSetUpdateStepStatePaused, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v1", UpdateRevision: "v2", UpdatedReadyReplicas: 5},
+			expectedStepStatus: stepStatus(1, appsv1alpha1.CloneSetUpdateStepStatePaused, longAgo),
+		},
+		{
+			name:               "last step completed",
+			oldStepStatus:      stepStatus(2, appsv1alpha1.CloneSetUpdateStepStateUpgrading, longAgo),
+			newStatus:          appsv1alpha1.CloneSetStatus{CurrentRevision: "v2", UpdateRevision: "v2", UpdatedReadyReplicas: 10},
+			expectedStepStatus: stepStatus(2, appsv1alpha1.CloneSetUpdateStepStateCompleted, now),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Annotations: tc.annotations},
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas:       utilpointer.Int32(10),
+					UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{Paused: tc.paused, Steps: steps},
+				},
+				Status: appsv1alpha1.CloneSetStatus{UpdateStepStatus: tc.oldStepStatus},
+			}
+			if tc.analysisRun != nil {
+				cs.Spec.UpdateStrategy.Analysis = &appspub.RolloutAnalysis{
+					Metrics: []appspub.AnalysisMetric{{Name: "stub", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}},
+				}
+			}
+			newStatus := tc.newStatus.DeepCopy()
+			newStatus.AnalysisRun = tc.analysisRun
+			requeue := calculateUpdateStepStatus(cs, newStatus, now)
+			if requeue.Round(time.Minute) != tc.expectedRequeue {
+				t.Fatalf("expected requeue %v, got %v", tc.expectedRequeue, requeue)
+			}
+
+			got := newStatus.UpdateStepStatus
+			if tc.expectedStepStatus == nil || got == nil {
+				if tc.expectedStepStatus != got {
+					t.Fatalf("expected step status %v, got %v", tc.expectedStepStatus, got)
+				}
+				return
+			}
+			if got.UpdateRevision != tc.expectedStepStatus.UpdateRevision || got.CurrentStepIndex != tc.expectedStepStatus.CurrentStepIndex ||
+				got.CurrentStepState != tc.expectedStepStatus.CurrentStepState ||
+				!got.CurrentStepStartTime.Equal(&tc.expectedStepStatus.CurrentStepStartTime) ||
+				!got.LastUpdateTime.Equal(&tc.expectedStepStatus.LastUpdateTime) {
+				t.Fatalf("expected step status %+v, got %+v", tc.expectedStepStatus, got)
+			}
+		})
+	}
+}
@@ -148,6 +148,10 @@ func (c *commonControl) GetUpdateOptions() *inplaceupdate.UpdateOptions {
 	if c.Spec.UpdateStrategy.Type == appsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType {
 		opts.IgnoreVolumeClaimTemplatesHashDiff = true
 	}
+	// the storage requests are resized onto the existing PVCs online, so they should not make pods recreated
+	if c.Spec.VolumeClaimUpdateStrategy.Type == appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType {
+		opts.IgnoreVolumeClaimTemplatesStorageDiff = true
+	}
 	return opts
 }
 
@@ -23,7 +23,9 @@ import (
 	"testing"
 
 	"github.com/openkruise/kruise/apis"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
 	clonesettest "github.com/openkruise/kruise/pkg/controller/cloneset/test"
+	"github.com/openkruise/kruise/pkg/util/volumeclaimtemplate"
 
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -169,3 +171,48 @@ func TestCreateApplyRevisionWithVCTemplates(t *testing.T) {
 		t.Errorf("for annotation %s wanted %s got %s", key, expectedValue, value)
 	}
 }
+
+func TestVCTemplateHashWithOnlineResize(t *testing.T) {
+	control := NewRevisionControl()
+	set := clonesettest.NewCloneSet(1)
+	set.Status.CollisionCount = new(int32)
+	set.Spec.VolumeClaimTemplates = []v1.PersistentVolumeClaim{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "www-data",
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				Resources: v1.ResourceRequirements{
+					Requests: map[v1.ResourceName]resource.Quantity{
+						v1.ResourceStorage: resource.MustParse("1Gi"),
+					},
+				},
+			},
+		},
+	}
+
+	getHash := func(storage string, strategyType appsv1alpha1.CloneSetVolumeClaimUpdateStrategyType, key string) string {
+		clone := set.DeepCopy()
+		clone.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse(storage)
+		clone.Spec.VolumeClaimUpdateStrategy.Type = strategyType
+		revision, err := control.NewRevision(clone, 1, clone.Status.CollisionCount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return revision.Annotations[key]
+	}
+
+	onDelete, onlineResize := appsv1alpha1.OnDeleteCloneSetVolumeClaimUpdateStrategyType, appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType
+	if getHash("1Gi", onDelete, volumeclaimtemplate.HashAnnotation) != getHash("1Gi", onlineResize, volumeclaimtemplate.HashAnnotation) {
+		t.Errorf("expected vct hash not changed with the volume claim update strategy")
+	}
+	if getHash("1Gi", onDelete, volumeclaimtemplate.HashAnnotation) == getHash("2Gi", onDelete, volumeclaimtemplate.HashAnnotation) {
+		t.Errorf("expected vct hash changed with storage")
+	}
+	if getHash("1Gi", onlineResize, volumeclaimtemplate.StorageExcludedHashAnnotation) != getHash("2Gi", onlineResize, volumeclaimtemplate.StorageExcludedHashAnnotation) {
+		t.Errorf("expected vct hash excluding storage not changed with storage")
+	}
+	if set.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String() != "1Gi" {
+		t.Errorf("expected templates of CloneSet not modified")
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"github.com/openkruise/kruise/pkg/util/controllerfinder"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
 	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -34,6 +36,7 @@ type Interface interface {
 		currentCS, updateCS *appsv1alpha1.CloneSet,
 		currentRevision, updateRevision string,
 		pods []*v1.Pod, pvcs []*v1.PersistentVolumeClaim,
+		newStatus *appsv1alpha1.CloneSetStatus,
 	) (bool, error)
 
 	Update(cs *appsv1alpha1.CloneSet,
@@ -44,18 +47,20 @@ type Interface interface {
 
 type realControl struct {
 	client.Client
-	lifecycleControl lifecycle.Interface
-	inplaceControl   inplaceupdate.Interface
-	recorder         record.EventRecorder
-	controllerFinder *controllerfinder.ControllerFinder
+	lifecycleControl    lifecycle.Interface
+	inplaceControl      inplaceupdate.Interface
+	recorder            record.EventRecorder
+	controllerFinder    *controllerfinder.ControllerFinder
+	podReadinessControl podreadiness.Interface
 }
 
 func New(c client.Client, recorder record.EventRecorder) Interface {
 	return &realControl{
-		Client:           c,
-		inplaceControl:   inplaceupdate.New(c, clonesetutils.RevisionAdapterImpl),
-		lifecycleControl: lifecycle.New(c),
-		recorder:         recorder,
-		controllerFinder: controllerfinder.Finder,
+		Client:              c,
+		inplaceControl:      inplaceupdate.New(c, clonesetutils.RevisionAdapterImpl),
+		lifecycleControl:    lifecycle.New(c),
+		recorder:            recorder,
+		controllerFinder:    controllerfinder.Finder,
+		podReadinessControl: podreadiness.NewForAdapter(&podadapter.AdapterRuntimeClient{Client: c}),
 	}
 }
@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
+)
+
+// blueGreenReadinessMessage is the not-ready key added to the held new revision pods.
+var blueGreenReadinessMessage = podreadiness.Message{UserAgent: "CloneSet", Key: "BlueGreen"}
+
+// isBlueGreenUpdating returns whether the CloneSet should replace pods in BlueGreen update,
+// which includes releasing the held pods after all old revision pods have been deleted.
+func isBlueGreenUpdating(cs *appsv1alpha1.CloneSet, updateRevision string, pods []*v1.Pod) bool {
+	if cs.Spec.UpdateStrategy.Type != appsv1alpha1.BlueGreenCloneSetUpdateStrategyType {
+		return false
+	}
+	for _, pod := range pods {
+		if !clonesetutils.EqualToRevisionHash("", pod, updateRevision) || pod.Annotations[appspub.BlueGreenHoldAnnotationKey] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// syncBlueGreen creates a full set of new revision pods besides the old ones, and keeps them not ready until all of
+// them are available. Then it turns them ready and deletes all the old revision pods at once.
+func (r *realControl) syncBlueGreen(
+	currentCS, updateCS *appsv1alpha1.CloneSet,
+	currentRevision, updateRevision string,
+	pods []*v1.Pod, pvcs []*v1.PersistentVolumeClaim,
+) (bool, error) {
+	var newPods, oldPods []*v1.Pod
+	for _, pod := range pods {
+		if clonesetutils.EqualToRevisionHash("", pod, updateRevision) {
+			if !isSpecifiedDelete(updateCS, pod) && lifecycle.GetPodLifecycleState(pod) != appspub.LifecycleStatePreparingDelete {
+				newPods = append(newPods, pod)
+			}
+		} else {
+			oldPods = append(oldPods, pod)
+		}
+	}
+	if len(oldPods) == 0 {
+		return false, r.releaseBlueGreenPods(updateCS, newPods)
+	}
+	if updateCS.Spec.UpdateStrategy.Paused {
+		return false, nil
+	}
+
+	// create the new revision pods up to replicas, which are held not ready
+	if diff := int(*updateCS.Spec.Replicas) - len(newPods); diff > 0 {
+		klog.V(3).InfoS("CloneSet began to create new revision pods for BlueGreen update", "cloneSet", klog.KObj(updateCS), "expectedCreations", diff)
+		holdCS := updateCS.DeepCopy()
+		if holdCS.Spec.Template.Annotations == nil {
+			holdCS.Spec.Template.Annotations = map[string]string{}
+		}
+		holdCS.Spec.Template.Annotations[appspub.BlueGreenHoldAnnotationKey] = "true"
+
+		var availableIDs sets.String
+		if updateCS.Spec.ScaleStrategy.InstanceIDPolicy == appsv1alpha1.LowestAvailableOrdinalCloneSetInstanceIDPolicyType {
+			var err error
+			if availableIDs, err = r.getLowestAvailableOrdinalIDs(updateCS, diff, pods); err != nil {
+				return false, err
+			}
+		} else {
+			availableIDs = getOrGenAvailableIDs(diff, pods, pvcs)
+		}
+		existingPVCNames := sets.NewString()
+		for _, pvc := range pvcs {
+			existingPVCNames.Insert(pvc.Name)
+		}
+		return r.createPods(diff, 0, currentCS, holdCS, currentRevision, updateRevision, availableIDs.List(), existingPVCNames)
+	}
+
+	// keep the new revision pods not ready until all of them are available
+	now := time.Now()
+	var notAvailable int
+	var waiting time.Duration
+	for _, pod := range newPods {
+		if pod.Annotations[appspub.BlueGreenHoldAnnotationKey] != "" && !podreadiness.ContainsNotReadyKey(pod, blueGreenReadinessMessage) {
+			if err := r.podReadinessControl.AddNotReadyKey(pod, blueGreenReadinessMessage); err != nil {
+				return false, fmt.Errorf("failed to hold pod %s not ready: %v", pod.Name, err)
+			}
+		}
+		if available, d := isBlueGreenPodAvailable(pod, updateCS.Spec.MinReadySeconds, now); !available {
+			notAvailable++
+			if d > waiting {
+				waiting = d
+			}
+		}
+	}
+	if notAvailable > 0 {
+		klog.V(3).InfoS("CloneSet waiting for new revision pods available to switch", "cloneSet", klog.KObj(updateCS),
+			"newPods", len(newPods), "notAvailable", notAvailable)
+		if waiting > 0 {
+			clonesetutils.DurationStore.Push(clonesetutils.GetControllerKey(updateCS), waiting)
+		}
+		return false, nil
+	}
+
+	// switch to the new revision pods
+	if err := r.releaseBlueGreenPods(updateCS, newPods); err != nil {
+		return false, err
+	}
+	klog.InfoS("CloneSet switched to new revision pods for BlueGreen update", "cloneSet", klog.KObj(updateCS),
+		"updateRevision", updateRevision, "newPods", len(newPods), "oldPods", len(oldPods))
+	r.recorder.Eventf(updateCS, v1.EventTypeNormal, "BlueGreenSwitched", "switched to %d pods of revision %s and deleting %d old pods",
+		len(newPods), updateRevision, len(oldPods))
+	return r.deletePods(updateCS, oldPods, pvcs, nil)
+}
+
+// releaseBlueGreenPods turns the held pods ready and removes their hold annotation.
+func (r *realControl) releaseBlueGreenPods(cs *appsv1alpha1.CloneSet, pods []*v1.Pod) error {
+	for _, pod := range pods {
+		if pod.Annotations[appspub.BlueGreenHoldAnnotationKey] == "" {
+			continue
+		}
+		if err := r.podReadinessControl.RemoveNotReadyKey(pod, blueGreenReadinessMessage); err != nil {
+			return fmt.Errorf("failed to release pod %s ready: %v", pod.Name, err)
+		}
+		body := fmt.Sprintf(`{"metadata":{"annotations":{"%s":null}}}`, appspub.BlueGreenHoldAnnotationKey)
+		clone := pod.DeepCopy()
+		if err := r.Patch(context.TODO(), clone, client.RawPatch(types.MergePatchType, []byte(body))); err != nil {
+			return fmt.Errorf("failed to remove hold annotation of pod %s: %v", pod.Name, err)
+		}
+		clonesetutils.ResourceVersionExpectations.Expect(clone)
+		klog.V(3).InfoS("CloneSet released pod held for BlueGreen update", "cloneSet", klog.KObj(cs), "pod", klog.KObj(pod))
+	}
+	return nil
+}
+
+// isBlueGreenPodAvailable returns whether all containers of the pod have been ready for minReadySeconds,
+// regardless of the readiness gate holding it, and the duration to wait if it will be available later.
+func isBlueGreenPodAvailable(pod *v1.Pod, minReadySeconds int32, now time.Time) (bool, time.Duration) {
+	if pod.Status.Phase != v1.PodRunning {
+		return false, 0
+	}
+	if state := lifecycle.GetPodLifecycleState(pod); state != "" && state != appspub.LifecycleStateNormal {
+		return false, 0
+	}
+	condition := util.GetCondition(pod, v1.ContainersReady)
+	if condition == nil || condition.Status != v1.ConditionTrue {
+		return false, 0
+	}
+	if availableTime := condition.LastTransitionTime.Add(time.Duration(minReadySeconds) * time.Second); now.Before(availableTime) {
+		return false, availableTime.Sub(now)
+	}
+	return true, 0
+}
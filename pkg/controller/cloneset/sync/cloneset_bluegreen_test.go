@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesettest "github.com/openkruise/kruise/pkg/controller/cloneset/test"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSyncBlueGreen(t *testing.T) {
+	now := time.Now()
+	newPod := func(name, revision string, held, containersReady bool) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Labels:      map[string]string{apps.ControllerRevisionHashLabelKey: revision, appsv1alpha1.CloneSetInstanceID: name},
+				Annotations: map[string]string{},
+			},
+			Spec: v1.PodSpec{ReadinessGates: []v1.PodReadinessGate{{ConditionType: appspub.KruisePodReadyConditionType}}},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				Conditions: []v1.PodCondition{
+					{Type: appspub.KruisePodReadyConditionType, Status: v1.ConditionTrue},
+					{Type: v1.PodReady, Status: v1.ConditionTrue},
+				},
+			},
+		}
+		if held {
+			pod.Annotations[appspub.BlueGreenHoldAnnotationKey] = "true"
+		}
+		if containersReady {
+			pod.Status.Conditions = append(pod.Status.Conditions, v1.PodCondition{
+				Type: v1.ContainersReady, Status: v1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-time.Minute)),
+			})
+		}
+		return pod
+	}
+
+	cases := []struct {
+		name             string
+		pods             []*v1.Pod
+		expectedModified bool
+		expectedOldPods  int
+		expectedNewPods  int
+		expectedHeldPods int
+		expectedNotReady int
+	}{
+		{
+			name:             "create new revision pods",
+			pods:             []*v1.Pod{newPod("old-0", "rev-old", false, true), newPod("old-1", "rev-old", false, true)},
+			expectedModified: true,
+			expectedOldPods:  2,
+			expectedNewPods:  2,
+			expectedHeldPods: 2,
+		},
+		{
+			name: "hold new revision pods until all available",
+			pods: []*v1.Pod{
+				newPod("old-0", "rev-old", false, true), newPod("old-1", "rev-old", false, true),
+				newPod("new-0", "rev-new", true, true), newPod("new-1", "rev-new", true, false),
+			},
+			expectedOldPods:  2,
+			expectedNewPods:  2,
+			expectedHeldPods: 2,
+			expectedNotReady: 2,
+		},
+		{
+			name: "switch to new revision pods",
+			pods: []*v1.Pod{
+				newPod("old-0", "rev-old", false, true), newPod("old-1", "rev-old", false, true),
+				newPod("new-0", "rev-new", true, true), newPod("new-1", "rev-new", true, true),
+			},
+			expectedModified: true,
+			expectedNewPods:  2,
+		},
+		{
+			name:            "release held pods after old pods deleted",
+			pods:            []*v1.Pod{newPod("new-0", "rev-new", true, true), newPod("new-1", "rev-new", false, true)},
+			expectedNewPods: 2,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			currentCS := clonesettest.NewCloneSet(2)
+			currentCS.Name = "blue-green"
+			currentCS.Spec.VolumeClaimTemplates = nil
+			currentCS.Spec.UpdateStrategy.Type = appsv1alpha1.BlueGreenCloneSetUpdateStrategyType
+			updateCS := currentCS.DeepCopy()
+			updateCS.Spec.Template.Spec.Containers[0].Image = "nginx:new"
+			defer clonesetutils.ScaleExpectations.DeleteExpectations(clonesetutils.GetControllerKey(updateCS))
+
+			fClient := fake.NewClientBuilder().WithStatusSubresource(&v1.Pod{}).Build()
+			for _, pod := range tc.pods {
+				if err := fClient.Create(context.TODO(), pod.DeepCopy()); err != nil {
+					t.Fatalf("failed to create pod: %v", err)
+				}
+			}
+			rControl := &realControl{
+				Client:              fClient,
+				lifecycleControl:    lifecycle.New(fClient),
+				podReadinessControl: podreadiness.NewForAdapter(&podadapter.AdapterRuntimeClient{Client: fClient}),
+				recorder:            record.NewFakeRecorder(10),
+			}
+
+			modified, err := rControl.Scale(currentCS, updateCS, "rev-old", "rev-new", tc.pods, nil, &appsv1alpha1.CloneSetStatus{})
+			if err != nil {
+				t.Fatalf("failed to scale: %v", err)
+			}
+			if modified != tc.expectedModified {
+				t.Fatalf("expected modified %v, got %v", tc.expectedModified, modified)
+			}
+
+			podList := &v1.PodList{}
+			if err := fClient.List(context.TODO(), podList, client.InNamespace("default")); err != nil {
+				t.Fatalf("failed to list pods: %v", err)
+			}
+			var oldPods, newPods, heldPods, notReadyPods int
+			for i := range podList.Items {
+				pod := &podList.Items[i]
+				if pod.Labels[apps.ControllerRevisionHashLabelKey] != "rev-new" {
+					oldPods++
+					continue
+				}
+				newPods++
+				if pod.Annotations[appspub.BlueGreenHoldAnnotationKey] != "" {
+					heldPods++
+				}
+				if condition := podreadiness.GetReadinessCondition(pod); condition != nil && condition.Status == v1.ConditionFalse {
+					notReadyPods++
+				}
+			}
+			result := fmt.Sprintf("old=%d new=%d held=%d notReady=%d", oldPods, newPods, heldPods, notReadyPods)
+			expected := fmt.Sprintf("old=%d new=%d held=%d notReady=%d", tc.expectedOldPods, tc.expectedNewPods, tc.expectedHeldPods, tc.expectedNotReady)
+			if result != expected {
+				t.Fatalf("expected %s, got %s", expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/integer"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetcore "github.com/openkruise/kruise/pkg/controller/cloneset/core"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/pvc"
+)
+
+// resizePVCs expands the PVCs of existing pods whose storage requests are less than the volumeClaimTemplates,
+// if the volumeClaimUpdateStrategy is OnlineResize. Like updating pods, the PVCs of at most replicas minus partition
+// pods are resized, and the pods are picked in the same order as updating.
+func (c *realControl) resizePVCs(cs *appsv1alpha1.CloneSet, coreControl clonesetcore.Control, pods []*v1.Pod, pvcs []*v1.PersistentVolumeClaim) error {
+	if cs.Spec.VolumeClaimUpdateStrategy.Type != appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType || len(cs.Spec.VolumeClaimTemplates) == 0 {
+		return nil
+	}
+
+	var partition int
+	if cs.Spec.UpdateStrategy.Partition != nil {
+		pValue, err := util.CalculatePartitionReplicas(cs.Spec.UpdateStrategy.Partition, cs.Spec.Replicas)
+		if err != nil {
+			klog.ErrorS(err, "CloneSet partition value was illegal", "cloneSet", klog.KObj(cs))
+			return nil
+		}
+		partition = pValue
+	}
+
+	claims := make(map[string]*v1.PersistentVolumeClaim, len(pvcs))
+	for _, claim := range pvcs {
+		claims[claim.Name] = claim
+	}
+
+	var resizedCount int
+	var waitResizeIndexes []int
+	for i, pod := range pods {
+		if isPodPVCNeedResize(cs, pod, claims) {
+			waitResizeIndexes = append(waitResizeIndexes, i)
+		} else {
+			resizedCount++
+		}
+	}
+	if len(waitResizeIndexes) == 0 {
+		return nil
+	}
+
+	waitResizeIndexes = SortUpdateIndexes(coreControl, cs.Spec.UpdateStrategy, pods, waitResizeIndexes)
+	if maxResize := integer.IntMax(int(*cs.Spec.Replicas)-partition-resizedCount, 0); len(waitResizeIndexes) > maxResize {
+		waitResizeIndexes = waitResizeIndexes[:maxResize]
+	}
+	for _, idx := range waitResizeIndexes {
+		if err := c.resizePodPVCs(cs, pods[idx], claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isPodPVCNeedResize returns true if any PVC of the pod can be expanded to its volumeClaimTemplate.
+func isPodPVCNeedResize(cs *appsv1alpha1.CloneSet, pod *v1.Pod, claims map[string]*v1.PersistentVolumeClaim) bool {
+	for _, template := range clonesetutils.GetPersistentVolumeClaims(cs, pod) {
+		claim, ok := claims[template.Name]
+		if !ok || claim.DeletionTimestamp != nil {
+			continue
+		}
+		if matched, needExpand := pvc.CompareWithCheckFn(claim, &template, pvc.IsPVCNeedExpand); !matched && needExpand {
+			return true
+		}
+	}
+	return false
+}
+
+// resizePodPVCs expands the PVCs of the pod to the storage requests of their volumeClaimTemplates.
+func (c *realControl) resizePodPVCs(cs *appsv1alpha1.CloneSet, pod *v1.Pod, claims map[string]*v1.PersistentVolumeClaim) error {
+	for _, template := range clonesetutils.GetPersistentVolumeClaims(cs, pod) {
+		claim, ok := claims[template.Name]
+		if !ok || claim.DeletionTimestamp != nil {
+			continue
+		}
+		matched, needExpand := pvc.CompareWithCheckFn(claim, &template, pvc.IsPVCNeedExpand)
+		if matched {
+			continue
+		}
+		if !needExpand {
+			// changes other than storage requests can not be applied onto the existing pvc
+			klog.V(4).InfoS("CloneSet skipped resizing pvc incompatible with template", "cloneSet", klog.KObj(cs), "pvc", klog.KObj(claim))
+			continue
+		}
+		if err := c.checkVolumeExpansion(claim); err != nil {
+			c.recorder.Eventf(cs, v1.EventTypeWarning, "FailedResizePVC", "failed to resize pvc %s for pod %s: %v", claim.Name, pod.Name, err)
+			continue
+		}
+
+		claimClone := claim.DeepCopy()
+		claimClone.Spec.Resources = template.Spec.Resources
+		if err := c.Client.Update(context.TODO(), claimClone); err != nil {
+			c.recorder.Eventf(cs, v1.EventTypeWarning, "FailedResizePVC", "failed to resize pvc %s for pod %s: %v", claim.Name, pod.Name, err)
+			return fmt.Errorf("failed to resize pvc %s: %v", claim.Name, err)
+		}
+		klog.InfoS("CloneSet resized pvc", "cloneSet", klog.KObj(cs), "pvc", klog.KObj(claim), "storage", template.Spec.Resources.Requests.Storage())
+		c.recorder.Eventf(cs, v1.EventTypeNormal, "SuccessfulResizePVC", "succeed to resize pvc %s for pod %s to %s",
+			claim.Name, pod.Name, template.Spec.Resources.Requests.Storage())
+	}
+	return nil
+}
+
+// checkVolumeExpansion returns error if the storage class of the pvc does not allow volume expansion.
+func (c *realControl) checkVolumeExpansion(claim *v1.PersistentVolumeClaim) error {
+	if claim.Spec.StorageClassName == nil {
+		return nil
+	}
+	scName := *claim.Spec.StorageClassName
+	sc := &storagev1.StorageClass{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Name: scName}, sc); err != nil {
+		return fmt.Errorf("could not get storage class %s: %v", scName, err)
+	}
+	if sc.AllowVolumeExpansion == nil || !*sc.AllowVolumeExpansion {
+		return fmt.Errorf("storage class %s does not support volume expansion", scName)
+	}
+	return nil
+}
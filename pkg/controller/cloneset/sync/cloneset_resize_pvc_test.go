@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetcore "github.com/openkruise/kruise/pkg/controller/cloneset/core"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResizePVCs(t *testing.T) {
+	newClaim := func(name, storageClass, storage string, accessMode v1.PersistentVolumeAccessMode) *v1.PersistentVolumeClaim {
+		claim := &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes: []v1.PersistentVolumeAccessMode{accessMode},
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)},
+				},
+			},
+		}
+		if storageClass != "" {
+			claim.Spec.StorageClassName = utilpointer.String(storageClass)
+		}
+		return claim
+	}
+	newPod := func(id string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-" + id,
+			Namespace: "default",
+			Labels:    map[string]string{appsv1alpha1.CloneSetInstanceID: id},
+		}}
+	}
+
+	cases := []struct {
+		name             string
+		strategyType     appsv1alpha1.CloneSetVolumeClaimUpdateStrategyType
+		claim            *v1.PersistentVolumeClaim
+		expectedStorage  string
+		expectedWarnings int
+	}{
+		{
+			name:            "resize pvc online",
+			strategyType:    appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType,
+			claim:           newClaim("data-sample-a", "expandable", "1Gi", v1.ReadWriteOnce),
+			expectedStorage: "2Gi",
+		},
+		{
+			name:            "not resize pvc on delete",
+			strategyType:    appsv1alpha1.OnDeleteCloneSetVolumeClaimUpdateStrategyType,
+			claim:           newClaim("data-sample-a", "expandable", "1Gi", v1.ReadWriteOnce),
+			expectedStorage: "1Gi",
+		},
+		{
+			name:            "not shrink pvc",
+			strategyType:    appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType,
+			claim:           newClaim("data-sample-a", "expandable", "3Gi", v1.ReadWriteOnce),
+			expectedStorage: "3Gi",
+		},
+		{
+			name:            "not resize pvc incompatible with template",
+			strategyType:    appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType,
+			claim:           newClaim("data-sample-a", "expandable", "1Gi", v1.ReadWriteMany),
+			expectedStorage: "1Gi",
+		},
+		{
+			name:             "storage class does not allow expansion",
+			strategyType:     appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType,
+			claim:            newClaim("data-sample-a", "fixed", "1Gi", v1.ReadWriteOnce),
+			expectedStorage:  "1Gi",
+			expectedWarnings: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas:                  utilpointer.Int32(1),
+					Selector:                  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "sample"}},
+					VolumeClaimTemplates:      []v1.PersistentVolumeClaim{*newClaim("data", "", "2Gi", v1.ReadWriteOnce)},
+					VolumeClaimUpdateStrategy: appsv1alpha1.CloneSetVolumeClaimUpdateStrategy{Type: tc.strategyType},
+				},
+			}
+			objects := []client.Object{
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, AllowVolumeExpansion: utilpointer.Bool(true)},
+				&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "fixed"}},
+				tc.claim.DeepCopy(),
+			}
+			fClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build()
+			recorder := record.NewFakeRecorder(10)
+			rControl := &realControl{Client: fClient, recorder: recorder}
+
+			claim := &v1.PersistentVolumeClaim{}
+			if err := fClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: tc.claim.Name}, claim); err != nil {
+				t.Fatalf("failed to get pvc: %v", err)
+			}
+			if err := rControl.resizePVCs(cs, clonesetcore.New(cs), []*v1.Pod{newPod("a")}, []*v1.PersistentVolumeClaim{claim}); err != nil {
+				t.Fatalf("failed to resize pvcs: %v", err)
+			}
+
+			if err := fClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: tc.claim.Name}, claim); err != nil {
+				t.Fatalf("failed to get pvc: %v", err)
+			}
+			if storage := claim.Spec.Resources.Requests.Storage(); storage.Cmp(resource.MustParse(tc.expectedStorage)) != 0 {
+				t.Fatalf("expected storage %s, got %s", tc.expectedStorage, storage.String())
+			}
+			var warnings int
+			for len(recorder.Events) > 0 {
+				if event := <-recorder.Events; strings.HasPrefix(event, v1.EventTypeWarning) {
+					warnings++
+				}
+			}
+			if warnings != tc.expectedWarnings {
+				t.Fatalf("expected %d warning events, got %d", tc.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestResizePVCsWithPartition(t *testing.T) {
+	newClaim := func(name, storage string) *v1.PersistentVolumeClaim {
+		return &v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: v1.PersistentVolumeClaimSpec{
+				AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+				StorageClassName: utilpointer.String("expandable"),
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)},
+				},
+			},
+		}
+	}
+	newPod := func(id string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-" + id,
+			Namespace: "default",
+			Labels:    map[string]string{appsv1alpha1.CloneSetInstanceID: id},
+		}}
+	}
+
+	cs := &appsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: appsv1alpha1.CloneSetSpec{
+			Replicas:                  utilpointer.Int32(3),
+			Selector:                  &metav1.LabelSelector{MatchLabels: map[string]string{"app": "sample"}},
+			VolumeClaimTemplates:      []v1.PersistentVolumeClaim{*newClaim("data", "2Gi")},
+			VolumeClaimUpdateStrategy: appsv1alpha1.CloneSetVolumeClaimUpdateStrategy{Type: appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType},
+			UpdateStrategy:            appsv1alpha1.CloneSetUpdateStrategy{Partition: &intstr.IntOrString{Type: intstr.Int, IntVal: 1}},
+		},
+	}
+	// pod c has been resized, so only one more pod can be resized within the partition
+	claims := []*v1.PersistentVolumeClaim{
+		newClaim("data-sample-a", "1Gi"),
+		newClaim("data-sample-b", "1Gi"),
+		newClaim("data-sample-c", "2Gi"),
+	}
+	pods := []*v1.Pod{newPod("a"), newPod("b"), newPod("c")}
+	objects := []client.Object{
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "expandable"}, AllowVolumeExpansion: utilpointer.Bool(true)},
+	}
+	for _, claim := range claims {
+		objects = append(objects, claim.DeepCopy())
+	}
+	fClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build()
+	rControl := &realControl{Client: fClient, recorder: record.NewFakeRecorder(10)}
+
+	for i := range claims {
+		if err := fClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: claims[i].Name}, claims[i]); err != nil {
+			t.Fatalf("failed to get pvc: %v", err)
+		}
+	}
+	if err := rControl.resizePVCs(cs, clonesetcore.New(cs), pods, claims); err != nil {
+		t.Fatalf("failed to resize pvcs: %v", err)
+	}
+
+	var resized int
+	for _, name := range []string{"data-sample-a", "data-sample-b"} {
+		claim := &v1.PersistentVolumeClaim{}
+		if err := fClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, claim); err != nil {
+			t.Fatalf("failed to get pvc: %v", err)
+		}
+		if storage := claim.Spec.Resources.Requests.Storage(); storage.Cmp(resource.MustParse("2Gi")) == 0 {
+			resized++
+		}
+	}
+	if resized != 1 {
+		t.Fatalf("expected 1 pvc resized within partition, got %d", resized)
+	}
+}
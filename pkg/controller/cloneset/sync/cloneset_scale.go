@@ -20,10 +20,14 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
@@ -50,12 +54,14 @@ func (r *realControl) Scale(
 	currentCS, updateCS *appsv1alpha1.CloneSet,
 	currentRevision, updateRevision string,
 	pods []*v1.Pod, pvcs []*v1.PersistentVolumeClaim,
+	newStatus *appsv1alpha1.CloneSetStatus,
 ) (bool, error) {
 	if updateCS.Spec.Replicas == nil {
 		return false, fmt.Errorf("spec.Replicas is nil")
 	}
 
 	coreControl := clonesetcore.New(updateCS)
+	throttle := newScaleDownThrottle(updateCS, pods, newStatus, time.Now())
 	if !coreControl.IsReadyToScale() {
 		klog.InfoS("CloneSet skipped scaling for not ready to scale", "cloneSet", klog.KObj(updateCS))
 		return false, nil
@@ -67,6 +73,11 @@ func (r *realControl) Scale(
 		return modified, err
 	}
 
+	// new revision pods are created and switched all at once for BlueGreen update
+	if isBlueGreenUpdating(updateCS, updateRevision, pods) {
+		return r.syncBlueGreen(currentCS, updateCS, currentRevision, updateRevision, pods, pvcs)
+	}
+
 	// 2. calculate scale numbers
 	diffRes := calculateDiffsWithExpectation(updateCS, pods, currentRevision, updateRevision, revision.IsPodUpdate)
 	updatedPods, notUpdatedPods := clonesetutils.GroupUpdateAndNotUpdatePods(pods, updateRevision)
@@ -85,8 +96,16 @@ func (r *realControl) Scale(
 		klog.V(3).InfoS("CloneSet began to scale out pods, including current revision",
 			"cloneSet", klog.KObj(updateCS), "expectedCreations", expectedCreations, "expectedCurrentCreations", expectedCurrentCreations)
 
-		// available instance-id come from free pvc
-		availableIDs := getOrGenAvailableIDs(expectedCreations, pods, pvcs)
+		// available instance-id come from free pvc, or the lowest ordinals not used by pods
+		var availableIDs sets.String
+		if updateCS.Spec.ScaleStrategy.InstanceIDPolicy == appsv1alpha1.LowestAvailableOrdinalCloneSetInstanceIDPolicyType {
+			var err error
+			if availableIDs, err = r.getLowestAvailableOrdinalIDs(updateCS, expectedCreations, pods); err != nil {
+				return false, err
+			}
+		} else {
+			availableIDs = getOrGenAvailableIDs(expectedCreations, pods, pvcs)
+		}
 		// existing pvc names
 		existingPVCNames := sets.NewString()
 		for _, pvc := range pvcs {
@@ -100,7 +119,8 @@ func (r *realControl) Scale(
 	// 4. try to delete pods already in pre-delete
 	if len(podsInPreDelete) > 0 {
 		klog.V(3).InfoS("CloneSet tried to delete pods in preDelete", "cloneSet", klog.KObj(updateCS), "pods", util.GetPodNames(podsInPreDelete).List())
-		if modified, err := r.deletePods(updateCS, podsInPreDelete, pvcs); err != nil || modified {
+		podsCanDelete, _ := throttle.limitPodsToDelete(podsInPreDelete)
+		if modified, err := r.deletePods(updateCS, podsCanDelete, pvcs, throttle); err != nil || modified {
 			return modified, err
 		}
 	}
@@ -121,7 +141,7 @@ func (r *realControl) Scale(
 			}
 		}
 
-		if modified, err := r.deletePods(updateCS, podsCanDelete, pvcs); err != nil || modified {
+		if modified, err := r.deletePods(updateCS, podsCanDelete, pvcs, throttle); err != nil || modified {
 			return modified, err
 		}
 	}
@@ -137,7 +157,12 @@ func (r *realControl) Scale(
 		klog.V(3).InfoS("CloneSet began to scale in", "cloneSet", klog.KObj(updateCS), "scaleDownNum", diffRes.scaleDownNum,
 			"oldRevision", diffRes.scaleDownNumOldRevision, "deleteReadyLimit", diffRes.deleteReadyLimit)
 
-		podsPreparingToDelete := r.choosePodsToDelete(updateCS, diffRes.scaleDownNum, diffRes.scaleDownNumOldRevision, notUpdatedPods, updatedPods)
+		podsPreparingToDelete, plan := r.choosePodsToDelete(updateCS, diffRes.scaleDownNum, diffRes.scaleDownNumOldRevision, notUpdatedPods, updatedPods)
+		// only the scale-down for the decrease of replicas is dry-run, the surge Pods of rollout are still deleted
+		if updateCS.Spec.ScaleStrategy.ScaleDownDryRun && getScaleDownNumByReplicas(updateCS, pods, podsInPreDelete, notUpdatedPods, diffRes.scaleDownNum) > 0 {
+			r.recordScaleDownPlan(updateCS, newStatus, plan)
+			return false, nil
+		}
 		podsToDelete := make([]*v1.Pod, 0, len(podsPreparingToDelete))
 		for _, pod := range podsPreparingToDelete {
 			if !isPodReady(coreControl, pod) {
@@ -148,7 +173,19 @@ func (r *realControl) Scale(
 			}
 		}
 
-		return r.deletePods(updateCS, podsToDelete, pvcs)
+		podsToDelete, podsToHold := throttle.limitPodsToDelete(podsToDelete)
+		if len(podsToHold) == 0 {
+			return r.deletePods(updateCS, podsToDelete, pvcs, throttle)
+		}
+
+		klog.V(3).InfoS("CloneSet throttled to scale in", "cloneSet", klog.KObj(updateCS), "podsToDelete", len(podsToDelete),
+			"podsToHold", len(podsToHold), "reason", throttle.status.Reason)
+		held, err := r.holdPods(updateCS, podsToHold, throttle)
+		if err != nil {
+			return held, err
+		}
+		deleted, err := r.deletePods(updateCS, podsToDelete, pvcs, throttle)
+		return held || deleted, err
 	}
 
 	return false, nil
@@ -264,7 +301,9 @@ func (r *realControl) createOnePod(cs *appsv1alpha1.CloneSet, pod *v1.Pod, exist
 	return nil
 }
 
-func (r *realControl) deletePods(cs *appsv1alpha1.CloneSet, podsToDelete []*v1.Pod, pvcs []*v1.PersistentVolumeClaim) (bool, error) {
+// deletePods deletes the pods and their pvcs, or marks them PreparingDelete if hooked by lifecycle preDelete.
+// The deleted pods are counted into the throttle, which can be nil.
+func (r *realControl) deletePods(cs *appsv1alpha1.CloneSet, podsToDelete []*v1.Pod, pvcs []*v1.PersistentVolumeClaim, throttle *scaleDownThrottle) (bool, error) {
 	var modified bool
 	for _, pod := range podsToDelete {
 		if cs.Spec.Lifecycle != nil && lifecycle.IsPodHooked(cs.Spec.Lifecycle.PreDelete, pod) {
@@ -287,6 +326,7 @@ func (r *realControl) deletePods(cs *appsv1alpha1.CloneSet, podsToDelete []*v1.P
 			return modified, err
 		}
 		modified = true
+		throttle.observeDeleted(pod)
 		r.recorder.Event(cs, v1.EventTypeNormal, "SuccessfulDelete", fmt.Sprintf("succeed to delete pod %s", pod.Name))
 
 		// delete pvcs which have the same instance-id
@@ -354,6 +394,34 @@ func getOrGenAvailableIDs(num int, pods []*v1.Pod, pvcs []*v1.PersistentVolumeCl
 	return retIDs
 }
 
+// Get the lowest ordinals which are not used by existing pods as instance-id. The PVCs of these ordinals,
+// if exist, will be reused by the new pods because they have the same names.
+func (r *realControl) getLowestAvailableOrdinalIDs(cs *appsv1alpha1.CloneSet, num int, pods []*v1.Pod) (sets.String, error) {
+	existingIDs := sets.NewString()
+	for _, pod := range pods {
+		if id := pod.Labels[appsv1alpha1.CloneSetInstanceID]; len(id) > 0 {
+			existingIDs.Insert(id)
+		}
+	}
+
+	retIDs := sets.NewString()
+	for ordinal := 0; retIDs.Len() < num; ordinal++ {
+		id := strconv.Itoa(ordinal)
+		if existingIDs.Has(id) {
+			continue
+		}
+		// skip the ordinal whose pod is still terminating
+		pod := &v1.Pod{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: cs.Namespace, Name: fmt.Sprintf("%s-%s", cs.Name, id)}, pod); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to check pod of ordinal %s: %v", id, err)
+		}
+		retIDs.Insert(id)
+	}
+	return retIDs, nil
+}
+
 func getOrGenInstanceID(existingIDs, availableIDs sets.String) string {
 	id, _ := availableIDs.PopAny()
 	if len(id) == 0 {
@@ -367,40 +435,51 @@ func getOrGenInstanceID(existingIDs, availableIDs sets.String) string {
 	return id
 }
 
-func (r *realControl) choosePodsToDelete(cs *appsv1alpha1.CloneSet, totalDiff int, currentRevDiff int, notUpdatedPods, updatedPods []*v1.Pod) []*v1.Pod {
+func (r *realControl) choosePodsToDelete(cs *appsv1alpha1.CloneSet, totalDiff int, currentRevDiff int, notUpdatedPods, updatedPods []*v1.Pod) ([]*v1.Pod, []appsv1alpha1.CloneSetScaleDownPlanPod) {
 	coreControl := clonesetcore.New(cs)
-	choose := func(pods []*v1.Pod, diff int) []*v1.Pod {
+	choose := func(pods []*v1.Pod, diff int) ([]*v1.Pod, clonesetutils.ActivePodsWithRanks) {
+		sorter := clonesetutils.ActivePodsWithRanks{
+			Pods: pods,
+			AvailableFunc: func(pod *v1.Pod) bool {
+				return IsPodAvailable(coreControl, pod, cs.Spec.MinReadySeconds)
+			},
+		}
 		// No need to sort pods if we are about to delete all of them.
 		if diff < len(pods) {
-			var ranker clonesetutils.Ranker
 			if constraints := coreControl.GetPodSpreadConstraint(); len(constraints) > 0 {
-				ranker = clonesetutils.NewSpreadConstraintsRanker(pods, constraints, r.Client)
+				sorter.Ranker = clonesetutils.NewSpreadConstraintsRanker(pods, constraints, r.Client)
 			} else {
-				ranker = clonesetutils.NewSameNodeRanker(pods)
+				sorter.Ranker = clonesetutils.NewSameNodeRanker(pods)
 			}
-			sort.Sort(clonesetutils.ActivePodsWithRanks{
-				Pods:   pods,
-				Ranker: ranker,
-				AvailableFunc: func(pod *v1.Pod) bool {
-					return IsPodAvailable(coreControl, pod, cs.Spec.MinReadySeconds)
-				},
-			})
+			sort.Sort(sorter)
 		} else if diff > len(pods) {
 			klog.InfoS("Diff > len(pods) in choosePodsToDelete func which is not expected")
-			return pods
+			return pods, sorter
 		}
-		return pods[:diff]
+		return pods[:diff], sorter
 	}
 
-	var podsToDelete []*v1.Pod
+	var notUpdatedPodsToDelete, updatedPodsToDelete []*v1.Pod
+	var notUpdatedSorter, updatedSorter clonesetutils.ActivePodsWithRanks
 	if currentRevDiff >= totalDiff {
-		podsToDelete = choose(notUpdatedPods, totalDiff)
+		notUpdatedPodsToDelete, notUpdatedSorter = choose(notUpdatedPods, totalDiff)
 	} else if currentRevDiff > 0 {
-		podsToDelete = choose(notUpdatedPods, currentRevDiff)
-		podsToDelete = append(podsToDelete, choose(updatedPods, totalDiff-currentRevDiff)...)
+		notUpdatedPodsToDelete, notUpdatedSorter = choose(notUpdatedPods, currentRevDiff)
+		updatedPodsToDelete, updatedSorter = choose(updatedPods, totalDiff-currentRevDiff)
 	} else {
-		podsToDelete = choose(updatedPods, totalDiff)
+		updatedPodsToDelete, updatedSorter = choose(updatedPods, totalDiff)
 	}
+	podsToDelete := make([]*v1.Pod, 0, totalDiff)
+	podsToDelete = append(podsToDelete, notUpdatedPodsToDelete...)
+	podsToDelete = append(podsToDelete, updatedPodsToDelete...)
 
-	return podsToDelete
+	if !cs.Spec.ScaleStrategy.ScaleDownDryRun {
+		return podsToDelete, nil
+	}
+	updatedKept := len(updatedPods) > len(updatedPodsToDelete)
+	notUpdatedKept := len(notUpdatedPods) > len(notUpdatedPodsToDelete)
+	// the not updated pods are preferred by revision only if some of them are kept, otherwise all of them are deleted
+	plan := explainPodsToDelete(notUpdatedSorter, len(notUpdatedPodsToDelete), updatedKept && notUpdatedKept, updatedKept)
+	plan = append(plan, explainPodsToDelete(updatedSorter, len(updatedPodsToDelete), false, notUpdatedKept)...)
+	return podsToDelete, plan
 }
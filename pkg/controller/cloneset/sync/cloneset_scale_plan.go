@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+	"strings"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/klog/v2"
+	"k8s.io/utils/integer"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+)
+
+// explainPodsToDelete explains the first num pods of the sorted pods, which are chosen to be deleted.
+// If preferredByRevision is true, the pods are chosen because of their revision before any other sorting.
+// If otherRevisionKept is true, the pods of the other revision are kept when all of these pods are to be deleted.
+func explainPodsToDelete(sorter clonesetutils.ActivePodsWithRanks, num int, preferredByRevision, otherRevisionKept bool) []appsv1alpha1.CloneSetScaleDownPlanPod {
+	var plan []appsv1alpha1.CloneSetScaleDownPlanPod
+	for i := 0; i < num && i < len(sorter.Pods); i++ {
+		pod := sorter.Pods[i]
+		planPod := appsv1alpha1.CloneSetScaleDownPlanPod{
+			Name:     pod.Name,
+			Revision: pod.Labels[apps.ControllerRevisionHashLabelKey],
+		}
+		switch {
+		case preferredByRevision:
+			planPod.Reason = appsv1alpha1.CloneSetScaleDownReasonRevision
+			planPod.Message = "pod is not updated and old revision pods are preferred to be deleted"
+		case num < len(sorter.Pods):
+			planPod.Reason, planPod.Message = sorter.ExplainLess(pod, sorter.Pods[num])
+		case otherRevisionKept:
+			planPod.Reason = appsv1alpha1.CloneSetScaleDownReasonRevision
+			planPod.Message = "all pods of this revision are to be deleted while pods of the other revision are kept"
+		default:
+			planPod.Reason = appsv1alpha1.CloneSetScaleDownReasonOther
+			planPod.Message = "all pods are to be deleted"
+		}
+		plan = append(plan, planPod)
+	}
+	return plan
+}
+
+// getScaleDownNumByReplicas returns the number of pods to scale down for the decrease of replicas,
+// excluding the surge pods of rollout, which can only be left when there are pods not updated.
+func getScaleDownNumByReplicas(cs *appsv1alpha1.CloneSet, pods, podsInPreDelete, notUpdatedPods []*v1.Pod, scaleDownNum int) int {
+	replicas := int(*cs.Spec.Replicas)
+	activeCount := len(pods)
+	if shouldScalingExcludePreparingDelete(cs) {
+		activeCount -= len(podsInPreDelete)
+	}
+	var surgeAllowance int
+	if cs.Spec.UpdateStrategy.MaxSurge != nil && len(notUpdatedPods) > 0 {
+		surgeAllowance, _ = intstrutil.GetValueFromIntOrPercent(cs.Spec.UpdateStrategy.MaxSurge, replicas, true)
+	}
+	return integer.IntMin(scaleDownNum, integer.IntMax(activeCount-replicas-surgeAllowance, 0))
+}
+
+// recordScaleDownPlan records the plan into newStatus instead of deleting pods, and emits an event if the plan changed.
+func (r *realControl) recordScaleDownPlan(cs *appsv1alpha1.CloneSet, newStatus *appsv1alpha1.CloneSetStatus, plan []appsv1alpha1.CloneSetScaleDownPlanPod) {
+	newStatus.ScaleDownPlan = &appsv1alpha1.CloneSetScaleDownPlan{
+		Replicas: *cs.Spec.Replicas,
+		Pods:     plan,
+	}
+	if isSameScaleDownPlan(cs.Status.ScaleDownPlan, newStatus.ScaleDownPlan) {
+		return
+	}
+
+	picks := make([]string, 0, len(plan))
+	for _, p := range plan {
+		picks = append(picks, fmt.Sprintf("%s(%s)", p.Name, p.Reason))
+	}
+	klog.InfoS("CloneSet planned pods to delete for scaling in with dry-run", "cloneSet", klog.KObj(cs), "replicas", *cs.Spec.Replicas, "pods", picks)
+	r.recorder.Eventf(cs, v1.EventTypeNormal, "ScaleDownDryRun", "planned to delete %d pods for scaling in to %d replicas: %s",
+		len(plan), *cs.Spec.Replicas, strings.Join(picks, ", "))
+}
+
+func isSameScaleDownPlan(oldPlan, newPlan *appsv1alpha1.CloneSetScaleDownPlan) bool {
+	if oldPlan == nil || newPlan == nil {
+		return oldPlan == newPlan
+	}
+	if oldPlan.Replicas != newPlan.Replicas || len(oldPlan.Pods) != len(newPlan.Pods) {
+		return false
+	}
+	for i := range oldPlan.Pods {
+		if oldPlan.Pods[i].Name != newPlan.Pods[i].Name || oldPlan.Pods[i].Reason != newPlan.Pods[i].Reason {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,219 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestScaleDownDryRun(t *testing.T) {
+	now := time.Now()
+	newPod := func(name, revision, node string, ready bool, created time.Duration, annotations map[string]string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				UID:               types.UID(name),
+				Labels:            map[string]string{apps.ControllerRevisionHashLabelKey: revision},
+				Annotations:       annotations,
+				CreationTimestamp: metav1.Time{Time: now.Add(-created)},
+			},
+			Spec: v1.PodSpec{NodeName: node},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				Conditions: []v1.PodCondition{{
+					Type:               v1.PodReady,
+					Status:             v1.ConditionTrue,
+					LastTransitionTime: metav1.Time{Time: now.Add(-time.Hour)},
+				}},
+			},
+		}
+		if !ready {
+			pod.Status.Conditions[0].Status = v1.ConditionFalse
+		}
+		return pod
+	}
+
+	cases := []struct {
+		name             string
+		replicas         int32
+		oldPlan          *appsv1alpha1.CloneSetScaleDownPlan
+		pods             []*v1.Pod
+		expectedPods     []string
+		expectedReasons  []appsv1alpha1.CloneSetScaleDownReason
+		expectedEventNum int
+	}{
+		{
+			name:     "not ready and deletion cost",
+			replicas: 2,
+			pods: []*v1.Pod{
+				newPod("pod-a", "rev-b", "node-a", true, time.Hour, nil),
+				newPod("pod-b", "rev-b", "node-b", false, time.Hour, nil),
+				newPod("pod-c", "rev-b", "node-c", true, time.Hour, map[string]string{clonesetutils.PodDeletionCost: "-10"}),
+				newPod("pod-d", "rev-b", "node-d", true, time.Hour, nil),
+			},
+			expectedPods:     []string{"pod-b", "pod-c"},
+			expectedReasons:  []appsv1alpha1.CloneSetScaleDownReason{appsv1alpha1.CloneSetScaleDownReasonNotReady, appsv1alpha1.CloneSetScaleDownReasonDeletionCost},
+			expectedEventNum: 1,
+		},
+		{
+			name:     "old revision and topology imbalance",
+			replicas: 2,
+			pods: []*v1.Pod{
+				newPod("pod-a", "rev-a", "node-a", true, time.Hour, nil),
+				newPod("pod-b", "rev-b", "node-a", true, time.Hour, nil),
+				newPod("pod-c", "rev-b", "node-b", true, time.Hour, nil),
+				newPod("pod-d", "rev-b", "node-b", true, time.Minute, nil),
+			},
+			expectedPods:     []string{"pod-a", "pod-d"},
+			expectedReasons:  []appsv1alpha1.CloneSetScaleDownReason{appsv1alpha1.CloneSetScaleDownReasonRevision, appsv1alpha1.CloneSetScaleDownReasonTopologyImbalance},
+			expectedEventNum: 1,
+		},
+		{
+			name:     "unchanged plan",
+			replicas: 3,
+			oldPlan: &appsv1alpha1.CloneSetScaleDownPlan{
+				Replicas: 3,
+				Pods:     []appsv1alpha1.CloneSetScaleDownPlanPod{{Name: "pod-b", Reason: appsv1alpha1.CloneSetScaleDownReasonNotReady}},
+			},
+			pods: []*v1.Pod{
+				newPod("pod-a", "rev-b", "node-a", true, time.Hour, nil),
+				newPod("pod-b", "rev-b", "node-b", false, time.Hour, nil),
+				newPod("pod-c", "rev-b", "node-c", true, time.Hour, nil),
+				newPod("pod-d", "rev-b", "node-d", true, time.Hour, nil),
+			},
+			expectedPods:    []string{"pod-b"},
+			expectedReasons: []appsv1alpha1.CloneSetScaleDownReason{appsv1alpha1.CloneSetScaleDownReasonNotReady},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas:      utilpointer.Int32(tc.replicas),
+					ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{ScaleDownDryRun: true},
+					UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+						MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+					},
+				},
+				Status: appsv1alpha1.CloneSetStatus{ScaleDownPlan: tc.oldPlan},
+			}
+			fClient := fake.NewClientBuilder().WithScheme(kscheme).Build()
+			for _, pod := range tc.pods {
+				if err := fClient.Create(context.TODO(), pod.DeepCopy()); err != nil {
+					t.Fatalf("failed to create pod: %v", err)
+				}
+			}
+			recorder := record.NewFakeRecorder(10)
+			rControl := &realControl{Client: fClient, recorder: recorder}
+
+			newStatus := &appsv1alpha1.CloneSetStatus{}
+			modified, err := rControl.Scale(cs, cs, "rev-a", "rev-b", tc.pods, nil, newStatus)
+			if err != nil || modified {
+				t.Fatalf("expected no modification, got modified=%v err=%v", modified, err)
+			}
+
+			podList := &v1.PodList{}
+			if err := fClient.List(context.TODO(), podList); err != nil {
+				t.Fatalf("failed to list pods: %v", err)
+			}
+			if len(podList.Items) != len(tc.pods) {
+				t.Fatalf("expected no pods deleted in dry-run, got %d pods", len(podList.Items))
+			}
+
+			plan := newStatus.ScaleDownPlan
+			if plan == nil || plan.Replicas != tc.replicas || len(plan.Pods) != len(tc.expectedPods) {
+				t.Fatalf("unexpected plan %+v", plan)
+			}
+			for i, p := range plan.Pods {
+				if p.Name != tc.expectedPods[i] || p.Reason != tc.expectedReasons[i] || p.Message == "" {
+					t.Fatalf("expected pod %s with reason %s, got %+v", tc.expectedPods[i], tc.expectedReasons[i], p)
+				}
+			}
+			if len(recorder.Events) != tc.expectedEventNum {
+				t.Fatalf("expected %d events, got %d", tc.expectedEventNum, len(recorder.Events))
+			}
+		})
+	}
+}
+
+func TestScaleDownDryRunWithSurge(t *testing.T) {
+	newPod := func(name, revision string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				UID:       types.UID(name),
+				Labels:    map[string]string{apps.ControllerRevisionHashLabelKey: revision},
+			},
+			Status: v1.PodStatus{
+				Phase:      v1.PodRunning,
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+			},
+		}
+	}
+	pods := []*v1.Pod{newPod("pod-a", "rev-a"), newPod("pod-b", "rev-b"), newPod("pod-c", "rev-b")}
+	maxSurge := intstr.FromInt(1)
+	cs := &appsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: appsv1alpha1.CloneSetSpec{
+			Replicas:      utilpointer.Int32(2),
+			ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{ScaleDownDryRun: true},
+			UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+				MaxSurge:       &maxSurge,
+				MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+			},
+		},
+	}
+	fClient := fake.NewClientBuilder().WithScheme(kscheme).Build()
+	for _, pod := range pods {
+		if err := fClient.Create(context.TODO(), pod.DeepCopy()); err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+	}
+	rControl := &realControl{Client: fClient, recorder: record.NewFakeRecorder(10)}
+
+	// the surge pod of rollout should still be deleted in dry-run
+	newStatus := &appsv1alpha1.CloneSetStatus{}
+	modified, err := rControl.Scale(cs, cs, "rev-a", "rev-b", pods, nil, newStatus)
+	if err != nil || !modified {
+		t.Fatalf("expected modification, got modified=%v err=%v", modified, err)
+	}
+	if newStatus.ScaleDownPlan != nil {
+		t.Fatalf("expected no plan for surge pods, got %+v", newStatus.ScaleDownPlan)
+	}
+	pod := &v1.Pod{}
+	if err := fClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "pod-a"}, pod); !errors.IsNotFound(err) {
+		t.Fatalf("expected old revision pod deleted, got %v", err)
+	}
+}
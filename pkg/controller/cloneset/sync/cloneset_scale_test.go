@@ -45,6 +45,7 @@ import (
 	utilpointer "k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 )
 
 var (
@@ -481,7 +482,7 @@ func TestDeletePods(t *testing.T) {
 		_ = ctrl.Create(context.TODO(), p)
 	}
 
-	deleted, err := ctrl.deletePods(cs, podsToDelete, pvcs)
+	deleted, err := ctrl.deletePods(cs, podsToDelete, pvcs, nil)
 	if err != nil {
 		t.Fatalf("failed to delete got pods: %v", err)
 	} else if !deleted {
@@ -547,6 +548,53 @@ func TestGetOrGenAvailableIDs(t *testing.T) {
 	}
 }
 
+func TestGetLowestAvailableOrdinalIDs(t *testing.T) {
+	cs := &appsv1alpha1.CloneSet{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "sample"}}
+	pods := []*v1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{appsv1alpha1.CloneSetInstanceID: "0"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{appsv1alpha1.CloneSetInstanceID: "2"},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Labels: map[string]string{appsv1alpha1.CloneSetInstanceID: "abcde"},
+			},
+		},
+	}
+	terminatingPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      "sample-3",
+			Labels:    map[string]string{appsv1alpha1.CloneSetInstanceID: "3"},
+		},
+	}
+
+	rControl := &realControl{Client: fake.NewClientBuilder().WithObjects(terminatingPod).Build()}
+	gotIDs, err := rControl.getLowestAvailableOrdinalIDs(cs, 3, pods)
+	if err != nil {
+		t.Fatalf("failed to get available ids: %v", err)
+	}
+	if expectedIDs := sets.NewString("1", "4", "5"); !gotIDs.Equal(expectedIDs) {
+		t.Fatalf("expected %v, got %v", expectedIDs.List(), gotIDs.List())
+	}
+
+	// the ordinal should not be taken as available if its pod can not be checked
+	rControl = &realControl{Client: fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+			return fmt.Errorf("connection refused")
+		},
+	}).Build()}
+	if _, err := rControl.getLowestAvailableOrdinalIDs(cs, 3, pods); err == nil {
+		t.Fatalf("expected error when failed to get pod")
+	}
+}
+
 func TestScale(t *testing.T) {
 	cases := []struct {
 		name             string
@@ -740,7 +788,7 @@ func TestScale(t *testing.T) {
 				Client:   fClient,
 				recorder: record.NewFakeRecorder(10),
 			}
-			modified, err := rControl.Scale(cs.getCloneSets()[0], cs.getCloneSets()[1], cs.getRevisions()[0], cs.getRevisions()[1], pods, nil)
+			modified, err := rControl.Scale(cs.getCloneSets()[0], cs.getCloneSets()[1], cs.getRevisions()[0], cs.getRevisions()[1], pods, nil, &appsv1alpha1.CloneSetStatus{})
 			if err != nil {
 				t.Fatalf(err.Error())
 			}
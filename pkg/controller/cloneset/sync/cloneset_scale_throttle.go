@@ -0,0 +1,199 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
+	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+)
+
+const defaultScaleDownIntervalSeconds = 60
+
+// scaleDownThrottle limits the number of pods deleted for scaling in according to scaleStrategy.scaleDownPolicy.
+// A nil scaleDownThrottle means no limit.
+type scaleDownThrottle struct {
+	cs       *appsv1alpha1.CloneSet
+	status   *appsv1alpha1.CloneSetScaleDownThrottle
+	limit    int
+	interval time.Duration
+	now      time.Time
+}
+
+// newScaleDownThrottle returns the throttle of the CloneSet, whose state is carried over into newStatus.
+func newScaleDownThrottle(cs *appsv1alpha1.CloneSet, pods []*v1.Pod, newStatus *appsv1alpha1.CloneSetStatus, now time.Time) *scaleDownThrottle {
+	policy := cs.Spec.ScaleStrategy.ScaleDownPolicy
+	if policy == nil {
+		newStatus.ScaleDownThrottle = nil
+		return nil
+	}
+
+	t := &scaleDownThrottle{
+		cs:       cs,
+		status:   cs.Status.ScaleDownThrottle.DeepCopy(),
+		limit:    math.MaxInt32,
+		interval: time.Duration(defaultScaleDownIntervalSeconds) * time.Second,
+		now:      now,
+	}
+	if t.status == nil {
+		t.status = &appsv1alpha1.CloneSetScaleDownThrottle{}
+	}
+	if policy.IntervalSeconds != nil {
+		t.interval = time.Duration(*policy.IntervalSeconds) * time.Second
+	}
+	if policy.MaxPodsPerInterval != nil {
+		if limit, err := util.GetScaledValueFromIntOrPercent(policy.MaxPodsPerInterval, len(pods), true); err == nil {
+			t.limit = limit
+		} else {
+			klog.ErrorS(err, "CloneSet failed to parse maxPodsPerInterval of scaleDownPolicy", "cloneSet", klog.KObj(cs))
+		}
+	}
+	// only the increase of replicas is a scale-up, not the creation of surge or replacement pods
+	if replicas := *cs.Spec.Replicas; t.status.ObservedReplicas == nil || *t.status.ObservedReplicas != replicas {
+		if t.status.ObservedReplicas != nil && *t.status.ObservedReplicas < replicas {
+			t.status.LastScaleUpTime = &metav1.Time{Time: now}
+		}
+		t.status.ObservedReplicas = &replicas
+	}
+	if t.status.IntervalStartTime != nil && !now.Before(t.status.IntervalStartTime.Add(t.interval)) {
+		t.status.IntervalStartTime = nil
+		t.status.DeletedReplicas = 0
+	}
+
+	t.status.HeldReplicas = 0
+	for _, pod := range pods {
+		if t.isThrottled(pod) && lifecycle.GetPodLifecycleState(pod) == appspub.LifecycleStatePreparingDelete {
+			t.status.HeldReplicas++
+		}
+	}
+	if t.status.HeldReplicas == 0 {
+		t.status.Reason = ""
+		t.status.Message = ""
+	}
+	newStatus.ScaleDownThrottle = t.status
+	return t
+}
+
+// isThrottled returns whether deleting the pod should be limited by the throttle.
+// Pods specified to delete or still hooked by lifecycle preDelete are not counted.
+func (t *scaleDownThrottle) isThrottled(pod *v1.Pod) bool {
+	if isSpecifiedDelete(t.cs, pod) {
+		return false
+	}
+	return t.cs.Spec.Lifecycle == nil || !lifecycle.IsPodHooked(t.cs.Spec.Lifecycle.PreDelete, pod)
+}
+
+// allowed returns the number of pods that can be deleted now, with the reason and message if it is limited.
+// The duration after which more pods will be allowed is returned for the stabilization window.
+func (t *scaleDownThrottle) allowed() (int, string, string, time.Duration) {
+	if window := t.cs.Spec.ScaleStrategy.ScaleDownPolicy.StabilizationWindowSeconds; window > 0 && t.status.LastScaleUpTime != nil {
+		if end := t.status.LastScaleUpTime.Add(time.Duration(window) * time.Second); t.now.Before(end) {
+			return 0, appsv1alpha1.CloneSetScaleDownThrottleStabilizing,
+				fmt.Sprintf("scaling in is stabilizing until %s after the last scale-up", end.UTC().Format(time.RFC3339)), end.Sub(t.now)
+		}
+	}
+	remaining := t.limit - int(t.status.DeletedReplicas)
+	if remaining < 0 {
+		remaining = 0
+	}
+	return remaining, appsv1alpha1.CloneSetScaleDownThrottleRateLimited,
+		fmt.Sprintf("at most %d pods can be deleted for scaling in every %v", t.limit, t.interval), 0
+}
+
+// limitPodsToDelete splits the pods into the ones that can be deleted now and the ones that should be held.
+// The former are counted into the current interval by observeDeleted only once they are deleted.
+func (t *scaleDownThrottle) limitPodsToDelete(pods []*v1.Pod) (podsToDelete, podsToHold []*v1.Pod) {
+	if t == nil {
+		return pods, nil
+	}
+
+	allowed, reason, message, waiting := t.allowed()
+	for _, pod := range pods {
+		if !t.isThrottled(pod) {
+			podsToDelete = append(podsToDelete, pod)
+		} else if allowed > 0 {
+			podsToDelete = append(podsToDelete, pod)
+			allowed--
+		} else {
+			podsToHold = append(podsToHold, pod)
+		}
+	}
+
+	t.status.HeldReplicas = int32(len(podsToHold))
+	if len(podsToHold) == 0 {
+		t.status.Reason = ""
+		t.status.Message = ""
+		return podsToDelete, podsToHold
+	}
+	if reason == appsv1alpha1.CloneSetScaleDownThrottleRateLimited {
+		// more pods will be allowed when the current interval ends, which starts now if no pod has been deleted yet
+		waiting = t.interval
+		if t.status.IntervalStartTime != nil {
+			waiting = t.status.IntervalStartTime.Add(t.interval).Sub(t.now)
+		}
+	}
+	t.status.Reason = reason
+	t.status.Message = message
+	if waiting > 0 {
+		clonesetutils.DurationStore.Push(clonesetutils.GetControllerKey(t.cs), waiting)
+	}
+	return podsToDelete, podsToHold
+}
+
+// observeDeleted counts the deleted pod into the current interval if it is limited by the throttle.
+func (t *scaleDownThrottle) observeDeleted(pod *v1.Pod) {
+	if t == nil || !t.isThrottled(pod) {
+		return
+	}
+	if t.status.IntervalStartTime == nil {
+		t.status.IntervalStartTime = &metav1.Time{Time: t.now}
+	}
+	t.status.DeletedReplicas++
+}
+
+// holdPods marks the pods as PreparingDelete, so that they will be deleted once the throttle allows,
+// or be turned back to normal if the CloneSet scales out again.
+func (r *realControl) holdPods(cs *appsv1alpha1.CloneSet, pods []*v1.Pod, throttle *scaleDownThrottle) (bool, error) {
+	var modified bool
+	for _, pod := range pods {
+		if lifecycle.GetPodLifecycleState(pod) == appspub.LifecycleStatePreparingDelete {
+			continue
+		}
+		if updated, gotPod, err := r.lifecycleControl.UpdatePodLifecycle(pod, appspub.LifecycleStatePreparingDelete, false); err != nil {
+			return modified, err
+		} else if updated {
+			klog.V(3).InfoS("CloneSet held pod in PreparingDelete for scale-down throttle", "cloneSet", klog.KObj(cs), "pod", klog.KObj(pod))
+			modified = true
+			clonesetutils.ResourceVersionExpectations.Expect(gotPod)
+		}
+	}
+	if modified {
+		r.recorder.Eventf(cs, v1.EventTypeNormal, "ScaleDownThrottled", "held %d pods in PreparingDelete: %s",
+			len(pods), throttle.status.Message)
+	}
+	return modified, nil
+}
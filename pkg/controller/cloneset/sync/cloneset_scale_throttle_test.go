@@ -0,0 +1,315 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sync
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestScaleDownThrottle(t *testing.T) {
+	now := time.Now()
+	newPods := func(num, preDeleting int) []*v1.Pod {
+		var pods []*v1.Pod
+		for i := 0; i < num; i++ {
+			pod := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              fmt.Sprintf("pod-%d", i),
+					Namespace:         "default",
+					UID:               types.UID(fmt.Sprintf("pod-%d", i)),
+					Labels:            map[string]string{apps.ControllerRevisionHashLabelKey: "rev"},
+					CreationTimestamp: metav1.Time{Time: now.Add(-time.Hour)},
+				},
+				Spec: v1.PodSpec{NodeName: fmt.Sprintf("node-%d", i)},
+				Status: v1.PodStatus{
+					Phase:      v1.PodRunning,
+					Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}},
+				},
+			}
+			if i < preDeleting {
+				pod.Labels[appspub.LifecycleStateKey] = string(appspub.LifecycleStatePreparingDelete)
+			}
+			pods = append(pods, pod)
+		}
+		return pods
+	}
+
+	cases := []struct {
+		name             string
+		replicas         int32
+		policy           *appsv1alpha1.CloneSetScaleDownPolicy
+		oldThrottle      *appsv1alpha1.CloneSetScaleDownThrottle
+		pods             []*v1.Pod
+		expectedPods     int
+		expectedHeld     int
+		expectedDeleted  int32
+		expectedReason   string
+		expectedModified bool
+	}{
+		{
+			name:     "rate limited",
+			replicas: 2,
+			policy: &appsv1alpha1.CloneSetScaleDownPolicy{
+				MaxPodsPerInterval: &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+			},
+			pods:             newPods(5, 0),
+			expectedPods:     4,
+			expectedHeld:     2,
+			expectedDeleted:  1,
+			expectedReason:   appsv1alpha1.CloneSetScaleDownThrottleRateLimited,
+			expectedModified: true,
+		},
+		{
+			name:     "stabilizing after scale-up",
+			replicas: 2,
+			policy: &appsv1alpha1.CloneSetScaleDownPolicy{
+				StabilizationWindowSeconds: 300,
+			},
+			oldThrottle: &appsv1alpha1.CloneSetScaleDownThrottle{
+				LastScaleUpTime: &metav1.Time{Time: now.Add(-time.Minute)},
+			},
+			pods:             newPods(4, 0),
+			expectedPods:     4,
+			expectedHeld:     2,
+			expectedReason:   appsv1alpha1.CloneSetScaleDownThrottleStabilizing,
+			expectedModified: true,
+		},
+		{
+			name:     "delete held pods in a new interval",
+			replicas: 2,
+			policy: &appsv1alpha1.CloneSetScaleDownPolicy{
+				MaxPodsPerInterval: &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+				IntervalSeconds:    utilpointer.Int32(60),
+			},
+			oldThrottle: &appsv1alpha1.CloneSetScaleDownThrottle{
+				IntervalStartTime: &metav1.Time{Time: now.Add(-2 * time.Minute)},
+				DeletedReplicas:   1,
+				HeldReplicas:      2,
+			},
+			pods:             newPods(4, 2),
+			expectedPods:     3,
+			expectedHeld:     1,
+			expectedDeleted:  1,
+			expectedReason:   appsv1alpha1.CloneSetScaleDownThrottleRateLimited,
+			expectedModified: true,
+		},
+		{
+			name:     "held pods wait for the current interval",
+			replicas: 2,
+			policy: &appsv1alpha1.CloneSetScaleDownPolicy{
+				MaxPodsPerInterval: &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+				IntervalSeconds:    utilpointer.Int32(60),
+			},
+			oldThrottle: &appsv1alpha1.CloneSetScaleDownThrottle{
+				IntervalStartTime: &metav1.Time{Time: now.Add(-10 * time.Second)},
+				DeletedReplicas:   1,
+				HeldReplicas:      1,
+			},
+			pods:            newPods(3, 1),
+			expectedPods:    3,
+			expectedHeld:    1,
+			expectedDeleted: 1,
+			expectedReason:  appsv1alpha1.CloneSetScaleDownThrottleRateLimited,
+		},
+		{
+			name:     "not throttled",
+			replicas: 2,
+			policy: &appsv1alpha1.CloneSetScaleDownPolicy{
+				MaxPodsPerInterval: &intstr.IntOrString{Type: intstr.String, StrVal: "50%"},
+			},
+			pods:             newPods(4, 0),
+			expectedPods:     2,
+			expectedDeleted:  2,
+			expectedModified: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas:      utilpointer.Int32(tc.replicas),
+					ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{ScaleDownPolicy: tc.policy},
+					UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+						MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+					},
+				},
+				Status: appsv1alpha1.CloneSetStatus{ScaleDownThrottle: tc.oldThrottle},
+			}
+			fClient := fake.NewClientBuilder().WithScheme(kscheme).Build()
+			for _, pod := range tc.pods {
+				if err := fClient.Create(context.TODO(), pod.DeepCopy()); err != nil {
+					t.Fatalf("failed to create pod: %v", err)
+				}
+			}
+			rControl := &realControl{Client: fClient, lifecycleControl: lifecycle.New(fClient), recorder: record.NewFakeRecorder(10)}
+
+			newStatus := &appsv1alpha1.CloneSetStatus{}
+			modified, err := rControl.Scale(cs, cs, "rev", "rev", tc.pods, nil, newStatus)
+			if err != nil {
+				t.Fatalf("failed to scale: %v", err)
+			}
+			if modified != tc.expectedModified {
+				t.Fatalf("expected modified %v, got %v", tc.expectedModified, modified)
+			}
+
+			podList := &v1.PodList{}
+			if err := fClient.List(context.TODO(), podList); err != nil {
+				t.Fatalf("failed to list pods: %v", err)
+			}
+			var held int
+			for i := range podList.Items {
+				if lifecycle.GetPodLifecycleState(&podList.Items[i]) == appspub.LifecycleStatePreparingDelete {
+					held++
+				}
+			}
+			if len(podList.Items) != tc.expectedPods || held != tc.expectedHeld {
+				t.Fatalf("expected %d pods with %d held, got %d pods with %d held", tc.expectedPods, tc.expectedHeld, len(podList.Items), held)
+			}
+
+			throttle := newStatus.ScaleDownThrottle
+			if throttle == nil || int(throttle.HeldReplicas) != tc.expectedHeld || throttle.DeletedReplicas != tc.expectedDeleted || throttle.Reason != tc.expectedReason {
+				t.Fatalf("unexpected throttle status %+v", throttle)
+			}
+		})
+	}
+}
+
+func TestScaleDownThrottleDeleteFailed(t *testing.T) {
+	cs := &appsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: appsv1alpha1.CloneSetSpec{
+			Replicas: utilpointer.Int32(1),
+			ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{ScaleDownPolicy: &appsv1alpha1.CloneSetScaleDownPolicy{
+				MaxPodsPerInterval: &intstr.IntOrString{Type: intstr.Int, IntVal: 1},
+			}},
+			UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+				MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+			},
+		},
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-0",
+			Namespace: "default",
+			Labels: map[string]string{
+				apps.ControllerRevisionHashLabelKey: "rev",
+				appspub.LifecycleStateKey:           string(appspub.LifecycleStatePreparingDelete),
+			},
+		},
+	}
+	normalPod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pod-1",
+			Namespace: "default",
+			Labels:    map[string]string{apps.ControllerRevisionHashLabelKey: "rev"},
+		},
+	}
+	fClient := fake.NewClientBuilder().WithScheme(kscheme).WithObjects(pod.DeepCopy(), normalPod.DeepCopy()).WithInterceptorFuncs(interceptor.Funcs{
+		Delete: func(_ context.Context, _ client.WithWatch, _ client.Object, _ ...client.DeleteOption) error {
+			return fmt.Errorf("injected error")
+		},
+	}).Build()
+	rControl := &realControl{Client: fClient, lifecycleControl: lifecycle.New(fClient), recorder: record.NewFakeRecorder(10)}
+
+	newStatus := &appsv1alpha1.CloneSetStatus{}
+	if _, err := rControl.Scale(cs, cs, "rev", "rev", []*v1.Pod{pod, normalPod}, nil, newStatus); err == nil {
+		t.Fatalf("expected error of deleting pod")
+	}
+	// the failed deletion should not be counted into the interval
+	if throttle := newStatus.ScaleDownThrottle; throttle == nil || throttle.DeletedReplicas != 0 || throttle.IntervalStartTime != nil {
+		t.Fatalf("unexpected throttle status %+v", throttle)
+	}
+}
+
+func TestScaleUpObservedByThrottle(t *testing.T) {
+	cases := []struct {
+		name             string
+		replicas         int32
+		observedReplicas *int32
+		expectedScaleUp  bool
+	}{
+		{
+			name:     "first observed",
+			replicas: 1,
+		},
+		{
+			name:             "replicas increased",
+			replicas:         2,
+			observedReplicas: utilpointer.Int32(1),
+			expectedScaleUp:  true,
+		},
+		{
+			name:             "replacement pods created without replicas changed",
+			replicas:         2,
+			observedReplicas: utilpointer.Int32(2),
+		},
+		{
+			name:             "replicas decreased",
+			replicas:         1,
+			observedReplicas: utilpointer.Int32(2),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cs := &appsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+				Spec: appsv1alpha1.CloneSetSpec{
+					Replicas: utilpointer.Int32(tc.replicas),
+					ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{
+						ScaleDownPolicy: &appsv1alpha1.CloneSetScaleDownPolicy{StabilizationWindowSeconds: 60},
+					},
+					UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+						MaxUnavailable: &intstr.IntOrString{Type: intstr.String, StrVal: "100%"},
+					},
+				},
+				Status: appsv1alpha1.CloneSetStatus{
+					ScaleDownThrottle: &appsv1alpha1.CloneSetScaleDownThrottle{ObservedReplicas: tc.observedReplicas},
+				},
+			}
+			newStatus := &appsv1alpha1.CloneSetStatus{}
+			if _, err := newFakeControl().Scale(cs, cs, "rev", "rev", nil, nil, newStatus); err != nil {
+				t.Fatalf("failed to scale: %v", err)
+			}
+			throttle := newStatus.ScaleDownThrottle
+			if throttle == nil || throttle.ObservedReplicas == nil || *throttle.ObservedReplicas != tc.replicas {
+				t.Fatalf("expected observed replicas %d, got %+v", tc.replicas, throttle)
+			}
+			if (throttle.LastScaleUpTime != nil) != tc.expectedScaleUp {
+				t.Fatalf("expected scale-up recorded %v, got %+v", tc.expectedScaleUp, throttle.LastScaleUpTime)
+			}
+		})
+	}
+}
@@ -77,6 +77,16 @@ func (c *realControl) Update(cs *appsv1alpha1.CloneSet,
 		return nil
 	}
 
+	// resize pvcs of the existing pods online, which does not need to update pods
+	if err := c.resizePVCs(cs, coreControl, pods, pvcs); err != nil {
+		return err
+	}
+
+	// pods are replaced in scaling for BlueGreen update
+	if cs.Spec.UpdateStrategy.Type == appsv1alpha1.BlueGreenCloneSetUpdateStrategyType {
+		return nil
+	}
+
 	// 2. calculate update diff and the revision to update
 	diffRes := calculateDiffsWithExpectation(cs, pods, currentRevision.Name, updateRevision.Name, nil)
 	if diffRes.updateNum == 0 {
@@ -166,6 +176,22 @@ func (c *realControl) refreshPodState(cs *appsv1alpha1.CloneSet, coreControl clo
 		return false, 0, res.RefreshErr
 	}
 
+	// the node can not fit the new resources, so back off to recreate the Pod
+	if res.ResizeInfeasible && cs.Spec.UpdateStrategy.Type != appsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType {
+		klog.InfoS("CloneSet could not resize Pod in-place, so it will back off to ReCreate", "cloneSet", klog.KObj(cs), "pod", klog.KObj(pod))
+		patched, err := specifieddelete.PatchPodSpecifiedDelete(c.Client, pod, "true")
+		if err != nil {
+			c.recorder.Eventf(cs, v1.EventTypeWarning, "FailedUpdatePodReCreate",
+				"failed to patch pod specified-delete %s for infeasible resize: %v", pod.Name, err)
+			return false, 0, err
+		} else if patched {
+			clonesetutils.ResourceVersionExpectations.Expect(pod)
+			c.recorder.Eventf(cs, v1.EventTypeNormal, "SuccessfulUpdatePodReCreate",
+				"successfully patch pod %s specified-delete for infeasible resize", pod.Name)
+		}
+		return patched, 0, nil
+	}
+
 	var state appspub.LifecycleStateType
 	switch lifecycle.GetPodLifecycleState(pod) {
 	case appspub.LifecycleStatePreparingNormal:
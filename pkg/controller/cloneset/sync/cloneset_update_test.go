@@ -49,6 +49,8 @@ import (
 	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
 	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
 )
 
 type manageCase struct {
@@ -959,6 +961,7 @@ func TestUpdate(t *testing.T) {
 				inplaceupdate.New(fakeClient, clonesetutils.RevisionAdapterImpl),
 				record.NewFakeRecorder(10),
 				&controllerfinder.ControllerFinder{Client: fakeClient},
+				podreadiness.NewForAdapter(&podadapter.AdapterRuntimeClient{Client: fakeClient}),
 			}
 			currentRevision := mc.updateRevision
 			if len(mc.revisions) > 0 {
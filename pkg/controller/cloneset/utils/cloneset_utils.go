@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 	kubecontroller "k8s.io/kubernetes/pkg/controller"
 	"k8s.io/utils/integer"
@@ -215,6 +216,22 @@ func getPersistentVolumeClaimName(cs *appsv1alpha1.CloneSet, claim *v1.Persisten
 	return fmt.Sprintf("%s-%s-%s", claim.Name, cs.Name, id)
 }
 
+// GetMaxUnavailableUpdated returns the maximum number of unavailable updated pods in rollbackPolicy for the replicas.
+// The value should be positive regardless of the replicas, which is checked against 100 to reject a zero value of
+// both int and percent, and the percent rounded up to less than one pod is taken as one pod.
+func GetMaxUnavailableUpdated(maxUnavailableUpdated *intstrutil.IntOrString, replicas int) (int, error) {
+	if value, err := intstrutil.GetScaledValueFromIntOrPercent(maxUnavailableUpdated, 100, true); err != nil {
+		return 0, err
+	} else if value < 1 {
+		return 0, fmt.Errorf("must be greater than 0")
+	}
+	value, err := intstrutil.GetScaledValueFromIntOrPercent(maxUnavailableUpdated, replicas, true)
+	if err != nil {
+		return 0, err
+	}
+	return integer.IntMax(value, 1), nil
+}
+
 // DoItSlowly tries to call the provided function a total of 'count' times,
 // starting slow to check for errors, then speeding up if calls succeed.
 //
@@ -251,3 +268,54 @@ func DoItSlowly(count int, initialBatchSize int, fn func() error) (int, error) {
 	}
 	return successes, nil
 }
+
+// NewCloneSetCondition creates a new CloneSet condition.
+func NewCloneSetCondition(condType appsv1alpha1.CloneSetConditionType, status v1.ConditionStatus, reason, message string) *appsv1alpha1.CloneSetCondition {
+	now := metav1.Now()
+	return &appsv1alpha1.CloneSetCondition{
+		Type:               condType,
+		Status:             status,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// GetCloneSetCondition returns the condition with the provided type.
+func GetCloneSetCondition(status appsv1alpha1.CloneSetStatus, condType appsv1alpha1.CloneSetConditionType) *appsv1alpha1.CloneSetCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetCloneSetCondition updates the CloneSet status to include the provided condition. If the condition that
+// we are about to add already exists and has the same status, its LastTransitionTime will be kept.
+func SetCloneSetCondition(status *appsv1alpha1.CloneSetStatus, condition *appsv1alpha1.CloneSetCondition) {
+	currentCond := GetCloneSetCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	newConditions := filterOutCondition(status.Conditions, condition.Type)
+	status.Conditions = append(newConditions, *condition)
+}
+
+// RemoveCloneSetCondition removes the CloneSet condition with the provided type.
+func RemoveCloneSetCondition(status *appsv1alpha1.CloneSetStatus, condType appsv1alpha1.CloneSetConditionType) {
+	status.Conditions = filterOutCondition(status.Conditions, condType)
+}
+
+func filterOutCondition(conditions []appsv1alpha1.CloneSetCondition, condType appsv1alpha1.CloneSetConditionType) []appsv1alpha1.CloneSetCondition {
+	var newConditions []appsv1alpha1.CloneSetCondition
+	for _, c := range conditions {
+		if c.Type == condType {
+			continue
+		}
+		newConditions = append(newConditions, c)
+	}
+	return newConditions
+}
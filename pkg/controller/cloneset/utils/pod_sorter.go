@@ -30,6 +30,8 @@ import (
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 	"k8s.io/utils/integer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
 )
 
 type Ranker interface {
@@ -54,64 +56,116 @@ func (s ActivePodsWithRanks) Len() int      { return len(s.Pods) }
 func (s ActivePodsWithRanks) Swap(i, j int) { s.Pods[i], s.Pods[j] = s.Pods[j], s.Pods[i] }
 
 func (s ActivePodsWithRanks) Less(i, j int) bool {
+	less, _ := s.compare(s.Pods[i], s.Pods[j])
+	return less
+}
+
+// rankCriterion is the criterion that decides the order of two pods.
+type rankCriterion int
+
+const (
+	criterionNone rankCriterion = iota
+	criterionUnassigned
+	criterionPhase
+	criterionUnavailable
+	criterionNotReady
+	criterionDeletionCost
+	criterionRank
+	criterionReadyTime
+	criterionRestarts
+	criterionCreationTime
+)
+
+// compare returns whether pod a is less than pod b, and the criterion that decides it.
+func (s ActivePodsWithRanks) compare(a, b *v1.Pod) (bool, rankCriterion) {
 	// 1. Unassigned < assigned
 	// If only one of the pods is unassigned, the unassigned one is smaller
-	if s.Pods[i].Spec.NodeName != s.Pods[j].Spec.NodeName && (len(s.Pods[i].Spec.NodeName) == 0 || len(s.Pods[j].Spec.NodeName) == 0) {
-		return len(s.Pods[i].Spec.NodeName) == 0
+	if a.Spec.NodeName != b.Spec.NodeName && (len(a.Spec.NodeName) == 0 || len(b.Spec.NodeName) == 0) {
+		return len(a.Spec.NodeName) == 0, criterionUnassigned
 	}
 	// 2. PodPending < PodUnknown < PodRunning
 	podPhaseToOrdinal := map[v1.PodPhase]int{v1.PodPending: 0, v1.PodUnknown: 1, v1.PodRunning: 2}
-	if podPhaseToOrdinal[s.Pods[i].Status.Phase] != podPhaseToOrdinal[s.Pods[j].Status.Phase] {
-		return podPhaseToOrdinal[s.Pods[i].Status.Phase] < podPhaseToOrdinal[s.Pods[j].Status.Phase]
+	if podPhaseToOrdinal[a.Status.Phase] != podPhaseToOrdinal[b.Status.Phase] {
+		return podPhaseToOrdinal[a.Status.Phase] < podPhaseToOrdinal[b.Status.Phase], criterionPhase
 	}
 	// 3. Not available < available; Not ready < ready
 	// If only one of the pods is not ready, the not ready one is smaller
 	if s.AvailableFunc != nil {
-		if s.AvailableFunc(s.Pods[i]) != s.AvailableFunc(s.Pods[j]) {
-			return !s.AvailableFunc(s.Pods[i])
+		if s.AvailableFunc(a) != s.AvailableFunc(b) {
+			return !s.AvailableFunc(a), criterionUnavailable
 		}
 	}
-	if podutil.IsPodReady(s.Pods[i]) != podutil.IsPodReady(s.Pods[j]) {
-		return !podutil.IsPodReady(s.Pods[i])
+	if podutil.IsPodReady(a) != podutil.IsPodReady(b) {
+		return !podutil.IsPodReady(a), criterionNotReady
 	}
 
 	// 4. Lower pod-deletion cost < higher pod-deletion-cost
-	pi, _ := getDeletionCostFromPodAnnotations(s.Pods[i].Annotations)
-	pj, _ := getDeletionCostFromPodAnnotations(s.Pods[j].Annotations)
+	pi, _ := getDeletionCostFromPodAnnotations(a.Annotations)
+	pj, _ := getDeletionCostFromPodAnnotations(b.Annotations)
 	if pi != pj {
-		return pi < pj
+		return pi < pj, criterionDeletionCost
 	}
 
 	// 5. Higher ranks < lower ranks
 	var rankI, rankJ float64
 	if s.Ranker != nil {
-		rankI = s.Ranker.GetRank(s.Pods[i])
-		rankJ = s.Ranker.GetRank(s.Pods[j])
+		rankI = s.Ranker.GetRank(a)
+		rankJ = s.Ranker.GetRank(b)
 	}
 	if rankI != rankJ {
-		return rankI > rankJ
+		return rankI > rankJ, criterionRank
 	}
 
 	// TODO: take availability into account when we push minReadySeconds information from deployment into pods,
 	//       see https://github.com/kubernetes/kubernetes/issues/22065
 	// 6. Been ready for empty time < less time < more time
 	// If both pods are ready, the latest ready one is smaller
-	if podutil.IsPodReady(s.Pods[i]) && podutil.IsPodReady(s.Pods[j]) {
-		readyTime1 := podReadyTime(s.Pods[i])
-		readyTime2 := podReadyTime(s.Pods[j])
+	if podutil.IsPodReady(a) && podutil.IsPodReady(b) {
+		readyTime1 := podReadyTime(a)
+		readyTime2 := podReadyTime(b)
 		if !readyTime1.Equal(readyTime2) {
-			return afterOrZero(readyTime1, readyTime2)
+			return afterOrZero(readyTime1, readyTime2), criterionReadyTime
 		}
 	}
 	// 7. Pods with containers with higher restart counts < lower restart counts
-	if maxContainerRestarts(s.Pods[i]) != maxContainerRestarts(s.Pods[j]) {
-		return maxContainerRestarts(s.Pods[i]) > maxContainerRestarts(s.Pods[j])
+	if maxContainerRestarts(a) != maxContainerRestarts(b) {
+		return maxContainerRestarts(a) > maxContainerRestarts(b), criterionRestarts
 	}
 	// 8. Empty creation time pods < newer pods < older pods
-	if !s.Pods[i].CreationTimestamp.Equal(&s.Pods[j].CreationTimestamp) {
-		return afterOrZero(&s.Pods[i].CreationTimestamp, &s.Pods[j].CreationTimestamp)
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return afterOrZero(&a.CreationTimestamp, &b.CreationTimestamp), criterionCreationTime
+	}
+	return false, criterionNone
+}
+
+// ExplainLess explains why the pod is ranked before the kept one, which means it is preferred to be deleted.
+func (s ActivePodsWithRanks) ExplainLess(pod, kept *v1.Pod) (appsv1alpha1.CloneSetScaleDownReason, string) {
+	_, criterion := s.compare(pod, kept)
+	switch criterion {
+	case criterionUnassigned:
+		return appsv1alpha1.CloneSetScaleDownReasonNotReady, "pod has not been scheduled"
+	case criterionPhase:
+		return appsv1alpha1.CloneSetScaleDownReasonNotReady, fmt.Sprintf("pod is in %s phase", pod.Status.Phase)
+	case criterionUnavailable:
+		return appsv1alpha1.CloneSetScaleDownReasonNotReady, "pod is not available"
+	case criterionNotReady:
+		return appsv1alpha1.CloneSetScaleDownReasonNotReady, "pod is not ready"
+	case criterionDeletionCost:
+		cost, _ := getDeletionCostFromPodAnnotations(pod.Annotations)
+		keptCost, _ := getDeletionCostFromPodAnnotations(kept.Annotations)
+		return appsv1alpha1.CloneSetScaleDownReasonDeletionCost,
+			fmt.Sprintf("pod has deletion cost %d lower than %d of kept pod %s", cost, keptCost, kept.Name)
+	case criterionRank:
+		return appsv1alpha1.CloneSetScaleDownReasonTopologyImbalance,
+			fmt.Sprintf("pod is in a more crowded node or topology than kept pod %s", kept.Name)
+	case criterionReadyTime:
+		return appsv1alpha1.CloneSetScaleDownReasonOther, fmt.Sprintf("pod became ready later than kept pod %s", kept.Name)
+	case criterionRestarts:
+		return appsv1alpha1.CloneSetScaleDownReasonOther, fmt.Sprintf("pod has more restarts than kept pod %s", kept.Name)
+	case criterionCreationTime:
+		return appsv1alpha1.CloneSetScaleDownReasonOther, fmt.Sprintf("pod was created later than kept pod %s", kept.Name)
 	}
-	return false
+	return appsv1alpha1.CloneSetScaleDownReasonOther, fmt.Sprintf("pod is ranked equally with kept pod %s", kept.Name)
 }
 
 // afterOrZero checks if time t1 is after time t2; if one of them
@@ -28,6 +28,7 @@ import (
 
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -110,6 +111,10 @@ const (
 
 	// BackoffGCInterval is the time that has to pass before next iteration of backoff GC is run
 	BackoffGCInterval = 1 * time.Minute
+
+	// maxStatusNodeNames is the max number of node names listed in a field of status, so that the size of status
+	// is bounded in large clusters.
+	maxStatusNodeNames = 10
 )
 
 // Reasons for DaemonSet events
@@ -400,9 +405,10 @@ func (dsc *ReconcileDaemonSet) syncDaemonSet(ctx context.Context, request reconc
 		return fmt.Errorf("failed to construct revisions of DaemonSet: %v", err)
 	}
 	hash := cur.Labels[apps.DefaultDaemonSetUniqueLabelKey]
+	pins := getRevisionPins(ds, nodeList, cur, old)
 
 	if !dsc.expectations.SatisfiedExpectations(logger, dsKey) || !dsc.hasPodExpectationsSatisfied(ctx, ds) {
-		return dsc.updateDaemonSetStatus(ctx, ds, nodeList, hash, false)
+		return dsc.updateDaemonSetStatus(ctx, ds, nodeList, hash, pins, false)
 	}
 
 	if !isPreDownloadDisabled && dsc.Client != nil {
@@ -431,17 +437,17 @@ func (dsc *ReconcileDaemonSet) syncDaemonSet(ctx context.Context, request reconc
 		}
 	}
 
-	err = dsc.manage(ctx, ds, nodeList, hash)
+	err = dsc.manage(ctx, ds, nodeList, hash, pins)
 	if err != nil {
 		return err
 	}
 
 	// return and wait next reconcile if expectation changed to unsatisfied
 	if !dsc.expectations.SatisfiedExpectations(logger, dsKey) || !dsc.hasPodExpectationsSatisfied(ctx, ds) {
-		return dsc.updateDaemonSetStatus(ctx, ds, nodeList, hash, false)
+		return dsc.updateDaemonSetStatus(ctx, ds, nodeList, hash, pins, false)
 	}
 
-	if err := dsc.refreshUpdateStates(ctx, ds); err != nil {
+	if err := dsc.refreshUpdateStates(ctx, ds, hash); err != nil {
 		return err
 	}
 
@@ -451,19 +457,39 @@ func (dsc *ReconcileDaemonSet) syncDaemonSet(ctx context.Context, request reconc
 		switch ds.Spec.UpdateStrategy.Type {
 		case appsv1alpha1.OnDeleteDaemonSetStrategyType:
 		case appsv1alpha1.RollingUpdateDaemonSetStrategyType:
-			err = dsc.rollingUpdate(ctx, ds, nodeList, cur, old)
+			err = dsc.rollingUpdate(ctx, ds, nodeList, cur, old, pins)
 			if err != nil {
 				return err
 			}
 		}
 	}
 
-	err = dsc.cleanupHistory(ctx, ds, old)
+	err = dsc.cleanupHistory(ctx, ds, old, pins)
 	if err != nil {
 		return fmt.Errorf("failed to clean up revisions of DaemonSet: %v", err)
 	}
 
-	return dsc.updateDaemonSetStatus(ctx, ds, nodeList, hash, true)
+	return dsc.updateDaemonSetStatus(ctx, ds, nodeList, hash, pins, true)
+}
+
+// newPodTemplate returns the template to create the daemon pods of the given revision.
+func newPodTemplate(ds *appsv1alpha1.DaemonSet, template corev1.PodTemplateSpec, generation *int64, hash string) *corev1.PodTemplateSpec {
+	newTemplate := util.CreatePodTemplate(template, generation, hash)
+	if ds.Spec.Lifecycle != nil && ds.Spec.Lifecycle.PreNormal != nil {
+		// new pods wait in PreparingNormal until the pre-normal hook is satisfied
+		newTemplate.Labels[appspub.LifecycleStateKey] = string(appspub.LifecycleStatePreparingNormal)
+	}
+
+	if ds.Spec.UpdateStrategy.Type == appsv1alpha1.RollingUpdateDaemonSetStrategyType &&
+		ds.Spec.UpdateStrategy.RollingUpdate != nil &&
+		(ds.Spec.UpdateStrategy.RollingUpdate.Type == appsv1alpha1.InplaceRollingUpdateType ||
+			ds.Spec.UpdateStrategy.RollingUpdate.SurgeFallbackStrategy == appsv1alpha1.InPlaceIfPossibleSurgeFallbackStrategyType) {
+		readinessGate := corev1.PodReadinessGate{
+			ConditionType: appspub.InPlaceUpdateReady,
+		}
+		newTemplate.Spec.ReadinessGates = append(newTemplate.Spec.ReadinessGates, readinessGate)
+	}
+	return &newTemplate
 }
 
 // Predicates checks if a DaemonSet's pod can run on a node.
@@ -514,7 +540,7 @@ func NewPod(ds *appsv1alpha1.DaemonSet, nodeName string) *corev1.Pod {
 	return newPod
 }
 
-func (dsc *ReconcileDaemonSet) updateDaemonSetStatus(ctx context.Context, ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, hash string, updateObservedGen bool) error {
+func (dsc *ReconcileDaemonSet) updateDaemonSetStatus(ctx context.Context, ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, hash string, pins *revisionPins, updateObservedGen bool) error {
 	nodeToDaemonPods, err := dsc.getNodesToDaemonPods(ctx, ds)
 	if err != nil {
 		return fmt.Errorf("couldn't get node to daemon pod mapping for DaemonSet %q: %v", ds.Name, err)
@@ -546,7 +572,7 @@ func (dsc *ReconcileDaemonSet) updateDaemonSetStatus(ctx context.Context, ds *ap
 				if err != nil {
 					generation = nil
 				}
-				if util.IsPodUpdated(pod, hash, generation) {
+				if util.IsPodUpdated(pod, pins.nodeHash(node.Name, hash), generation) {
 					updatedNumberScheduled++
 				}
 			}
@@ -558,7 +584,17 @@ func (dsc *ReconcileDaemonSet) updateDaemonSetStatus(ctx context.Context, ds *ap
 	}
 	numberUnavailable := desiredNumberScheduled - numberAvailable
 
-	err = dsc.storeDaemonSetStatus(ctx, ds, desiredNumberScheduled, currentNumberScheduled, numberMisscheduled, numberReady, updatedNumberScheduled, numberAvailable, numberUnavailable, updateObservedGen, hash)
+	var currentWave *appsv1alpha1.DaemonSetUpdateWaveStatus
+	if wave, err := dsc.getCurrentUpdateWave(ds, nodeList, nodeToDaemonPods, hash, pins); err != nil {
+		return fmt.Errorf("couldn't get current update wave for DaemonSet %q: %v", ds.Name, err)
+	} else if wave != nil {
+		currentWave = wave.status
+	}
+
+	numberDeferred, deferredNodes := getDeferredNodes(ds, nodeList, nodeToDaemonPods, hash, pins)
+	conditions := getSurgeDisabledConditions(ds, nodeList, nodeToDaemonPods, hash, pins, metav1.Time{Time: now})
+
+	err = dsc.storeDaemonSetStatus(ctx, ds, desiredNumberScheduled, currentNumberScheduled, numberMisscheduled, numberReady, updatedNumberScheduled, numberAvailable, numberUnavailable, updateObservedGen, hash, currentWave, numberDeferred, deferredNodes, pins.status(), conditions)
 	if err != nil {
 		return fmt.Errorf("error storing status for DaemonSet %v: %v", ds.Name, err)
 	}
@@ -581,7 +617,12 @@ func (dsc *ReconcileDaemonSet) storeDaemonSetStatus(
 	numberAvailable,
 	numberUnavailable int,
 	updateObservedGen bool,
-	hash string) error {
+	hash string,
+	currentWave *appsv1alpha1.DaemonSetUpdateWaveStatus,
+	numberDeferred int,
+	deferredNodes []string,
+	pinnedRevisions []appsv1alpha1.DaemonSetPinnedRevision,
+	conditions []apps.DaemonSetCondition) error {
 	if int(ds.Status.DesiredNumberScheduled) == desiredNumberScheduled &&
 		int(ds.Status.CurrentNumberScheduled) == currentNumberScheduled &&
 		int(ds.Status.NumberMisscheduled) == numberMisscheduled &&
@@ -590,7 +631,12 @@ func (dsc *ReconcileDaemonSet) storeDaemonSetStatus(
 		int(ds.Status.NumberAvailable) == numberAvailable &&
 		int(ds.Status.NumberUnavailable) == numberUnavailable &&
 		ds.Status.ObservedGeneration >= ds.Generation &&
-		ds.Status.DaemonSetHash == hash {
+		ds.Status.DaemonSetHash == hash &&
+		apiequality.Semantic.DeepEqual(ds.Status.CurrentWave, currentWave) &&
+		int(ds.Status.NumberDeferred) == numberDeferred &&
+		apiequality.Semantic.DeepEqual(ds.Status.DeferredNodes, deferredNodes) &&
+		apiequality.Semantic.DeepEqual(ds.Status.PinnedRevisions, pinnedRevisions) &&
+		apiequality.Semantic.DeepEqual(ds.Status.Conditions, conditions) {
 		return nil
 	}
 
@@ -609,6 +655,11 @@ func (dsc *ReconcileDaemonSet) storeDaemonSetStatus(
 		toUpdate.Status.NumberAvailable = int32(numberAvailable)
 		toUpdate.Status.NumberUnavailable = int32(numberUnavailable)
 		toUpdate.Status.DaemonSetHash = hash
+		toUpdate.Status.CurrentWave = currentWave
+		toUpdate.Status.NumberDeferred = int32(numberDeferred)
+		toUpdate.Status.DeferredNodes = deferredNodes
+		toUpdate.Status.PinnedRevisions = pinnedRevisions
+		toUpdate.Status.Conditions = conditions
 
 		if _, updateErr = dsClient.UpdateStatus(ctx, toUpdate, metav1.UpdateOptions{}); updateErr == nil {
 			klog.InfoS("Updated DaemonSet status", "daemonSet", klog.KObj(ds), "status", kruiseutil.DumpJSON(toUpdate.Status))
@@ -631,7 +682,7 @@ func (dsc *ReconcileDaemonSet) storeDaemonSetStatus(
 // After figuring out which nodes should run a Pod of ds but not yet running one and
 // which nodes should not run a Pod of ds but currently running one, it calls function
 // syncNodes with a list of pods to remove and a list of nodes to run a Pod of ds.
-func (dsc *ReconcileDaemonSet) manage(ctx context.Context, ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, hash string) error {
+func (dsc *ReconcileDaemonSet) manage(ctx context.Context, ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, hash string, pins *revisionPins) error {
 	// Find out the pods which are created for the nodes by DaemonSets.
 	nodeToDaemonPods, err := dsc.getNodesToDaemonPods(ctx, ds)
 	if err != nil {
@@ -643,7 +694,7 @@ func (dsc *ReconcileDaemonSet) manage(ctx context.Context, ds *appsv1alpha1.Daem
 	var nodesNeedingDaemonPods, podsToDelete []string
 	var nodesDesireScheduled, newPodCount int
 	for _, node := range nodeList {
-		nodesNeedingDaemonPodsOnNode, podsToDeleteOnNode := dsc.podsShouldBeOnNode(node, nodeToDaemonPods, ds, hash)
+		nodesNeedingDaemonPodsOnNode, podsToDeleteOnNode := dsc.podsShouldBeOnNode(node, nodeToDaemonPods, ds, pins.nodeHash(node.Name, hash))
 
 		nodesNeedingDaemonPods = append(nodesNeedingDaemonPods, nodesNeedingDaemonPodsOnNode...)
 		podsToDelete = append(podsToDelete, podsToDeleteOnNode...)
@@ -651,7 +702,7 @@ func (dsc *ReconcileDaemonSet) manage(ctx context.Context, ds *appsv1alpha1.Daem
 		if shouldRun, _ := nodeShouldRunDaemonPod(node, ds); shouldRun {
 			nodesDesireScheduled++
 		}
-		if newPod, _, ok := findUpdatedPodsOnNode(ds, nodeToDaemonPods[node.Name], hash); ok && newPod != nil {
+		if newPod, _, ok := findUpdatedPodsOnNode(ds, nodeToDaemonPods[node.Name], pins.nodeHash(node.Name, hash)); ok && newPod != nil {
 			newPodCount++
 		}
 	}
@@ -672,13 +723,13 @@ func (dsc *ReconcileDaemonSet) manage(ctx context.Context, ds *appsv1alpha1.Daem
 		}
 	}
 
-	// Label new pods using the hash label value of the current history when creating them
-	return dsc.syncNodes(ctx, ds, podsToDelete, nodesNeedingDaemonPods, hash)
+	// Label new pods using the hash label value of the current history, or the pinned one, when creating them
+	return dsc.syncNodes(ctx, ds, podsToDelete, nodesNeedingDaemonPods, hash, pins)
 }
 
 // syncNodes deletes given pods and creates new daemon set pods on the given nodes
 // returns slice with errors if any
-func (dsc *ReconcileDaemonSet) syncNodes(ctx context.Context, ds *appsv1alpha1.DaemonSet, podsToDelete, nodesNeedingDaemonPods []string, hash string) error {
+func (dsc *ReconcileDaemonSet) syncNodes(ctx context.Context, ds *appsv1alpha1.DaemonSet, podsToDelete, nodesNeedingDaemonPods []string, hash string, pins *revisionPins) error {
 	if ds.Spec.Lifecycle != nil && ds.Spec.Lifecycle.PreDelete != nil {
 		var err error
 		podsToDelete, err = dsc.syncWithPreparingDelete(ds, podsToDelete)
@@ -700,6 +751,12 @@ func (dsc *ReconcileDaemonSet) syncNodes(ctx context.Context, ds *appsv1alpha1.D
 		deleteDiff = burstReplicas
 	}
 
+	// Advanced: the pods on the pinned nodes are created from the templates of their pinned revisions
+	pinnedTemplates, err := pins.podTemplates(ds, nodesNeedingDaemonPods[:createDiff])
+	if err != nil {
+		return fmt.Errorf("failed to restore pinned revisions of DaemonSet: %v", err)
+	}
+
 	if err := dsc.expectations.SetExpectations(logger, dsKey, createDiff, deleteDiff); err != nil {
 		utilruntime.HandleError(err)
 	}
@@ -714,16 +771,7 @@ func (dsc *ReconcileDaemonSet) syncNodes(ctx context.Context, ds *appsv1alpha1.D
 	if err != nil {
 		generation = nil
 	}
-	template := util.CreatePodTemplate(ds.Spec.Template, generation, hash)
-
-	if ds.Spec.UpdateStrategy.Type == appsv1alpha1.RollingUpdateDaemonSetStrategyType &&
-		ds.Spec.UpdateStrategy.RollingUpdate != nil &&
-		ds.Spec.UpdateStrategy.RollingUpdate.Type == appsv1alpha1.InplaceRollingUpdateType {
-		readinessGate := corev1.PodReadinessGate{
-			ConditionType: appspub.InPlaceUpdateReady,
-		}
-		template.Spec.ReadinessGates = append(template.Spec.ReadinessGates, readinessGate)
-	}
+	template := newPodTemplate(ds, ds.Spec.Template, generation, hash)
 
 	// Batch the pod creates. Batch sizes start at SlowStartInitialBatchSize
 	// and double with each successful iteration in a kind of "slow start".
@@ -743,6 +791,9 @@ func (dsc *ReconcileDaemonSet) syncNodes(ctx context.Context, ds *appsv1alpha1.D
 				var err error
 
 				podTemplate := template.DeepCopy()
+				if pins.isPinned(nodesNeedingDaemonPods[ix]) {
+					podTemplate = pinnedTemplates[pins.nodeHash(nodesNeedingDaemonPods[ix], hash)].DeepCopy()
+				}
 				if scheduleDaemonSetPods {
 					// The pod's NodeAffinity will be updated to make sure the Pod is bound
 					// to the target node by default scheduler. It is safe to do so because there
@@ -1009,7 +1060,7 @@ func (o podByCreationTimestampAndPhase) Less(i, j int) bool {
 	return o[i].CreationTimestamp.Before(&o[j].CreationTimestamp)
 }
 
-func (dsc *ReconcileDaemonSet) cleanupHistory(ctx context.Context, ds *appsv1alpha1.DaemonSet, old []*apps.ControllerRevision) error {
+func (dsc *ReconcileDaemonSet) cleanupHistory(ctx context.Context, ds *appsv1alpha1.DaemonSet, old []*apps.ControllerRevision, pins *revisionPins) error {
 	nodesToDaemonPods, err := dsc.getNodesToDaemonPods(ctx, ds)
 	if err != nil {
 		return fmt.Errorf("couldn't get node to daemon pod mapping for DaemonSet %q: %v", ds.Name, err)
@@ -1024,8 +1075,11 @@ func (dsc *ReconcileDaemonSet) cleanupHistory(ctx context.Context, ds *appsv1alp
 		return nil
 	}
 
-	// Find all hashes of live pods
+	// Find all hashes of live pods, and keep the pinned revisions as well
 	liveHashes := make(map[string]bool)
+	for hash := range pins.hashes() {
+		liveHashes[hash] = true
+	}
 	for _, pods := range nodesToDaemonPods {
 		for _, pod := range pods {
 			if hash := pod.Labels[apps.DefaultDaemonSetUniqueLabelKey]; len(hash) > 0 {
@@ -1053,7 +1107,7 @@ func (dsc *ReconcileDaemonSet) cleanupHistory(ctx context.Context, ds *appsv1alp
 	return nil
 }
 
-func (dsc *ReconcileDaemonSet) refreshUpdateStates(ctx context.Context, ds *appsv1alpha1.DaemonSet) error {
+func (dsc *ReconcileDaemonSet) refreshUpdateStates(ctx context.Context, ds *appsv1alpha1.DaemonSet, hash string) error {
 	dsKey := keyFunc(ds)
 	pods, err := dsc.getDaemonPods(ctx, ds)
 	if err != nil {
@@ -1063,6 +1117,10 @@ func (dsc *ReconcileDaemonSet) refreshUpdateStates(ctx context.Context, ds *apps
 	opts := &inplaceupdate.UpdateOptions{}
 	opts = inplaceupdate.SetOptionsDefaults(opts)
 	for _, pod := range pods {
+		if err := dsc.refreshPodLifecycle(ds, pod, hash, opts); err != nil {
+			klog.ErrorS(err, "DaemonSet failed to update pod lifecycle", "daemonSet", klog.KObj(ds), "pod", klog.KObj(pod))
+			return err
+		}
 		if dsc.inplaceControl == nil {
 			continue
 		}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+func getDrainingNodeStrategy(ds *appsv1alpha1.DaemonSet) *appsv1alpha1.DaemonSetDrainingNodeStrategy {
+	if ds.Spec.UpdateStrategy.RollingUpdate == nil {
+		return nil
+	}
+	return ds.Spec.UpdateStrategy.RollingUpdate.DrainingNodeStrategy
+}
+
+// isNodeDraining returns true if the update of the node should be deferred, which means the node is unschedulable,
+// or has any of the taints or the label of the draining node strategy.
+func isNodeDraining(strategy *appsv1alpha1.DaemonSetDrainingNodeStrategy, node *corev1.Node) bool {
+	if strategy == nil {
+		return false
+	}
+	if node.Spec.Unschedulable {
+		return true
+	}
+	if strategy.LabelKey != "" {
+		if _, ok := node.Labels[strategy.LabelKey]; ok {
+			return true
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == corev1.TaintNodeUnschedulable {
+			return true
+		}
+		for _, key := range strategy.TaintKeys {
+			if taint.Key == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// getDrainingNodes returns the names of the nodes whose update should be deferred.
+func getDrainingNodes(ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node) sets.String {
+	drainingNodes := sets.NewString()
+	strategy := getDrainingNodeStrategy(ds)
+	if strategy == nil {
+		return drainingNodes
+	}
+	for _, node := range nodeList {
+		if isNodeDraining(strategy, node) {
+			drainingNodes.Insert(node.Name)
+		}
+	}
+	return drainingNodes
+}
+
+// getDeferredNodes returns the number of the draining nodes which should run the daemon pod but still have an old one,
+// and the first sorted names of them up to maxStatusNodeNames.
+func getDeferredNodes(ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, nodeToDaemonPods map[string][]*corev1.Pod, hash string, pins *revisionPins) (int, []string) {
+	strategy := getDrainingNodeStrategy(ds)
+	if strategy == nil {
+		return 0, nil
+	}
+	var deferredNodes []string
+	for _, node := range nodeList {
+		if !isNodeDraining(strategy, node) {
+			continue
+		}
+		if shouldRun, _ := nodeShouldRunDaemonPod(node, ds); !shouldRun {
+			continue
+		}
+		if _, oldPod, ok := findUpdatedPodsOnNode(ds, nodeToDaemonPods[node.Name], pins.nodeHash(node.Name, hash)); ok && oldPod != nil {
+			deferredNodes = append(deferredNodes, node.Name)
+		}
+	}
+	sort.Strings(deferredNodes)
+	numberDeferred := len(deferredNodes)
+	if numberDeferred > maxStatusNodeNames {
+		deferredNodes = deferredNodes[:maxStatusNodeNames]
+	}
+	return numberDeferred, deferredNodes
+}
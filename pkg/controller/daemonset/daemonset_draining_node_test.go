@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+func TestIsNodeDraining(t *testing.T) {
+	strategy := &appsv1alpha1.DaemonSetDrainingNodeStrategy{
+		TaintKeys: []string{"ToBeDeletedByClusterAutoscaler"},
+		LabelKey:  "example.com/draining",
+	}
+	cases := []struct {
+		name     string
+		strategy *appsv1alpha1.DaemonSetDrainingNodeStrategy
+		node     *corev1.Node
+		expected bool
+	}{
+		{
+			name:     "no strategy",
+			node:     &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			expected: false,
+		},
+		{
+			name:     "schedulable node",
+			strategy: strategy,
+			node:     &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "foo", Effect: corev1.TaintEffectNoSchedule}}}},
+			expected: false,
+		},
+		{
+			name:     "cordoned node",
+			strategy: strategy,
+			node:     &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			expected: true,
+		},
+		{
+			name:     "node with draining taint",
+			strategy: strategy,
+			node:     &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "ToBeDeletedByClusterAutoscaler", Effect: corev1.TaintEffectNoSchedule}}}},
+			expected: true,
+		},
+		{
+			name:     "node with draining label",
+			strategy: strategy,
+			node:     &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"example.com/draining": ""}}},
+			expected: true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isNodeDraining(tc.strategy, tc.node); got != tc.expected {
+				t.Fatalf("expected draining %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDaemonSetUpdatesDeferDrainingNodes(t *testing.T) {
+	ds := newDaemonSet("foo")
+	manager, podControl, _, err := newTestController(ds)
+	if err != nil {
+		t.Fatalf("error creating DaemonSets controller: %v", err)
+	}
+	addNodes(manager.nodeStore, 0, 5, nil)
+	manager.dsStore.Add(ds)
+	expectSyncDaemonSets(t, manager, ds, podControl, 5, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	// node-0 is cordoned and node-1 is labeled draining
+	for _, obj := range manager.nodeStore.List() {
+		node := obj.(*corev1.Node)
+		switch node.Name {
+		case "node-0":
+			node.Spec.Unschedulable = true
+		case "node-1":
+			node.Labels = map[string]string{"example.com/draining": "true"}
+		}
+	}
+
+	maxUnavailable := intstr.FromInt(5)
+	ds.Spec.Template.Spec.Containers[0].Image = "foo2/bar2"
+	ds.Spec.UpdateStrategy.Type = appsv1alpha1.RollingUpdateDaemonSetStrategyType
+	ds.Spec.UpdateStrategy.RollingUpdate = &appsv1alpha1.RollingUpdateDaemonSet{
+		MaxUnavailable:       &maxUnavailable,
+		DrainingNodeStrategy: &appsv1alpha1.DaemonSetDrainingNodeStrategy{LabelKey: "example.com/draining"},
+	}
+	manager.dsStore.Update(ds)
+
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 3, 0)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 3, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 0, 0)
+	updated, err := manager.kruiseClient.AppsV1alpha1().DaemonSets(ds.Namespace).Get(context.TODO(), ds.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get DaemonSet: %v", err)
+	}
+	if expected := []string{"node-0", "node-1"}; !reflect.DeepEqual(updated.Status.DeferredNodes, expected) {
+		t.Fatalf("expected deferred nodes %v, got %v", expected, updated.Status.DeferredNodes)
+	}
+	if updated.Status.NumberDeferred != 2 {
+		t.Fatalf("expected 2 deferred nodes, got %d", updated.Status.NumberDeferred)
+	}
+
+	// node-0 comes back and gets updated
+	for _, obj := range manager.nodeStore.List() {
+		if node := obj.(*corev1.Node); node.Name == "node-0" {
+			node.Spec.Unschedulable = false
+		}
+	}
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 1, 0)
+}
+
+func TestGetDeferredNodesLimited(t *testing.T) {
+	ds := newDaemonSet("foo")
+	ds.Spec.UpdateStrategy.RollingUpdate = &appsv1alpha1.RollingUpdateDaemonSet{
+		DrainingNodeStrategy: &appsv1alpha1.DaemonSetDrainingNodeStrategy{LabelKey: "example.com/draining"},
+	}
+	var nodeList []*corev1.Node
+	nodeToDaemonPods := map[string][]*corev1.Pod{}
+	for i := 0; i < maxStatusNodeNames+2; i++ {
+		node := newNode(fmt.Sprintf("node-%02d", i), map[string]string{"example.com/draining": "true"})
+		nodeList = append(nodeList, node)
+		nodeToDaemonPods[node.Name] = []*corev1.Pod{newPod("foo-", node.Name, nil, ds)}
+	}
+
+	numberDeferred, deferredNodes := getDeferredNodes(ds, nodeList, nodeToDaemonPods, "new-hash", nil)
+	if numberDeferred != maxStatusNodeNames+2 {
+		t.Fatalf("expected %d deferred nodes, got %d", maxStatusNodeNames+2, numberDeferred)
+	}
+	if len(deferredNodes) != maxStatusNodeNames || deferredNodes[0] != "node-00" || deferredNodes[maxStatusNodeNames-1] != "node-09" {
+		t.Fatalf("expected the first %d deferred nodes, got %v", maxStatusNodeNames, deferredNodes)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/klog/v2"
 	kubecontroller "k8s.io/kubernetes/pkg/controller"
 	labelsutil "k8s.io/kubernetes/pkg/util/labels"
@@ -135,6 +136,23 @@ func Match(ds *appsv1alpha1.DaemonSet, history *apps.ControllerRevision) (bool,
 	return bytes.Equal(patch, history.Data.Raw), nil
 }
 
+// applyDaemonSetHistory returns a DaemonSet with the template restored from the given history.
+func applyDaemonSetHistory(ds *appsv1alpha1.DaemonSet, history *apps.ControllerRevision) (*appsv1alpha1.DaemonSet, error) {
+	dsBytes, err := json.Marshal(ds)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(dsBytes, history.Data.Raw, ds)
+	if err != nil {
+		return nil, err
+	}
+	restored := &appsv1alpha1.DaemonSet{}
+	if err = json.Unmarshal(patched, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
+
 // getPatch returns a strategic merge patch that can be applied to restore a Daemonset to a
 // previous version. If the returned error is nil the patch is valid. The current state that we save is just the
 // PodSpecTemplate. We can modify this later to encompass more state (or less) and remain compatible with previously
@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/controller/daemon/util"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+)
+
+func getInPlaceUpdateHook(ds *appsv1alpha1.DaemonSet) *appspub.LifecycleHook {
+	if ds.Spec.Lifecycle == nil {
+		return nil
+	}
+	return ds.Spec.Lifecycle.InPlaceUpdate
+}
+
+// getNextLifecycleState returns the state that the pod should move to according to the lifecycle hooks of the DaemonSet,
+// or an empty state if the pod should stay in its current state.
+func getNextLifecycleState(ds *appsv1alpha1.DaemonSet, pod *corev1.Pod, hash string, opts *inplaceupdate.UpdateOptions) appspub.LifecycleStateType {
+	inPlaceUpdateHook := getInPlaceUpdateHook(ds)
+	// the pod goes to Updated if the in-place update hook has been removed from it by the hook handler,
+	// and goes back to Normal once the hook is added again
+	updatedOrNormal := appspub.LifecycleStateNormal
+	if inPlaceUpdateHook != nil && !lifecycle.IsPodAllHooked(inPlaceUpdateHook, pod) {
+		updatedOrNormal = appspub.LifecycleStateUpdated
+	}
+
+	switch lifecycle.GetPodLifecycleState(pod) {
+	case appspub.LifecycleStatePreparingNormal:
+		if ds.Spec.Lifecycle == nil ||
+			ds.Spec.Lifecycle.PreNormal == nil ||
+			lifecycle.IsPodAllHooked(ds.Spec.Lifecycle.PreNormal, pod) {
+			return appspub.LifecycleStateNormal
+		}
+	case appspub.LifecycleStatePreparingUpdate:
+		// the DaemonSet has been rolled back to the revision of the pod while it was waiting for the hook,
+		// so there is nothing to update in-place.
+		if util.IsPodUpdated(pod, hash, nil) {
+			return updatedOrNormal
+		}
+	case appspub.LifecycleStateUpdating:
+		if opts.CheckPodUpdateCompleted(pod) == nil {
+			return updatedOrNormal
+		}
+	case appspub.LifecycleStateUpdated:
+		if inPlaceUpdateHook == nil || lifecycle.IsPodAllHooked(inPlaceUpdateHook, pod) {
+			return appspub.LifecycleStateNormal
+		}
+	}
+	return ""
+}
+
+// refreshPodLifecycle moves the pod to the next lifecycle state if its hooks are satisfied.
+func (dsc *ReconcileDaemonSet) refreshPodLifecycle(ds *appsv1alpha1.DaemonSet, pod *corev1.Pod, hash string, opts *inplaceupdate.UpdateOptions) error {
+	state := getNextLifecycleState(ds, pod, hash, opts)
+	if state == "" {
+		return nil
+	}
+	markPodNotReady := lifecycle.IsHookMarkPodNotReady(getInPlaceUpdateHook(ds))
+	if updated, gotPod, err := dsc.lifecycleControl.UpdatePodLifecycle(pod, state, markPodNotReady); err != nil {
+		return err
+	} else if updated {
+		dsc.resourceVersionExpectations.Expect(gotPod)
+		klog.V(3).InfoS("DaemonSet updated pod lifecycle", "daemonSet", klog.KObj(ds), "pod", klog.KObj(pod), "newState", state)
+	}
+	return nil
+}
+
+// prepareInPlaceUpdate moves the pod to PreparingUpdate if it is hooked by the in-place update hook,
+// and returns true only if the pod is ready to be updated in-place.
+func (dsc *ReconcileDaemonSet) prepareInPlaceUpdate(ds *appsv1alpha1.DaemonSet, pod *corev1.Pod) (bool, error) {
+	inPlaceUpdateHook := getInPlaceUpdateHook(ds)
+	switch state := lifecycle.GetPodLifecycleState(pod); state {
+	case "", appspub.LifecycleStatePreparingNormal, appspub.LifecycleStateNormal:
+		if !lifecycle.IsPodHooked(inPlaceUpdateHook, pod) {
+			return true, nil
+		}
+		updated, gotPod, err := dsc.lifecycleControl.UpdatePodLifecycle(pod, appspub.LifecycleStatePreparingUpdate, inPlaceUpdateHook.MarkPodNotReady)
+		if err == nil && updated {
+			dsc.resourceVersionExpectations.Expect(gotPod)
+			klog.V(3).InfoS("DaemonSet updated pod lifecycle to PreparingUpdate", "daemonSet", klog.KObj(ds), "pod", klog.KObj(pod))
+		}
+		return false, err
+	case appspub.LifecycleStateUpdated:
+		updated, gotPod, err := dsc.lifecycleControl.UpdatePodLifecycleWithHandler(pod, appspub.LifecycleStatePreparingUpdate, inPlaceUpdateHook)
+		if err == nil && updated {
+			dsc.resourceVersionExpectations.Expect(gotPod)
+			klog.V(3).InfoS("DaemonSet updated pod lifecycle to PreparingUpdate", "daemonSet", klog.KObj(ds), "pod", klog.KObj(pod))
+		}
+		return false, err
+	case appspub.LifecycleStatePreparingUpdate:
+		return !lifecycle.IsPodHooked(inPlaceUpdateHook, pod), nil
+	case appspub.LifecycleStateUpdating:
+		return true, nil
+	default:
+		return false, fmt.Errorf("not allowed to in-place update pod %s in state %s", pod.Name, state)
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+)
+
+func TestGetNextLifecycleState(t *testing.T) {
+	preNormalHook := &appspub.LifecycleHook{FinalizersHandler: []string{"example.com/pre-normal"}}
+	inPlaceUpdateHook := &appspub.LifecycleHook{LabelsHandler: map[string]string{"example.com/drained": "true"}}
+	newPod := func(state appspub.LifecycleStateType, hash string, hooked bool) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{
+				appspub.LifecycleStateKey:           string(state),
+				apps.DefaultDaemonSetUniqueLabelKey: hash,
+			},
+		}}
+		if hooked {
+			pod.Labels["example.com/drained"] = "true"
+			pod.Finalizers = []string{"example.com/pre-normal"}
+		}
+		return pod
+	}
+
+	cases := []struct {
+		name     string
+		hooks    *appspub.Lifecycle
+		pod      *corev1.Pod
+		expected appspub.LifecycleStateType
+	}{
+		{
+			name:     "preparing normal without hook",
+			pod:      newPod(appspub.LifecycleStatePreparingNormal, "v1", false),
+			expected: appspub.LifecycleStateNormal,
+		},
+		{
+			name:     "preparing normal waits for hook",
+			hooks:    &appspub.Lifecycle{PreNormal: preNormalHook},
+			pod:      newPod(appspub.LifecycleStatePreparingNormal, "v1", false),
+			expected: "",
+		},
+		{
+			name:     "preparing normal with hook satisfied",
+			hooks:    &appspub.Lifecycle{PreNormal: preNormalHook},
+			pod:      newPod(appspub.LifecycleStatePreparingNormal, "v1", true),
+			expected: appspub.LifecycleStateNormal,
+		},
+		{
+			name:     "preparing update of old revision",
+			hooks:    &appspub.Lifecycle{InPlaceUpdate: inPlaceUpdateHook},
+			pod:      newPod(appspub.LifecycleStatePreparingUpdate, "v1", false),
+			expected: "",
+		},
+		{
+			name:     "preparing update rolled back",
+			hooks:    &appspub.Lifecycle{InPlaceUpdate: inPlaceUpdateHook},
+			pod:      newPod(appspub.LifecycleStatePreparingUpdate, "v2", false),
+			expected: appspub.LifecycleStateUpdated,
+		},
+		{
+			name:     "updating completed and hooked",
+			hooks:    &appspub.Lifecycle{InPlaceUpdate: inPlaceUpdateHook},
+			pod:      newPod(appspub.LifecycleStateUpdating, "v2", true),
+			expected: appspub.LifecycleStateNormal,
+		},
+		{
+			name:     "updated waits for hook",
+			hooks:    &appspub.Lifecycle{InPlaceUpdate: inPlaceUpdateHook},
+			pod:      newPod(appspub.LifecycleStateUpdated, "v2", false),
+			expected: "",
+		},
+		{
+			name:     "updated with hook satisfied",
+			hooks:    &appspub.Lifecycle{InPlaceUpdate: inPlaceUpdateHook},
+			pod:      newPod(appspub.LifecycleStateUpdated, "v2", true),
+			expected: appspub.LifecycleStateNormal,
+		},
+	}
+
+	opts := inplaceupdate.SetOptionsDefaults(&inplaceupdate.UpdateOptions{})
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds := newDaemonSet("foo")
+			ds.Spec.Lifecycle = tc.hooks
+			if got := getNextLifecycleState(ds, tc.pod, "v2", opts); got != tc.expected {
+				t.Fatalf("expected state %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDaemonSetCreatesPreparingNormalPods(t *testing.T) {
+	ds := newDaemonSet("foo")
+	ds.Spec.Lifecycle = &appspub.Lifecycle{PreNormal: &appspub.LifecycleHook{FinalizersHandler: []string{"example.com/pre-normal"}}}
+	manager, podControl, _, err := newTestController(ds)
+	if err != nil {
+		t.Fatalf("error creating DaemonSets controller: %v", err)
+	}
+	addNodes(manager.nodeStore, 0, 2, nil)
+	manager.dsStore.Add(ds)
+	expectSyncDaemonSets(t, manager, ds, podControl, 2, 0, 0)
+	for _, template := range podControl.Templates {
+		if state := template.Labels[appspub.LifecycleStateKey]; state != string(appspub.LifecycleStatePreparingNormal) {
+			t.Fatalf("expected new pod in PreparingNormal, got %q", state)
+		}
+	}
+}
+
+func TestPrepareInPlaceUpdate(t *testing.T) {
+	ds := newDaemonSet("foo")
+	ds.Spec.Lifecycle = &appspub.Lifecycle{InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{"example.com/drained": "true"}}}
+	manager, podControl, _, err := newTestController(ds)
+	if err != nil {
+		t.Fatalf("error creating DaemonSets controller: %v", err)
+	}
+
+	pod := newPod("pod-0", "node-0", simpleDaemonSetLabel, ds)
+	pod.Labels[appspub.LifecycleStateKey] = string(appspub.LifecycleStateNormal)
+	pod.Labels["example.com/drained"] = "true"
+	podControl.podStore.Add(pod)
+
+	// the hooked pod waits in PreparingUpdate until the hook handler removes the label
+	if ready, err := manager.prepareInPlaceUpdate(ds, pod); err != nil || ready {
+		t.Fatalf("expected pod not ready for in-place update, got %v, %v", ready, err)
+	}
+	got, err := manager.podLister.Pods(pod.Namespace).Get(pod.Name)
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	if state := lifecycle.GetPodLifecycleState(got); state != appspub.LifecycleStatePreparingUpdate {
+		t.Fatalf("expected pod in PreparingUpdate, got %q", state)
+	}
+	if ready, err := manager.prepareInPlaceUpdate(ds, got); err != nil || ready {
+		t.Fatalf("expected hooked pod not ready for in-place update, got %v, %v", ready, err)
+	}
+
+	got = got.DeepCopy()
+	delete(got.Labels, "example.com/drained")
+	if ready, err := manager.prepareInPlaceUpdate(ds, got); err != nil || !ready {
+		t.Fatalf("expected pod ready for in-place update, got %v, %v", ready, err)
+	}
+}
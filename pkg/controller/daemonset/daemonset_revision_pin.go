@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"sort"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+// revisionPins are the revisions that the daemon pods on some nodes are pinned to by the node label.
+// A nil revisionPins means no node is pinned.
+type revisionPins struct {
+	// nodeHashes are the hashes of the pinned revisions, keyed by the node names.
+	nodeHashes map[string]string
+	// revisions are the pinned revisions, keyed by their hashes.
+	revisions map[string]*apps.ControllerRevision
+}
+
+// getRevisionPins returns the revisions that the nodes are pinned to, ignoring the labels naming unknown revisions.
+func getRevisionPins(ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, curRevision *apps.ControllerRevision, oldRevisions []*apps.ControllerRevision) *revisionPins {
+	if ds.Spec.UpdateStrategy.RollingUpdate == nil || ds.Spec.UpdateStrategy.RollingUpdate.PinnedRevisionLabelKey == "" {
+		return nil
+	}
+	labelKey := ds.Spec.UpdateStrategy.RollingUpdate.PinnedRevisionLabelKey
+
+	hashToRevision := make(map[string]*apps.ControllerRevision, len(oldRevisions)+1)
+	for _, revision := range append([]*apps.ControllerRevision{curRevision}, oldRevisions...) {
+		hashToRevision[revision.Labels[apps.DefaultDaemonSetUniqueLabelKey]] = revision
+	}
+
+	pins := &revisionPins{nodeHashes: map[string]string{}, revisions: map[string]*apps.ControllerRevision{}}
+	for _, node := range nodeList {
+		hash, ok := node.Labels[labelKey]
+		if !ok {
+			continue
+		}
+		revision, ok := hashToRevision[hash]
+		if !ok {
+			klog.V(4).InfoS("DaemonSet ignored the node pinned to unknown revision", "daemonSet", klog.KObj(ds), "nodeName", node.Name, "revision", hash)
+			continue
+		}
+		pins.nodeHashes[node.Name] = hash
+		pins.revisions[hash] = revision
+	}
+	return pins
+}
+
+// isPinned returns true if the node is pinned to a revision.
+func (p *revisionPins) isPinned(nodeName string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.nodeHashes[nodeName]
+	return ok
+}
+
+// nodeHash returns the hash of the revision that the node is pinned to, or the given hash if it is not pinned.
+func (p *revisionPins) nodeHash(nodeName, hash string) string {
+	if p == nil {
+		return hash
+	}
+	if pinned, ok := p.nodeHashes[nodeName]; ok {
+		return pinned
+	}
+	return hash
+}
+
+// hashes returns the hashes of all the pinned revisions.
+func (p *revisionPins) hashes() sets.String {
+	if p == nil {
+		return sets.NewString()
+	}
+	return sets.StringKeySet(p.revisions)
+}
+
+// status returns the pinned nodes grouped by revisions, sorted by the revision hashes and node names,
+// listing up to maxStatusNodeNames nodes of each revision.
+func (p *revisionPins) status() []appsv1alpha1.DaemonSetPinnedRevision {
+	if p == nil || len(p.nodeHashes) == 0 {
+		return nil
+	}
+	hashToNodes := make(map[string][]string, len(p.revisions))
+	for nodeName, hash := range p.nodeHashes {
+		hashToNodes[hash] = append(hashToNodes[hash], nodeName)
+	}
+	pinnedRevisions := make([]appsv1alpha1.DaemonSetPinnedRevision, 0, len(hashToNodes))
+	for _, hash := range sets.StringKeySet(hashToNodes).List() {
+		nodes := hashToNodes[hash]
+		numberNodes := len(nodes)
+		sort.Strings(nodes)
+		if numberNodes > maxStatusNodeNames {
+			nodes = nodes[:maxStatusNodeNames]
+		}
+		pinnedRevisions = append(pinnedRevisions, appsv1alpha1.DaemonSetPinnedRevision{Revision: hash, NumberNodes: int32(numberNodes), Nodes: nodes})
+	}
+	return pinnedRevisions
+}
+
+// podTemplates returns the templates to create the daemon pods on the given pinned nodes, keyed by the revision hashes.
+func (p *revisionPins) podTemplates(ds *appsv1alpha1.DaemonSet, nodeNames []string) (map[string]*corev1.PodTemplateSpec, error) {
+	templates := make(map[string]*corev1.PodTemplateSpec)
+	for _, nodeName := range nodeNames {
+		if !p.isPinned(nodeName) {
+			continue
+		}
+		hash := p.nodeHashes[nodeName]
+		if _, ok := templates[hash]; ok {
+			continue
+		}
+		restored, err := applyDaemonSetHistory(ds, p.revisions[hash])
+		if err != nil {
+			return nil, err
+		}
+		// the template generation is not set, so that the pods are only matched by the hash of the pinned revision
+		templates[hash] = newPodTemplate(ds, restored.Spec.Template, nil, hash)
+	}
+	return templates, nil
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	kubecontroller "k8s.io/kubernetes/pkg/controller"
+	"k8s.io/kubernetes/pkg/controller/daemon/util"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+const testPinnedRevisionLabelKey = "example.com/pinned-revision"
+
+func newRevision(hash string) *apps.ControllerRevision {
+	return &apps.ControllerRevision{ObjectMeta: metav1.ObjectMeta{
+		Name:   "foo-" + hash,
+		Labels: map[string]string{apps.DefaultDaemonSetUniqueLabelKey: hash},
+	}}
+}
+
+func TestGetRevisionPins(t *testing.T) {
+	ds := newDaemonSet("foo")
+	nodes := []*corev1.Node{
+		newNode("node-0", map[string]string{testPinnedRevisionLabelKey: "v1"}),
+		newNode("node-1", map[string]string{testPinnedRevisionLabelKey: "v2"}),
+		newNode("node-2", map[string]string{testPinnedRevisionLabelKey: "v1"}),
+		newNode("node-3", map[string]string{testPinnedRevisionLabelKey: "unknown"}),
+		newNode("node-4", nil),
+	}
+	if pins := getRevisionPins(ds, nodes, newRevision("v2"), []*apps.ControllerRevision{newRevision("v1")}); pins != nil {
+		t.Fatalf("expected no pins without label key, got %v", pins)
+	}
+
+	ds.Spec.UpdateStrategy.RollingUpdate = &appsv1alpha1.RollingUpdateDaemonSet{PinnedRevisionLabelKey: testPinnedRevisionLabelKey}
+	pins := getRevisionPins(ds, nodes, newRevision("v2"), []*apps.ControllerRevision{newRevision("v1")})
+	for nodeName, expected := range map[string]string{"node-0": "v1", "node-1": "v2", "node-3": "v2", "node-4": "v2"} {
+		if got := pins.nodeHash(nodeName, "v2"); got != expected {
+			t.Fatalf("expected %s pinned to %s, got %s", nodeName, expected, got)
+		}
+	}
+	if pins.isPinned("node-3") {
+		t.Fatalf("expected node-3 pinned to unknown revision to be ignored")
+	}
+	expected := []appsv1alpha1.DaemonSetPinnedRevision{
+		{Revision: "v1", NumberNodes: 2, Nodes: []string{"node-0", "node-2"}},
+		{Revision: "v2", NumberNodes: 1, Nodes: []string{"node-1"}},
+	}
+	if got := pins.status(); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected pinned revisions %v, got %v", expected, got)
+	}
+}
+
+func TestRevisionPinsStatusLimited(t *testing.T) {
+	pins := &revisionPins{nodeHashes: map[string]string{}, revisions: map[string]*apps.ControllerRevision{"v1": newRevision("v1")}}
+	for i := 0; i < maxStatusNodeNames+2; i++ {
+		pins.nodeHashes[fmt.Sprintf("node-%02d", i)] = "v1"
+	}
+	status := pins.status()
+	if len(status) != 1 || status[0].NumberNodes != int32(maxStatusNodeNames+2) {
+		t.Fatalf("expected %d nodes pinned to v1, got %v", maxStatusNodeNames+2, status)
+	}
+	if nodes := status[0].Nodes; len(nodes) != maxStatusNodeNames || nodes[0] != "node-00" || nodes[maxStatusNodeNames-1] != "node-09" {
+		t.Fatalf("expected the first %d pinned nodes, got %v", maxStatusNodeNames, nodes)
+	}
+}
+
+func TestDaemonSetUpdatesPinnedNodes(t *testing.T) {
+	ds := newDaemonSet("foo")
+	manager, podControl, _, err := newTestController(ds)
+	if err != nil {
+		t.Fatalf("error creating DaemonSets controller: %v", err)
+	}
+	addNodes(manager.nodeStore, 0, 4, nil)
+	manager.dsStore.Add(ds)
+	expectSyncDaemonSets(t, manager, ds, podControl, 4, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	// the revisions created by the controller are not synced into the informer
+	revisions, err := manager.kubeClient.AppsV1().ControllerRevisions(ds.Namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("failed to list revisions: %v", err)
+	}
+	for i := range revisions.Items {
+		manager.historyStore.Add(&revisions.Items[i])
+	}
+	oldHash := kubecontroller.ComputeHash(&ds.Spec.Template, ds.Status.CollisionCount)
+
+	// hold back node-0 on the old revision, and only update node-1 ahead of the others held by partition
+	ds.Spec.Template.Spec.Containers[0].Image = "foo2/bar2"
+	newHash := kubecontroller.ComputeHash(&ds.Spec.Template, ds.Status.CollisionCount)
+	partition := int32(4)
+	maxUnavailable := intstr.FromInt(1)
+	ds.Spec.UpdateStrategy.Type = appsv1alpha1.RollingUpdateDaemonSetStrategyType
+	ds.Spec.UpdateStrategy.RollingUpdate = &appsv1alpha1.RollingUpdateDaemonSet{
+		MaxUnavailable:         &maxUnavailable,
+		Partition:              &partition,
+		PinnedRevisionLabelKey: testPinnedRevisionLabelKey,
+	}
+	manager.dsStore.Update(ds)
+	pinNode := func(nodeName, hash string) {
+		obj, _, _ := manager.nodeStore.GetByKey(nodeName)
+		node := obj.(*corev1.Node).DeepCopy()
+		node.Labels = map[string]string{testPinnedRevisionLabelKey: hash}
+		manager.nodeStore.Update(node)
+	}
+	pinNode("node-0", oldHash)
+	pinNode("node-1", newHash)
+
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 1, 0)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 1, 0, 0)
+	if image := podControl.Templates[0].Spec.Containers[0].Image; image != "foo2/bar2" {
+		t.Fatalf("expected pinned pod created with image foo2/bar2, got %s", image)
+	}
+	markPodsReady(podControl.podStore)
+
+	// node-2 pinned to the old revision is kept while the others are updated after the partition is removed
+	partition = 0
+	manager.dsStore.Update(ds)
+	pinNode("node-2", oldHash)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 1, 0)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 1, 0, 0)
+	markPodsReady(podControl.podStore)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 0, 0)
+
+	for _, obj := range podControl.podStore.List() {
+		pod := obj.(*corev1.Pod)
+		nodeName, _ := util.GetTargetNodeName(pod)
+		expected := newHash
+		if nodeName == "node-0" || nodeName == "node-2" {
+			expected = oldHash
+		}
+		if hash := pod.Labels[apps.DefaultDaemonSetUniqueLabelKey]; hash != expected {
+			t.Fatalf("expected pod on %s of revision %s, got %s", nodeName, expected, hash)
+		}
+	}
+	updated, err := manager.kruiseClient.AppsV1alpha1().DaemonSets(ds.Namespace).Get(context.TODO(), ds.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get DaemonSet: %v", err)
+	}
+	if updated.Status.UpdatedNumberScheduled != 4 {
+		t.Fatalf("expected 4 updated pods, got %d", updated.Status.UpdatedNumberScheduled)
+	}
+	expected := []appsv1alpha1.DaemonSetPinnedRevision{
+		{Revision: oldHash, NumberNodes: 2, Nodes: []string{"node-0", "node-2"}},
+		{Revision: newHash, NumberNodes: 1, Nodes: []string{"node-1"}},
+	}
+	if oldHash > newHash {
+		expected[0], expected[1] = expected[1], expected[0]
+	}
+	if !reflect.DeepEqual(updated.Status.PinnedRevisions, expected) {
+		t.Fatalf("expected pinned revisions %v, got %v", expected, updated.Status.PinnedRevisions)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+const (
+	// surgeConflictReason is the reason of the SurgeDisabled condition.
+	surgeConflictReason = "HostPortConflict"
+)
+
+func getSurgeFallbackStrategy(ds *appsv1alpha1.DaemonSet) appsv1alpha1.SurgeFallbackStrategyType {
+	if ds.Spec.UpdateStrategy.RollingUpdate == nil || ds.Spec.UpdateStrategy.RollingUpdate.SurgeFallbackStrategy == "" {
+		return appsv1alpha1.RecreateSurgeFallbackStrategyType
+	}
+	return ds.Spec.UpdateStrategy.RollingUpdate.SurgeFallbackStrategy
+}
+
+// hostPort is a port of the node used by a pod.
+type hostPort struct {
+	ip       string
+	port     int32
+	protocol corev1.Protocol
+}
+
+// isWildcardHostIP returns true if the host ip means all the addresses of the node.
+func isWildcardHostIP(ip string) bool {
+	return ip == "" || ip == "0.0.0.0" || ip == "::"
+}
+
+// conflicts returns true if the two host ports can not be used on the same node at the same time.
+func (p hostPort) conflicts(other hostPort) bool {
+	if p.port != other.port || p.protocol != other.protocol {
+		return false
+	}
+	return p.ip == other.ip || isWildcardHostIP(p.ip) || isWildcardHostIP(other.ip)
+}
+
+func (p hostPort) String() string {
+	if isWildcardHostIP(p.ip) {
+		return fmt.Sprintf("%d/%s", p.port, p.protocol)
+	}
+	return fmt.Sprintf("%s/%s", net.JoinHostPort(p.ip, strconv.Itoa(int(p.port))), p.protocol)
+}
+
+// getHostPorts returns the host ports used by the init containers and containers.
+// All the container ports are host ports if the pod uses hostNetwork.
+func getHostPorts(spec *corev1.PodSpec) []hostPort {
+	var ports []hostPort
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for _, p := range containers[i].Ports {
+				port := p.HostPort
+				if spec.HostNetwork {
+					port = p.ContainerPort
+				}
+				if port <= 0 {
+					continue
+				}
+				protocol := p.Protocol
+				if protocol == "" {
+					protocol = corev1.ProtocolTCP
+				}
+				ports = append(ports, hostPort{ip: p.HostIP, port: port, protocol: protocol})
+			}
+		}
+	}
+	return ports
+}
+
+// getSurgeConflict returns why a surge pod of the template can not run together with the old pod on the same node,
+// or an empty string if they can.
+func getSurgeConflict(template *corev1.PodTemplateSpec, oldPod *corev1.Pod) string {
+	if template.Spec.HostNetwork && oldPod.Spec.HostNetwork {
+		return "hostNetwork"
+	}
+	oldPorts := getHostPorts(&oldPod.Spec)
+	for _, port := range getHostPorts(&template.Spec) {
+		for _, oldPort := range oldPorts {
+			if port.conflicts(oldPort) {
+				return fmt.Sprintf("hostPort %s", port)
+			}
+		}
+	}
+	return ""
+}
+
+// isSurgeLimited returns true if some pods may be updated without surging, so that the new pods
+// updated in-place or recreated should also be counted against maxSurge.
+func isSurgeLimited(ds *appsv1alpha1.DaemonSet) bool {
+	if ds.Spec.UpdateStrategy.RollingUpdate.Type == appsv1alpha1.InplaceRollingUpdateType {
+		return true
+	}
+	return ds.Spec.Template.Spec.HostNetwork || len(getHostPorts(&ds.Spec.Template.Spec)) > 0
+}
+
+// splitSurgeNodes decides how to update the old pods on the given nodes in a surging rolling update.
+// It returns the nodes to create surge pods on, and the old pods to update in-place or to delete
+// on the nodes where surging is not needed or not possible. The old pods on the pinned nodes are never updated in-place.
+// The available old pods which fall back to be updated without surging make their nodes unavailable, so at most
+// maxFallback of them are updated, while the old pods on the unavailableNodes are always allowed to.
+func (dsc *ReconcileDaemonSet) splitSurgeNodes(ds *appsv1alpha1.DaemonSet, nodeNames []string, oldPods map[string]*corev1.Pod,
+	curRevision *apps.ControllerRevision, oldRevisions []*apps.ControllerRevision, pins *revisionPins,
+	unavailableNodes sets.String, maxFallback int) (nodesToSurge, podsToUpdate, podsToDelete []string) {
+	inPlace := ds.Spec.UpdateStrategy.RollingUpdate.Type == appsv1alpha1.InplaceRollingUpdateType
+	fallback := getSurgeFallbackStrategy(ds)
+	for _, nodeName := range nodeNames {
+		oldPod := oldPods[nodeName]
+		pinned := pins.isPinned(nodeName)
+		if inPlace && !pinned && dsc.canPodInPlaceUpdate(oldPod, curRevision, oldRevisions) {
+			podsToUpdate = append(podsToUpdate, oldPod.Name)
+			continue
+		} else if getSurgeConflict(&ds.Spec.Template, oldPod) == "" {
+			nodesToSurge = append(nodesToSurge, nodeName)
+			continue
+		}
+
+		if !unavailableNodes.Has(nodeName) {
+			if maxFallback <= 0 {
+				klog.V(5).InfoS("DaemonSet was waiting for unavailable nodes to update the old pod without surging",
+					"daemonSet", klog.KObj(ds), "pod", klog.KObj(oldPod), "nodeName", nodeName)
+				continue
+			}
+			maxFallback--
+		}
+		if fallback == appsv1alpha1.InPlaceIfPossibleSurgeFallbackStrategyType && !pinned && dsc.canPodInPlaceUpdate(oldPod, curRevision, oldRevisions) {
+			podsToUpdate = append(podsToUpdate, oldPod.Name)
+		} else {
+			podsToDelete = append(podsToDelete, oldPod.Name)
+		}
+	}
+	return
+}
+
+// getSurgeDisabledConditions returns the conditions of the DaemonSet with the SurgeDisabled condition set if the surge
+// pods can not run together with the old pods on some nodes, or removed if they can.
+func getSurgeDisabledConditions(ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, nodeToDaemonPods map[string][]*corev1.Pod, hash string, pins *revisionPins, now metav1.Time) []apps.DaemonSetCondition {
+	var conflictNodes int
+	var conflict string
+	if allowSurge(ds) {
+		for _, node := range nodeList {
+			if shouldRun, _ := nodeShouldRunDaemonPod(node, ds); !shouldRun {
+				continue
+			}
+			newPod, oldPod, ok := findUpdatedPodsOnNode(ds, nodeToDaemonPods[node.Name], pins.nodeHash(node.Name, hash))
+			if !ok || newPod != nil || isPodNilOrPreDeleting(oldPod) {
+				continue
+			}
+			if c := getSurgeConflict(&ds.Spec.Template, oldPod); c != "" {
+				conflictNodes++
+				conflict = c
+			}
+		}
+	}
+
+	var conditions []apps.DaemonSetCondition
+	var existing *apps.DaemonSetCondition
+	for i := range ds.Status.Conditions {
+		if ds.Status.Conditions[i].Type == appsv1alpha1.DaemonSetSurgeDisabled {
+			existing = &ds.Status.Conditions[i]
+			continue
+		}
+		conditions = append(conditions, ds.Status.Conditions[i])
+	}
+	if conflictNodes == 0 {
+		return conditions
+	}
+
+	condition := apps.DaemonSetCondition{
+		Type:               appsv1alpha1.DaemonSetSurgeDisabled,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: now,
+		Reason:             surgeConflictReason,
+		Message: fmt.Sprintf("surge is disabled on %d node(s) because the new pods conflict with the old ones on %s, falling back to %s",
+			conflictNodes, conflict, getSurgeFallbackStrategy(ds)),
+	}
+	if existing != nil && existing.Status == condition.Status {
+		condition.LastTransitionTime = existing.LastTransitionTime
+	}
+	return append(conditions, condition)
+}
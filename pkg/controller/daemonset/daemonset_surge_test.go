@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+func TestGetSurgeConflict(t *testing.T) {
+	newSpec := func(hostNetwork bool, ports ...corev1.ContainerPort) corev1.PodSpec {
+		return corev1.PodSpec{HostNetwork: hostNetwork, Containers: []corev1.Container{{Name: "c", Ports: ports}}}
+	}
+	cases := []struct {
+		name     string
+		template corev1.PodSpec
+		old      corev1.PodSpec
+		expected string
+	}{
+		{
+			name:     "no host ports",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 80}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80}),
+		},
+		{
+			name:     "different host ports",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8081}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080}),
+		},
+		{
+			name:     "different protocols",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 53, HostPort: 53, Protocol: corev1.ProtocolUDP}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 53, HostPort: 53}),
+		},
+		{
+			name:     "same host port",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, Protocol: corev1.ProtocolTCP}),
+			expected: "hostPort 8080/TCP",
+		},
+		{
+			name:     "host network port",
+			template: newSpec(true, corev1.ContainerPort{ContainerPort: 9100}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 9100}),
+			expected: "hostPort 9100/TCP",
+		},
+		{
+			name:     "different host ips",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.1"}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.2"}),
+		},
+		{
+			name:     "same host ip",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.1"}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.1"}),
+			expected: "hostPort 10.0.0.1:8080/TCP",
+		},
+		{
+			name:     "wildcard host ip",
+			template: newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "10.0.0.1"}),
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080, HostIP: "0.0.0.0"}),
+			expected: "hostPort 10.0.0.1:8080/TCP",
+		},
+		{
+			name:     "init container host port",
+			template: corev1.PodSpec{InitContainers: []corev1.Container{{Name: "init", Ports: []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}}}}},
+			old:      newSpec(false, corev1.ContainerPort{ContainerPort: 80, HostPort: 8080}),
+			expected: "hostPort 8080/TCP",
+		},
+		{
+			name:     "both host network",
+			template: newSpec(true),
+			old:      newSpec(true),
+			expected: "hostNetwork",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			template := &corev1.PodTemplateSpec{Spec: tc.template}
+			if got := getSurgeConflict(template, &corev1.Pod{Spec: tc.old}); got != tc.expected {
+				t.Fatalf("expected conflict %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDaemonSetUpdatesSurgeFallbackOnHostPortConflict(t *testing.T) {
+	ds := newDaemonSet("foo")
+	ds.Spec.Template.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: 80, HostPort: 8080}}
+	manager, podControl, _, err := newTestController(ds)
+	if err != nil {
+		t.Fatalf("error creating DaemonSets controller: %v", err)
+	}
+	addNodes(manager.nodeStore, 0, 5, nil)
+	manager.dsStore.Add(ds)
+	expectSyncDaemonSets(t, manager, ds, podControl, 5, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	maxSurge := 2
+	ds.Spec.Template.Spec.Containers[0].Image = "foo2/bar2"
+	ds.Spec.UpdateStrategy = newUpdateSurge(intstr.FromInt(maxSurge))
+	manager.dsStore.Update(ds)
+
+	// the old pods are recreated instead of surging, one node at a time as maxUnavailable is 0
+	for i := 0; i < 5; i++ {
+		clearExpectations(t, manager, ds, podControl)
+		expectSyncDaemonSets(t, manager, ds, podControl, 0, 1, 0)
+		if i == 0 {
+			updated, err := manager.kruiseClient.AppsV1alpha1().DaemonSets(ds.Namespace).Get(context.TODO(), ds.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get DaemonSet: %v", err)
+			}
+			if len(updated.Status.Conditions) != 1 || updated.Status.Conditions[0].Type != appsv1alpha1.DaemonSetSurgeDisabled ||
+				updated.Status.Conditions[0].Reason != surgeConflictReason {
+				t.Fatalf("expected SurgeDisabled condition, got %v", updated.Status.Conditions)
+			}
+		}
+
+		clearExpectations(t, manager, ds, podControl)
+		expectSyncDaemonSets(t, manager, ds, podControl, 1, 0, 0)
+		// the recreated pod is unavailable until it is ready, so no more old pods are deleted
+		clearExpectations(t, manager, ds, podControl)
+		expectSyncDaemonSets(t, manager, ds, podControl, 0, 0, 0)
+		markPodsReady(podControl.podStore)
+	}
+
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 0, 0)
+	updated, err := manager.kruiseClient.AppsV1alpha1().DaemonSets(ds.Namespace).Get(context.TODO(), ds.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get DaemonSet: %v", err)
+	}
+	if len(updated.Status.Conditions) != 0 {
+		t.Fatalf("expected no conditions after update, got %v", updated.Status.Conditions)
+	}
+}
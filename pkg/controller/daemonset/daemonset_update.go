@@ -32,15 +32,17 @@ import (
 	"k8s.io/klog/v2"
 	podutil "k8s.io/kubernetes/pkg/api/v1/pod"
 
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
 	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
 	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
 	"github.com/openkruise/kruise/pkg/util"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
 )
 
 // rollingUpdate identifies the set of old pods to in-place update, delete, or additional pods to create on nodes,
 // remaining within the constraints imposed by the update strategy.
-func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, curRevision *apps.ControllerRevision, oldRevisions []*apps.ControllerRevision) error {
+func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, curRevision *apps.ControllerRevision, oldRevisions []*apps.ControllerRevision, pins *revisionPins) error {
 	hash := curRevision.Labels[apps.DefaultDaemonSetUniqueLabelKey]
 	nodeToDaemonPods, err := dsc.getNodesToDaemonPods(ctx, ds)
 	if err != nil {
@@ -51,12 +53,60 @@ func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alph
 		return fmt.Errorf("couldn't get unavailable numbers: %v", err)
 	}
 
+	// Advanced: find the current wave before the nodes are filtered
+	wave, err := dsc.getCurrentUpdateWave(ds, nodeList, nodeToDaemonPods, hash, pins)
+	if err != nil {
+		return fmt.Errorf("couldn't get current update wave: %v", err)
+	}
+
+	// Advanced: the pinned nodes are updated to their pinned revisions regardless of partition, selector and waves
+	pinnedNodeToDaemonPods := make(map[string][]*corev1.Pod)
+	for nodeName, pods := range nodeToDaemonPods {
+		if pins.isPinned(nodeName) {
+			pinnedNodeToDaemonPods[nodeName] = pods
+			delete(nodeToDaemonPods, nodeName)
+		}
+	}
+
 	// Advanced: filter the pods updated, updating and can update, according to partition and selector
 	nodeToDaemonPods, err = dsc.filterDaemonPodsToUpdate(ds, nodeList, hash, nodeToDaemonPods)
 	if err != nil {
 		return fmt.Errorf("failed to filterDaemonPodsToUpdate: %v", err)
 	}
 
+	// Advanced: defer the nodes being drained, which are neither updated nor counted as unavailable
+	drainingNodes := getDrainingNodes(ds, nodeList)
+	if drainingNodes.Len() > 0 {
+		for nodeName := range nodeToDaemonPods {
+			if drainingNodes.Has(nodeName) {
+				klog.V(5).InfoS("DaemonSet deferred the update of draining node", "daemonSet", klog.KObj(ds), "nodeName", nodeName)
+				delete(nodeToDaemonPods, nodeName)
+			}
+		}
+	}
+
+	// Advanced: only update the nodes in the current wave, and wait for it to soak before the next one
+	if wave != nil {
+		for nodeName := range nodeToDaemonPods {
+			if !wave.nodes.Has(nodeName) {
+				delete(nodeToDaemonPods, nodeName)
+			}
+		}
+		if maxUnavailable, err = waveUnavailableCount(wave, maxUnavailable); err != nil {
+			return fmt.Errorf("invalid value for MaxUnavailable of wave %s: %v", wave.wave.Name, err)
+		}
+		if wave.soakRemaining > 0 {
+			klog.V(4).InfoS("DaemonSet was soaking the completed wave", "daemonSet", klog.KObj(ds), "wave", wave.wave.Name, "remaining", wave.soakRemaining)
+			durationStore.Push(keyFunc(ds), wave.soakRemaining)
+		}
+	}
+
+	for nodeName, pods := range pinnedNodeToDaemonPods {
+		if !drainingNodes.Has(nodeName) {
+			nodeToDaemonPods[nodeName] = pods
+		}
+	}
+
 	now := dsc.failedPodsBackoff.Clock.Now()
 
 	// When not surging, we delete just enough pods to stay under the maxUnavailable limit, if any
@@ -75,8 +125,9 @@ func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alph
 		var numUnavailable int
 		var allowedReplacementPods []string
 		var candidatePodsToDelete []string
+		pinnedOldPods := sets.NewString()
 		for nodeName, pods := range nodeToDaemonPods {
-			newPod, oldPod, ok := findUpdatedPodsOnNode(ds, pods, hash)
+			newPod, oldPod, ok := findUpdatedPodsOnNode(ds, pods, pins.nodeHash(nodeName, hash))
 			if !ok {
 				// let the manage loop clean up this node, and treat it as an unavailable node
 				klog.V(3).InfoS("DaemonSet had excess pods on node, skipped to allow the core loop to process", "daemonSet", klog.KObj(ds), "nodeName", nodeName)
@@ -102,7 +153,18 @@ func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alph
 				}
 			default:
 				// this pod is old, it is an update candidate
+				if pins.isPinned(nodeName) {
+					pinnedOldPods.Insert(oldPod.Name)
+				}
 				switch {
+				case lifecycle.GetPodLifecycleState(oldPod) == appspub.LifecycleStatePreparingUpdate:
+					// the pod is waiting for the in-place update hook, keep updating it and count it against maxUnavailable
+					numUnavailable++
+					klog.V(5).InfoS("DaemonSet pod on node was preparing update", "daemonSet", klog.KObj(ds), "pod", klog.KObj(oldPod), "nodeName", nodeName)
+					if allowedReplacementPods == nil {
+						allowedReplacementPods = make([]string, 0, len(nodeToDaemonPods))
+					}
+					allowedReplacementPods = append(allowedReplacementPods, oldPod.Name)
 				case !podutil.IsPodAvailable(oldPod, ds.Spec.MinReadySeconds, metav1.Time{Time: now}):
 					// the old pod isn't available, so it needs to be replaced
 					klog.V(5).InfoS("DaemonSet pod on node was out of date and not available, allowed replacement", "daemonSet", klog.KObj(ds), "pod", klog.KObj(oldPod), "nodeName", nodeName)
@@ -137,15 +199,24 @@ func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alph
 		}
 		oldPodsToDelete := append(allowedReplacementPods, candidatePodsToDelete[:remainingUnavailable]...)
 
-		// Advanced: update pods in-place first and still delete the others
+		// Advanced: update pods in-place first and still delete the others, while the pinned pods are always recreated
 		if ds.Spec.UpdateStrategy.RollingUpdate.Type == appsv1alpha1.InplaceRollingUpdateType {
-			oldPodsToDelete, err = dsc.inPlaceUpdatePods(ds, oldPodsToDelete, curRevision, oldRevisions)
+			var podsToUpdate, podsToRecreate []string
+			for _, podName := range oldPodsToDelete {
+				if pinnedOldPods.Has(podName) {
+					podsToRecreate = append(podsToRecreate, podName)
+				} else {
+					podsToUpdate = append(podsToUpdate, podName)
+				}
+			}
+			oldPodsToDelete, err = dsc.inPlaceUpdatePods(ds, podsToUpdate, curRevision, oldRevisions)
 			if err != nil {
 				return err
 			}
+			oldPodsToDelete = append(oldPodsToDelete, podsToRecreate...)
 		}
 
-		return dsc.syncNodes(ctx, ds, oldPodsToDelete, nil, hash)
+		return dsc.syncNodes(ctx, ds, oldPodsToDelete, nil, hash, pins)
 	}
 
 	// When surging, we create new pods whenever an old pod is unavailable, and we can create up
@@ -165,24 +236,46 @@ func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alph
 	var candidateNewNodes []string
 	var allowedNewNodes []string
 	var numSurge int
+	oldPodsOnNodes := make(map[string]*corev1.Pod, len(nodeToDaemonPods))
+	surgeLimited := isSurgeLimited(ds)
+	// Advanced: the nodes without any available pod, which limit the old pods updated without surging
+	var numUnavailable int
+	unavailableOldPodNodes := sets.NewString()
 
 	for nodeName, pods := range nodeToDaemonPods {
-		newPod, oldPod, ok := findUpdatedPodsOnNode(ds, pods, hash)
+		newPod, oldPod, ok := findUpdatedPodsOnNode(ds, pods, pins.nodeHash(nodeName, hash))
 		if !ok {
 			// let the manage loop clean up this node, and treat it as a surge node
 			klog.V(3).InfoS("DaemonSet has excess pods on node, skipping to allow the core loop to process", "daemonSet", klog.KObj(ds), "nodeName", nodeName)
 			numSurge++
+			numUnavailable++
 			continue
 		}
 		switch {
 		case isPodNilOrPreDeleting(oldPod):
-			// we don't need to do anything to this node, the manage loop will handle it
+			// we don't need to do anything to this node, the manage loop will handle it.
+			// Advanced: the new pod updated in-place or recreated instead of surging is counted until it goes available.
+			if surgeLimited && newPod != nil && !podutil.IsPodAvailable(newPod, ds.Spec.MinReadySeconds, metav1.Time{Time: now}) {
+				numSurge++
+			}
+			if isPodNilOrPreDeleting(newPod) || !podutil.IsPodAvailable(newPod, ds.Spec.MinReadySeconds, metav1.Time{Time: now}) {
+				numUnavailable++
+			}
 		case newPod == nil:
 			// this is a surge candidate
+			oldPodsOnNodes[nodeName] = oldPod
 			switch {
+			case lifecycle.GetPodLifecycleState(oldPod) == appspub.LifecycleStatePreparingUpdate:
+				// the pod is waiting for the in-place update hook, keep updating it and count it against maxSurge
+				klog.V(5).InfoS("DaemonSet pod on node was preparing update", "daemonSet", klog.KObj(ds), "pod", klog.KObj(oldPod), "nodeName", nodeName)
+				numSurge++
+				numUnavailable++
+				unavailableOldPodNodes.Insert(nodeName)
+				allowedNewNodes = append(allowedNewNodes, nodeName)
 			case !podutil.IsPodAvailable(oldPod, ds.Spec.MinReadySeconds, metav1.Time{Time: now}):
 				// the old pod isn't available, allow it to become a replacement
 				klog.V(5).InfoS("DaemonSet Pod on node was out of date and not available, allowed replacement", "daemonSet", klog.KObj(ds), "pod", klog.KObj(oldPod), "nodeName", nodeName)
+				unavailableOldPodNodes.Insert(nodeName)
 				// record the replacement
 				if allowedNewNodes == nil {
 					allowedNewNodes = make([]string, 0, len(nodeToDaemonPods))
@@ -228,7 +321,25 @@ func (dsc *ReconcileDaemonSet) rollingUpdate(ctx context.Context, ds *appsv1alph
 	}
 	newNodesToCreate := append(allowedNewNodes, candidateNewNodes[:remainingSurge]...)
 
-	return dsc.syncNodes(ctx, ds, oldPodsToDelete, newNodesToCreate, hash)
+	// Advanced: update the old pods in-place or recreate them on the nodes where surging is not needed or not possible,
+	// which is limited by maxUnavailable, or one node at a time if maxUnavailable is 0 as it must be with maxSurge
+	maxFallback := maxUnavailable
+	if maxFallback < 1 {
+		maxFallback = 1
+	}
+	maxFallback -= numUnavailable
+	newNodesToCreate, podsToUpdate, podsToDelete := dsc.splitSurgeNodes(ds, newNodesToCreate, oldPodsOnNodes, curRevision, oldRevisions, pins,
+		unavailableOldPodNodes, maxFallback)
+	if len(podsToUpdate) > 0 {
+		podsNeedDelete, err := dsc.inPlaceUpdatePods(ds, podsToUpdate, curRevision, oldRevisions)
+		if err != nil {
+			return err
+		}
+		podsToDelete = append(podsToDelete, podsNeedDelete...)
+	}
+	oldPodsToDelete = append(oldPodsToDelete, podsToDelete...)
+
+	return dsc.syncNodes(ctx, ds, oldPodsToDelete, newNodesToCreate, hash, pins)
 }
 
 // updatedDesiredNodeCounts calculates the true number of allowed unavailable or surge pods and
@@ -423,7 +534,19 @@ func (dsc *ReconcileDaemonSet) inPlaceUpdatePods(ds *appsv1alpha1.DaemonSet, pod
 					break
 				}
 			}
-			res := dsc.inplaceControl.Update(pod, oldRevision, curRevision, getInPlaceUpdateOptions())
+			if ready, err := dsc.prepareInPlaceUpdate(ds, pod); err != nil {
+				errCh <- err
+				return
+			} else if !ready {
+				klog.V(4).InfoS("DaemonSet was waiting for the in-place update hook of pod", "daemonSet", klog.KObj(ds), "pod", klog.KObj(pod))
+				return
+			}
+
+			opts := getInPlaceUpdateOptions()
+			if ds.Spec.Lifecycle != nil {
+				opts.AdditionalFuncs = append(opts.AdditionalFuncs, lifecycle.SetPodLifecycle(appspub.LifecycleStateUpdating))
+			}
+			res := dsc.inplaceControl.Update(pod, oldRevision, curRevision, opts)
 			if res.InPlaceUpdate {
 				if res.UpdateErr == nil {
 					dsc.eventRecorder.Eventf(ds, corev1.EventTypeNormal, "SuccessfulUpdatePodInPlace", "successfully update pod %s in-place", pod.Name)
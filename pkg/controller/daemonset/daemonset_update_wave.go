@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	"github.com/openkruise/kruise/pkg/util"
+)
+
+// updateWave is the wave of the rolling update that is being updated or soaking.
+type updateWave struct {
+	status *appsv1alpha1.DaemonSetUpdateWaveStatus
+	wave   *appsv1alpha1.DaemonSetUpdateWave
+	// nodes are the names of the nodes in the wave that should run the daemon pod.
+	nodes sets.String
+	// soakRemaining is the time to wait before the next wave begins.
+	soakRemaining time.Duration
+}
+
+func getUpdateWaves(ds *appsv1alpha1.DaemonSet) []appsv1alpha1.DaemonSetUpdateWave {
+	if ds.Spec.UpdateStrategy.RollingUpdate == nil {
+		return nil
+	}
+	return ds.Spec.UpdateStrategy.RollingUpdate.Waves
+}
+
+// getCurrentUpdateWave returns the first wave which still has pods not updated or not available,
+// or the last completed wave whose soak time has not passed yet. It returns nil if there is no wave.
+// The pinned nodes are not updated by waves, so they are not in any wave.
+func (dsc *ReconcileDaemonSet) getCurrentUpdateWave(ds *appsv1alpha1.DaemonSet, nodeList []*corev1.Node, nodeToDaemonPods map[string][]*corev1.Pod, hash string, pins *revisionPins) (*updateWave, error) {
+	waves := getUpdateWaves(ds)
+	if len(waves) == 0 {
+		return nil, nil
+	}
+
+	// the index of the wave recorded in status for the same revision, which means the waves before it have soaked
+	recordedIndex := -1
+	if cur := ds.Status.CurrentWave; cur != nil && ds.Status.DaemonSetHash == hash {
+		for i := range waves {
+			if waves[i].Name == cur.Name {
+				recordedIndex = i
+				break
+			}
+		}
+	}
+
+	now := dsc.failedPodsBackoff.Clock.Now()
+	drainingNodeStrategy := getDrainingNodeStrategy(ds)
+	assigned := sets.NewString()
+	var current *updateWave
+	for i := range waves {
+		wave := &waves[i]
+		selector, err := util.ValidatedLabelSelectorAsSelector(wave.Selector)
+		if err != nil {
+			return nil, err
+		}
+
+		current = &updateWave{
+			status: &appsv1alpha1.DaemonSetUpdateWaveStatus{Name: wave.Name, Index: int32(i)},
+			wave:   wave,
+			nodes:  sets.NewString(),
+		}
+		soaked := recordedIndex > i
+		completed := true
+		for _, node := range nodeList {
+			if assigned.Has(node.Name) || !selector.Matches(labels.Set(node.Labels)) {
+				continue
+			}
+			assigned.Insert(node.Name)
+			if shouldRun, _ := nodeShouldRunDaemonPod(node, ds); !shouldRun || pins.isPinned(node.Name) {
+				continue
+			}
+			current.nodes.Insert(node.Name)
+			if isNodeDraining(drainingNodeStrategy, node) {
+				// the deferred nodes will not block the waves
+				continue
+			}
+			newPod, oldPod, ok := findUpdatedPodsOnNode(ds, nodeToDaemonPods[node.Name], hash)
+			switch {
+			case !ok || oldPod != nil:
+				completed = false
+			case soaked:
+				// the waves already soaked only need their pods to be updated,
+				// so that a pod becoming unavailable later will not block the next waves
+			case newPod == nil || !isDaemonPodAvailable(newPod, ds.Spec.MinReadySeconds, metav1.Time{Time: now}):
+				completed = false
+			}
+		}
+		if !completed {
+			return current, nil
+		}
+
+		completedTime := now
+		if cur := ds.Status.CurrentWave; recordedIndex == i && cur.CompletedTime != nil {
+			completedTime = cur.CompletedTime.Time
+		}
+		current.status.CompletedTime = &metav1.Time{Time: completedTime}
+		if soaked || i == len(waves)-1 {
+			continue
+		}
+		if remaining := completedTime.Add(time.Duration(wave.SoakSeconds) * time.Second).Sub(now); remaining > 0 {
+			current.soakRemaining = remaining
+			return current, nil
+		}
+	}
+	return current, nil
+}
+
+// waveUnavailableCount returns the maxUnavailable of the wave, or the given default if the wave does not set it.
+func waveUnavailableCount(wave *updateWave, defaultCount int) (int, error) {
+	if wave.wave.MaxUnavailable == nil {
+		return defaultCount, nil
+	}
+	maxUnavailable, err := intstrutil.GetScaledValueFromIntOrPercent(wave.wave.MaxUnavailable, wave.nodes.Len(), true)
+	if err != nil {
+		return -1, err
+	}
+	// maxUnavailable should not be less than 1
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+	return maxUnavailable, nil
+}
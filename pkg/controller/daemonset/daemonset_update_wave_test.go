@@ -0,0 +1,178 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package daemonset
+
+import (
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/util/flowcontrol"
+	testingclock "k8s.io/utils/clock/testing"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+)
+
+func TestGetCurrentUpdateWave(t *testing.T) {
+	now := time.Now()
+	dsc := &ReconcileDaemonSet{failedPodsBackoff: flowcontrol.NewFakeBackOff(time.Second, time.Minute, testingclock.NewFakeClock(now))}
+
+	nodes := []*corev1.Node{
+		newNode("n1", map[string]string{"pool": "canary"}),
+		newNode("n2", map[string]string{"pool": "canary"}),
+		newNode("n3", map[string]string{"pool": "general"}),
+		newNode("n4", map[string]string{"pool": "general"}),
+	}
+	newDaemonPods := func(ready bool, hashes ...string) map[string][]*corev1.Pod {
+		nodeToDaemonPods := map[string][]*corev1.Pod{}
+		for i, hash := range hashes {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{apps.DefaultDaemonSetUniqueLabelKey: hash}}}
+			if ready {
+				markPodReady(pod)
+			}
+			nodeToDaemonPods[nodes[i].Name] = []*corev1.Pod{pod}
+		}
+		return nodeToDaemonPods
+	}
+
+	cases := []struct {
+		name                  string
+		status                appsv1alpha1.DaemonSetStatus
+		nodeToDaemonPods      map[string][]*corev1.Pod
+		expectedWave          string
+		expectedNodes         []string
+		expectedCompleted     bool
+		expectedSoakRemaining time.Duration
+	}{
+		{
+			name:             "update the first wave",
+			nodeToDaemonPods: newDaemonPods(true, "v2", "v1", "v1", "v1"),
+			expectedWave:     "canary",
+			expectedNodes:    []string{"n1", "n2"},
+		},
+		{
+			name:                  "soak the completed wave",
+			nodeToDaemonPods:      newDaemonPods(true, "v2", "v2", "v1", "v1"),
+			expectedWave:          "canary",
+			expectedNodes:         []string{"n1", "n2"},
+			expectedCompleted:     true,
+			expectedSoakRemaining: time.Minute,
+		},
+		{
+			name: "move to the next wave after soaking",
+			status: appsv1alpha1.DaemonSetStatus{
+				DaemonSetHash: "v2",
+				CurrentWave:   &appsv1alpha1.DaemonSetUpdateWaveStatus{Name: "canary", CompletedTime: &metav1.Time{Time: now.Add(-2 * time.Minute)}},
+			},
+			nodeToDaemonPods: newDaemonPods(true, "v2", "v2", "v1", "v1"),
+			expectedWave:     "general",
+			expectedNodes:    []string{"n3", "n4"},
+		},
+		{
+			name: "soaked wave is not blocking the next wave",
+			status: appsv1alpha1.DaemonSetStatus{
+				DaemonSetHash: "v2",
+				CurrentWave:   &appsv1alpha1.DaemonSetUpdateWaveStatus{Name: "general", Index: 1},
+			},
+			nodeToDaemonPods: newDaemonPods(false, "v2", "v2", "v2", "v1"),
+			expectedWave:     "general",
+			expectedNodes:    []string{"n3", "n4"},
+		},
+		{
+			name: "restart from the first wave for a new revision",
+			status: appsv1alpha1.DaemonSetStatus{
+				DaemonSetHash: "v1",
+				CurrentWave:   &appsv1alpha1.DaemonSetUpdateWaveStatus{Name: "general", Index: 1},
+			},
+			nodeToDaemonPods: newDaemonPods(true, "v1", "v1", "v1", "v1"),
+			expectedWave:     "canary",
+			expectedNodes:    []string{"n1", "n2"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ds := newDaemonSet("foo")
+			maxUnavailable := intstr.FromInt(1)
+			ds.Spec.UpdateStrategy.RollingUpdate = &appsv1alpha1.RollingUpdateDaemonSet{
+				Waves: []appsv1alpha1.DaemonSetUpdateWave{
+					{Name: "canary", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "canary"}}, SoakSeconds: 60},
+					{Name: "general", Selector: &metav1.LabelSelector{}, MaxUnavailable: &maxUnavailable},
+				},
+			}
+			ds.Status = tc.status
+
+			wave, err := dsc.getCurrentUpdateWave(ds, nodes, tc.nodeToDaemonPods, "v2", nil)
+			if err != nil {
+				t.Fatalf("failed to get current wave: %v", err)
+			}
+			if wave.status.Name != tc.expectedWave || !wave.nodes.HasAll(tc.expectedNodes...) || wave.nodes.Len() != len(tc.expectedNodes) ||
+				(wave.status.CompletedTime != nil) != tc.expectedCompleted || wave.soakRemaining != tc.expectedSoakRemaining {
+				t.Fatalf("unexpected wave %+v, nodes %v, soak remaining %v", wave.status, wave.nodes.List(), wave.soakRemaining)
+			}
+		})
+	}
+}
+
+func TestDaemonSetUpdatesPodsByWaves(t *testing.T) {
+	ds := newDaemonSet("foo")
+	manager, podControl, _, err := newTestController(ds)
+	if err != nil {
+		t.Fatalf("error creating DaemonSets controller: %v", err)
+	}
+	addNodes(manager.nodeStore, 0, 2, map[string]string{"pool": "canary"})
+	addNodes(manager.nodeStore, 2, 3, map[string]string{"pool": "general"})
+	manager.dsStore.Add(ds)
+	expectSyncDaemonSets(t, manager, ds, podControl, 5, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	maxUnavailable := intstr.FromInt(3)
+	ds.Spec.Template.Spec.Containers[0].Image = "foo2/bar2"
+	ds.Spec.UpdateStrategy.Type = appsv1alpha1.RollingUpdateDaemonSetStrategyType
+	ds.Spec.UpdateStrategy.RollingUpdate = &appsv1alpha1.RollingUpdateDaemonSet{
+		MaxUnavailable: &maxUnavailable,
+		Waves: []appsv1alpha1.DaemonSetUpdateWave{
+			{Name: "canary", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "canary"}}},
+			{Name: "general", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "general"}}},
+		},
+	}
+	manager.dsStore.Update(ds)
+
+	// only the canary pool is updated in the first wave
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 2, 0)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 2, 0, 0)
+
+	// wait for the canary pool to be available
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	// then the general pool
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 3, 0)
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 3, 0, 0)
+	markPodsReady(podControl.podStore)
+
+	clearExpectations(t, manager, ds, podControl)
+	expectSyncDaemonSets(t, manager, ds, podControl, 0, 0, 0)
+}
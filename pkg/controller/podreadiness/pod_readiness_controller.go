@@ -130,6 +130,10 @@ func (r *ReconcilePodReadiness) Reconcile(_ context.Context, request reconcile.R
 	if utilpodreadiness.GetReadinessCondition(pod) != nil {
 		return reconcile.Result{}, nil
 	}
+	// the new revision pods of BlueGreen update are kept not ready by the workload until switched
+	if pod.Annotations[appspub.BlueGreenHoldAnnotationKey] != "" {
+		return reconcile.Result{}, nil
+	}
 
 	// patch pod condition
 	status := v1.PodStatus{
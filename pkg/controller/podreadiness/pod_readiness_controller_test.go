@@ -43,7 +43,14 @@ func TestReconcile(t *testing.T) {
 			ReadinessGates: []v1.PodReadinessGate{},
 		},
 	}
-	fakeClient := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pod0, pod1).Build()
+	pod2 := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: metav1.NamespaceDefault, Name: "pod2",
+			Annotations: map[string]string{appspub.BlueGreenHoldAnnotationKey: "true"}},
+		Spec: v1.PodSpec{
+			ReadinessGates: []v1.PodReadinessGate{{ConditionType: appspub.KruisePodReadyConditionType}},
+		},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(pod0, pod1, pod2).Build()
 	reconciler := &ReconcilePodReadiness{Client: fakeClient}
 
 	_, err := reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pod0.Namespace, Name: pod0.Name}})
@@ -54,6 +61,10 @@ func TestReconcile(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	_, err = reconciler.Reconcile(context.TODO(), reconcile.Request{NamespacedName: types.NamespacedName{Namespace: pod2.Namespace, Name: pod2.Name}})
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	newPod0 := &v1.Pod{}
 	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: pod0.Namespace, Name: pod0.Name}, newPod0); err != nil {
@@ -72,4 +83,13 @@ func TestReconcile(t *testing.T) {
 	if condition != nil {
 		t.Fatalf("expect pod1 no ready, got %v", condition)
 	}
+
+	newPod2 := &v1.Pod{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: pod2.Namespace, Name: pod2.Name}, newPod2); err != nil {
+		t.Fatal(err)
+	}
+	condition = utilpodreadiness.GetReadinessCondition(newPod2)
+	if condition != nil {
+		t.Fatalf("expect pod2 held by BlueGreen no ready, got %v", condition)
+	}
 }
@@ -2,6 +2,7 @@ package sidecarset
 
 import (
 	"context"
+	"reflect"
 	"testing"
 
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
@@ -272,6 +273,173 @@ func testUpdateWhenMaxUnavailableNotZero(t *testing.T, sidecarSetInput *appsv1al
 	}
 }
 
+func TestUpdateNativeSidecarInitContainer(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	sidecarSetInput := sidecarSetDemo.DeepCopy()
+	sidecarSetInput.Spec.InitContainers = sidecarSetInput.Spec.Containers
+	sidecarSetInput.Spec.InitContainers[0].RestartPolicy = &always
+	sidecarSetInput.Spec.Containers = nil
+	podInput := podDemo.DeepCopy()
+	podInput.Spec.InitContainers = podInput.Spec.Containers[1:]
+	podInput.Spec.InitContainers[0].RestartPolicy = &always
+	podInput.Spec.Containers = podInput.Spec.Containers[:1]
+	podInput.Status.InitContainerStatuses = podInput.Status.ContainerStatuses[1:]
+	podInput.Status.ContainerStatuses = podInput.Status.ContainerStatuses[:1]
+	// clean the expectations of the same pod left by the other cases
+	sidecarcontrol.UpdateExpectations.DeleteObject(sidecarSetInput.Name, podInput)
+	request := reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Namespace: sidecarSetInput.Namespace,
+			Name:      sidecarSetInput.Name,
+		},
+	}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(sidecarSetInput, podInput).
+		WithStatusSubresource(&appsv1alpha1.SidecarSet{}).Build()
+	reconciler := ReconcileSidecarSet{
+		Client:    fakeClient,
+		processor: NewSidecarSetProcessor(fakeClient, record.NewFakeRecorder(10)),
+	}
+	if _, err := reconciler.Reconcile(context.TODO(), request); err != nil {
+		t.Errorf("reconcile failed, err: %v", err)
+	}
+
+	podOutput, err := getLatestPod(fakeClient, podInput)
+	if err != nil {
+		t.Errorf("get latest pod failed, err: %v", err)
+	}
+	if image := podOutput.Spec.InitContainers[0].Image; image != "test-image:v2" {
+		t.Errorf("expect native sidecar image test-image:v2, but got %s", image)
+	}
+}
+
+func TestUpdateHotUpgradeNativeSidecarInitContainer(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	sidecarSetInput := sidecarSetHotUpgrade.DeepCopy()
+	sidecarSetInput.Spec.InitContainers = sidecarSetInput.Spec.Containers
+	sidecarSetInput.Spec.InitContainers[0].RestartPolicy = &always
+	sidecarSetInput.Spec.Containers = nil
+	podInput := podHotUpgrade.DeepCopy()
+	podInput.Name = "test-native-sidecar-pod"
+	podInput.Spec.InitContainers = append([]corev1.Container{}, podInput.Spec.Containers[1:]...)
+	for i := range podInput.Spec.InitContainers {
+		podInput.Spec.InitContainers[i].RestartPolicy = &always
+	}
+	podInput.Spec.Containers = podInput.Spec.Containers[:1]
+	podInput.Status.InitContainerStatuses = append([]corev1.ContainerStatus{}, podInput.Status.ContainerStatuses[1:]...)
+	podInput.Status.ContainerStatuses = podInput.Status.ContainerStatuses[:1]
+	// clean the expectations of the sidecarset left by the other cases
+	sidecarcontrol.UpdateExpectations.DeleteExpectations(sidecarSetInput.Name)
+
+	getInitContainerImage := func(pod *corev1.Pod, name string) string {
+		for _, container := range pod.Spec.InitContainers {
+			if container.Name == name {
+				return container.Image
+			}
+		}
+		return ""
+	}
+	cases := []struct {
+		name string
+		// init container name -> image
+		expectedImages map[string]string
+		// the working container after reconciling
+		expectedWorking string
+		// MatchedPods, UpdatedPods, ReadyPods, UpdatedReadyPods
+		expectedStatus []int32
+		// handle the pod after reconciling, as if kubelet has run the containers
+		handle func(pod *corev1.Pod)
+	}{
+		{
+			name:            "the empty native sidecar is upgraded to the new image",
+			expectedImages:  map[string]string{"test-sidecar-1": "test-image:v1", "test-sidecar-2": "test-image:v2"},
+			expectedWorking: "test-sidecar-2",
+			expectedStatus:  []int32{1, 0, 1, 0},
+			handle: func(pod *corev1.Pod) {
+				pod.Status.InitContainerStatuses[1].Image = "test-image:v2"
+				pod.Status.InitContainerStatuses[1].ImageID = testImageV2ImageID
+			},
+		},
+		{
+			name:            "the new native sidecar is running, and reset the old one to the empty image",
+			expectedImages:  map[string]string{"test-sidecar-1": hotUpgradeEmptyImage, "test-sidecar-2": "test-image:v2"},
+			expectedWorking: "test-sidecar-2",
+			expectedStatus:  []int32{1, 1, 0, 0},
+			handle: func(pod *corev1.Pod) {
+				pod.Status.InitContainerStatuses[0].Image = hotUpgradeEmptyImage
+				pod.Status.InitContainerStatuses[0].ImageID = hotUpgradeEmptyImageID
+			},
+		},
+		{
+			name:            "the native sidecar hot upgrade is completed",
+			expectedImages:  map[string]string{"test-sidecar-1": hotUpgradeEmptyImage, "test-sidecar-2": "test-image:v2"},
+			expectedWorking: "test-sidecar-2",
+			expectedStatus:  []int32{1, 1, 1, 1},
+		},
+	}
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			sidecarSet := sidecarSetInput.DeepCopy()
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sidecarSet, podInput.DeepCopy()).
+				WithStatusSubresource(&appsv1alpha1.SidecarSet{}).Build()
+			processor := NewSidecarSetProcessor(fakeClient, record.NewFakeRecorder(10))
+			if _, err := processor.UpdateSidecarSet(sidecarSet); err != nil {
+				t.Fatalf("processor update sidecarset failed: %s", err.Error())
+			}
+			podOutput, err := getLatestPod(fakeClient, podInput)
+			if err != nil {
+				t.Fatalf("get latest pod failed: %s", err.Error())
+			}
+			for name, image := range cs.expectedImages {
+				if actual := getInitContainerImage(podOutput, name); actual != image {
+					t.Fatalf("expect init container(%s) image(%s), but got image(%s)", name, image, actual)
+				}
+			}
+			if working, _ := sidecarcontrol.GetPodHotUpgradeContainers("test-sidecar", podOutput); working != cs.expectedWorking {
+				t.Fatalf("expect working container(%s), but got(%s)", cs.expectedWorking, working)
+			}
+			sidecarSetOutput, err := getLatestSidecarSet(fakeClient, sidecarSet)
+			if err != nil {
+				t.Fatalf("get latest sidecarset failed: %s", err.Error())
+			}
+			status := sidecarSetOutput.Status
+			if actual := []int32{status.MatchedPods, status.UpdatedPods, status.ReadyPods, status.UpdatedReadyPods}; !reflect.DeepEqual(actual, cs.expectedStatus) {
+				t.Fatalf("expect sidecarset status(%v), but got(%v)", cs.expectedStatus, actual)
+			}
+
+			sidecarSetInput = sidecarSetOutput.DeepCopy()
+			podInput = podOutput.DeepCopy()
+			if cs.handle != nil {
+				cs.handle(podInput)
+			}
+		})
+	}
+
+	// the pod is hot upgrading ready only if the native sidecars except the empty one are ready
+	pod := podInput.DeepCopy()
+	pod.Status.Conditions = nil
+	pod.Status.InitContainerStatuses[0].Ready = false
+	if !isHotUpgradingReady(sidecarSetInput, pod) {
+		t.Fatalf("expect hot upgrading ready with the empty native sidecar not ready")
+	}
+	pod.Status.InitContainerStatuses[1].Ready = false
+	if isHotUpgradingReady(sidecarSetInput, pod) {
+		t.Fatalf("expect hot upgrading not ready with the working native sidecar not ready")
+	}
+
+	// the native sidecar which is not working is reset to the empty image by the flip
+	pod = podInput.DeepCopy()
+	pod.Spec.InitContainers[0].Image = "test-image:v1"
+	flipPodSidecarContainerDo(sidecarcontrol.New(sidecarSetInput), pod)
+	if image := getInitContainerImage(pod, "test-sidecar-1"); image != hotUpgradeEmptyImage {
+		t.Fatalf("expect init container(test-sidecar-1) reset to empty image, but got image(%s)", image)
+	}
+	if image := getInitContainerImage(pod, "test-sidecar-2"); image != "test-image:v2" {
+		t.Fatalf("expect init container(test-sidecar-2) image(test-image:v2), but got image(%s)", image)
+	}
+}
+
 func TestUpdateWhenPartitionFinished(t *testing.T) {
 	sidecarSetInput := sidecarSetDemo.DeepCopy()
 	testUpdateWhenPartitionFinished(t, sidecarSetInput)
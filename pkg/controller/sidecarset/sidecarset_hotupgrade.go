@@ -69,13 +69,17 @@ func (p *Processor) flipPodSidecarContainer(control sidecarcontrol.SidecarContro
 func flipPodSidecarContainerDo(control sidecarcontrol.SidecarControl, pod *corev1.Pod) {
 	sidecarSet := control.GetSidecarset()
 	containersInPod := make(map[string]*corev1.Container)
+	for i := range pod.Spec.InitContainers {
+		container := &pod.Spec.InitContainers[i]
+		containersInPod[container.Name] = container
+	}
 	for i := range pod.Spec.Containers {
 		container := &pod.Spec.Containers[i]
 		containersInPod[container.Name] = container
 	}
 
 	var changedContainer []string
-	for _, sidecarContainer := range sidecarSet.Spec.Containers {
+	for _, sidecarContainer := range sidecarcontrol.GetUpdatableSidecarContainers(sidecarSet) {
 		if sidecarcontrol.IsHotUpgradeContainer(&sidecarContainer) {
 			workContainer, emptyContainer := sidecarcontrol.GetPodHotUpgradeContainers(sidecarContainer.Name, pod)
 			if containersInPod[emptyContainer].Image == sidecarContainer.UpgradeStrategy.HotUpgradeEmptyImage {
@@ -97,7 +101,7 @@ func flipPodSidecarContainerDo(control sidecarcontrol.SidecarControl, pod *corev
 }
 
 func isSidecarSetHasHotUpgradeContainer(sidecarSet *appsv1alpha1.SidecarSet) bool {
-	for _, sidecarContainer := range sidecarSet.Spec.Containers {
+	for _, sidecarContainer := range sidecarcontrol.GetUpdatableSidecarContainers(sidecarSet) {
 		if sidecarcontrol.IsHotUpgradeContainer(&sidecarContainer) {
 			return true
 		}
@@ -111,14 +115,14 @@ func isHotUpgradingReady(sidecarSet *appsv1alpha1.SidecarSet, pod *corev1.Pod) b
 	}
 
 	emptyContainers := sets.NewString()
-	for _, sidecarContainer := range sidecarSet.Spec.Containers {
+	for _, sidecarContainer := range sidecarcontrol.GetUpdatableSidecarContainers(sidecarSet) {
 		if sidecarcontrol.IsHotUpgradeContainer(&sidecarContainer) {
 			_, emptyContainer := sidecarcontrol.GetPodHotUpgradeContainers(sidecarContainer.Name, pod)
 			emptyContainers.Insert(emptyContainer)
 		}
 	}
 
-	for _, containerStatus := range pod.Status.ContainerStatuses {
+	for _, containerStatus := range sidecarcontrol.GetPodRunningContainerStatuses(pod) {
 		// ignore empty sidecar container status
 		if emptyContainers.Has(containerStatus.Name) {
 			continue
@@ -136,11 +140,11 @@ func isHotUpgradingReady(sidecarSet *appsv1alpha1.SidecarSet, pod *corev1.Pod) b
 // then Pod is in hotUpgrading and return true
 func isPodSidecarInHotUpgrading(sidecarSet *appsv1alpha1.SidecarSet, pod *corev1.Pod) bool {
 	containerImage := make(map[string]string)
-	for _, container := range pod.Spec.Containers {
+	for _, container := range sidecarcontrol.GetPodRunningContainers(pod) {
 		containerImage[container.Name] = container.Image
 	}
 
-	for _, sidecar := range sidecarSet.Spec.Containers {
+	for _, sidecar := range sidecarcontrol.GetUpdatableSidecarContainers(sidecarSet) {
 		if sidecarcontrol.IsHotUpgradeContainer(&sidecar) {
 			_, emptyContainer := sidecarcontrol.GetPodHotUpgradeContainers(sidecar.Name, pod)
 			if containerImage[emptyContainer] != sidecar.UpgradeStrategy.HotUpgradeEmptyImage {
@@ -37,6 +37,7 @@ import (
 
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
@@ -88,6 +89,15 @@ func (p *Processor) UpdateSidecarSet(sidecarSet *appsv1alpha1.SidecarSet) (recon
 
 	// 2. calculate SidecarSet status based on pod and revision information
 	status := calculateStatus(control, pods, latestRevision, collisionCount)
+	// find the current stage of the rolling update before the status is updated
+	stage, err := p.getCurrentUpdateStage(control, pods, latestRevision.Name)
+	if err != nil {
+		klog.ErrorS(err, "SidecarSet get current update stage error", "sidecarSet", klog.KObj(sidecarSet))
+		return reconcile.Result{}, err
+	}
+	if stage != nil {
+		status.CurrentStage = stage.status
+	}
 	//update sidecarSet status in store
 	if err := p.updateSidecarSetStatus(sidecarSet, status); err != nil {
 		return reconcile.Result{}, err
@@ -115,7 +125,7 @@ func (p *Processor) UpdateSidecarSet(sidecarSet *appsv1alpha1.SidecarSet) (recon
 
 			// don't contain sidecar empty containers
 			sidecarContainers := sidecarcontrol.GetSidecarContainersInPod(sidecarSet)
-			for _, sidecarContainer := range sidecarSet.Spec.Containers {
+			for _, sidecarContainer := range sidecarcontrol.GetUpdatableSidecarContainers(sidecarSet) {
 				if sidecarcontrol.IsHotUpgradeContainer(&sidecarContainer) {
 					_, emptyContainer := sidecarcontrol.GetPodHotUpgradeContainers(sidecarContainer.Name, pod)
 					sidecarContainers.Delete(emptyContainer)
@@ -148,17 +158,23 @@ func (p *Processor) UpdateSidecarSet(sidecarSet *appsv1alpha1.SidecarSet) (recon
 		return reconcile.Result{}, nil
 	}
 
-	// 7. upgrade pod sidecar
-	if err := p.updatePods(control, pods); err != nil {
+	// 7. wait for the completed stage to soak before the next stage begins
+	if stage != nil && stage.soakRemaining > 0 {
+		klog.V(3).InfoS("SidecarSet was soaking the completed stage", "sidecarSet", klog.KObj(sidecarSet), "stage", stage.stage.Name, "remaining", stage.soakRemaining)
+		return reconcile.Result{RequeueAfter: stage.soakRemaining}, nil
+	}
+
+	// 8. upgrade pod sidecar, only the pods in the current stage if any
+	if err := p.updatePods(control, pods, stage.podFilter(sidecarSet.Spec.UpdateStrategy.Stages)); err != nil {
 		return reconcile.Result{}, err
 	}
 	return reconcile.Result{}, nil
 }
 
-func (p *Processor) updatePods(control sidecarcontrol.SidecarControl, pods []*corev1.Pod) error {
+func (p *Processor) updatePods(control sidecarcontrol.SidecarControl, pods []*corev1.Pod, inStage func(pod *corev1.Pod) bool) error {
 	sidecarset := control.GetSidecarset()
 	// compute next updated pods based on the sidecarset upgrade strategy
-	upgradePods, notUpgradablePods := NewStrategy().GetNextUpgradePods(control, pods)
+	upgradePods, notUpgradablePods := NewStrategy().GetNextUpgradePods(control, pods, inStage)
 	for _, pod := range notUpgradablePods {
 		if err := p.updatePodSidecarSetUpgradableCondition(sidecarset, pod, false); err != nil {
 			klog.ErrorS(err, "Failed to update NotUpgradable PodCondition", "sidecarSet", klog.KObj(sidecarset), "pod", klog.KObj(pod))
@@ -552,6 +568,12 @@ func isSidecarSetNotUpdate(s *appsv1alpha1.SidecarSet) bool {
 }
 
 func updateContainerInPod(container corev1.Container, pod *corev1.Pod) {
+	for i := range pod.Spec.InitContainers {
+		if pod.Spec.InitContainers[i].Name == container.Name {
+			pod.Spec.InitContainers[i] = container
+			return
+		}
+	}
 	for i := range pod.Spec.Containers {
 		if pod.Spec.Containers[i].Name == container.Name {
 			pod.Spec.Containers[i] = container
@@ -565,7 +587,8 @@ func updatePodSidecarContainer(control sidecarcontrol.SidecarControl, pod *corev
 
 	// upgrade sidecar containers
 	var changedContainers []string
-	for _, sidecarContainer := range sidecarSet.Spec.Containers {
+	// the native sidecar initContainers are upgraded as well as the containers
+	for _, sidecarContainer := range sidecarcontrol.GetUpdatableSidecarContainers(sidecarSet) {
 		//sidecarContainer := &sidecarset.Spec.Containers[i]
 		// volumeMounts that injected into sidecar container
 		// when volumeMounts SubPathExpr contains expansions, then need copy container EnvVars(injectEnvs)
@@ -626,7 +649,8 @@ func inconsistentStatus(sidecarSet *appsv1alpha1.SidecarSet, status *appsv1alpha
 		status.ReadyPods != sidecarSet.Status.ReadyPods ||
 		status.UpdatedReadyPods != sidecarSet.Status.UpdatedReadyPods ||
 		status.LatestRevision != sidecarSet.Status.LatestRevision ||
-		!pointer.Int32Equal(sidecarSet.Status.CollisionCount, status.CollisionCount)
+		!pointer.Int32Equal(sidecarSet.Status.CollisionCount, status.CollisionCount) ||
+		!apiequality.Semantic.DeepEqual(sidecarSet.Status.CurrentStage, status.CurrentStage)
 }
 
 func isSidecarSetUpdateFinish(status *appsv1alpha1.SidecarSetStatus) bool {
@@ -19,11 +19,12 @@ type Strategy interface {
 	//1. select which pods can be upgrade, the following:
 	//	* pod must be not updated for the latest sidecarSet
 	//	* If selector is not nil, this upgrade will only update the selected pods.
+	//	* If inStage is not nil, this upgrade will only update the pods in the current stage.
 	//2. Sort Pods with default sequence
 	//3. sort waitUpdateIndexes based on the scatter rules
-	//4. calculate max count of pods can update with maxUnavailable
+	//4. calculate max count of pods can update with maxUnavailable, against all the pods
 	//5. also return the pods that are not upgradable
-	GetNextUpgradePods(control sidecarcontrol.SidecarControl, pods []*corev1.Pod) (upgradePods []*corev1.Pod, notUpgradablePods []*corev1.Pod)
+	GetNextUpgradePods(control sidecarcontrol.SidecarControl, pods []*corev1.Pod, inStage func(pod *corev1.Pod) bool) (upgradePods []*corev1.Pod, notUpgradablePods []*corev1.Pod)
 }
 
 type spreadingStrategy struct{}
@@ -36,7 +37,7 @@ func NewStrategy() Strategy {
 	return globalSpreadingStrategy
 }
 
-func (p *spreadingStrategy) GetNextUpgradePods(control sidecarcontrol.SidecarControl, pods []*corev1.Pod) (upgradePods []*corev1.Pod, notUpgradablePods []*corev1.Pod) {
+func (p *spreadingStrategy) GetNextUpgradePods(control sidecarcontrol.SidecarControl, pods []*corev1.Pod, inStage func(pod *corev1.Pod) bool) (upgradePods []*corev1.Pod, notUpgradablePods []*corev1.Pod) {
 	sidecarset := control.GetSidecarset()
 	// wait to upgrade pod index
 	var waitUpgradedIndexes []int
@@ -46,6 +47,10 @@ func (p *spreadingStrategy) GetNextUpgradePods(control sidecarcontrol.SidecarCon
 
 	// If selector is not nil, check whether the pods is selected to upgrade
 	isSelected := func(pod *corev1.Pod) bool {
+		// the pods not in the current stage are not selected
+		if inStage != nil && !inStage(pod) {
+			return false
+		}
 		//when selector is nil, always return true
 		if strategy.Selector == nil {
 			return true
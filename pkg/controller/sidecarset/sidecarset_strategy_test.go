@@ -361,7 +361,7 @@ func testGetNextUpgradePods(t *testing.T, factoryPods FactoryPods, factorySideca
 		t.Run(cs.name, func(t *testing.T) {
 			control := sidecarcontrol.New(cs.getSidecarset())
 			pods := cs.getPods()
-			upgradePods, notUpgradablePods := strategy.GetNextUpgradePods(control, pods)
+			upgradePods, notUpgradablePods := strategy.GetNextUpgradePods(control, pods, nil)
 			if cs.exceptNeedUpgradeCount != len(upgradePods) {
 				t.Fatalf("except NeedUpgradeCount(%d), but get value(%d)", cs.exceptNeedUpgradeCount, len(upgradePods))
 			}
@@ -372,6 +372,52 @@ func testGetNextUpgradePods(t *testing.T, factoryPods FactoryPods, factorySideca
 	}
 }
 
+func TestGetNextUpgradePodsInStage(t *testing.T) {
+	cases := []struct {
+		name                   string
+		maxUnavailable         intstr.IntOrString
+		exceptNeedUpgradeCount int
+	}{
+		{
+			name:                   "maxUnavailable(int=3) counts the unavailable pods out of the stage",
+			maxUnavailable:         intstr.FromInt(3),
+			exceptNeedUpgradeCount: 1,
+		},
+		{
+			name:                   "maxUnavailable(percent=50%) is calculated against all the pods",
+			maxUnavailable:         intstr.FromString("50%"),
+			exceptNeedUpgradeCount: 3,
+		},
+	}
+	strategy := NewStrategy()
+	for _, cs := range cases {
+		t.Run(cs.name, func(t *testing.T) {
+			sidecarSet := factorySidecarSet()
+			sidecarSet.Spec.UpdateStrategy.MaxUnavailable = &cs.maxUnavailable
+			control := sidecarcontrol.New(sidecarSet)
+			// pod-0 and pod-1 are upgraded but not ready, and only the last 5 pods are in the stage
+			pods := factoryPods(10, 2, 0)
+			inStage := func(pod *corev1.Pod) bool {
+				for _, p := range pods[5:] {
+					if p.Name == pod.Name {
+						return true
+					}
+				}
+				return false
+			}
+			upgradePods, _ := strategy.GetNextUpgradePods(control, pods, inStage)
+			if cs.exceptNeedUpgradeCount != len(upgradePods) {
+				t.Fatalf("except NeedUpgradeCount(%d), but get value(%d)", cs.exceptNeedUpgradeCount, len(upgradePods))
+			}
+			for _, pod := range upgradePods {
+				if !inStage(pod) {
+					t.Fatalf("except pods in the stage upgraded, but get pod(%s)", pod.Name)
+				}
+			}
+		})
+	}
+}
+
 func TestParseUpdateScatterTerms(t *testing.T) {
 	cases := []struct {
 		name                  string
@@ -630,7 +676,7 @@ func testSortNextUpgradePods(t *testing.T, factoryPods FactoryPods, factorySidec
 		t.Run(cs.name, func(t *testing.T) {
 			control := sidecarcontrol.New(cs.getSidecarset())
 			pods := cs.getPods()
-			injectedPods, _ := strategy.GetNextUpgradePods(control, pods)
+			injectedPods, _ := strategy.GetNextUpgradePods(control, pods, nil)
 			if len(cs.exceptNextUpgradePods) != len(injectedPods) {
 				t.Fatalf("except NeedUpgradeCount(%d), but get value(%d)", len(cs.exceptNextUpgradePods), len(injectedPods))
 			}
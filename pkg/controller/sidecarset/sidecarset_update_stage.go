@@ -0,0 +1,206 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"context"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	"github.com/openkruise/kruise/pkg/control/sidecarcontrol"
+)
+
+// updateStage is the stage of the rolling update that is being updated or soaking.
+type updateStage struct {
+	status *appsv1alpha1.SidecarSetUpdateStageStatus
+	stage  *appsv1alpha1.SidecarSetUpdateStage
+	// pods are the keys of the pods in the stage.
+	pods sets.String
+	// soakRemaining is the time to wait before the next stage begins.
+	soakRemaining time.Duration
+}
+
+// finished returns true if all the stages are completed and soaked,
+// so that the pods matched by none of the stages can be updated.
+func (s *updateStage) finished(stages []appsv1alpha1.SidecarSetUpdateStage) bool {
+	return int(s.status.Index) == len(stages)-1 && s.status.CompletedTime != nil && s.soakRemaining <= 0
+}
+
+// podFilter returns the function checking whether the pod is allowed to be updated in the stage,
+// or nil if all the pods are allowed.
+func (s *updateStage) podFilter(stages []appsv1alpha1.SidecarSetUpdateStage) func(pod *corev1.Pod) bool {
+	if s == nil || s.finished(stages) {
+		return nil
+	}
+	return func(pod *corev1.Pod) bool {
+		return s.pods.Has(podKey(pod))
+	}
+}
+
+func podKey(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
+
+// getCurrentUpdateStage returns the first stage which still has pods not updated or not ready,
+// or the last completed stage whose soak time has not passed yet. It returns nil if there is no stage.
+func (p *Processor) getCurrentUpdateStage(control sidecarcontrol.SidecarControl, pods []*corev1.Pod, latestRevision string) (*updateStage, error) {
+	sidecarSet := control.GetSidecarset()
+	stages := sidecarSet.Spec.UpdateStrategy.Stages
+	if len(stages) == 0 {
+		return nil, nil
+	}
+
+	// the index of the stage recorded in status for the same revision, which means the stages before it have soaked
+	recordedIndex := -1
+	if cur := sidecarSet.Status.CurrentStage; cur != nil && sidecarSet.Status.LatestRevision == latestRevision {
+		for i := range stages {
+			if stages[i].Name == cur.Name {
+				recordedIndex = i
+				break
+			}
+		}
+	}
+
+	matcher := &stageMatcher{client: p.Client, workloads: map[types.NamespacedName]metav1.OwnerReference{}}
+	now := time.Now()
+	assigned := sets.NewString()
+	var current *updateStage
+	for i := range stages {
+		stage := &stages[i]
+		current = &updateStage{
+			status: &appsv1alpha1.SidecarSetUpdateStageStatus{Name: stage.Name, Index: int32(i)},
+			stage:  stage,
+			pods:   sets.NewString(),
+		}
+		soaked := recordedIndex > i
+		completed := true
+		for _, pod := range pods {
+			key := podKey(pod)
+			if assigned.Has(key) {
+				continue
+			}
+			matched, err := matcher.matches(stage, pod)
+			if err != nil {
+				return nil, err
+			} else if !matched {
+				continue
+			}
+			assigned.Insert(key)
+			current.pods.Insert(key)
+			switch {
+			case !sidecarcontrol.IsPodSidecarUpdated(sidecarSet, pod):
+				completed = false
+			case soaked:
+				// the stages already soaked only need their pods to be updated,
+				// so that a pod becoming not ready later will not block the next stages
+			case !control.IsPodStateConsistent(pod, nil) || !control.IsPodReady(pod):
+				completed = false
+			}
+		}
+		if !completed {
+			return current, nil
+		}
+
+		completedTime := now
+		if cur := sidecarSet.Status.CurrentStage; recordedIndex == i && cur.CompletedTime != nil {
+			completedTime = cur.CompletedTime.Time
+		}
+		current.status.CompletedTime = &metav1.Time{Time: completedTime}
+		if soaked {
+			continue
+		}
+		if remaining := completedTime.Add(time.Duration(stage.SoakSeconds) * time.Second).Sub(now); remaining > 0 {
+			current.soakRemaining = remaining
+			return current, nil
+		}
+	}
+	return current, nil
+}
+
+// stageMatcher matches the pods against the stages, caching the workloads owning the ReplicaSets.
+type stageMatcher struct {
+	client    client.Client
+	workloads map[types.NamespacedName]metav1.OwnerReference
+}
+
+func (m *stageMatcher) matches(stage *appsv1alpha1.SidecarSetUpdateStage, pod *corev1.Pod) (bool, error) {
+	for _, ns := range stage.Namespaces {
+		if ns == pod.Namespace {
+			return true, nil
+		}
+	}
+	if stage.NamespaceSelector != nil && sidecarcontrol.IsSelectorNamespace(m.client, pod.Namespace, stage.NamespaceSelector) {
+		return true, nil
+	}
+	if len(stage.Workloads) == 0 {
+		return false, nil
+	}
+	owner, err := m.getPodWorkload(pod)
+	if err != nil || owner == nil {
+		return false, err
+	}
+	ownerGV, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return false, nil
+	}
+	for _, workload := range stage.Workloads {
+		gv, err := schema.ParseGroupVersion(workload.APIVersion)
+		if err != nil {
+			continue
+		}
+		if gv.Group == ownerGV.Group && workload.Kind == owner.Kind &&
+			workload.Namespace == pod.Namespace && workload.Name == owner.Name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// getPodWorkload returns the workload owning the pod, which is the Deployment if the pod is owned by its ReplicaSet.
+func (m *stageMatcher) getPodWorkload(pod *corev1.Pod) (*metav1.OwnerReference, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "ReplicaSet" || owner.APIVersion != apps.SchemeGroupVersion.String() {
+		return owner, nil
+	}
+
+	key := types.NamespacedName{Namespace: pod.Namespace, Name: owner.Name}
+	if workload, ok := m.workloads[key]; ok {
+		return &workload, nil
+	}
+	rs := &apps.ReplicaSet{}
+	if err := m.client.Get(context.TODO(), key, rs); err != nil {
+		if errors.IsNotFound(err) {
+			return owner, nil
+		}
+		return nil, err
+	}
+	workload := owner
+	if rsOwner := metav1.GetControllerOf(rs); rsOwner != nil {
+		workload = rsOwner
+	}
+	m.workloads[key] = *workload
+	return workload, nil
+}
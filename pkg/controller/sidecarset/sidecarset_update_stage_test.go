@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
+	"github.com/openkruise/kruise/pkg/control/sidecarcontrol"
+)
+
+func TestUpdateSidecarSetByStages(t *testing.T) {
+	sidecarSetInput := sidecarSetDemo.DeepCopy()
+	sidecarSetInput.Name = "test-stage-sidecarset"
+	maxUnavailable := intstr.FromInt(10)
+	sidecarSetInput.Spec.UpdateStrategy.MaxUnavailable = &maxUnavailable
+	sidecarSetInput.Spec.UpdateStrategy.Stages = []appsv1alpha1.SidecarSetUpdateStage{
+		{Name: "canary", Namespaces: []string{"canary"}, SoakSeconds: 3600},
+		{Name: "web", Workloads: []appsv1alpha1.SidecarSetUpdateStageWorkload{{APIVersion: "apps/v1", Kind: "Deployment", Namespace: "default", Name: "web"}}},
+	}
+
+	newPod := func(namespace, name string) *corev1.Pod {
+		pod := podDemo.DeepCopy()
+		pod.Namespace = namespace
+		pod.Name = name
+		pod.Annotations = map[string]string{
+			sidecarcontrol.SidecarSetHashAnnotation: `{"test-stage-sidecarset":{"hash":"aaa","sidecarList":["test-sidecar"]}}`,
+			sidecarcontrol.SidecarSetListAnnotation: "test-stage-sidecarset",
+		}
+		return pod
+	}
+	canaryPod := newPod("canary", "canary-pod")
+	webPod := newPod("default", "web-pod")
+	webPod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-6d4f8c", UID: "rs-uid", Controller: pointer.Bool(true)},
+	}
+	webReplicaSet := &apps.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      "web-6d4f8c",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deploy-uid", Controller: pointer.Bool(true)},
+		},
+	}}
+	otherPod := newPod("default", "other-pod")
+	// the Deployment of the same name in another namespace is not in the web stage
+	otherWebPod := newPod("other", "web-pod")
+	otherWebPod.OwnerReferences = []metav1.OwnerReference{
+		{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7b9c5d", UID: "other-rs-uid", Controller: pointer.Bool(true)},
+	}
+	otherWebReplicaSet := &apps.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "other",
+		Name:      "web-7b9c5d",
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "other-deploy-uid", Controller: pointer.Bool(true)},
+		},
+	}}
+
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(sidecarSetInput, canaryPod, webPod, webReplicaSet, otherPod, otherWebPod, otherWebReplicaSet).
+		WithStatusSubresource(&appsv1alpha1.SidecarSet{}).Build()
+	reconciler := ReconcileSidecarSet{
+		Client:    fakeClient,
+		processor: NewSidecarSetProcessor(fakeClient, record.NewFakeRecorder(10)),
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: sidecarSetInput.Name}}
+	reconcileAndCheck := func(expectedStage string, expectedUpdated ...*corev1.Pod) reconcile.Result {
+		result, err := reconciler.Reconcile(context.TODO(), request)
+		if err != nil {
+			t.Fatalf("reconcile failed, err: %v", err)
+		}
+		updated := map[string]bool{}
+		for _, pod := range expectedUpdated {
+			updated[podKey(pod)] = true
+		}
+		for _, pod := range []*corev1.Pod{canaryPod, webPod, otherPod, otherWebPod} {
+			podOutput, err := getLatestPod(fakeClient, pod)
+			if err != nil {
+				t.Fatalf("get latest pod failed, err: %v", err)
+			}
+			if isSidecarImageUpdated(podOutput, "test-sidecar", "test-image:v2") != updated[podKey(pod)] {
+				t.Fatalf("expect pod %s updated %v", podKey(pod), updated[podKey(pod)])
+			}
+		}
+		sidecarSetOutput, err := getLatestSidecarSet(fakeClient, sidecarSetInput)
+		if err != nil {
+			t.Fatalf("get latest sidecarSet failed, err: %v", err)
+		}
+		if stage := sidecarSetOutput.Status.CurrentStage; stage == nil || stage.Name != expectedStage {
+			t.Fatalf("expect current stage %s, but got %v", expectedStage, stage)
+		}
+		return result
+	}
+	// simulate the kubelet which has restarted the sidecar container with the new image
+	runNewImage := func(pod *corev1.Pod) {
+		podOutput, err := getLatestPod(fakeClient, pod)
+		if err != nil {
+			t.Fatalf("get latest pod failed, err: %v", err)
+		}
+		podOutput.Status.ContainerStatuses[1].Image = "test-image:v2"
+		podOutput.Status.ContainerStatuses[1].ImageID = "docker-pullable://test-image@sha256:b9286defaba7b3a519d585ba0e37d0b2cbee74ebfe590960b0b1d6a5e97d1e1d"
+		if err := fakeClient.Status().Update(context.TODO(), podOutput); err != nil {
+			t.Fatalf("update pod status failed, err: %v", err)
+		}
+	}
+
+	// only the canary namespace is updated in the first stage
+	reconcileAndCheck("canary", canaryPod)
+	// the next stage waits for the canary stage to soak
+	runNewImage(canaryPod)
+	if result := reconcileAndCheck("canary", canaryPod); result.RequeueAfter <= 0 {
+		t.Fatalf("expect requeue after the soak time, but got %v", result)
+	}
+
+	// the web workload is updated after the canary stage soaked
+	sidecarSetOutput, err := getLatestSidecarSet(fakeClient, sidecarSetInput)
+	if err != nil {
+		t.Fatalf("get latest sidecarSet failed, err: %v", err)
+	}
+	sidecarSetOutput.Status.CurrentStage.CompletedTime = &metav1.Time{Time: time.Now().Add(-2 * time.Hour)}
+	if err := fakeClient.Status().Update(context.TODO(), sidecarSetOutput); err != nil {
+		t.Fatalf("update sidecarSet status failed, err: %v", err)
+	}
+	reconcileAndCheck("web", canaryPod, webPod)
+
+	// the pods matched by none of the stages are updated after all the stages completed
+	runNewImage(webPod)
+	reconcileAndCheck("web", canaryPod, webPod, otherPod, otherWebPod)
+}
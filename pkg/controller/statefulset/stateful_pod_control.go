@@ -26,6 +26,8 @@ import (
 	storagev1 "k8s.io/api/storage/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
 	errorutils "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientset "k8s.io/client-go/kubernetes"
@@ -34,6 +36,7 @@ import (
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/retry"
 	"k8s.io/klog/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
 	"github.com/openkruise/kruise/pkg/features"
@@ -50,7 +53,12 @@ type StatefulPodControlObjectManager interface {
 	CreateClaim(claim *v1.PersistentVolumeClaim) error
 	GetClaim(namespace, claimName string) (*v1.PersistentVolumeClaim, error)
 	UpdateClaim(claim *v1.PersistentVolumeClaim) error
+	DeleteClaim(claim *v1.PersistentVolumeClaim) error
 	GetStorageClass(scName string) (*storagev1.StorageClass, error)
+	GetNode(nodeName string) (*v1.Node, error)
+	CreateVolumeSnapshot(snapshot *unstructured.Unstructured) error
+	GetVolumeSnapshot(namespace, snapshotName string) (*unstructured.Unstructured, error)
+	DeleteVolumeSnapshot(snapshot *unstructured.Unstructured) error
 }
 
 // StatefulPodControl defines the interface that StatefulSetController uses to create, update, and delete Pods,
@@ -60,16 +68,39 @@ type StatefulPodControl struct {
 	recorder  record.EventRecorder
 }
 
+// StatefulPodControlOption configures the optional dependencies of the realStatefulPodControlObjectManager.
+type StatefulPodControlOption func(om *realStatefulPodControlObjectManager)
+
+// WithNodeLister sets the lister to get the nodes of the Pods, which is required by the topology domain update.
+func WithNodeLister(nodeLister corelisters.NodeLister) StatefulPodControlOption {
+	return func(om *realStatefulPodControlObjectManager) {
+		om.nodeLister = nodeLister
+	}
+}
+
+// WithSnapshotClient sets the client to manage the VolumeSnapshots, which is required by the Migrate volume claim
+// update strategy.
+func WithSnapshotClient(snapshotClient client.Client) StatefulPodControlOption {
+	return func(om *realStatefulPodControlObjectManager) {
+		om.snapshotClient = snapshotClient
+	}
+}
+
 // NewStatefulPodControl constructs a StatefulPodControl using a realStatefulPodControlObjectManager with the given
-// clientset, listers and EventRecorder.
+// clientset, listers, EventRecorder and options.
 func NewStatefulPodControl(
 	client clientset.Interface,
 	podLister corelisters.PodLister,
 	claimLister corelisters.PersistentVolumeClaimLister,
 	scLister storagelisters.StorageClassLister,
 	recorder record.EventRecorder,
+	opts ...StatefulPodControlOption,
 ) *StatefulPodControl {
-	return &StatefulPodControl{&realStatefulPodControlObjectManager{client, podLister, claimLister, scLister}, recorder}
+	om := &realStatefulPodControlObjectManager{client: client, podLister: podLister, claimLister: claimLister, scLister: scLister}
+	for _, opt := range opts {
+		opt(om)
+	}
+	return &StatefulPodControl{om, recorder}
 }
 
 // NewStatefulPodControlFromManager creates a StatefulPodControl using the given StatefulPodControlObjectManager and recorder.
@@ -83,6 +114,10 @@ type realStatefulPodControlObjectManager struct {
 	podLister   corelisters.PodLister
 	claimLister corelisters.PersistentVolumeClaimLister
 	scLister    storagelisters.StorageClassLister
+	nodeLister  corelisters.NodeLister
+	// snapshotClient reads and writes the VolumeSnapshots without cache, for their CRDs may not be installed in the
+	// cluster and the controller is not allowed to list or watch them.
+	snapshotClient client.Client
 }
 
 func (om *realStatefulPodControlObjectManager) CreatePod(ctx context.Context, pod *v1.Pod) error {
@@ -117,10 +152,33 @@ func (om *realStatefulPodControlObjectManager) UpdateClaim(claim *v1.PersistentV
 	return err
 }
 
+func (om *realStatefulPodControlObjectManager) DeleteClaim(claim *v1.PersistentVolumeClaim) error {
+	return om.client.CoreV1().PersistentVolumeClaims(claim.Namespace).Delete(context.TODO(), claim.Name, metav1.DeleteOptions{})
+}
+
 func (om *realStatefulPodControlObjectManager) GetStorageClass(scName string) (*storagev1.StorageClass, error) {
 	return om.scLister.Get(scName)
 }
 
+func (om *realStatefulPodControlObjectManager) GetNode(nodeName string) (*v1.Node, error) {
+	return om.nodeLister.Get(nodeName)
+}
+
+func (om *realStatefulPodControlObjectManager) CreateVolumeSnapshot(snapshot *unstructured.Unstructured) error {
+	return om.snapshotClient.Create(context.TODO(), snapshot)
+}
+
+func (om *realStatefulPodControlObjectManager) GetVolumeSnapshot(namespace, snapshotName string) (*unstructured.Unstructured, error) {
+	snapshot := &unstructured.Unstructured{}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	err := om.snapshotClient.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: snapshotName}, snapshot)
+	return snapshot, err
+}
+
+func (om *realStatefulPodControlObjectManager) DeleteVolumeSnapshot(snapshot *unstructured.Unstructured) error {
+	return om.snapshotClient.Delete(context.TODO(), snapshot)
+}
+
 func (spc *StatefulPodControl) CreateStatefulPod(ctx context.Context, set *appsv1beta1.StatefulSet, pod *v1.Pod) error {
 	// Create the Pod's PVCs prior to creating the Pod
 	if err := spc.createPersistentVolumeClaims(set, pod); err != nil {
@@ -268,12 +326,11 @@ func (spc *StatefulPodControl) UpdatePodClaimForRetentionPolicy(set *appsv1beta1
 // policy is deletion, and a PVC has an ownerRef that does not match the pod, the PVC is stale. This
 // includes pods whose UID has not been created.
 func (spc *StatefulPodControl) PodClaimIsStale(set *appsv1beta1.StatefulSet, pod *v1.Pod) (bool, error) {
-	policy := getPersistentVolumeClaimRetentionPolicy(set)
-	if policy.WhenScaled == appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType {
-		// PVCs are meant to be reused and so can't be stale.
-		return false, nil
-	}
-	for _, claim := range getPersistentVolumeClaims(set, pod) {
+	for templateName, claim := range getPersistentVolumeClaims(set, pod) {
+		if getClaimTemplateRetentionPolicy(set, templateName).WhenScaled == appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType {
+			// PVCs are meant to be reused and so can't be stale.
+			continue
+		}
 		pvc, err := spc.objectMgr.GetClaim(claim.Namespace, claim.Name)
 		switch {
 		case apierrors.IsNotFound(err):
@@ -350,6 +407,14 @@ func (spc *StatefulPodControl) createPersistentVolumeClaims(set *appsv1beta1.Sta
 		pvc, err := spc.objectMgr.GetClaim(claim.Namespace, claim.Name)
 		switch {
 		case apierrors.IsNotFound(err):
+			// restore the data from the backup if the claim is being migrated
+			if utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) &&
+				set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType {
+				if err := spc.setMigrationDataSource(&claim); err != nil {
+					errs = append(errs, err)
+					continue
+				}
+			}
 			err := spc.objectMgr.CreateClaim(&claim)
 			if err != nil {
 				errs = append(errs, fmt.Errorf("failed to create PVC %s: %s", claim.Name, err))
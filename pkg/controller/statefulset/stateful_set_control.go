@@ -41,10 +41,12 @@ import (
 	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
 	"github.com/openkruise/kruise/pkg/features"
 	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
 	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
 	imagejobutilfunc "github.com/openkruise/kruise/pkg/util/imagejob/utilfunction"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
 	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
 	"github.com/openkruise/kruise/pkg/util/specifieddelete"
 )
 
@@ -73,15 +75,19 @@ type StatefulSetControlInterface interface {
 // NewDefaultStatefulSetControl returns a new instance of the default implementation ControlInterface that
 // implements the documented semantics for StatefulSets. podControl is the PodControlInterface used to create, update,
 // and delete Pods and to create PersistentVolumeClaims. statusUpdater is the StatusUpdaterInterface used
-// to update the status of StatefulSets. You should use an instance returned from NewRealStatefulPodControl() for any
-// scenario other than testing.
+// to update the status of StatefulSets. analyzer is used to measure the metrics of rollout analysis, and updateApprover
+// is used to request the pre-update approval webhooks. You should use an instance returned from NewRealStatefulPodControl()
+// for any scenario other than testing.
 func NewDefaultStatefulSetControl(
 	podControl *StatefulPodControl,
 	inplaceControl inplaceupdate.Interface,
 	lifecycleControl lifecycle.Interface,
+	podReadinessControl podreadiness.Interface,
 	statusUpdater StatusUpdaterInterface,
 	controllerHistory history.Interface,
-	recorder record.EventRecorder) StatefulSetControlInterface {
+	recorder record.EventRecorder,
+	analyzer *analysis.Analyzer,
+	updateApprover *lifecycle.UpdateApprover) StatefulSetControlInterface {
 	return &defaultStatefulSetControl{
 		podControl,
 		statusUpdater,
@@ -89,6 +95,9 @@ func NewDefaultStatefulSetControl(
 		recorder,
 		inplaceControl,
 		lifecycleControl,
+		podReadinessControl,
+		analyzer,
+		updateApprover,
 	}
 }
 
@@ -96,12 +105,15 @@ func NewDefaultStatefulSetControl(
 var _ StatefulSetControlInterface = &defaultStatefulSetControl{}
 
 type defaultStatefulSetControl struct {
-	podControl        *StatefulPodControl
-	statusUpdater     StatusUpdaterInterface
-	controllerHistory history.Interface
-	recorder          record.EventRecorder
-	inplaceControl    inplaceupdate.Interface
-	lifecycleControl  lifecycle.Interface
+	podControl          *StatefulPodControl
+	statusUpdater       StatusUpdaterInterface
+	controllerHistory   history.Interface
+	recorder            record.EventRecorder
+	inplaceControl      inplaceupdate.Interface
+	lifecycleControl    lifecycle.Interface
+	podReadinessControl podreadiness.Interface
+	analyzer            *analysis.Analyzer
+	updateApprover      *lifecycle.UpdateApprover
 }
 
 // UpdateStatefulSet executes the core logic loop for a stateful set, applying the predictable and
@@ -169,6 +181,10 @@ func (ssc *defaultStatefulSetControl) performUpdate(
 		return currentRevision, updateRevision, updateStatusErr
 	}
 
+	if err := ssc.pauseOnAnalysisFailed(set, currentStatus); err != nil {
+		return currentRevision, updateRevision, err
+	}
+
 	klog.V(4).InfoS("StatefulSet revisions", "statefulSet", klog.KObj(set),
 		"currentRevision", currentStatus.CurrentRevision,
 		"updateRevision", currentStatus.UpdateRevision)
@@ -388,11 +404,12 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 
 	ssc.updatePVCStatus(&status, set, pods)
 	updateStatus(&status, minReadySeconds, currentRevision, updateRevision, pods)
+	ssc.updateOrdinalStatuses(&status, set, pods)
 
-	startOrdinal, endOrdinal, reserveOrdinals := getStatefulSetReplicasRange(set)
-	// slice that will contain all Pods such that startOrdinal <= getOrdinal(pod) < endOrdinal and not in reserveOrdinals
-	replicas := make([]*v1.Pod, endOrdinal-startOrdinal)
-	// slice that will contain all Pods such that getOrdinal(pod) < startOrdinal or getOrdinal(pod) >= endOrdinal or in reserveOrdinals
+	ordinals, reserveOrdinals := getReplicaOrdinals(set)
+	// slice that will contain all Pods such that getOrdinal(pod) is in ordinals and not in reserveOrdinals
+	replicas := make([]*v1.Pod, len(ordinals))
+	// slice that will contain all Pods such that getOrdinal(pod) is not in ordinals or in reserveOrdinals
 	condemned := make([]*v1.Pod, 0, len(pods))
 	unhealthy := 0
 	firstUnhealthyOrdinal := math.MaxInt32
@@ -401,10 +418,11 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 
 	// First we partition pods into two lists valid replicas and condemned Pods
 	for i := range pods {
-		if ord := getOrdinal(pods[i]); podInOrdinalRangeWithParams(pods[i], startOrdinal, endOrdinal, reserveOrdinals) {
+		ord := getOrdinal(pods[i])
+		if replicaIdx := getReplicaIndex(ordinals, ord); replicaIdx >= 0 && !reserveOrdinals.Has(ord) {
 			// if the ordinal of the pod is within the range of the current number of replicas and not in reserveOrdinals,
 			// insert it at the indirection of its ordinal
-			replicas[ord-startOrdinal] = pods[i]
+			replicas[replicaIdx] = pods[i]
 
 		} else if ord >= 0 {
 			// if the ordinal is valid, but not within the range or in reserveOrdinals,
@@ -415,11 +433,10 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 	}
 
 	// for any empty indices in the sequence [0,set.Spec.Replicas) create a new Pod at the correct revision
-	for ord := startOrdinal; ord < endOrdinal; ord++ {
+	for replicaIdx, ord := range ordinals {
 		if reserveOrdinals.Has(ord) {
 			continue
 		}
-		replicaIdx := ord - startOrdinal
 		if replicas[replicaIdx] == nil {
 			replicas[replicaIdx] = newVersionedStatefulSetPod(
 				currentSet,
@@ -429,6 +446,12 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 		}
 	}
 
+	// take the replicas under maintenance out of scaling, updates and availability accounting
+	maintained, err := ssc.processMaintenance(set, &status, replicas)
+	if err != nil {
+		return &status, err
+	}
+
 	// sort the condemned Pods by their ordinals
 	sort.Sort(descendingOrdinal(condemned))
 
@@ -477,7 +500,8 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 	}
 	if shouldExit, err := runForAllWithBreak(replicas, processReplicaFn); shouldExit || err != nil {
 		ssc.updatePVCStatus(&status, set, replicas)
-		updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned)
+		updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned, maintained)
+		ssc.updateOrdinalStatuses(&status, set, replicas, condemned, maintained)
 		return &status, err
 	}
 
@@ -495,7 +519,8 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 		}
 		if shouldExit, err := runForAll(condemned, fixPodClaim, monotonic); shouldExit || err != nil {
 			ssc.updatePVCStatus(&status, set, replicas)
-			updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned)
+			updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned, maintained)
+			ssc.updateOrdinalStatuses(&status, set, replicas, condemned, maintained)
 			return &status, err
 		}
 	}
@@ -511,11 +536,13 @@ func (ssc *defaultStatefulSetControl) updateStatefulSet(
 	}
 	if shouldExit, err := runForAll(condemned, processCondemnedFn, monotonic); shouldExit || err != nil {
 		ssc.updatePVCStatus(&status, set, replicas)
-		updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned)
+		updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned, maintained)
+		ssc.updateOrdinalStatuses(&status, set, replicas, condemned, maintained)
 		return &status, err
 	}
 	ssc.updatePVCStatus(&status, set, replicas)
-	updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned)
+	updateStatus(&status, minReadySeconds, currentRevision, updateRevision, replicas, condemned, maintained)
+	ssc.updateOrdinalStatuses(&status, set, replicas, condemned, maintained)
 
 	// for the OnDelete strategy we short circuit. Pods will be updated when they are manually deleted.
 	if set.Spec.UpdateStrategy.Type == apps.OnDeleteStatefulSetStrategyType {
@@ -582,6 +609,19 @@ func (ssc *defaultStatefulSetControl) rollingUpdateStatefulsetPods(
 		}
 	}
 
+	// pause the rolling update while the pvc resize of any pod to update is stuck, until the failed resize is abandoned
+	if utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) &&
+		(set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.OnPodRollingUpdateVolumeClaimUpdateStrategyType ||
+			set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType) {
+		if stuckClaims := getStuckResizeClaims(status, replicas, updateRevision.Name); len(stuckClaims) > 0 {
+			klog.V(3).InfoS("StatefulSet paused rolling update for failed pvc resize", "statefulSet", klog.KObj(set), "claims", stuckClaims)
+			ssc.recorder.Eventf(set, v1.EventTypeWarning, "PVCResizeStuck",
+				"rolling update paused as resize of pvc %v failed, annotate them with %s=true to abandon the resize",
+				stuckClaims, appsv1beta1.AbandonPVCResizeAnnotationKey)
+			return status, nil
+		}
+	}
+
 	minWaitTime := appsv1beta1.MaxMinReadySeconds * time.Second
 	unavailablePods := sets.NewString()
 	opts := &inplaceupdate.UpdateOptions{}
@@ -632,18 +672,47 @@ func (ssc *defaultStatefulSetControl) rollingUpdateStatefulsetPods(
 		return status, err
 	}
 
-	updateIndexes := sortPodsToUpdate(set.Spec.UpdateStrategy.RollingUpdate, updateRevision.Name, *set.Spec.Replicas, replicas)
+	// hold the partition until the analysis of the current batch has passed
+	rollingUpdateStrategy := holdPartitionForAnalysis(set, updateRevision.Name)
+	updateIndexes := sortPodsToUpdate(rollingUpdateStrategy, updateRevision.Name, *set.Spec.Replicas, replicas)
+	// update pods domain by domain, and enforce the maxUnavailable within the domain being updated besides the global one
+	if topologyStrategy := getTopologyDomainStrategy(set); topologyStrategy != nil {
+		domainUpdate, err := ssc.limitUpdateToTopologyDomain(set, topologyStrategy.TopologyKey, updateRevision.Name, replicas, updateIndexes, maxUnavailable, unavailablePods)
+		if err != nil {
+			return status, err
+		}
+		if domainUpdate != nil {
+			status.TopologyDomainUpdate = domainUpdate.status()
+			klog.V(3).InfoS("StatefulSet updating pods in topology domain", "statefulSet", klog.KObj(set),
+				"topologyKey", topologyStrategy.TopologyKey, "domain", domainUpdate.domain, "maxUnavailable", domainUpdate.maxUnavailable,
+				"domainUnavailablePods", domainUpdate.unavailablePods.List(), "pendingDomains", domainUpdate.pendingDomains)
+			updateIndexes, maxUnavailable = domainUpdate.updateIndexes, domainUpdate.maxUnavailable
+		}
+	}
 	klog.V(3).InfoS("Prepare to update pods indexes for StatefulSet", "statefulSet", klog.KObj(set), "podIndexes", updateIndexes)
+	// the leaders are updated only after all the other roles have been updated and available
+	rolePriority := getRolePriority(set)
+	leaderBlocked := isLeaderUpdateBlocked(rolePriority, updateRevision.Name, replicas, unavailablePods)
 	// update pods in sequence
 	for _, target := range updateIndexes {
 		var pvcMatched bool = true
 		if utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) &&
-			set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.OnPodRollingUpdateVolumeClaimUpdateStrategyType {
+			(set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.OnPodRollingUpdateVolumeClaimUpdateStrategyType ||
+				set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType) {
 			if pvcMatched, err = ssc.podControl.IsClaimsCompatible(set, replicas[target]); err != nil {
 				return status, err
 			}
 		}
 
+		// clean up the backup snapshots after the pod has been recreated with the migrated claims
+		if pvcMatched && !unavailablePods.Has(replicas[target].Name) &&
+			utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) &&
+			set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType {
+			if err = ssc.podControl.CleanupMigratedPVC(set, replicas[target]); err != nil {
+				return status, err
+			}
+		}
+
 		// the target is already up-to-date, go to next
 		if getPodRevision(replicas[target]) == updateRevision.Name && pvcMatched {
 			continue
@@ -657,6 +726,39 @@ func (ssc *defaultStatefulSetControl) rollingUpdateStatefulsetPods(
 			return status, nil
 		}
 
+		if leaderBlocked && rolePriority.IsLeader(replicas[target].Labels) {
+			klog.V(4).InfoS("StatefulSet was waiting for the other roles to update, blocked leader pod",
+				"statefulSet", klog.KObj(set), "blockedPod", klog.KObj(replicas[target]))
+			return status, nil
+		}
+
+		// migrate the claims one ordinal at a time: mark the claims to be migrated and delete the pod, so that the
+		// claims are backed up after no longer written, and rebuilt from the templates restoring the backups before
+		// the pod is recreated.
+		if !pvcMatched && utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) &&
+			set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType {
+			if len(unavailablePods) > 0 && !unavailablePods.Has(replicas[target].Name) {
+				klog.V(4).InfoS("StatefulSet was waiting for unavailable Pods to migrate claims, blocked pod",
+					"statefulSet", klog.KObj(set), "unavailablePods", unavailablePods.List(), "blockedPod", klog.KObj(replicas[target]))
+				return status, nil
+			}
+			if err = ssc.podControl.MarkPVCMigrating(set, replicas[target]); err != nil {
+				return status, err
+			}
+			if isTerminating(replicas[target]) {
+				return status, nil
+			}
+			klog.V(2).InfoS("StatefulSet terminating Pod for claims migration", "statefulSet", klog.KObj(set), "pod", klog.KObj(replicas[target]))
+			_, actualDeleting, err := ssc.deletePod(set, replicas[target])
+			if err != nil {
+				return status, err
+			}
+			if actualDeleting && getPodRevision(replicas[target]) == currentRevision.Name {
+				status.CurrentReplicas--
+			}
+			return status, nil
+		}
+
 		// Kruise currently will not patch pvc size until a pod references the resized volume.
 		// online-file-system-expansion: if no pods referencing the volume are running, file system expansion will not happen.
 		// refer to https://kubernetes.io/blog/2018/07/12/resizing-persistent-volumes-using-kubernetes/#online-file-system-expansion
@@ -683,6 +785,9 @@ func (ssc *defaultStatefulSetControl) rollingUpdateStatefulsetPods(
 
 		// delete the Pod if it is not already terminating and does not match the update revision.
 		if !specifiedDeletedPods.Has(replicas[target].Name) && !isTerminating(replicas[target]) {
+			if approved, err := ssc.approvePodUpdate(set, replicas[target], updateRevision.Name); err != nil || !approved {
+				return status, err
+			}
 			// todo validate in-place for pub
 			inplacing, inplaceUpdateErr := ssc.inPlaceUpdatePod(set, replicas[target], updateRevision, revisions)
 			if inplaceUpdateErr != nil {
@@ -786,6 +891,14 @@ func (ssc *defaultStatefulSetControl) refreshPodState(set *appsv1beta1.StatefulS
 		return false, 0, res.RefreshErr
 	}
 
+	// the node can not fit the new resources, so back off to recreate the Pod
+	if res.ResizeInfeasible && (set.Spec.UpdateStrategy.RollingUpdate == nil ||
+		set.Spec.UpdateStrategy.RollingUpdate.PodUpdatePolicy != appsv1beta1.InPlaceOnlyPodUpdateStrategyType) {
+		klog.InfoS("AdvancedStatefulSet could not resize Pod in-place, so it will back off to ReCreate", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
+		modified, _, err := ssc.deletePod(set, pod)
+		return modified, 0, err
+	}
+
 	var state appspub.LifecycleStateType
 	switch lifecycle.GetPodLifecycleState(pod) {
 	case appspub.LifecycleStatePreparingUpdate:
@@ -926,6 +1039,9 @@ func (ssc *defaultStatefulSetControl) updateStatefulSetStatus(
 	// complete any in progress rolling update if necessary
 	completeRollingUpdate(set, status)
 
+	// maintain the analysis run of the current update batch
+	ssc.syncAnalysisRun(set, status)
+
 	// if the status is not inconsistent do not perform an update
 	if !inconsistentStatus(set, status) {
 		return nil
@@ -1022,6 +1138,31 @@ func getStartOrdinal(set *appsv1beta1.StatefulSet) int {
 	return 0
 }
 
+// getEndOrdinal gets the last possible ordinal (inclusive).
+// Returns spec.ordinals.end if it is set, otherwise returns math.MaxInt32.
+func getEndOrdinal(set *appsv1beta1.StatefulSet) int {
+	if utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetStartOrdinal) {
+		if set.Spec.Ordinals != nil && set.Spec.Ordinals.End != nil {
+			return int(*set.Spec.Ordinals.End)
+		}
+	}
+	return math.MaxInt32
+}
+
+// getListedOrdinals gets the ordinals explicitly listed in spec.ordinals.list in ascending order,
+// or nil if it is not set.
+func getListedOrdinals(set *appsv1beta1.StatefulSet) []int {
+	if !utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetStartOrdinal) ||
+		set.Spec.Ordinals == nil || len(set.Spec.Ordinals.List) == 0 {
+		return nil
+	}
+	listed := sets.NewInt()
+	for _, ordinal := range set.Spec.Ordinals.List {
+		listed.Insert(int(ordinal))
+	}
+	return listed.List()
+}
+
 func (ssc *defaultStatefulSetControl) processCondemned(ctx context.Context, set *appsv1beta1.StatefulSet, firstUnhealthyPod *v1.Pod, monotonic bool, condemned []*v1.Pod, i int) (bool, error) {
 	logger := klog.FromContext(ctx)
 	if isTerminating(condemned[i]) {
@@ -1049,6 +1190,18 @@ func (ssc *defaultStatefulSetControl) processCondemned(ctx context.Context, set
 		return true, nil
 	}
 
+	// take snapshots of the claims with Snapshot policy before they are deleted along with the pod
+	if utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoDeletePVC) {
+		if ready, err := ssc.podControl.TrySnapshotPVC(set, condemned[i]); err != nil {
+			return true, err
+		} else if !ready {
+			logger.V(4).Info("StatefulSet is waiting for snapshots of claims prior to scale down",
+				"statefulSet", klog.KObj(set), "pod", klog.KObj(condemned[i]))
+			durationStore.Push(getStatefulSetKey(set), snapshotCheckInterval)
+			return monotonic, nil
+		}
+	}
+
 	logger.V(2).Info("Pod of StatefulSet is terminating for scale down",
 		"statefulSet", klog.KObj(set), "pod", klog.KObj(condemned[i]))
 
@@ -1112,6 +1265,18 @@ func (ssc *defaultStatefulSetControl) processReplica(
 				return true, false, err
 			}
 		}
+		// back up and delete the claims being migrated before the pod is recreated
+		if utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) &&
+			set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType {
+			if migrated, err := ssc.podControl.TryMigratePVC(set, replicas[i]); err != nil {
+				return true, false, err
+			} else if !migrated {
+				logger.V(4).Info("StatefulSet is waiting for claims to be backed up prior to recreating Pod",
+					"statefulSet", klog.KObj(set), "pod", klog.KObj(replicas[i]))
+				durationStore.Push(getStatefulSetKey(set), snapshotCheckInterval)
+				return monotonic, false, nil
+			}
+		}
 		lifecycle.SetPodLifecycle(appspub.LifecycleStateNormal)(replicas[i])
 		if err := ssc.podControl.CreateStatefulPod(ctx, set, replicas[i]); err != nil {
 			msg := fmt.Sprintf("StatefulPodControl failed to create Pod error: %s", err)
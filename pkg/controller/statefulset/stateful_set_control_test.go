@@ -39,6 +39,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -64,9 +65,12 @@ import (
 	kruiseappsinformers "github.com/openkruise/kruise/pkg/client/informers/externalversions/apps/v1beta1"
 	kruiseappslisters "github.com/openkruise/kruise/pkg/client/listers/apps/v1beta1"
 	"github.com/openkruise/kruise/pkg/features"
+	"github.com/openkruise/kruise/pkg/util/analysis"
 	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
 	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
 	"github.com/openkruise/kruise/pkg/util/revisionadapter"
 )
 
@@ -81,7 +85,8 @@ func setupController(client clientset.Interface, kruiseClient kruiseclientset.In
 	recorder := &noopRecorder{}
 	inplaceControl := inplaceupdate.NewForInformer(informerFactory.Core().V1().Pods(), revisionadapter.NewDefaultImpl())
 	lifecycleControl := lifecycle.NewForInformer(informerFactory.Core().V1().Pods())
-	ssc := NewDefaultStatefulSetControl(spc, inplaceControl, lifecycleControl, ssu, history.NewFakeHistory(informerFactory.Apps().V1().ControllerRevisions()), recorder)
+	podReadinessControl := podreadiness.NewForAdapter(&podadapter.AdapterInformer{PodInformer: informerFactory.Core().V1().Pods()})
+	ssc := NewDefaultStatefulSetControl(spc, inplaceControl, lifecycleControl, podReadinessControl, ssu, history.NewFakeHistory(informerFactory.Apps().V1().ControllerRevisions()), recorder, analysis.NewDefaultAnalyzer(), lifecycle.NewDefaultUpdateApprover())
 
 	stop := make(chan struct{})
 	informerFactory.Start(stop)
@@ -691,7 +696,8 @@ func TestStatefulSetControl_getSetRevisions(t *testing.T) {
 		recorder := record.NewFakeRecorder(10)
 		inplaceControl := inplaceupdate.NewForInformer(informerFactory.Core().V1().Pods(), revisionadapter.NewDefaultImpl())
 		lifecycleControl := lifecycle.NewForInformer(informerFactory.Core().V1().Pods())
-		ssc := defaultStatefulSetControl{spc, ssu, history.NewFakeHistory(informerFactory.Apps().V1().ControllerRevisions()), recorder, inplaceControl, lifecycleControl}
+		podReadinessControl := podreadiness.NewForAdapter(&podadapter.AdapterInformer{PodInformer: informerFactory.Core().V1().Pods()})
+		ssc := defaultStatefulSetControl{spc, ssu, history.NewFakeHistory(informerFactory.Apps().V1().ControllerRevisions()), recorder, inplaceControl, lifecycleControl, podReadinessControl, analysis.NewDefaultAnalyzer(), lifecycle.NewDefaultUpdateApprover()}
 
 		stop := make(chan struct{})
 		defer close(stop)
@@ -3064,11 +3070,13 @@ type fakeObjectManager struct {
 	podsLister       corelisters.PodLister
 	claimsLister     corelisters.PersistentVolumeClaimLister
 	scLister         storagelisters.StorageClassLister
+	nodesLister      corelisters.NodeLister
 	setsLister       kruiseappslisters.StatefulSetLister
 	podsIndexer      cache.Indexer
 	claimsIndexer    cache.Indexer
 	setsIndexer      cache.Indexer
 	revisionsIndexer cache.Indexer
+	snapshotsIndexer cache.Indexer
 	createPodTracker requestTracker
 	updatePodTracker requestTracker
 	deletePodTracker requestTracker
@@ -3080,16 +3088,19 @@ func newFakeObjectManager(informerFactory informers.SharedInformerFactory, kruis
 	revisionInformer := informerFactory.Apps().V1().ControllerRevisions()
 	setInformer := kruiseInformerFactory.Apps().V1beta1().StatefulSets()
 	scInformer := informerFactory.Storage().V1().StorageClasses()
+	nodeInformer := informerFactory.Core().V1().Nodes()
 
 	return &fakeObjectManager{
 		podInformer.Lister(),
 		claimInformer.Lister(),
 		scInformer.Lister(),
+		nodeInformer.Lister(),
 		setInformer.Lister(),
 		podInformer.Informer().GetIndexer(),
 		claimInformer.Informer().GetIndexer(),
 		setInformer.Informer().GetIndexer(),
 		revisionInformer.Informer().GetIndexer(),
+		cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
 		requestTracker{sync.Mutex{}, 0, nil, 0},
 		requestTracker{sync.Mutex{}, 0, nil, 0},
 		requestTracker{sync.Mutex{}, 0, nil, 0}}
@@ -3151,10 +3162,43 @@ func (om *fakeObjectManager) UpdateClaim(claim *v1.PersistentVolumeClaim) error
 	return nil
 }
 
+func (om *fakeObjectManager) DeleteClaim(claim *v1.PersistentVolumeClaim) error {
+	if key, err := controller.KeyFunc(claim); err != nil {
+		return err
+	} else if obj, found, err := om.claimsIndexer.GetByKey(key); err != nil {
+		return err
+	} else if found {
+		return om.claimsIndexer.Delete(obj)
+	}
+	return nil
+}
+
 func (om *fakeObjectManager) GetStorageClass(scName string) (*storagev1.StorageClass, error) {
 	return om.scLister.Get(scName)
 }
 
+func (om *fakeObjectManager) GetNode(nodeName string) (*v1.Node, error) {
+	return om.nodesLister.Get(nodeName)
+}
+
+func (om *fakeObjectManager) CreateVolumeSnapshot(snapshot *unstructured.Unstructured) error {
+	return om.snapshotsIndexer.Add(snapshot)
+}
+
+func (om *fakeObjectManager) GetVolumeSnapshot(namespace, snapshotName string) (*unstructured.Unstructured, error) {
+	obj, exists, err := om.snapshotsIndexer.GetByKey(namespace + "/" + snapshotName)
+	if err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, apierrors.NewNotFound(volumeSnapshotGVK.GroupVersion().WithResource("volumesnapshots").GroupResource(), snapshotName)
+	}
+	return obj.(*unstructured.Unstructured), nil
+}
+
+func (om *fakeObjectManager) DeleteVolumeSnapshot(snapshot *unstructured.Unstructured) error {
+	return om.snapshotsIndexer.Delete(snapshot)
+}
+
 func (om *fakeObjectManager) SetCreateStatefulPodError(err error, after int) {
 	om.createPodTracker.err = err
 	om.createPodTracker.after = after
@@ -3908,6 +3952,24 @@ func TestStatefulSetControlWithStartOrdinal(t *testing.T) {
 			},
 			[]int{2, 5, 6, 7},
 		},
+		{
+			CreatesPodsWithStartOrdinal,
+			func() *appsv1beta1.StatefulSet {
+				statefulSet := simpleSetFn(2, 5, 6)
+				statefulSet.Spec.Ordinals.End = utilpointer.Int32(7)
+				return statefulSet
+			},
+			[]int{5, 7},
+		},
+		{
+			CreatesPodsWithStartOrdinal,
+			func() *appsv1beta1.StatefulSet {
+				statefulSet := simpleSetFn(3, 0, 4)
+				statefulSet.Spec.Ordinals.List = []int32{9, 2, 4, 3}
+				return statefulSet
+			},
+			[]int{2, 3, 9},
+		},
 	}
 
 	for _, testCase := range testCases {
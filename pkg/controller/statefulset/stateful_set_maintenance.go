@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
+)
+
+// maintenanceReadinessMessage is the not-ready key added to the Pods under maintenance.
+var maintenanceReadinessMessage = podreadiness.Message{UserAgent: "StatefulSet", Key: "Maintenance"}
+
+// getMaintenancePodPolicies returns the pod policies of the replicas under maintenance indexed by their ordinals.
+func getMaintenancePodPolicies(set *appsv1beta1.StatefulSet) map[int]appsv1beta1.MaintenancePodPolicyType {
+	policies := make(map[int]appsv1beta1.MaintenancePodPolicyType, len(set.Spec.MaintenanceOrdinals))
+	for _, maintenance := range set.Spec.MaintenanceOrdinals {
+		policy := maintenance.PodPolicy
+		if policy == "" {
+			policy = appsv1beta1.KeepMaintenancePodPolicyType
+		}
+		policies[int(maintenance.Ordinal)] = policy
+	}
+	return policies
+}
+
+// processMaintenance takes the replicas under maintenance out of replicas, so that they will not be created, updated
+// or counted as unavailable, and returns the existing Pods of them. The Pods with Keep policy are marked not-ready
+// and the Pods with Delete policy are deleted, while the Pods whose maintenance has ended are marked ready again.
+func (ssc *defaultStatefulSetControl) processMaintenance(
+	set *appsv1beta1.StatefulSet,
+	status *appsv1beta1.StatefulSetStatus,
+	replicas []*v1.Pod,
+) ([]*v1.Pod, error) {
+	policies := getMaintenancePodPolicies(set)
+	var maintained []*v1.Pod
+	for i := range replicas {
+		pod := replicas[i]
+		if pod == nil {
+			continue
+		}
+		policy, ok := policies[getOrdinal(pod)]
+		if !ok {
+			if set.DeletionTimestamp == nil && isCreated(pod) && podreadiness.ContainsNotReadyKey(pod, maintenanceReadinessMessage) {
+				if err := ssc.podReadinessControl.RemoveNotReadyKey(pod, maintenanceReadinessMessage); err != nil {
+					return nil, err
+				}
+				klog.V(3).InfoS("StatefulSet ended maintenance of Pod", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
+			}
+			continue
+		}
+
+		replicas[i] = nil
+		status.MaintenanceReplicas++
+		if !isCreated(pod) {
+			continue
+		}
+		maintained = append(maintained, pod)
+		if set.DeletionTimestamp != nil || isTerminating(pod) {
+			continue
+		}
+
+		switch policy {
+		case appsv1beta1.DeleteMaintenancePodPolicyType:
+			if _, _, err := ssc.deletePod(set, pod); err != nil {
+				return nil, err
+			}
+			klog.V(3).InfoS("StatefulSet deleted Pod under maintenance", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
+		default:
+			if podreadiness.ContainsNotReadyKey(pod, maintenanceReadinessMessage) {
+				continue
+			}
+			// the Pod created without the readiness gate could not be marked not-ready
+			if !podreadiness.ContainsReadinessGate(pod) {
+				klog.V(3).InfoS("StatefulSet could not mark Pod under maintenance not-ready without readiness gate",
+					"statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
+				continue
+			}
+			if err := ssc.podReadinessControl.AddNotReadyKey(pod, maintenanceReadinessMessage); err != nil {
+				return nil, err
+			}
+			klog.V(3).InfoS("StatefulSet marked Pod under maintenance not-ready", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
+		}
+	}
+	return maintained, nil
+}
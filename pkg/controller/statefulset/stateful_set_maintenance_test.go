@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"context"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise/pkg/client/clientset/versioned/fake"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
+)
+
+func TestProcessMaintenance(t *testing.T) {
+	set := newStatefulSet(4)
+	set.Spec.MaintenanceOrdinals = []appsv1beta1.MaintenanceOrdinal{
+		{Ordinal: 1},
+		{Ordinal: 2, PodPolicy: appsv1beta1.DeleteMaintenancePodPolicyType},
+		{Ordinal: 3, PodPolicy: appsv1beta1.DeleteMaintenancePodPolicyType},
+	}
+
+	// pod-3 has been deleted for maintenance, and the maintenance of pod-0 has ended
+	replicas := make([]*v1.Pod, 4)
+	var created []*v1.Pod
+	for i := range replicas {
+		replicas[i] = newStatefulSetPod(set, i)
+		if i == 3 {
+			continue
+		}
+		replicas[i].Spec.ReadinessGates = []v1.PodReadinessGate{{ConditionType: appspub.KruisePodReadyConditionType}}
+		replicas[i].Status.Phase = v1.PodRunning
+		if i == 0 {
+			replicas[i].Status.Conditions = []v1.PodCondition{{
+				Type:    appspub.KruisePodReadyConditionType,
+				Status:  v1.ConditionFalse,
+				Message: `[{"userAgent":"StatefulSet","key":"Maintenance"}]`,
+			}}
+		}
+		created = append(created, replicas[i].DeepCopy())
+	}
+
+	client := fake.NewSimpleClientset()
+	om, _, _, stop := setupController(fake.NewSimpleClientset(), kruisefake.NewSimpleClientset(set))
+	defer close(stop)
+	for _, pod := range created {
+		if _, err := client.CoreV1().Pods(pod.Namespace).Create(context.TODO(), pod, metav1.CreateOptions{}); err != nil {
+			t.Fatalf("failed to create pod: %v", err)
+		}
+		if err := om.podsIndexer.Add(pod); err != nil {
+			t.Fatalf("failed to add pod: %v", err)
+		}
+	}
+	ssc := &defaultStatefulSetControl{
+		podControl:          NewStatefulPodControlFromManager(om, &noopRecorder{}),
+		podReadinessControl: podreadiness.NewForAdapter(&podadapter.AdapterTypedClient{Client: client}),
+		recorder:            &noopRecorder{},
+	}
+
+	status := &appsv1beta1.StatefulSetStatus{}
+	maintained, err := ssc.processMaintenance(set, status, replicas)
+	if err != nil {
+		t.Fatalf("failed to process maintenance: %v", err)
+	}
+	if status.MaintenanceReplicas != 3 || len(maintained) != 2 {
+		t.Fatalf("expected 3 replicas under maintenance with 2 pods, got %d with %d pods", status.MaintenanceReplicas, len(maintained))
+	}
+	if replicas[0] == nil || replicas[1] != nil || replicas[2] != nil || replicas[3] != nil {
+		t.Fatalf("expected only pod-0 left in replicas, got %v", replicas)
+	}
+
+	getPod := func(ordinal int) *v1.Pod {
+		pod, err := client.CoreV1().Pods(set.Namespace).Get(context.TODO(), getPodName(set, ordinal), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get pod: %v", err)
+		}
+		return pod
+	}
+	if pod := getPod(0); podreadiness.ContainsNotReadyKey(pod, maintenanceReadinessMessage) {
+		t.Fatalf("expected pod-0 marked ready after maintenance, got %v", pod.Status.Conditions)
+	}
+	if pod := getPod(1); !podreadiness.ContainsNotReadyKey(pod, maintenanceReadinessMessage) {
+		t.Fatalf("expected pod-1 under maintenance marked not-ready, got %v", pod.Status.Conditions)
+	}
+	if _, err := om.GetPod(set.Namespace, getPodName(set, 2)); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected pod-2 under maintenance deleted, got %v", err)
+	}
+}
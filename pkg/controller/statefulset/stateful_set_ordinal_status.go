@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/features"
+	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
+	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+)
+
+// getInPlaceUpdateOrdinalState returns the in-place update state of the pod, or empty if it has never been updated in-place.
+func getInPlaceUpdateOrdinalState(pod *v1.Pod) appsv1beta1.OrdinalInPlaceUpdateStateType {
+	if _, ok := appspub.GetInPlaceUpdateState(pod); !ok {
+		return ""
+	}
+	if err := inplaceupdate.DefaultCheckInPlaceUpdateCompleted(pod); err != nil {
+		return appsv1beta1.InPlaceUpdatingOrdinalState
+	}
+	return appsv1beta1.InPlaceUpdatedOrdinalState
+}
+
+// updateOrdinalStatuses reports the state of the created pods in the order of ordinals, at most spec.ordinalStatusLimit
+// of them. It is computed along with the other replica counts of the status, from the pods after reconciling.
+func (ssc *defaultStatefulSetControl) updateOrdinalStatuses(status *appsv1beta1.StatefulSetStatus, set *appsv1beta1.StatefulSet, podLists ...[]*v1.Pod) {
+	status.OrdinalStatuses = nil
+	if set.Spec.OrdinalStatusLimit == nil || *set.Spec.OrdinalStatusLimit <= 0 {
+		return
+	}
+	limit := int(*set.Spec.OrdinalStatusLimit)
+
+	var sorted []*v1.Pod
+	for _, pods := range podLists {
+		for _, pod := range pods {
+			if pod != nil && isCreated(pod) {
+				sorted = append(sorted, pod)
+			}
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return getOrdinal(sorted[i]) < getOrdinal(sorted[j]) })
+	if len(sorted) > limit {
+		sorted = sorted[:limit]
+	}
+
+	checkClaims := utilfeature.DefaultFeatureGate.Enabled(features.StatefulSetAutoResizePVCGate) && len(set.Spec.VolumeClaimTemplates) > 0
+	status.OrdinalStatuses = make([]appsv1beta1.StatefulSetOrdinalStatus, 0, len(sorted))
+	for _, pod := range sorted {
+		ordinalStatus := appsv1beta1.StatefulSetOrdinalStatus{
+			Ordinal:            int32(getOrdinal(pod)),
+			Revision:           getPodRevision(pod),
+			LifecycleState:     lifecycle.GetPodLifecycleState(pod),
+			InPlaceUpdateState: getInPlaceUpdateOrdinalState(pod),
+			Ready:              isRunningAndReady(pod),
+		}
+		if checkClaims {
+			if compatible, err := ssc.podControl.IsClaimsCompatible(set, pod); err != nil {
+				klog.V(4).InfoS("Could not check PVC compatibility of Pod", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod), "error", err)
+			} else {
+				ordinalStatus.ClaimsCompatible = &compatible
+			}
+		}
+		status.OrdinalStatuses = append(status.OrdinalStatuses, ordinalStatus)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes/fake"
+	utilpointer "k8s.io/utils/pointer"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise/pkg/client/clientset/versioned/fake"
+	"github.com/openkruise/kruise/pkg/features"
+	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
+)
+
+func TestUpdateOrdinalStatuses(t *testing.T) {
+	defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.StatefulSetAutoResizePVCGate, true)()
+
+	set := newStatefulSet(3)
+	om, _, _, stop := setupController(fake.NewSimpleClientset(), kruisefake.NewSimpleClientset(set))
+	defer close(stop)
+	spc := NewStatefulPodControlFromManager(om, &noopRecorder{})
+	ssc := &defaultStatefulSetControl{podControl: spc}
+
+	pods := make([]*v1.Pod, 3)
+	for i := range pods {
+		pods[i] = newStatefulSetPod(set, i)
+		pods[i].Labels[apps.ControllerRevisionHashLabelKey] = "rev-old"
+	}
+	pods[0].Labels[apps.ControllerRevisionHashLabelKey] = "rev-new"
+	pods[0].Status.Phase = v1.PodRunning
+	pods[0].Status.Conditions = []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}
+	pods[0].Annotations = map[string]string{appspub.InPlaceUpdateStateKey: `{"revision":"rev-new"}`}
+	pods[1].Status.Phase = v1.PodPending
+	pods[2].Status.Phase = v1.PodPending
+	pods[1].Labels[appspub.LifecycleStateKey] = string(appspub.LifecycleStatePreparingUpdate)
+	pods[1].Annotations = map[string]string{appspub.InPlaceUpdateStateKey: `{"revision":"rev-new","nextContainerImages":{"nginx":"nginx:new"}}`}
+
+	// the claims of pod-1 are smaller than the expanded templates
+	if err := spc.createPersistentVolumeClaims(set, pods[1]); err != nil {
+		t.Fatalf("failed to create claims: %v", err)
+	}
+	for i := range set.Spec.VolumeClaimTemplates {
+		set.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse("10Gi")
+	}
+	if err := spc.createPersistentVolumeClaims(set, pods[0]); err != nil {
+		t.Fatalf("failed to create claims: %v", err)
+	}
+
+	status := &appsv1beta1.StatefulSetStatus{}
+	ssc.updateOrdinalStatuses(status, set, []*v1.Pod{pods[2], nil, pods[1], pods[0]})
+	if status.OrdinalStatuses != nil {
+		t.Fatalf("expected no ordinal statuses without limit, got %v", status.OrdinalStatuses)
+	}
+
+	set.Spec.OrdinalStatusLimit = utilpointer.Int32(2)
+	ssc.updateOrdinalStatuses(status, set, []*v1.Pod{nil, pods[1], pods[2]}, []*v1.Pod{pods[0]})
+	expected := []appsv1beta1.StatefulSetOrdinalStatus{
+		{
+			Ordinal:            0,
+			Revision:           "rev-new",
+			InPlaceUpdateState: appsv1beta1.InPlaceUpdatedOrdinalState,
+			ClaimsCompatible:   utilpointer.Bool(true),
+			Ready:              true,
+		},
+		{
+			Ordinal:            1,
+			Revision:           "rev-old",
+			LifecycleState:     appspub.LifecycleStatePreparingUpdate,
+			InPlaceUpdateState: appsv1beta1.InPlaceUpdatingOrdinalState,
+			ClaimsCompatible:   utilpointer.Bool(false),
+		},
+	}
+	if len(status.OrdinalStatuses) != len(expected) {
+		t.Fatalf("expected %d ordinal statuses, got %v", len(expected), status.OrdinalStatuses)
+	}
+	for i := range expected {
+		got, want := status.OrdinalStatuses[i], expected[i]
+		if got.Ordinal != want.Ordinal || got.Revision != want.Revision || got.LifecycleState != want.LifecycleState ||
+			got.InPlaceUpdateState != want.InPlaceUpdateState || got.Ready != want.Ready ||
+			got.ClaimsCompatible == nil || *got.ClaimsCompatible != *want.ClaimsCompatible {
+			t.Fatalf("unexpected ordinal status %d: %+v", i, got)
+		}
+	}
+
+	// the replicas not created yet are not reported
+	set.Spec.OrdinalStatusLimit = utilpointer.Int32(10)
+	ssc.updateOrdinalStatuses(status, set, []*v1.Pod{pods[0], pods[1], pods[2], newStatefulSetPod(set, 3)})
+	if len(status.OrdinalStatuses) != 3 || status.OrdinalStatuses[2].Ordinal != 2 {
+		t.Fatalf("expected ordinal statuses of the created pods only, got %v", status.OrdinalStatuses)
+	}
+}
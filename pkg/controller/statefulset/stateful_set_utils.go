@@ -28,6 +28,7 @@ import (
 
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -88,14 +89,9 @@ func getOrdinal(pod *v1.Pod) int {
  *                   not reserved; false otherwise.
  */
 func podInOrdinalRange(pod *v1.Pod, set *appsv1beta1.StatefulSet) bool {
-	startOrdinal, endOrdinal, reserveOrdinals := getStatefulSetReplicasRange(set)
-	return podInOrdinalRangeWithParams(pod, startOrdinal, endOrdinal, reserveOrdinals)
-}
-
-func podInOrdinalRangeWithParams(pod *v1.Pod, startOrdinal, endOrdinal int, reserveOrdinals sets.Int) bool {
+	ordinals, reserveOrdinals := getReplicaOrdinals(set)
 	ordinal := getOrdinal(pod)
-	return ordinal >= startOrdinal && ordinal < endOrdinal &&
-		!reserveOrdinals.Has(ordinal)
+	return getReplicaIndex(ordinals, ordinal) >= 0 && !reserveOrdinals.Has(ordinal)
 }
 
 // getPodName gets the name of set's child Pod with an ordinal index of ordinal
@@ -159,10 +155,55 @@ func getPersistentVolumeClaimRetentionPolicy(set *appsv1beta1.StatefulSet) appsv
 	return policy
 }
 
+// getClaimTemplateRetentionPolicy returns the PVC policy for the claim template of the given name, in which the
+// policies not overridden by the template are inherited from the StatefulSet.
+func getClaimTemplateRetentionPolicy(set *appsv1beta1.StatefulSet, templateName string) appsv1beta1.VolumeClaimTemplateRetentionPolicy {
+	setPolicy := getPersistentVolumeClaimRetentionPolicy(set)
+	policy := appsv1beta1.VolumeClaimTemplateRetentionPolicy{
+		Name:                    templateName,
+		WhenDeleted:             setPolicy.WhenDeleted,
+		WhenScaled:              setPolicy.WhenScaled,
+		VolumeSnapshotClassName: setPolicy.VolumeSnapshotClassName,
+	}
+	for _, templatePolicy := range setPolicy.Templates {
+		if templatePolicy.Name != templateName {
+			continue
+		}
+		if templatePolicy.WhenDeleted != "" {
+			policy.WhenDeleted = templatePolicy.WhenDeleted
+		}
+		if templatePolicy.WhenScaled != "" {
+			policy.WhenScaled = templatePolicy.WhenScaled
+		}
+		if templatePolicy.VolumeSnapshotClassName != nil {
+			policy.VolumeSnapshotClassName = templatePolicy.VolumeSnapshotClassName
+		}
+	}
+	return policy
+}
+
+// getClaimRetentionPolicy returns the PVC policy for the claim of pod. The claim is deleted in the same way for
+// the Snapshot policy, after the snapshot has been taken before the pod is deleted.
+func getClaimRetentionPolicy(claim *v1.PersistentVolumeClaim, set *appsv1beta1.StatefulSet, pod *v1.Pod) appsv1beta1.StatefulSetPersistentVolumeClaimRetentionPolicy {
+	policy := getPersistentVolumeClaimRetentionPolicy(set)
+	ordinal := getOrdinal(pod)
+	for i := range set.Spec.VolumeClaimTemplates {
+		if getPersistentVolumeClaimName(set, &set.Spec.VolumeClaimTemplates[i], ordinal) == claim.Name {
+			templatePolicy := getClaimTemplateRetentionPolicy(set, set.Spec.VolumeClaimTemplates[i].Name)
+			policy.WhenDeleted, policy.WhenScaled = templatePolicy.WhenDeleted, templatePolicy.WhenScaled
+			break
+		}
+	}
+	if policy.WhenScaled == appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType {
+		policy.WhenScaled = appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType
+	}
+	return policy
+}
+
 // claimOwnerMatchesSetAndPod returns false if the ownerRefs of the claim are not set consistently with the
 // PVC deletion policy for the StatefulSet.
 func claimOwnerMatchesSetAndPod(claim *v1.PersistentVolumeClaim, set *appsv1beta1.StatefulSet, pod *v1.Pod) bool {
-	policy := getPersistentVolumeClaimRetentionPolicy(set)
+	policy := getClaimRetentionPolicy(claim, set, pod)
 	const retain = appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType
 	const delete = appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType
 	switch {
@@ -224,7 +265,7 @@ func updateClaimOwnerRefForSetAndPod(claim *v1.PersistentVolumeClaim, set *appsv
 	updateMeta(&podMeta, "Pod")
 	setMeta := set.TypeMeta
 	updateMeta(&setMeta, "StatefulSet")
-	policy := getPersistentVolumeClaimRetentionPolicy(set)
+	policy := getClaimRetentionPolicy(claim, set, pod)
 	const retain = appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType
 	const delete = appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType
 	switch {
@@ -511,10 +552,10 @@ func isCurrentRevisionNeeded(set *appsv1beta1.StatefulSet, updateRevision string
 		return false
 	}
 	if set.Spec.UpdateStrategy.RollingUpdate == nil {
-		return ordinal < getStartOrdinal(set)+int(set.Status.CurrentReplicas)
+		return countReplicasBefore(set, ordinal) < int(set.Status.CurrentReplicas)
 	}
 	if set.Spec.UpdateStrategy.RollingUpdate.UnorderedUpdate == nil {
-		return ordinal < getStartOrdinal(set)+int(*set.Spec.UpdateStrategy.RollingUpdate.Partition)
+		return countReplicasBefore(set, ordinal) < int(*set.Spec.UpdateStrategy.RollingUpdate.Partition)
 	}
 
 	var noUpdatedReplicas int
@@ -529,6 +570,26 @@ func isCurrentRevisionNeeded(set *appsv1beta1.StatefulSet, updateRevision string
 	return noUpdatedReplicas < int(*set.Spec.UpdateStrategy.RollingUpdate.Partition)
 }
 
+// countReplicasBefore counts the replicas indexed before the given ordinal, which is compared with the partition.
+// The replicas are counted in list order if spec.ordinals.list is set, otherwise by the offset from the start ordinal.
+func countReplicasBefore(set *appsv1beta1.StatefulSet, ordinal int) int {
+	listed := getListedOrdinals(set)
+	if listed == nil {
+		return ordinal - getStartOrdinal(set)
+	}
+	reserveOrdinals := sets.NewInt(set.Spec.ReserveOrdinals...)
+	count := 0
+	for _, listedOrdinal := range listed {
+		if listedOrdinal >= ordinal {
+			break
+		}
+		if !reserveOrdinals.Has(listedOrdinal) {
+			count++
+		}
+	}
+	return count
+}
+
 // Match check if the given StatefulSet's template matches the template stored in the given history.
 func Match(ss *appsv1beta1.StatefulSet, history *apps.ControllerRevision) (bool, error) {
 	// Encoding the set for the patch may update its GVK metadata, which causes data races if this
@@ -629,9 +690,13 @@ func inconsistentStatus(set *appsv1beta1.StatefulSet, status *appsv1beta1.Statef
 		status.ReadyReplicas != set.Status.ReadyReplicas ||
 		status.AvailableReplicas != set.Status.AvailableReplicas ||
 		status.UpdatedReplicas != set.Status.UpdatedReplicas ||
+		status.MaintenanceReplicas != set.Status.MaintenanceReplicas ||
 		status.CurrentRevision != set.Status.CurrentRevision ||
 		status.UpdateRevision != set.Status.UpdateRevision ||
-		status.LabelSelector != set.Status.LabelSelector {
+		status.LabelSelector != set.Status.LabelSelector ||
+		!apiequality.Semantic.DeepEqual(status.AnalysisRun, set.Status.AnalysisRun) ||
+		!apiequality.Semantic.DeepEqual(status.OrdinalStatuses, set.Status.OrdinalStatuses) ||
+		!apiequality.Semantic.DeepEqual(status.TopologyDomainUpdate, set.Status.TopologyDomainUpdate) {
 		return true
 	}
 
@@ -644,7 +709,11 @@ func inconsistentStatus(set *appsv1beta1.StatefulSet, status *appsv1beta1.Statef
 			// raw template not exist in current status => inconsistent
 			return true
 		} else if status.VolumeClaims[idx].CompatibleReplicas != v.CompatibleReplicas ||
-			status.VolumeClaims[idx].CompatibleReadyReplicas != v.CompatibleReadyReplicas {
+			status.VolumeClaims[idx].CompatibleReadyReplicas != v.CompatibleReadyReplicas ||
+			status.VolumeClaims[idx].MigratingReplicas != v.MigratingReplicas ||
+			status.VolumeClaims[idx].FailedReplicas != v.FailedReplicas ||
+			status.VolumeClaims[idx].Message != v.Message ||
+			!apiequality.Semantic.DeepEqual(status.VolumeClaims[idx].ResizeFailures, v.ResizeFailures) {
 			return true
 		}
 	}
@@ -777,10 +846,14 @@ func decreaseAndCheckMaxUnavailable(maxUnavailable *int) bool {
 // result is startOrdinal 2(inclusive), endOrdinal 7(exclusive), reserveOrdinals = {1, 3}
 // replicas[endOrdinal - startOrdinal] stores [replica-2, nil(reserveOrdinal 3), replica-4, replica-5, replica-6]
 // todo: maybe we should remove ineffective reserveOrdinals in webhook, reserveOrdinals = {3}
+//
+// If Spec.Ordinals.End is set, endOrdinal will not exceed it even though there are fewer replicas than expected.
+// Spec.Ordinals.List is not taken into account, use getReplicaOrdinals instead.
 func getStatefulSetReplicasRange(set *appsv1beta1.StatefulSet) (int, int, sets.Int) {
 	reserveOrdinals := sets.NewInt(set.Spec.ReserveOrdinals...)
+	lastOrdinal := getEndOrdinal(set)
 	replicaMaxOrdinal := getStartOrdinal(set)
-	for realReplicaCount := 0; realReplicaCount < int(*set.Spec.Replicas); replicaMaxOrdinal++ {
+	for realReplicaCount := 0; realReplicaCount < int(*set.Spec.Replicas) && replicaMaxOrdinal <= lastOrdinal; replicaMaxOrdinal++ {
 		if reserveOrdinals.Has(replicaMaxOrdinal) {
 			continue
 		}
@@ -788,3 +861,40 @@ func getStatefulSetReplicasRange(set *appsv1beta1.StatefulSet) (int, int, sets.I
 	}
 	return getStartOrdinal(set), replicaMaxOrdinal, reserveOrdinals
 }
+
+// getReplicaOrdinals returns the ordinals of the replicas in ascending order, so that replicas[i] is the Pod of
+// ordinals[i], along with the reserveOrdinals.
+// If Spec.Ordinals.List is set, the ordinals are the first Spec.Replicas listed ones not in reserveOrdinals, without
+// filling the gaps between them. Otherwise, they are the range [startOrdinal, endOrdinal) of getStatefulSetReplicasRange,
+// where the reserveOrdinals are left nil in replicas.
+func getReplicaOrdinals(set *appsv1beta1.StatefulSet) ([]int, sets.Int) {
+	listed := getListedOrdinals(set)
+	if listed == nil {
+		startOrdinal, endOrdinal, reserveOrdinals := getStatefulSetReplicasRange(set)
+		ordinals := make([]int, 0, endOrdinal-startOrdinal)
+		for ord := startOrdinal; ord < endOrdinal; ord++ {
+			ordinals = append(ordinals, ord)
+		}
+		return ordinals, reserveOrdinals
+	}
+
+	reserveOrdinals := sets.NewInt(set.Spec.ReserveOrdinals...)
+	ordinals := make([]int, 0, len(listed))
+	for _, ord := range listed {
+		if len(ordinals) >= int(*set.Spec.Replicas) {
+			break
+		}
+		if !reserveOrdinals.Has(ord) {
+			ordinals = append(ordinals, ord)
+		}
+	}
+	return ordinals, reserveOrdinals
+}
+
+// getReplicaIndex returns the index of the ordinal in the ascending ordinals, or -1 if it is not found.
+func getReplicaIndex(ordinals []int, ordinal int) int {
+	if i := sort.SearchInts(ordinals, ordinal); i < len(ordinals) && ordinals[i] == ordinal {
+		return i
+	}
+	return -1
+}
@@ -19,6 +19,7 @@ package statefulset
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
 	"reflect"
 	"regexp"
@@ -965,6 +966,21 @@ func TestGetStatefulSetReplicasRange(t *testing.T) {
 			expectedCount: 4,
 			expectedRes:   sets.NewInt(1, 3),
 		},
+		{
+			name: "Ordinals start 5 end 7 with ReserveOrdinals 6",
+			statefulSet: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					Replicas:        int32Ptr(4),
+					ReserveOrdinals: []int{6},
+					Ordinals: &appsv1beta1.StatefulSetOrdinals{
+						Start: 5,
+						End:   int32Ptr(7),
+					},
+				},
+			},
+			expectedCount: 3,
+			expectedRes:   sets.NewInt(6),
+		},
 		// ... other test cases
 	}
 	defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.StatefulSetStartOrdinal, true)()
@@ -982,6 +998,72 @@ func TestGetStatefulSetReplicasRange(t *testing.T) {
 	}
 }
 
+func TestGetReplicaOrdinals(t *testing.T) {
+	defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.StatefulSetStartOrdinal, true)()
+	tests := []struct {
+		name             string
+		replicas         int32
+		reserveOrdinals  []int
+		ordinals         *appsv1beta1.StatefulSetOrdinals
+		expectedOrdinals []int
+	}{
+		{
+			name:             "ReserveOrdinals 1 without ordinals",
+			replicas:         3,
+			reserveOrdinals:  []int{1},
+			expectedOrdinals: []int{0, 1, 2, 3},
+		},
+		{
+			name:             "Ordinals list 9&2&4",
+			replicas:         3,
+			ordinals:         &appsv1beta1.StatefulSetOrdinals{List: []int32{9, 2, 4}},
+			expectedOrdinals: []int{2, 4, 9},
+		},
+		{
+			name:             "Ordinals list 9&2&4 with fewer replicas",
+			replicas:         2,
+			ordinals:         &appsv1beta1.StatefulSetOrdinals{List: []int32{9, 2, 4}},
+			expectedOrdinals: []int{2, 4},
+		},
+		{
+			name:             "Ordinals list 9&2&4 with ReserveOrdinals 4",
+			replicas:         3,
+			reserveOrdinals:  []int{4},
+			ordinals:         &appsv1beta1.StatefulSetOrdinals{List: []int32{9, 2, 4}},
+			expectedOrdinals: []int{2, 9},
+		},
+		{
+			name:             "Ordinals list with huge ordinals",
+			replicas:         2,
+			ordinals:         &appsv1beta1.StatefulSetOrdinals{List: []int32{math.MaxInt32 - 1, 0}},
+			expectedOrdinals: []int{0, math.MaxInt32 - 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					Replicas:        &tt.replicas,
+					ReserveOrdinals: tt.reserveOrdinals,
+					Ordinals:        tt.ordinals,
+				},
+			}
+			ordinals, _ := getReplicaOrdinals(set)
+			if !reflect.DeepEqual(ordinals, tt.expectedOrdinals) {
+				t.Errorf("getReplicaOrdinals(%v) got %v, want %v", tt.name, ordinals, tt.expectedOrdinals)
+			}
+			for i, ordinal := range ordinals {
+				if index := getReplicaIndex(ordinals, ordinal); index != i {
+					t.Errorf("getReplicaIndex(%v, %v) got %v, want %v", ordinals, ordinal, index, i)
+				}
+			}
+			if index := getReplicaIndex(ordinals, 5); index != -1 {
+				t.Errorf("getReplicaIndex(%v, 5) got %v, want -1", ordinals, index)
+			}
+		})
+	}
+}
+
 func newStorageClass(name string, canExpand bool) storagev1.StorageClass {
 	return storagev1.StorageClass{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1770,6 +1852,83 @@ func TestIsCurrentRevisionNeeded(t *testing.T) {
 			}(),
 			expectedRes: false,
 		},
+		{
+			// ordinals list [2,4,9], replicas 3, partition 2
+			// 2, 9: current revision
+			// => 4: should be current revision, as it is the second replica in list order
+			name: "Ordinals list 9&2&4, partition 2, create pod4",
+			statefulSet: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					Replicas: int32Ptr(3),
+					Ordinals: &appsv1beta1.StatefulSetOrdinals{
+						List: []int32{9, 2, 4},
+					},
+					UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{
+						Type: apps.RollingUpdateStatefulSetStrategyType,
+						RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+							Partition: int32Ptr(2),
+						},
+					},
+				},
+			},
+			updateRevision: updatedRevisionHash,
+			ordinal:        4,
+			replicas: func() []*corev1.Pod {
+				pods := newReplicas(2, 1, currentRevisionHash)
+				pods = append(pods, newReplicas(9, 1, currentRevisionHash)...)
+				return pods
+			}(),
+			expectedRes: true,
+		},
+		{
+			// ordinals list [2,4,9], replicas 3, partition 2
+			// 2, 4: current revision
+			// => 9: should be updated revision, as it is the third replica in list order
+			name: "Ordinals list 9&2&4, partition 2, create pod9",
+			statefulSet: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					Replicas: int32Ptr(3),
+					Ordinals: &appsv1beta1.StatefulSetOrdinals{
+						List: []int32{9, 2, 4},
+					},
+					UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{
+						Type: apps.RollingUpdateStatefulSetStrategyType,
+						RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+							Partition: int32Ptr(2),
+						},
+					},
+				},
+			},
+			updateRevision: updatedRevisionHash,
+			ordinal:        9,
+			replicas:       newReplicas(2, 1, currentRevisionHash),
+			expectedRes:    false,
+		},
+		{
+			// ordinals list [2,4,9], reservedId 4, replicas 2, partition 2
+			// 2: current revision
+			// => 9: should be current revision, as it is the second replica in list order
+			name: "Ordinals list 9&2&4, reservedId 4, partition 2, create pod9",
+			statefulSet: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					Replicas:        int32Ptr(2),
+					ReserveOrdinals: []int{4},
+					Ordinals: &appsv1beta1.StatefulSetOrdinals{
+						List: []int32{9, 2, 4},
+					},
+					UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{
+						Type: apps.RollingUpdateStatefulSetStrategyType,
+						RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+							Partition: int32Ptr(2),
+						},
+					},
+				},
+			},
+			updateRevision: updatedRevisionHash,
+			ordinal:        9,
+			replicas:       newReplicas(2, 1, currentRevisionHash),
+			expectedRes:    true,
+		},
 	}
 
 	for _, tt := range tests {
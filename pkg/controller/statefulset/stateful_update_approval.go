@@ -0,0 +1,94 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+)
+
+// updateApprovalRetryInterval is the interval to check again the Pods waiting for update approval.
+const updateApprovalRetryInterval = 10 * time.Second
+
+// getRolePriority returns the role priority of the rolling update if it is set.
+func getRolePriority(set *appsv1beta1.StatefulSet) *appsv1beta1.UpdatePriorityRoleTerm {
+	rollingUpdate := set.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil || rollingUpdate.UnorderedUpdate == nil {
+		return nil
+	}
+	return rollingUpdate.UnorderedUpdate.RolePriority
+}
+
+// isLeaderUpdateBlocked returns whether the leaders should wait, because some Pods of the other roles
+// have not been updated to the update revision or are not available yet.
+func isLeaderUpdateBlocked(rolePriority *appsv1beta1.UpdatePriorityRoleTerm, updateRevision string, replicas []*v1.Pod, unavailablePods sets.String) bool {
+	if rolePriority == nil {
+		return false
+	}
+	for _, pod := range replicas {
+		if pod == nil || rolePriority.IsLeader(pod.Labels) {
+			continue
+		}
+		if getPodRevision(pod) != updateRevision || unavailablePods.Has(pod.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// approvePodUpdate returns whether the Pod has been approved to be updated to the update revision by the
+// PreUpdateApproval hook. If the hook has a web approval, it will be requested in background and the Pod will be
+// annotated as approved once the webhook approves it. The StatefulSet will be requeued if the Pod is still waiting.
+func (ssc *defaultStatefulSetControl) approvePodUpdate(set *appsv1beta1.StatefulSet, pod *v1.Pod, updateRevision string) (bool, error) {
+	if set.Spec.PreUpdateApproval == nil {
+		return true, nil
+	}
+	// the pod in the middle of an update has been approved
+	if state := lifecycle.GetPodLifecycleState(pod); state == appspub.LifecycleStatePreparingUpdate || state == appspub.LifecycleStateUpdating ||
+		lifecycle.IsPodUpdateApproved(pod, updateRevision) {
+		return true, nil
+	}
+
+	approved, err := ssc.updateApprover.RequestPodUpdateApproval(set.Spec.PreUpdateApproval, pod, updateRevision)
+	if err != nil {
+		klog.ErrorS(err, "StatefulSet failed to request update approval", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
+	}
+	if !approved {
+		klog.V(4).InfoS("StatefulSet was waiting for update approval of Pod", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod), "updateRevision", updateRevision)
+		durationStore.Push(getStatefulSetKey(set), updateApprovalRetryInterval)
+		return false, nil
+	}
+
+	clone := pod.DeepCopy()
+	if clone.Annotations == nil {
+		clone.Annotations = map[string]string{}
+	}
+	clone.Annotations[appspub.LifecycleUpdateApprovedKey] = updateRevision
+	if err := ssc.podControl.objectMgr.UpdatePod(clone); err != nil {
+		return false, err
+	}
+	klog.V(3).InfoS("StatefulSet approved update of Pod", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod), "updateRevision", updateRevision)
+	// wait for the approved pod to be observed before updating it
+	return false, nil
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise/pkg/client/clientset/versioned/fake"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
+)
+
+func TestIsLeaderUpdateBlocked(t *testing.T) {
+	rolePriority := &appsv1beta1.UpdatePriorityRoleTerm{RoleKey: "role", LeaderValues: []string{"leader"}}
+	set := newStatefulSet(3)
+	newReplicas := func(updated ...int) []*v1.Pod {
+		replicas := make([]*v1.Pod, 3)
+		for i := range replicas {
+			replicas[i] = newStatefulSetPod(set, i)
+			replicas[i].Labels[apps.ControllerRevisionHashLabelKey] = "rev-old"
+			replicas[i].Labels["role"] = "replica"
+		}
+		replicas[0].Labels["role"] = "leader"
+		for _, i := range updated {
+			replicas[i].Labels[apps.ControllerRevisionHashLabelKey] = "rev-new"
+		}
+		return replicas
+	}
+
+	cases := []struct {
+		name         string
+		rolePriority *appsv1beta1.UpdatePriorityRoleTerm
+		updated      []int
+		unavailable  []string
+		expected     bool
+	}{
+		{
+			name:     "no role priority",
+			expected: false,
+		},
+		{
+			name:         "replicas not updated",
+			rolePriority: rolePriority,
+			updated:      []int{2},
+			expected:     true,
+		},
+		{
+			name:         "replicas updated but not available",
+			rolePriority: rolePriority,
+			updated:      []int{1, 2},
+			unavailable:  []string{"foo-1"},
+			expected:     true,
+		},
+		{
+			name:         "replicas updated and available",
+			rolePriority: rolePriority,
+			updated:      []int{1, 2},
+			unavailable:  []string{"foo-0"},
+			expected:     false,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isLeaderUpdateBlocked(tc.rolePriority, "rev-new", newReplicas(tc.updated...), sets.NewString(tc.unavailable...)); got != tc.expected {
+				t.Fatalf("expected blocked %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestApprovePodUpdate(t *testing.T) {
+	// approve the update to rev-new only
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req lifecycle.UpdateApprovalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Revision != "rev-new" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	set := newStatefulSet(1)
+	set.Spec.PreUpdateApproval = &appspub.LifecycleApprovalHook{
+		Web: &appspub.LifecycleWebApproval{URL: server.URL},
+	}
+	pod := newStatefulSetPod(set, 0)
+	om, _, _, stop := setupController(fake.NewSimpleClientset(), kruisefake.NewSimpleClientset(set))
+	defer close(stop)
+	if err := om.podsIndexer.Add(pod); err != nil {
+		t.Fatalf("failed to add pod: %v", err)
+	}
+	ssc := &defaultStatefulSetControl{
+		podControl:     NewStatefulPodControlFromManager(om, &noopRecorder{}),
+		updateApprover: lifecycle.NewUpdateApprover(server.Client()),
+	}
+
+	// annotate the pod once the webhook approves the update in background
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		if approved, err := ssc.approvePodUpdate(set, pod, "rev-new"); err != nil || approved {
+			return false, fmt.Errorf("expected pod waiting to be observed, got %v, %v", approved, err)
+		}
+		pod, err := om.GetPod(set.Namespace, pod.Name)
+		if err != nil {
+			return false, err
+		}
+		return pod.Annotations[appspub.LifecycleUpdateApprovedKey] == "rev-new", nil
+	})
+	if err != nil {
+		t.Fatalf("failed to wait for pod approved: %v", err)
+	}
+	pod, err = om.GetPod(set.Namespace, pod.Name)
+	if err != nil {
+		t.Fatalf("failed to get pod: %v", err)
+	}
+	if approved, err := ssc.approvePodUpdate(set, pod, "rev-new"); err != nil || !approved {
+		t.Fatalf("expected pod approved, got %v, %v", approved, err)
+	}
+
+	// the approval does not cover the next revision, which is rejected by the webhook
+	for i := 0; i < 3; i++ {
+		if approved, err := ssc.approvePodUpdate(set, pod, "rev-next"); err != nil || approved {
+			t.Fatalf("expected pod not approved for the next revision, got %v, %v", approved, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
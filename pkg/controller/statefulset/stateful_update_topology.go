@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	intstrutil "k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+)
+
+// topologyDomainUpdate is the topology domain whose pods are being updated.
+type topologyDomainUpdate struct {
+	domain        string
+	updateIndexes []int
+	// maxUnavailable is the limit of all unavailable pods of the StatefulSet, which enforces both
+	// the global maxUnavailable and the maxUnavailable within the domain.
+	maxUnavailable int
+	// unavailablePods is the unavailable pods within the domain.
+	unavailablePods sets.String
+	// pendingDomains is the number of the later domains which are blocked until the domain is done.
+	pendingDomains int
+}
+
+// maxStatusPodNames is the max number of pod names listed in the status of the topology domain update.
+const maxStatusPodNames = 10
+
+// status returns the status of the topology domain update, which shows the unavailable pods blocking the later domains,
+// or nil if the domain neither has unavailable pods nor blocks any later domain.
+func (u *topologyDomainUpdate) status() *appsv1beta1.TopologyDomainUpdateStatus {
+	if u.unavailablePods.Len() == 0 && u.pendingDomains == 0 {
+		return nil
+	}
+	var unavailablePods []string
+	if u.unavailablePods.Len() > 0 {
+		unavailablePods = u.unavailablePods.List()
+	}
+	if len(unavailablePods) > maxStatusPodNames {
+		unavailablePods = unavailablePods[:maxStatusPodNames]
+	}
+	return &appsv1beta1.TopologyDomainUpdateStatus{
+		Domain:              u.domain,
+		UnavailableReplicas: int32(u.unavailablePods.Len()),
+		UnavailablePods:     unavailablePods,
+		PendingDomains:      int32(u.pendingDomains),
+	}
+}
+
+// getTopologyDomainStrategy returns the topology domain strategy of the rolling update if it is set.
+func getTopologyDomainStrategy(set *appsv1beta1.StatefulSet) *appsv1beta1.TopologyDomainUpdateStrategy {
+	rollingUpdate := set.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil || rollingUpdate.UnorderedUpdate == nil {
+		return nil
+	}
+	return rollingUpdate.UnorderedUpdate.TopologyDomainStrategy
+}
+
+// getPodTopologyDomain returns the value of the topology key on the node of the pod,
+// or empty if the pod has not been scheduled.
+func (ssc *defaultStatefulSetControl) getPodTopologyDomain(pod *v1.Pod, topologyKey string) (string, error) {
+	if pod.Spec.NodeName == "" {
+		return "", nil
+	}
+	node, err := ssc.podControl.objectMgr.GetNode(pod.Spec.NodeName)
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", fmt.Errorf("could not get node %s of pod %s: %v", pod.Spec.NodeName, pod.Name, err)
+	}
+	return node.Labels[topologyKey], nil
+}
+
+// limitUpdateToTopologyDomain picks the first topology domain, in the order of the pods to update, which still has
+// pods not updated or not available. It returns the pods to update in that domain, so that the next domain will not be
+// updated until the picked one is done, and the limit of all unavailable pods, which is reached once either the
+// global maxUnavailable or the maxUnavailable counted within that domain is reached.
+func (ssc *defaultStatefulSetControl) limitUpdateToTopologyDomain(
+	set *appsv1beta1.StatefulSet,
+	topologyKey string,
+	updateRevision string,
+	replicas []*v1.Pod,
+	updateIndexes []int,
+	maxUnavailable int,
+	unavailablePods sets.String,
+) (*topologyDomainUpdate, error) {
+	podDomains := make(map[int]string, len(replicas))
+	domainSizes := map[string]int{}
+	for i := range replicas {
+		if replicas[i] == nil {
+			continue
+		}
+		domain, err := ssc.getPodTopologyDomain(replicas[i], topologyKey)
+		if err != nil {
+			return nil, err
+		}
+		podDomains[i] = domain
+		domainSizes[domain]++
+	}
+
+	var domains []string
+	domainIndexes := map[string][]int{}
+	pending := sets.NewString()
+	for _, target := range updateIndexes {
+		domain := podDomains[target]
+		if _, ok := domainIndexes[domain]; !ok {
+			domains = append(domains, domain)
+		}
+		domainIndexes[domain] = append(domainIndexes[domain], target)
+		if getPodRevision(replicas[target]) != updateRevision || unavailablePods.Has(replicas[target].Name) {
+			pending.Insert(domain)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, nil
+	}
+
+	picked := domains[0]
+	for _, domain := range domains {
+		if pending.Has(domain) {
+			picked = domain
+			break
+		}
+	}
+	pendingDomains := pending.Len()
+	if pending.Has(picked) {
+		pendingDomains--
+	}
+
+	domainMaxUnavailable, err := intstrutil.GetValueFromIntOrPercent(
+		intstrutil.ValueOrDefault(set.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable, intstrutil.FromInt(1)), domainSizes[picked], false)
+	if err != nil {
+		return nil, err
+	}
+	// maxUnavailable should not be less than 1
+	if domainMaxUnavailable < 1 {
+		domainMaxUnavailable = 1
+	}
+
+	domainUnavailablePods := sets.NewString()
+	for i, domain := range podDomains {
+		if domain == picked && unavailablePods.Has(replicas[i].Name) {
+			domainUnavailablePods.Insert(replicas[i].Name)
+		}
+	}
+	// the pods unavailable out of the domain are counted in the global limit only
+	if limit := unavailablePods.Len() - domainUnavailablePods.Len() + domainMaxUnavailable; limit < maxUnavailable {
+		maxUnavailable = limit
+	}
+	return &topologyDomainUpdate{
+		domain:          picked,
+		updateIndexes:   domainIndexes[picked],
+		maxUnavailable:  maxUnavailable,
+		unavailablePods: domainUnavailablePods,
+		pendingDomains:  pendingDomains,
+	}, nil
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"reflect"
+	"testing"
+
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+)
+
+func TestLimitUpdateToTopologyDomain(t *testing.T) {
+	const zoneKey = "topology.kubernetes.io/zone"
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for name, zone := range map[string]string{"node-a": "zone-a", "node-b": "zone-b"} {
+		if err := nodeIndexer.Add(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{zoneKey: zone}}}); err != nil {
+			t.Fatalf("failed to add node: %v", err)
+		}
+	}
+	spc := NewStatefulPodControl(&fake.Clientset{}, nil, nil, nil, record.NewFakeRecorder(10), WithNodeLister(corelisters.NewNodeLister(nodeIndexer)))
+	ssc := &defaultStatefulSetControl{podControl: spc}
+
+	// pods with even ordinals are in zone-a and odd ones are in zone-b
+	newReplicas := func(set *appsv1beta1.StatefulSet, updated ...int) []*v1.Pod {
+		replicas := make([]*v1.Pod, 6)
+		for i := range replicas {
+			replicas[i] = newStatefulSetPod(set, i)
+			replicas[i].Labels[apps.ControllerRevisionHashLabelKey] = "rev-old"
+			replicas[i].Spec.NodeName = "node-a"
+			if i%2 == 1 {
+				replicas[i].Spec.NodeName = "node-b"
+			}
+		}
+		for _, i := range updated {
+			replicas[i].Labels[apps.ControllerRevisionHashLabelKey] = "rev-new"
+		}
+		return replicas
+	}
+
+	cases := []struct {
+		name            string
+		maxUnavailable  intstr.IntOrString
+		updated         []int
+		unavailable     []string
+		unscheduled     []int
+		expectedDomain  string
+		expectedIndexes []int
+		// expectedMaxUnavailable is the limit of all unavailable pods
+		expectedMaxUnavailable  int
+		expectedUnavailablePods []string
+		expectedStatus          *appsv1beta1.TopologyDomainUpdateStatus
+	}{
+		{
+			name:                   "update the first domain",
+			maxUnavailable:         intstr.FromString("50%"),
+			unavailable:            []string{"foo-0"},
+			expectedDomain:         "zone-b",
+			expectedIndexes:        []int{5, 3, 1},
+			expectedMaxUnavailable: 2,
+			expectedStatus:         &appsv1beta1.TopologyDomainUpdateStatus{Domain: "zone-b", PendingDomains: 1},
+		},
+		{
+			name:                   "limited by the global maxUnavailable",
+			maxUnavailable:         intstr.FromInt(2),
+			unavailable:            []string{"foo-0", "foo-2"},
+			expectedDomain:         "zone-b",
+			expectedIndexes:        []int{5, 3, 1},
+			expectedMaxUnavailable: 2,
+			expectedStatus:         &appsv1beta1.TopologyDomainUpdateStatus{Domain: "zone-b", PendingDomains: 1},
+		},
+		{
+			name:                    "wait for the updated domain to be available",
+			maxUnavailable:          intstr.FromString("100%"),
+			updated:                 []int{5, 3, 1},
+			unavailable:             []string{"foo-3", "foo-4"},
+			expectedDomain:          "zone-b",
+			expectedIndexes:         []int{5, 3, 1},
+			expectedMaxUnavailable:  4,
+			expectedUnavailablePods: []string{"foo-3"},
+			expectedStatus: &appsv1beta1.TopologyDomainUpdateStatus{
+				Domain: "zone-b", UnavailableReplicas: 1, UnavailablePods: []string{"foo-3"}, PendingDomains: 1,
+			},
+		},
+		{
+			name:                    "move to the next domain",
+			maxUnavailable:          intstr.FromInt(2),
+			updated:                 []int{5, 3, 1},
+			unavailable:             []string{"foo-4"},
+			expectedDomain:          "zone-a",
+			expectedIndexes:         []int{4, 2, 0},
+			expectedMaxUnavailable:  2,
+			expectedUnavailablePods: []string{"foo-4"},
+			expectedStatus: &appsv1beta1.TopologyDomainUpdateStatus{
+				Domain: "zone-a", UnavailableReplicas: 1, UnavailablePods: []string{"foo-4"},
+			},
+		},
+		{
+			name:                   "unscheduled pods are in empty domain",
+			maxUnavailable:         intstr.FromInt(1),
+			updated:                []int{5, 4, 3, 2, 1},
+			unscheduled:            []int{0},
+			expectedDomain:         "",
+			expectedIndexes:        []int{0},
+			expectedMaxUnavailable: 1,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			set := newStatefulSet(6)
+			set.Spec.UpdateStrategy.RollingUpdate = &appsv1beta1.RollingUpdateStatefulSetStrategy{
+				MaxUnavailable: &tc.maxUnavailable,
+				UnorderedUpdate: &appsv1beta1.UnorderedUpdateStrategy{
+					TopologyDomainStrategy: &appsv1beta1.TopologyDomainUpdateStrategy{TopologyKey: zoneKey},
+				},
+			}
+			replicas := newReplicas(set, tc.updated...)
+			globalMaxUnavailable, _ := intstr.GetScaledValueFromIntOrPercent(&tc.maxUnavailable, len(replicas), false)
+			for _, i := range tc.unscheduled {
+				replicas[i].Spec.NodeName = ""
+			}
+
+			domainUpdate, err := ssc.limitUpdateToTopologyDomain(set, zoneKey, "rev-new", replicas, []int{5, 4, 3, 2, 1, 0}, globalMaxUnavailable, sets.NewString(tc.unavailable...))
+			if err != nil {
+				t.Fatalf("failed to limit update to topology domain: %v", err)
+			}
+			if domainUpdate.domain != tc.expectedDomain || !reflect.DeepEqual(domainUpdate.updateIndexes, tc.expectedIndexes) ||
+				domainUpdate.maxUnavailable != tc.expectedMaxUnavailable || !domainUpdate.unavailablePods.Equal(sets.NewString(tc.expectedUnavailablePods...)) {
+				t.Fatalf("unexpected domain update: domain %q, indexes %v, maxUnavailable %d, unavailable %v",
+					domainUpdate.domain, domainUpdate.updateIndexes, domainUpdate.maxUnavailable, domainUpdate.unavailablePods.List())
+			}
+			if status := domainUpdate.status(); !reflect.DeepEqual(status, tc.expectedStatus) {
+				t.Fatalf("expected status %+v, got %+v", tc.expectedStatus, status)
+			}
+		})
+	}
+}
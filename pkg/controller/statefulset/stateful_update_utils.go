@@ -17,6 +17,8 @@ limitations under the License.
 package statefulset
 
 import (
+	"sort"
+
 	v1 "k8s.io/api/core/v1"
 
 	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
@@ -65,6 +67,12 @@ func sortPodsToUpdate(rollingUpdateStrategy *appsv1beta1.RollingUpdateStatefulSe
 	if priorityStrategy != nil {
 		waitUpdateIdxs = updatesort.NewPrioritySorter(priorityStrategy).Sort(replicas, waitUpdateIdxs)
 	}
+	// the leaders should always be updated after the other roles
+	if rolePriority := rollingUpdateStrategy.UnorderedUpdate.RolePriority; rolePriority != nil {
+		sort.SliceStable(waitUpdateIdxs, func(i, j int) bool {
+			return !rolePriority.IsLeader(replicas[waitUpdateIdxs[i]].Labels) && rolePriority.IsLeader(replicas[waitUpdateIdxs[j]].Labels)
+		})
+	}
 
 	allIdxs := append(updatedIdxs, waitUpdateIdxs...)
 	if len(allIdxs) > maxUpdate {
@@ -155,6 +155,22 @@ func TestSortPodsToUpdate(t *testing.T) {
 			},
 			expected: []int{8, 7, 1, 0},
 		},
+		{
+			strategy: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+				UnorderedUpdate: &appsv1beta1.UnorderedUpdateStrategy{RolePriority: &appsv1beta1.UpdatePriorityRoleTerm{
+					RoleKey:      "role",
+					LeaderValues: []string{"leader"},
+				}},
+			},
+			updateRevision: "r1",
+			totalReplicas:  3,
+			replicas: []*v1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{apps.ControllerRevisionHashLabelKey: "r0", "role": "follower"}}},
+				{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{apps.ControllerRevisionHashLabelKey: "r0", "role": "leader"}}},
+				{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{apps.ControllerRevisionHashLabelKey: "r0"}}},
+			},
+			expected: []int{2, 0, 1},
+		},
 	}
 
 	defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.PreparingUpdateAsUpdate, true)()
@@ -166,3 +182,27 @@ func TestSortPodsToUpdate(t *testing.T) {
 		}
 	}
 }
+
+func TestSortPodsToUpdateWithOrdinalsList(t *testing.T) {
+	defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.StatefulSetStartOrdinal, true)()
+	partition := int32(1)
+	set := newStatefulSet(3)
+	set.Spec.Ordinals = &appsv1beta1.StatefulSetOrdinals{List: []int32{9, 2, 4}}
+	set.Spec.UpdateStrategy.RollingUpdate = &appsv1beta1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+
+	ordinals, _ := getReplicaOrdinals(set)
+	replicas := make([]*v1.Pod, len(ordinals))
+	for i, ordinal := range ordinals {
+		replicas[i] = newStatefulSetPod(set, ordinal)
+		setPodRevision(replicas[i], "r0")
+	}
+
+	// the partition counts the replicas in list order, so the ones after the first listed ordinal are updated
+	var updatedOrdinals []int
+	for _, target := range sortPodsToUpdate(set.Spec.UpdateStrategy.RollingUpdate, "r1", *set.Spec.Replicas, replicas) {
+		updatedOrdinals = append(updatedOrdinals, getOrdinal(replicas[target]))
+	}
+	if expected := []int{9, 4}; !reflect.DeepEqual(updatedOrdinals, expected) {
+		t.Fatalf("expected ordinals %v to update, got %v", expected, updatedOrdinals)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	utilpointer "k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/util/analysis"
+)
+
+// syncAnalysisRun maintains the analysis run of the current update batch in status.
+func (ssc *defaultStatefulSetControl) syncAnalysisRun(set *appsv1beta1.StatefulSet, status *appsv1beta1.StatefulSetStatus) {
+	rollingUpdate := set.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil {
+		status.AnalysisRun = nil
+		return
+	}
+
+	replicas := *set.Spec.Replicas
+	expectedUpdatedReplicas := replicas
+	if rollingUpdate.Partition != nil {
+		expectedUpdatedReplicas -= *rollingUpdate.Partition
+	}
+	if expectedUpdatedReplicas < 0 {
+		expectedUpdatedReplicas = 0
+	}
+	rollout := analysis.Rollout{
+		Args: analysis.Args{
+			Namespace: set.Namespace,
+			Name:      set.Name,
+			Kind:      controllerKind.Kind,
+			UID:       set.UID,
+			Revision:  status.UpdateRevision,
+		},
+		Generation:              set.Generation,
+		Paused:                  rollingUpdate.Paused,
+		CurrentRevision:         status.CurrentRevision,
+		Replicas:                replicas,
+		ExpectedUpdatedReplicas: expectedUpdatedReplicas,
+		UpdatedReadyReplicas:    status.UpdatedReadyReplicas,
+	}
+	run, requeueDuration := ssc.analyzer.Sync(context.TODO(), rollingUpdate.Analysis, set.Status.AnalysisRun, rollout, metav1.Now())
+	status.AnalysisRun = run
+	if requeueDuration > 0 {
+		durationStore.Push(getStatefulSetKey(set), requeueDuration)
+	}
+}
+
+// holdPartitionForAnalysis returns the rolling update strategy whose partition has been raised, so that no
+// more pods will be updated than the batch whose analysis has not passed yet.
+func holdPartitionForAnalysis(set *appsv1beta1.StatefulSet, updateRevision string) *appsv1beta1.RollingUpdateStatefulSetStrategy {
+	rollingUpdate := set.Spec.UpdateStrategy.RollingUpdate
+	if rollingUpdate == nil {
+		return nil
+	}
+	held, ok := analysis.GetHeldUpdatedReplicas(rollingUpdate.Analysis, set.Status.AnalysisRun, updateRevision)
+	if !ok {
+		return rollingUpdate
+	}
+	partition := *set.Spec.Replicas - held
+	if partition < 0 {
+		partition = 0
+	}
+	if rollingUpdate.Partition != nil && *rollingUpdate.Partition >= partition {
+		return rollingUpdate
+	}
+	klog.V(4).InfoS("StatefulSet held partition for analysis", "statefulSet", klog.KObj(set), "partition", partition)
+	rollingUpdate = rollingUpdate.DeepCopy()
+	rollingUpdate.Partition = utilpointer.Int32(partition)
+	return rollingUpdate
+}
+
+// pauseOnAnalysisFailed pauses the StatefulSet if the analysis of the current batch has just failed.
+func (ssc *defaultStatefulSetControl) pauseOnAnalysisFailed(set *appsv1beta1.StatefulSet, status *appsv1beta1.StatefulSetStatus) error {
+	rollingUpdate := set.Spec.UpdateStrategy.RollingUpdate
+	if sigsruntimeClient == nil || rollingUpdate == nil || rollingUpdate.Paused || !analysis.IsNewlyFailed(status.AnalysisRun, set.Generation) {
+		return nil
+	}
+
+	body := `{"spec":{"updateStrategy":{"rollingUpdate":{"paused":true}}}}`
+	if err := sigsruntimeClient.Patch(context.TODO(), set.DeepCopy(), client.RawPatch(types.MergePatchType, []byte(body))); err != nil {
+		ssc.recorder.Eventf(set, v1.EventTypeWarning, "FailedPause", "failed to pause StatefulSet for failed analysis: %v", err)
+		return err
+	}
+	klog.InfoS("Paused StatefulSet for failed analysis", "statefulSet", klog.KObj(set), "updatedReplicas", status.AnalysisRun.UpdatedReplicas)
+	ssc.recorder.Eventf(set, v1.EventTypeWarning, "AnalysisFailed", "paused StatefulSet for failed analysis of %d updated replicas: %s",
+		status.AnalysisRun.UpdatedReplicas, status.AnalysisRun.Message)
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"testing"
+
+	utilpointer "k8s.io/utils/pointer"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+)
+
+func TestHoldPartitionForAnalysis(t *testing.T) {
+	cases := []struct {
+		name              string
+		partition         int32
+		run               *appspub.AnalysisRunStatus
+		expectedPartition int32
+	}{
+		{
+			name:              "no analysis run",
+			partition:         3,
+			expectedPartition: 3,
+		},
+		{
+			name:              "analysis running",
+			partition:         0,
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 1, Phase: appspub.AnalysisPhaseRunning},
+			expectedPartition: 4,
+		},
+		{
+			name:              "analysis passed",
+			partition:         2,
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 1, Phase: appspub.AnalysisPhaseSuccessful},
+			expectedPartition: 2,
+		},
+		{
+			name:              "analysis of old revision",
+			partition:         0,
+			run:               &appspub.AnalysisRunStatus{UpdateRevision: "v1", UpdatedReplicas: 1, Phase: appspub.AnalysisPhaseFailed},
+			expectedPartition: 0,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			set := &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					Replicas: utilpointer.Int32(5),
+					UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{
+						RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+							Partition: utilpointer.Int32(tc.partition),
+							Analysis: &appspub.RolloutAnalysis{
+								Metrics: []appspub.AnalysisMetric{{Name: "stub", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}},
+							},
+						},
+					},
+				},
+				Status: appsv1beta1.StatefulSetStatus{AnalysisRun: tc.run},
+			}
+			strategy := holdPartitionForAnalysis(set, "v2")
+			if *strategy.Partition != tc.expectedPartition {
+				t.Fatalf("expected partition %d, got %d", tc.expectedPartition, *strategy.Partition)
+			}
+			if *set.Spec.UpdateStrategy.RollingUpdate.Partition != tc.partition {
+				t.Fatalf("expected the partition of set not modified")
+			}
+		})
+	}
+}
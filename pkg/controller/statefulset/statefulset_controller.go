@@ -56,12 +56,15 @@ import (
 	kruiseappslisters "github.com/openkruise/kruise/pkg/client/listers/apps/v1beta1"
 	"github.com/openkruise/kruise/pkg/features"
 	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
 	utilclient "github.com/openkruise/kruise/pkg/util/client"
 	utildiscovery "github.com/openkruise/kruise/pkg/util/discovery"
 	"github.com/openkruise/kruise/pkg/util/expectations"
 	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
 	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
 	"github.com/openkruise/kruise/pkg/util/ratelimiter"
 	"github.com/openkruise/kruise/pkg/util/requeueduration"
 	"github.com/openkruise/kruise/pkg/util/revisionadapter"
@@ -132,11 +135,16 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 	if err != nil {
 		return nil, err
 	}
+	nodeInformer, err := cacher.GetInformerForKind(context.TODO(), v1.SchemeGroupVersion.WithKind("Node"))
+	if err != nil {
+		return nil, err
+	}
 
 	statefulSetLister := kruiseappslisters.NewStatefulSetLister(statefulSetInformer.(toolscache.SharedIndexInformer).GetIndexer())
 	podLister := corelisters.NewPodLister(podInformer.(toolscache.SharedIndexInformer).GetIndexer())
 	pvcLister := corelisters.NewPersistentVolumeClaimLister(pvcInformer.(toolscache.SharedIndexInformer).GetIndexer())
 	scLister := storagelisters.NewStorageClassLister(scInformer.(toolscache.SharedIndexInformer).GetIndexer())
+	nodeLister := corelisters.NewNodeLister(nodeInformer.(toolscache.SharedIndexInformer).GetIndexer())
 
 	genericClient := client.GetGenericClientWithName("statefulset-controller")
 	eventBroadcaster := record.NewBroadcaster()
@@ -146,6 +154,7 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 
 	// new a client
 	sigsruntimeClient = utilclient.NewClientFromManager(mgr, "statefulset-controller")
+	analyzer := analysis.NewDefaultAnalyzer()
 
 	return &ReconcileStatefulSet{
 		kruiseClient: genericClient.KruiseClient,
@@ -155,16 +164,22 @@ func newReconciler(mgr manager.Manager) (reconcile.Reconciler, error) {
 				podLister,
 				pvcLister,
 				scLister,
-				recorder),
+				recorder,
+				WithNodeLister(nodeLister),
+				WithSnapshotClient(utilclient.NewNoCacheClientFromManager(mgr, "statefulset-controller"))),
 			inplaceupdate.New(utilclient.NewClientFromManager(mgr, "statefulset-controller"), revisionadapter.NewDefaultImpl()),
 			lifecycle.New(utilclient.NewClientFromManager(mgr, "statefulset-controller")),
+			podreadiness.NewForAdapter(&podadapter.AdapterRuntimeClient{Client: utilclient.NewClientFromManager(mgr, "statefulset-controller")}),
 			NewRealStatefulSetStatusUpdater(genericClient.KruiseClient, statefulSetLister),
 			history.NewHistory(genericClient.KubeClient, appslisters.NewControllerRevisionLister(revInformer.(toolscache.SharedIndexInformer).GetIndexer())),
 			recorder,
+			analyzer,
+			lifecycle.NewDefaultUpdateApprover(),
 		),
 		podControl: kubecontroller.RealPodControl{KubeClient: genericClient.KubeClient, Recorder: recorder},
 		podLister:  podLister,
 		setLister:  statefulSetLister,
+		analyzer:   analyzer,
 	}, nil
 }
 
@@ -183,6 +198,8 @@ type ReconcileStatefulSet struct {
 	podLister corelisters.PodLister
 	// setLister is able to list/get stateful sets from a shared informer's store
 	setLister kruiseappslisters.StatefulSetLister
+	// analyzer measures the metrics of rollout analysis
+	analyzer *analysis.Analyzer
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
@@ -234,6 +251,8 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;create;delete
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.kruise.io,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.kruise.io,resources=statefulsets/status,verbs=get;update;patch
@@ -264,6 +283,9 @@ func (ssc *ReconcileStatefulSet) Reconcile(ctx context.Context, request reconcil
 	if errors.IsNotFound(err) {
 		klog.InfoS("StatefulSet deleted", "statefulSet", key)
 		updateExpectations.DeleteExpectations(key)
+		if ssc.analyzer != nil {
+			ssc.analyzer.Forget(controllerKind.Kind, namespace, name)
+		}
 		return reconcile.Result{}, nil
 	}
 	if err != nil {
@@ -55,8 +55,11 @@ import (
 	kruiseinformers "github.com/openkruise/kruise/pkg/client/informers/externalversions"
 	kruiseappsinformers "github.com/openkruise/kruise/pkg/client/informers/externalversions/apps/v1beta1"
 	kruiseappslisters "github.com/openkruise/kruise/pkg/client/listers/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/util/analysis"
 	"github.com/openkruise/kruise/pkg/util/inplaceupdate"
 	"github.com/openkruise/kruise/pkg/util/lifecycle"
+	"github.com/openkruise/kruise/pkg/util/podadapter"
+	"github.com/openkruise/kruise/pkg/util/podreadiness"
 	"github.com/openkruise/kruise/pkg/util/revisionadapter"
 )
 
@@ -639,7 +642,8 @@ func newFakeStatefulSetController(initialObjects ...runtime.Object) (*StatefulSe
 	recorder := record.NewFakeRecorder(10)
 	inplaceControl := inplaceupdate.NewForInformer(informerFactory.Core().V1().Pods(), revisionadapter.NewDefaultImpl())
 	lifecycleControl := lifecycle.NewForInformer(informerFactory.Core().V1().Pods())
-	ssc.control = NewDefaultStatefulSetControl(fpc, inplaceControl, lifecycleControl, ssu, ssh, recorder)
+	podReadinessControl := podreadiness.NewForAdapter(&podadapter.AdapterInformer{PodInformer: informerFactory.Core().V1().Pods()})
+	ssc.control = NewDefaultStatefulSetControl(fpc, inplaceControl, lifecycleControl, podReadinessControl, ssu, ssh, recorder, analysis.NewDefaultAnalyzer(), lifecycle.NewDefaultUpdateApprover())
 
 	return ssc, om
 }
@@ -800,9 +804,12 @@ func NewStatefulSetController(
 					recorder),
 				inplaceupdate.NewForTypedClient(kubeClient, revisionadapter.NewDefaultImpl()),
 				lifecycle.NewForTypedClient(kubeClient),
+				podreadiness.NewForAdapter(&podadapter.AdapterTypedClient{Client: kubeClient}),
 				NewRealStatefulSetStatusUpdater(kruiseClient, setInformer.Lister()),
 				history.NewHistory(kubeClient, revInformer.Lister()),
 				recorder,
+				analysis.NewDefaultAnalyzer(),
+				lifecycle.NewDefaultUpdateApprover(),
 			),
 			podControl: controller.RealPodControl{KubeClient: kubeClient, Recorder: recorder},
 			podLister:  podInformer.Lister(),
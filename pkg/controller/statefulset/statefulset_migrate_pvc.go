@@ -0,0 +1,245 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog/v2"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/util/pvc"
+)
+
+// volumeSnapshotGVK is the kind of the snapshots backing up the migrating claims, and taken on scale-down.
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+const (
+	// PVCMigrationSourceAnnotationKey is set on the backup snapshot with the name of the claim being migrated.
+	PVCMigrationSourceAnnotationKey = "apps.kruise.io/pvc-migration-source"
+	// PVCMigratingAnnotationKey is set on the claim to be migrated, before its Pod is deleted. The Pod will not be
+	// recreated until the claim has been backed up and deleted.
+	PVCMigratingAnnotationKey = "apps.kruise.io/pvc-migrating"
+
+	// snapshotCheckInterval is the interval to check again the snapshots not ready to use.
+	snapshotCheckInterval = 5 * time.Second
+)
+
+// getVolumeSnapshotState returns whether the snapshot is ready to use, and the error message if it failed.
+func getVolumeSnapshotState(snapshot *unstructured.Unstructured) (bool, string) {
+	ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse")
+	message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+	return ready, message
+}
+
+// getMigrationBackupName returns the name of the backup snapshot used to migrate the given claim.
+func getMigrationBackupName(claimName string) string {
+	return claimName + "-migration-backup"
+}
+
+// newMigrationBackupSnapshot returns a snapshot of the given claim. Unlike cloning a claim, a snapshot is not bound to
+// the storage class, so the claim could be restored from it with the storage class of the template.
+// The snapshot is not owned by the StatefulSet, so the data will not be lost if the StatefulSet is deleted during migration.
+func newMigrationBackupSnapshot(set *appsv1beta1.StatefulSet, claim *v1.PersistentVolumeClaim) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"persistentVolumeClaimName": claim.Name,
+			},
+		},
+	}}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetNamespace(claim.Namespace)
+	snapshot.SetName(getMigrationBackupName(claim.Name))
+	snapshot.SetAnnotations(map[string]string{
+		PVCOwnedByStsAnnotationKey:      set.Name,
+		PVCMigrationSourceAnnotationKey: claim.Name,
+	})
+	return snapshot
+}
+
+// MarkPVCMigrating marks the claims of the Pod which are incompatible with the templates to be migrated.
+// The Pod should be deleted after that, so that the claims are backed up when no longer written.
+func (spc *StatefulPodControl) MarkPVCMigrating(set *appsv1beta1.StatefulSet, pod *v1.Pod) error {
+	fn := func(claim, template *v1.PersistentVolumeClaim) (bool, error) {
+		if matched, _ := pvc.CompareWithCheckFn(claim, template, pvc.IsPVCNeedExpand); matched {
+			return true, nil
+		}
+		if _, ok := claim.Annotations[PVCMigratingAnnotationKey]; ok {
+			return true, nil
+		}
+		claimClone := claim.DeepCopy()
+		if claimClone.Annotations == nil {
+			claimClone.Annotations = map[string]string{}
+		}
+		claimClone.Annotations[PVCMigratingAnnotationKey] = "true"
+		if err := spc.objectMgr.UpdateClaim(claimClone); err != nil {
+			return false, fmt.Errorf("could not mark claim %s migrating: %w", claim.Name, err)
+		}
+		return true, nil
+	}
+	_, err := spc.handlePVCWithCustomFn(set, pod, true, fn)
+	return err
+}
+
+// TryMigratePVC takes snapshots of the claims marked to be migrated, after their Pod has been deleted, and deletes
+// the claims once the snapshots are ready to use. It returns whether there is no claim left to be migrated, so that
+// the Pod could be recreated along with the claims restoring the snapshots.
+func (spc *StatefulPodControl) TryMigratePVC(set *appsv1beta1.StatefulSet, pod *v1.Pod) (bool, error) {
+	fn := func(claim, template *v1.PersistentVolumeClaim) (bool, error) {
+		if _, ok := claim.Annotations[PVCMigratingAnnotationKey]; !ok {
+			return true, nil
+		} else if claim.DeletionTimestamp != nil {
+			// wait for the claim to be deleted before recreating it
+			return false, nil
+		}
+		backupName := getMigrationBackupName(claim.Name)
+		backup, err := spc.objectMgr.GetVolumeSnapshot(claim.Namespace, backupName)
+		if apierrors.IsNotFound(err) {
+			backup = newMigrationBackupSnapshot(set, claim)
+			err = spc.objectMgr.CreateVolumeSnapshot(backup)
+			spc.recordClaimEvent("backup", set, pod, claim, err)
+			if err != nil {
+				return false, fmt.Errorf("could not create backup snapshot %s: %w", backupName, err)
+			}
+			klog.V(2).InfoS("StatefulSet created backup snapshot for migration", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod), "claim", klog.KObj(claim))
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("could not retrieve backup snapshot %s for %s: %w", backupName, pod.Name, err)
+		}
+
+		ready, message := getVolumeSnapshotState(backup)
+		if message != "" {
+			return false, fmt.Errorf("backup snapshot %s for %s failed: %s", backupName, pod.Name, message)
+		} else if !ready {
+			return false, nil
+		}
+		err = spc.objectMgr.DeleteClaim(claim)
+		spc.recordClaimEvent("delete", set, pod, claim, err)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return false, fmt.Errorf("could not delete claim %s: %w", claim.Name, err)
+		}
+		return false, nil
+	}
+	return spc.handlePVCWithCustomFn(set, pod, false, fn)
+}
+
+// CleanupMigratedPVC deletes the backup snapshots once the claims of the Pod have been rebuilt and bound.
+func (spc *StatefulPodControl) CleanupMigratedPVC(set *appsv1beta1.StatefulSet, pod *v1.Pod) error {
+	ordinal := getOrdinal(pod)
+	for i := range set.Spec.VolumeClaimTemplates {
+		claimName := getPersistentVolumeClaimName(set, &set.Spec.VolumeClaimTemplates[i], ordinal)
+		backup, err := spc.objectMgr.GetVolumeSnapshot(set.Namespace, getMigrationBackupName(claimName))
+		if apierrors.IsNotFound(err) || (err == nil && backup.GetDeletionTimestamp() != nil) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		claim, err := spc.objectMgr.GetClaim(set.Namespace, claimName)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if claim.DeletionTimestamp != nil || claim.Status.Phase != v1.ClaimBound {
+			continue
+		}
+
+		err = spc.objectMgr.DeleteVolumeSnapshot(backup)
+		spc.recordClaimEvent("cleanup", set, pod, claim, err)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("could not delete backup snapshot %s: %w", backup.GetName(), err)
+		}
+		klog.V(2).InfoS("StatefulSet finished claim migration", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod), "claim", klog.KObj(claim))
+	}
+	return nil
+}
+
+// setMigrationDataSource makes the claim to be created restore the data from its backup snapshot if exists.
+func (spc *StatefulPodControl) setMigrationDataSource(claim *v1.PersistentVolumeClaim) error {
+	backup, err := spc.objectMgr.GetVolumeSnapshot(claim.Namespace, getMigrationBackupName(claim.Name))
+	if apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to retrieve backup snapshot for %s: %s", claim.Name, err)
+	}
+	if backup.GetAnnotations()[PVCMigrationSourceAnnotationKey] != claim.Name || backup.GetDeletionTimestamp() != nil {
+		return nil
+	}
+	if ready, _ := getVolumeSnapshotState(backup); !ready {
+		return fmt.Errorf("backup snapshot %s for %s is not ready to use", backup.GetName(), claim.Name)
+	}
+	apiGroup := volumeSnapshotGVK.Group
+	claim.Spec.DataSource = &v1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     volumeSnapshotGVK.Kind,
+		Name:     backup.GetName(),
+	}
+	return nil
+}
+
+// updatePVCMigrationStatus counts the replicas whose claims are being migrated, and records the pending or failed
+// backups and restores if any.
+func (ssc *defaultStatefulSetControl) updatePVCMigrationStatus(status *appsv1beta1.StatefulSetStatus, set *appsv1beta1.StatefulSet, pods []*v1.Pod) {
+	for _, pod := range pods {
+		if pod == nil {
+			continue
+		}
+		ordinal := getOrdinal(pod)
+		for i := range set.Spec.VolumeClaimTemplates {
+			claimName := getPersistentVolumeClaimName(set, &set.Spec.VolumeClaimTemplates[i], ordinal)
+			templateStatus := &status.VolumeClaims[i]
+			backup, err := ssc.podControl.objectMgr.GetVolumeSnapshot(set.Namespace, getMigrationBackupName(claimName))
+			if err != nil {
+				// the claim is waiting for its Pod to be deleted before backed up
+				if claim, err := ssc.podControl.objectMgr.GetClaim(set.Namespace, claimName); err == nil && claim.DeletionTimestamp == nil {
+					if _, ok := claim.Annotations[PVCMigratingAnnotationKey]; ok {
+						templateStatus.MigratingReplicas++
+					}
+				}
+				continue
+			}
+			templateStatus.MigratingReplicas++
+
+			var message string
+			var failed bool
+			if _, snapshotMessage := getVolumeSnapshotState(backup); snapshotMessage != "" {
+				message, failed = fmt.Sprintf("backup snapshot %s failed: %s", backup.GetName(), snapshotMessage), true
+			} else if claim, err := ssc.podControl.objectMgr.GetClaim(set.Namespace, claimName); err == nil &&
+				claim.Spec.DataSource != nil && claim.Spec.DataSource.Name == backup.GetName() {
+				switch claim.Status.Phase {
+				case v1.ClaimLost:
+					message, failed = fmt.Sprintf("claim %s restored from %s is lost", claimName, backup.GetName()), true
+				case v1.ClaimPending:
+					message = fmt.Sprintf("claim %s is pending to be restored from %s", claimName, backup.GetName())
+				}
+			}
+			if failed {
+				templateStatus.FailedReplicas++
+			}
+			if message != "" {
+				templateStatus.Message = message
+			}
+		}
+	}
+}
@@ -0,0 +1,205 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise/pkg/client/clientset/versioned/fake"
+	"github.com/openkruise/kruise/pkg/features"
+	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
+)
+
+func TestMigratePVC(t *testing.T) {
+	defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.StatefulSetAutoResizePVCGate, true)()
+	// storage classes of the same provisioner, which could restore the snapshots from each other
+	oldSC := newStorageClass("disk-old", true)
+	oldSC.Provisioner = "disk.csi.k8s.io"
+	newSC := newStorageClass("disk-new", true)
+	newSC.Provisioner = "disk.csi.k8s.io"
+
+	set := newStatefulSetWithGivenSC(1, 1, []*string{&newSC.Name})
+	set.Spec.VolumeClaimUpdateStrategy.Type = appsv1beta1.MigrateVolumeClaimUpdateStrategyType
+	pod := newStatefulSetPod(set, 0)
+	claimName := getPersistentVolumeClaimName(set, &set.Spec.VolumeClaimTemplates[0], 0)
+	backupName := getMigrationBackupName(claimName)
+
+	client := fake.NewSimpleClientset(&oldSC, &newSC)
+	kruiseClient := kruisefake.NewSimpleClientset(set)
+	om, _, _, stop := setupController(client, kruiseClient)
+	defer close(stop)
+	spc := NewStatefulPodControlFromManager(om, &noopRecorder{})
+	ssc := &defaultStatefulSetControl{podControl: spc}
+
+	claim := newPVC(claimName)
+	claim.Spec.StorageClassName = &oldSC.Name
+	claim.Status.Phase = v1.ClaimBound
+	if err := om.CreateClaim(&claim); err != nil {
+		t.Fatalf("failed to create claim: %v", err)
+	}
+	setClaimPhase := func(name string, phase v1.PersistentVolumeClaimPhase) {
+		c, err := om.GetClaim(set.Namespace, name)
+		if err != nil {
+			t.Fatalf("failed to get claim %s: %v", name, err)
+		}
+		c = c.DeepCopy()
+		c.Status.Phase = phase
+		if err := om.claimsIndexer.Update(c); err != nil {
+			t.Fatalf("failed to update claim %s: %v", name, err)
+		}
+	}
+	setSnapshotStatus := func(fields map[string]interface{}) {
+		snapshot, err := om.GetVolumeSnapshot(set.Namespace, backupName)
+		if err != nil {
+			t.Fatalf("failed to get snapshot: %v", err)
+		}
+		snapshot = snapshot.DeepCopy()
+		snapshot.Object["status"] = fields
+		if err := om.snapshotsIndexer.Update(snapshot); err != nil {
+			t.Fatalf("failed to update snapshot: %v", err)
+		}
+	}
+
+	// mark the incompatible claim to be migrated before the pod is deleted
+	if err := spc.MarkPVCMigrating(set, pod); err != nil {
+		t.Fatalf("failed to mark claim migrating: %v", err)
+	}
+	marked, err := om.GetClaim(set.Namespace, claimName)
+	if err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+	if _, ok := marked.Annotations[PVCMigratingAnnotationKey]; !ok {
+		t.Fatalf("expected claim marked migrating, got %v", marked.Annotations)
+	}
+	if _, err := om.GetVolumeSnapshot(set.Namespace, backupName); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected no backup taken while the pod is running, got %v", err)
+	}
+	status := &appsv1beta1.StatefulSetStatus{}
+	ssc.updatePVCStatus(status, set, []*v1.Pod{pod})
+	if status.VolumeClaims[0].MigratingReplicas != 1 || status.VolumeClaims[0].FailedReplicas != 0 {
+		t.Fatalf("unexpected volume claim status %+v", status.VolumeClaims[0])
+	}
+
+	// back up the idle claim into a snapshot after the pod deleted
+	if migrated, err := spc.TryMigratePVC(set, pod); err != nil || migrated {
+		t.Fatalf("expected backup created and not ready, got %v, %v", migrated, err)
+	}
+	backup, err := om.GetVolumeSnapshot(set.Namespace, backupName)
+	if err != nil {
+		t.Fatalf("failed to get backup snapshot: %v", err)
+	}
+	if source, _, _ := unstructured.NestedString(backup.Object, "spec", "source", "persistentVolumeClaimName"); source != claimName {
+		t.Fatalf("unexpected backup snapshot %v", backup.Object)
+	}
+	if migrated, err := spc.TryMigratePVC(set, pod); err != nil || migrated {
+		t.Fatalf("expected claim kept before backed up, got %v, %v", migrated, err)
+	}
+	if _, err := om.GetClaim(set.Namespace, claimName); err != nil {
+		t.Fatalf("expected claim kept before backed up, got %v", err)
+	}
+
+	// report the failure if the snapshot failed
+	setSnapshotStatus(map[string]interface{}{"readyToUse": false, "error": map[string]interface{}{"message": "snapshot failed"}})
+	if migrated, err := spc.TryMigratePVC(set, pod); err == nil || migrated {
+		t.Fatalf("expected backup failed, got %v, %v", migrated, err)
+	}
+	status = &appsv1beta1.StatefulSetStatus{}
+	ssc.updatePVCStatus(status, set, []*v1.Pod{pod})
+	if status.VolumeClaims[0].FailedReplicas != 1 || status.VolumeClaims[0].Message == "" {
+		t.Fatalf("expected backup failure reported, got %+v", status.VolumeClaims[0])
+	}
+
+	// delete the claim once backed up, and rebuild it from the template with the new storage class restoring the snapshot
+	setSnapshotStatus(map[string]interface{}{"readyToUse": true})
+	if migrated, err := spc.TryMigratePVC(set, pod); err != nil || migrated {
+		t.Fatalf("expected claim deleted, got %v, %v", migrated, err)
+	}
+	if _, err := om.GetClaim(set.Namespace, claimName); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected incompatible claim deleted, got %v", err)
+	}
+	if migrated, err := spc.TryMigratePVC(set, pod); err != nil || !migrated {
+		t.Fatalf("expected claims migrated, got %v, %v", migrated, err)
+	}
+	if err := spc.createPersistentVolumeClaims(set, pod); err != nil {
+		t.Fatalf("failed to create claims: %v", err)
+	}
+	restored, err := om.GetClaim(set.Namespace, claimName)
+	if err != nil {
+		t.Fatalf("failed to get restored claim: %v", err)
+	}
+	if *restored.Spec.StorageClassName != newSC.Name || restored.Spec.DataSource == nil ||
+		restored.Spec.DataSource.Kind != "VolumeSnapshot" || restored.Spec.DataSource.Name != backupName {
+		t.Fatalf("unexpected restored claim spec %+v", restored.Spec)
+	}
+
+	// report the pending restore without failure
+	setClaimPhase(claimName, v1.ClaimPending)
+	status = &appsv1beta1.StatefulSetStatus{}
+	ssc.updatePVCStatus(status, set, []*v1.Pod{pod})
+	if status.VolumeClaims[0].FailedReplicas != 0 || status.VolumeClaims[0].Message == "" {
+		t.Fatalf("expected pending restore reported, got %+v", status.VolumeClaims[0])
+	}
+
+	// report the failure if the restored claim is lost
+	setClaimPhase(claimName, v1.ClaimLost)
+	status = &appsv1beta1.StatefulSetStatus{}
+	ssc.updatePVCStatus(status, set, []*v1.Pod{pod})
+	if status.VolumeClaims[0].FailedReplicas != 1 || status.VolumeClaims[0].Message == "" {
+		t.Fatalf("expected migration failure reported, got %+v", status.VolumeClaims[0])
+	}
+
+	// clean up the backup after the restored claim bound
+	if err := spc.CleanupMigratedPVC(set, pod); err != nil {
+		t.Fatalf("failed to clean up backup: %v", err)
+	}
+	if _, err := om.GetVolumeSnapshot(set.Namespace, backupName); err != nil {
+		t.Fatalf("expected backup kept before restored claim bound, got %v", err)
+	}
+	setClaimPhase(claimName, v1.ClaimBound)
+	if err := spc.CleanupMigratedPVC(set, pod); err != nil {
+		t.Fatalf("failed to clean up backup: %v", err)
+	}
+	if _, err := om.GetVolumeSnapshot(set.Namespace, backupName); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected backup deleted, got %v", err)
+	}
+	status = &appsv1beta1.StatefulSetStatus{}
+	ssc.updatePVCStatus(status, set, []*v1.Pod{pod})
+	if status.VolumeClaims[0].MigratingReplicas != 0 || status.VolumeClaims[0].CompatibleReplicas != 1 {
+		t.Fatalf("unexpected volume claim status %+v", status.VolumeClaims[0])
+	}
+}
+
+func TestMigrationBackupSnapshotOwnership(t *testing.T) {
+	set := newStatefulSetWithGivenSC(1, 1, nil)
+	claim := newPVC("datadir-0-foo-0")
+	backup := newMigrationBackupSnapshot(set, &claim)
+	if len(backup.GetOwnerReferences()) != 0 {
+		t.Fatalf("expected backup snapshot not owned, got %v", backup.GetOwnerReferences())
+	}
+	if backup.GetAnnotations()[PVCMigrationSourceAnnotationKey] != claim.Name || backup.GetAnnotations()[PVCOwnedByStsAnnotationKey] != set.Name {
+		t.Fatalf("unexpected backup snapshot annotations %v", backup.GetAnnotations())
+	}
+	if backup.GetName() == claim.Name || backup.GetNamespace() != claim.Namespace {
+		t.Fatalf("unexpected backup snapshot %s/%s", backup.GetNamespace(), backup.GetName())
+	}
+}
@@ -18,9 +18,11 @@ package statefulset
 
 import (
 	"fmt"
+	"sort"
 
 	v1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
 	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
@@ -71,6 +73,9 @@ type PVCHandler interface {
 
 func (spc *StatefulPodControl) IsOwnedPVCsReady(set *appsv1beta1.StatefulSet, pod *v1.Pod) (bool, error) {
 	checkFn := func(claim, template *v1.PersistentVolumeClaim) (bool, error) {
+		if isPVCResizeAbandoned(claim) {
+			return true, nil
+		}
 		_, ready := pvc.IsPVCCompatibleAndReady(claim, template)
 		if !ready {
 			return false, nil
@@ -133,6 +138,9 @@ func (spc *StatefulPodControl) TryPatchPVC(set *appsv1beta1.StatefulSet, pod *v1
 
 func (spc *StatefulPodControl) IsOwnedPVCsCompleted(set *appsv1beta1.StatefulSet, pod *v1.Pod) (bool, error) {
 	checkFn := func(claim, template *v1.PersistentVolumeClaim) (bool, error) {
+		if isPVCResizeAbandoned(claim) {
+			return true, nil
+		}
 		completed := pvc.IsPatchPVCCompleted(claim, template)
 		if !completed {
 			return false, nil
@@ -151,15 +159,27 @@ func (ssc *defaultStatefulSetControl) updatePVCStatus(status *appsv1beta1.Statef
 		status.VolumeClaims[i].VolumeClaimName = templates[i].Name
 		templateNameMap[templates[i].Name] = &status.VolumeClaims[i]
 	}
+	if set.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType {
+		ssc.updatePVCMigrationStatus(status, set, pods)
+	}
 
+	var ordinal int
 	fn := func(claim, template *v1.PersistentVolumeClaim) (bool, error) {
+		templateStatus := templateNameMap[template.Name]
 		if compatible, ready := pvc.IsPVCCompatibleAndReady(claim, template); compatible {
-			templateStatus := templateNameMap[template.Name]
 			templateStatus.CompatibleReplicas++
 			if ready {
 				templateStatus.CompatibleReadyReplicas++
 			}
 		}
+		if resizeStatus, failed := pvc.GetPVCResizeFailure(claim); failed {
+			templateStatus.ResizeFailures = append(templateStatus.ResizeFailures, appsv1beta1.VolumeClaimResizeFailure{
+				Ordinal:   int32(ordinal),
+				ClaimName: claim.Name,
+				Status:    resizeStatus,
+				Abandoned: isPVCResizeAbandoned(claim),
+			})
+		}
 		return true, nil
 	}
 
@@ -170,11 +190,46 @@ func (ssc *defaultStatefulSetControl) updatePVCStatus(status *appsv1beta1.Statef
 			continue
 		}
 
-		success, err := ssc.podControl.handlePVCWithCustomFn(set, pod, true, fn)
-		if err != nil || !success {
-			return
+		// keep on counting the claims of the other pods, so that all the failed resizes are reported
+		ordinal = getOrdinal(pod)
+		if _, err := ssc.podControl.handlePVCWithCustomFn(set, pod, true, fn); err != nil {
+			klog.V(4).ErrorS(err, "StatefulSet failed to refresh pvc status", "statefulSet", klog.KObj(set), "pod", klog.KObj(pod))
 		}
 	}
+	for i := range status.VolumeClaims {
+		failures := status.VolumeClaims[i].ResizeFailures
+		sort.Slice(failures, func(a, b int) bool { return failures[a].Ordinal < failures[b].Ordinal })
+	}
+}
+
+// getStuckResizeClaims returns the names of the claims whose resize has failed and not been abandoned,
+// and which belong to the pods still waiting to be updated to the update revision.
+// The failed resizes of the claims of updated pods do not block the rolling update.
+func getStuckResizeClaims(status *appsv1beta1.StatefulSetStatus, replicas []*v1.Pod, updateRevision string) []string {
+	outdatedOrdinals := sets.NewInt32()
+	for _, pod := range replicas {
+		if pod != nil && getPodRevision(pod) != updateRevision {
+			outdatedOrdinals.Insert(int32(getOrdinal(pod)))
+		}
+	}
+	var claimNames []string
+	for _, volumeClaim := range status.VolumeClaims {
+		for _, failure := range volumeClaim.ResizeFailures {
+			if !failure.Abandoned && outdatedOrdinals.Has(failure.Ordinal) {
+				claimNames = append(claimNames, failure.ClaimName)
+			}
+		}
+	}
+	return claimNames
+}
+
+// isPVCResizeAbandoned returns whether the resize of the claim has failed and been abandoned by user,
+// so that the claim is considered as ready with the old size.
+func isPVCResizeAbandoned(claim *v1.PersistentVolumeClaim) bool {
+	if _, failed := pvc.GetPVCResizeFailure(claim); !failed {
+		return false
+	}
+	return claim.Annotations[appsv1beta1.AbandonPVCResizeAnnotationKey] == "true"
 }
 
 type handlePVCWithFailFastFn = func(claim, template *v1.PersistentVolumeClaim) (success bool, err error)
@@ -216,6 +271,8 @@ func resizeClaim(set *appsv1beta1.StatefulSet, claim, template *v1.PersistentVol
 	claim.Annotations[PVCOwnedByStsAnnotationKey] = set.Name
 	if pvc.IsPVCNeedExpand(claim, template) {
 		claim.Spec.Resources = template.Spec.Resources
+		// the abandonment only applies to the failed resize before
+		delete(claim.Annotations, appsv1beta1.AbandonPVCResizeAnnotationKey)
 		return true
 	}
 	return false
@@ -621,3 +621,65 @@ func TestIsOwnedPVCsCompleted(t *testing.T) {
 		})
 	}
 }
+
+func TestPVCResizeFailure(t *testing.T) {
+	sc1 := newStorageClass("can_expand", true)
+	set := newStatefulSetWithGivenSC(2, 2, []*string{&sc1.Name, &sc1.Name})
+	set.Spec.VolumeClaimUpdateStrategy.Type = appsv1beta1.OnPodRollingUpdateVolumeClaimUpdateStrategyType
+	for i := range set.Spec.VolumeClaimTemplates {
+		set.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse("1Gi")
+	}
+	failedPVC := func(name string, resizeStatus v1.ClaimResourceStatus, abandoned bool) *v1.PersistentVolumeClaim {
+		claim := newTestPVCWithSC(name, &sc1.Name, true, false, nil)
+		claim.Status.AllocatedResourceStatuses = map[v1.ResourceName]v1.ClaimResourceStatus{v1.ResourceStorage: resizeStatus}
+		if abandoned {
+			claim.Annotations = map[string]string{appsv1beta1.AbandonPVCResizeAnnotationKey: "true"}
+		}
+		return &claim
+	}
+	readyPVC := func(name string) *v1.PersistentVolumeClaim {
+		claim := newTestPVCWithSC(name, &sc1.Name, true, true, nil)
+		return &claim
+	}
+
+	client := fake.NewSimpleClientset(&sc1,
+		failedPVC("datadir-0-foo-1", v1.PersistentVolumeClaimNodeResizeFailed, true), readyPVC("datadir-1-foo-1"),
+		failedPVC("datadir-0-foo-0", v1.PersistentVolumeClaimControllerResizeFailed, false), readyPVC("datadir-1-foo-0"))
+	kruiseClient := kruisefake.NewSimpleClientset(set)
+	om, _, ctrl, stop := setupController(client, kruiseClient)
+	defer close(stop)
+	spc := NewStatefulPodControlFromManager(om, &noopRecorder{})
+	ssc := ctrl.(*defaultStatefulSetControl)
+	pods := []*v1.Pod{newStatefulSetPod(set, 0), newStatefulSetPod(set, 1)}
+
+	status := &appsv1beta1.StatefulSetStatus{}
+	ssc.updatePVCStatus(status, set, pods)
+	expectedFailures := []appsv1beta1.VolumeClaimResizeFailure{
+		{Ordinal: 0, ClaimName: "datadir-0-foo-0", Status: v1.PersistentVolumeClaimControllerResizeFailed},
+		{Ordinal: 1, ClaimName: "datadir-0-foo-1", Status: v1.PersistentVolumeClaimNodeResizeFailed, Abandoned: true},
+	}
+	assert.Equal(t, expectedFailures, status.VolumeClaims[0].ResizeFailures)
+	assert.Empty(t, status.VolumeClaims[1].ResizeFailures)
+	assert.Equal(t, []string{"datadir-0-foo-0"}, getStuckResizeClaims(status, pods, "r1"))
+	// the failed resize of an updated pod does not block the rolling update
+	updatedPod := pods[0].DeepCopy()
+	setPodRevision(updatedPod, "r1")
+	assert.Empty(t, getStuckResizeClaims(status, []*v1.Pod{updatedPod, pods[1]}, "r1"))
+
+	// the abandoned claim is considered as ready with the old size
+	for i, expected := range []bool{false, true} {
+		ready, err := spc.IsOwnedPVCsReady(set, pods[i])
+		assert.Nil(t, err)
+		assert.Equal(t, expected, ready, "ready of pod %s", pods[i].Name)
+		completed, err := spc.IsOwnedPVCsCompleted(set, pods[i])
+		assert.Nil(t, err)
+		assert.Equal(t, expected, completed, "completed of pod %s", pods[i].Name)
+	}
+
+	// a new resize is not abandoned
+	claim := failedPVC("datadir-0-foo-1", v1.PersistentVolumeClaimNodeResizeFailed, true)
+	template := set.Spec.VolumeClaimTemplates[0].DeepCopy()
+	template.Spec.Resources.Requests[v1.ResourceStorage] = resource.MustParse("2Gi")
+	assert.True(t, resizeClaim(set, claim, template))
+	assert.NotContains(t, claim.Annotations, appsv1beta1.AbandonPVCResizeAnnotationKey)
+}
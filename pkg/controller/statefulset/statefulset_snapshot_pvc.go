@@ -0,0 +1,120 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog/v2"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+)
+
+// PVCSnapshotSourceAnnotationKey is set on the snapshot with the name of the claim it is taken from.
+const PVCSnapshotSourceAnnotationKey = "apps.kruise.io/pvc-snapshot-source"
+
+// getScaleDownSnapshotName returns the name of the snapshot taken from the claim on scale-down. The uid of the claim
+// is included, so that a claim recreated after scaling up again will be snapshotted again on the next scale-down.
+func getScaleDownSnapshotName(claim *v1.PersistentVolumeClaim) string {
+	uid := string(claim.UID)
+	if len(uid) > 8 {
+		uid = uid[:8]
+	}
+	if uid == "" {
+		return claim.Name
+	}
+	return fmt.Sprintf("%s-%s", claim.Name, uid)
+}
+
+// newScaleDownSnapshot returns a snapshot of the claim. The snapshot is not owned by the StatefulSet or the Pod,
+// so it will be kept after the claim has been deleted.
+func newScaleDownSnapshot(set *appsv1beta1.StatefulSet, claim *v1.PersistentVolumeClaim, snapshotClassName *string) *unstructured.Unstructured {
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"source": map[string]interface{}{
+				"persistentVolumeClaimName": claim.Name,
+			},
+		},
+	}}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetNamespace(claim.Namespace)
+	snapshot.SetName(getScaleDownSnapshotName(claim))
+	snapshot.SetLabels(set.Spec.Selector.MatchLabels)
+	snapshot.SetAnnotations(map[string]string{
+		PVCOwnedByStsAnnotationKey:     set.Name,
+		PVCSnapshotSourceAnnotationKey: claim.Name,
+	})
+	if snapshotClassName != nil && *snapshotClassName != "" {
+		_ = unstructured.SetNestedField(snapshot.Object, *snapshotClassName, "spec", "volumeSnapshotClassName")
+	}
+	return snapshot
+}
+
+// TrySnapshotPVC takes snapshots of the claims of the Pod being scaled down whose templates have the Snapshot policy,
+// and returns whether all of the snapshots are ready to use, so that the Pod and its claims could be deleted.
+// The scale-down keeps blocked as long as any snapshot is not ready, which is reported by Warning events if the
+// snapshot failed or the VolumeSnapshot CRDs are not installed.
+func (spc *StatefulPodControl) TrySnapshotPVC(set *appsv1beta1.StatefulSet, pod *v1.Pod) (bool, error) {
+	allReady := true
+	for templateName, template := range getPersistentVolumeClaims(set, pod) {
+		policy := getClaimTemplateRetentionPolicy(set, templateName)
+		if policy.WhenScaled != appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType {
+			continue
+		}
+		claim, err := spc.objectMgr.GetClaim(template.Namespace, template.Name)
+		if apierrors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return false, fmt.Errorf("could not get claim %s: %w", template.Name, err)
+		}
+
+		snapshotName := getScaleDownSnapshotName(claim)
+		snapshot, err := spc.objectMgr.GetVolumeSnapshot(claim.Namespace, snapshotName)
+		if apierrors.IsNotFound(err) {
+			snapshot = newScaleDownSnapshot(set, claim, policy.VolumeSnapshotClassName)
+			err = spc.objectMgr.CreateVolumeSnapshot(snapshot)
+			spc.recordClaimEvent("snapshot", set, pod, claim, err)
+			if err != nil {
+				return false, fmt.Errorf("could not create snapshot %s of claim %s: %w", snapshotName, claim.Name, err)
+			}
+			allReady = false
+			continue
+		} else if meta.IsNoMatchError(err) {
+			// the claim will never be deleted without the snapshot, so the scale-down is blocked until the
+			// VolumeSnapshot CRDs have been installed or the policy has been changed.
+			err = fmt.Errorf("VolumeSnapshot is not available in the cluster: %w", err)
+			spc.recordClaimEvent("snapshot", set, pod, claim, err)
+			return false, err
+		} else if err != nil {
+			return false, fmt.Errorf("could not get snapshot %s of claim %s: %w", snapshotName, claim.Name, err)
+		}
+
+		if ready, message := getVolumeSnapshotState(snapshot); !ready {
+			if message != "" {
+				klog.V(3).InfoS("StatefulSet found snapshot of claim failed", "statefulSet", klog.KObj(set),
+					"claim", klog.KObj(claim), "snapshot", snapshotName, "message", message)
+				spc.recordClaimEvent("snapshot", set, pod, claim, fmt.Errorf("snapshot %s is not ready: %s", snapshotName, message))
+			}
+			allReady = false
+		}
+	}
+	return allReady, nil
+}
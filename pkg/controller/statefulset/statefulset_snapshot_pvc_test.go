@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package statefulset
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+	utilpointer "k8s.io/utils/pointer"
+
+	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	kruisefake "github.com/openkruise/kruise/pkg/client/clientset/versioned/fake"
+)
+
+func TestClaimTemplateRetentionPolicy(t *testing.T) {
+	set := newStatefulSetWithGivenSC(3, 2, nil)
+	set.UID = "set-uid"
+	set.Spec.PersistentVolumeClaimRetentionPolicy = &appsv1beta1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+		WhenDeleted:             appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType,
+		WhenScaled:              appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType,
+		VolumeSnapshotClassName: utilpointer.String("csi-hostpath-snapclass"),
+		Templates: []appsv1beta1.VolumeClaimTemplateRetentionPolicy{
+			{Name: "datadir-1", WhenDeleted: appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType, WhenScaled: appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType},
+		},
+	}
+
+	dataPolicy := getClaimTemplateRetentionPolicy(set, "datadir-0")
+	if dataPolicy.WhenScaled != appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType ||
+		dataPolicy.WhenDeleted != appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType || *dataPolicy.VolumeSnapshotClassName != "csi-hostpath-snapclass" {
+		t.Fatalf("expected policy inherited from the set, got %+v", dataPolicy)
+	}
+	cachePolicy := getClaimTemplateRetentionPolicy(set, "datadir-1")
+	if cachePolicy.WhenScaled != appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType ||
+		cachePolicy.WhenDeleted != appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType {
+		t.Fatalf("expected policy overridden by the template, got %+v", cachePolicy)
+	}
+
+	// the claims of the pod scaled down are owned by the pod for snapshot, and by the set for the overridden template
+	pod := newStatefulSetPod(set, 3)
+	pod.UID = "pod-uid"
+	claims := getPersistentVolumeClaims(set, pod)
+	dataClaim, cacheClaim := claims["datadir-0"], claims["datadir-1"]
+	if !updateClaimOwnerRefForSetAndPod(&dataClaim, set, pod) || !hasOwnerRef(&dataClaim, pod) || hasOwnerRef(&dataClaim, set) {
+		t.Fatalf("expected data claim owned by the pod, got %v", dataClaim.OwnerReferences)
+	}
+	if !updateClaimOwnerRefForSetAndPod(&cacheClaim, set, pod) || hasOwnerRef(&cacheClaim, pod) || !hasOwnerRef(&cacheClaim, set) {
+		t.Fatalf("expected cache claim owned by the set, got %v", cacheClaim.OwnerReferences)
+	}
+	if !claimOwnerMatchesSetAndPod(&dataClaim, set, pod) || !claimOwnerMatchesSetAndPod(&cacheClaim, set, pod) {
+		t.Fatalf("expected claims matching the policies")
+	}
+}
+
+func TestTrySnapshotPVC(t *testing.T) {
+	set := newStatefulSetWithGivenSC(1, 2, nil)
+	set.Spec.PersistentVolumeClaimRetentionPolicy = &appsv1beta1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+		WhenDeleted: appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType,
+		WhenScaled:  appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType,
+		Templates: []appsv1beta1.VolumeClaimTemplateRetentionPolicy{
+			{Name: "datadir-0", WhenScaled: appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType, VolumeSnapshotClassName: utilpointer.String("csi-hostpath-snapclass")},
+		},
+	}
+	pod := newStatefulSetPod(set, 1)
+
+	om, _, _, stop := setupController(fake.NewSimpleClientset(), kruisefake.NewSimpleClientset(set))
+	defer close(stop)
+	recorder := record.NewFakeRecorder(10)
+	spc := NewStatefulPodControlFromManager(om, recorder)
+	for _, claim := range getPersistentVolumeClaims(set, pod) {
+		claim.UID = types.UID("uid-of-" + claim.Name)
+		if err := om.claimsIndexer.Add(claim.DeepCopy()); err != nil {
+			t.Fatalf("failed to add claim: %v", err)
+		}
+	}
+	dataClaim, err := om.GetClaim(set.Namespace, getPersistentVolumeClaimName(set, &set.Spec.VolumeClaimTemplates[0], 1))
+	if err != nil {
+		t.Fatalf("failed to get claim: %v", err)
+	}
+	snapshotName := getScaleDownSnapshotName(dataClaim)
+
+	// take the snapshot of the data claim only
+	if ready, err := spc.TrySnapshotPVC(set, pod); err != nil || ready {
+		t.Fatalf("expected snapshot created and not ready, got %v, %v", ready, err)
+	}
+	if len(om.snapshotsIndexer.List()) != 1 {
+		t.Fatalf("expected only one snapshot created, got %d", len(om.snapshotsIndexer.List()))
+	}
+	snapshot, err := om.GetVolumeSnapshot(set.Namespace, snapshotName)
+	if err != nil {
+		t.Fatalf("failed to get snapshot: %v", err)
+	}
+	source, _, _ := unstructured.NestedString(snapshot.Object, "spec", "source", "persistentVolumeClaimName")
+	className, _, _ := unstructured.NestedString(snapshot.Object, "spec", "volumeSnapshotClassName")
+	if source != dataClaim.Name || className != "csi-hostpath-snapclass" || len(snapshot.GetOwnerReferences()) != 0 {
+		t.Fatalf("unexpected snapshot %v", snapshot.Object)
+	}
+	if ready, err := spc.TrySnapshotPVC(set, pod); err != nil || ready {
+		t.Fatalf("expected snapshot not ready, got %v, %v", ready, err)
+	}
+	collectEvents(recorder.Events)
+
+	// the failed snapshot is reported by a warning event
+	snapshot = snapshot.DeepCopy()
+	if err := unstructured.SetNestedField(snapshot.Object, "snapshot controller failed", "status", "error", "message"); err != nil {
+		t.Fatalf("failed to set snapshot status: %v", err)
+	}
+	if err := om.snapshotsIndexer.Update(snapshot); err != nil {
+		t.Fatalf("failed to update snapshot: %v", err)
+	}
+	if ready, err := spc.TrySnapshotPVC(set, pod); err != nil || ready {
+		t.Fatalf("expected failed snapshot not ready, got %v, %v", ready, err)
+	}
+	if events := collectEvents(recorder.Events); len(events) != 1 || !strings.Contains(events[0], v1.EventTypeWarning) ||
+		!strings.Contains(events[0], "snapshot controller failed") {
+		t.Fatalf("expected a warning event for the failed snapshot, got %v", events)
+	}
+
+	// the scale-down is blocked with a warning event if the VolumeSnapshot CRDs are not installed
+	noSnapshotSPC := NewStatefulPodControlFromManager(&noSnapshotAPIObjectManager{om}, recorder)
+	if ready, err := noSnapshotSPC.TrySnapshotPVC(set, pod); err == nil || ready {
+		t.Fatalf("expected error for missing snapshot API, got %v, %v", ready, err)
+	}
+	if events := collectEvents(recorder.Events); len(events) != 1 || !strings.Contains(events[0], v1.EventTypeWarning) {
+		t.Fatalf("expected a warning event for the missing snapshot API, got %v", events)
+	}
+
+	// the pod could be deleted after the snapshot is ready
+	snapshot = snapshot.DeepCopy()
+	unstructured.RemoveNestedField(snapshot.Object, "status", "error")
+	if err := unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse"); err != nil {
+		t.Fatalf("failed to set snapshot status: %v", err)
+	}
+	if err := om.snapshotsIndexer.Update(snapshot); err != nil {
+		t.Fatalf("failed to update snapshot: %v", err)
+	}
+	if ready, err := spc.TrySnapshotPVC(set, pod); err != nil || !ready {
+		t.Fatalf("expected snapshot ready, got %v, %v", ready, err)
+	}
+}
+
+// noSnapshotAPIObjectManager behaves as if the VolumeSnapshot CRDs were not installed.
+type noSnapshotAPIObjectManager struct {
+	*fakeObjectManager
+}
+
+func (om *noSnapshotAPIObjectManager) GetVolumeSnapshot(_, _ string) (*unstructured.Unstructured, error) {
+	return nil, &meta.NoKindMatchError{GroupKind: volumeSnapshotGVK.GroupKind(), SearchedVersions: []string{volumeSnapshotGVK.Version}}
+}
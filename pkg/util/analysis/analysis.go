@@ -0,0 +1,441 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// ProviderTypePrometheus is the type of provider that measures metrics by Prometheus queries.
+	ProviderTypePrometheus = "Prometheus"
+	// ProviderTypeWeb is the type of provider that measures metrics by HTTP webhooks.
+	ProviderTypeWeb = "Web"
+
+	defaultIntervalSeconds = 60
+	defaultCount           = 1
+
+	// measureTimeout is the total deadline of measuring all metrics of a run once
+	measureTimeout = 30 * time.Second
+	// measurePollInterval is the interval to check if the asynchronous measurements have finished
+	measurePollInterval = 2 * time.Second
+)
+
+// Args contains the information of the workload under analysis.
+type Args struct {
+	Namespace       string    `json:"namespace"`
+	Name            string    `json:"name"`
+	Kind            string    `json:"kind"`
+	UID             types.UID `json:"uid"`
+	Revision        string    `json:"revision"`
+	UpdatedReplicas int32     `json:"updatedReplicas"`
+}
+
+// Measurement is the result of a single measurement of a metric.
+type Measurement struct {
+	// Phase should be either Successful or Failed.
+	Phase   appspub.AnalysisPhase
+	Value   string
+	Message string
+}
+
+// AnalysisProvider measures metrics from a kind of source.
+type AnalysisProvider interface {
+	// Type returns the type of metrics that this provider can measure.
+	Type() string
+	// Measure measures the metric once. An error means the metric can not be measured for now,
+	// and it will be measured again later.
+	Measure(ctx context.Context, metric *appspub.AnalysisMetric, args Args) (Measurement, error)
+}
+
+// Rollout describes the progress of a workload rollout to be analyzed.
+type Rollout struct {
+	Args
+	Generation      int64
+	Paused          bool
+	CurrentRevision string
+	// Replicas is the desired number of replicas.
+	Replicas int32
+	// ExpectedUpdatedReplicas is the number of updated replicas expected by the partition.
+	ExpectedUpdatedReplicas int32
+	// UpdatedReadyReplicas is the number of ready replicas of the update revision.
+	UpdatedReadyReplicas int32
+}
+
+// Analyzer runs the analysis of rollouts with the registered providers.
+// The metrics are measured asynchronously, so that the reconciling of workloads will not be blocked.
+type Analyzer struct {
+	providers map[string]AnalysisProvider
+
+	mu sync.Mutex
+	// measurements are the asynchronous measurements by workload UID
+	measurements map[types.UID]*asyncMeasurement
+	// workloads are the UIDs of the workloads under analysis, which are used to clean up after they are deleted
+	workloads map[workloadKey]types.UID
+}
+
+type workloadKey struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// workloadForgetter is implemented by the providers that cache resources for each workload.
+type workloadForgetter interface {
+	forget(uid types.UID)
+}
+
+// asyncMeasurement is the measurement of all metrics of a batch in progress.
+type asyncMeasurement struct {
+	revision        string
+	updatedReplicas int32
+	done            chan struct{}
+	// results are the measurements by metric name, which should only be read after done is closed
+	results map[string]measureResult
+}
+
+type measureResult struct {
+	measurement Measurement
+	err         error
+}
+
+// NewAnalyzer returns an Analyzer with the given providers.
+func NewAnalyzer(providers ...AnalysisProvider) *Analyzer {
+	a := &Analyzer{
+		providers:    make(map[string]AnalysisProvider, len(providers)),
+		measurements: map[types.UID]*asyncMeasurement{},
+		workloads:    map[workloadKey]types.UID{},
+	}
+	for _, p := range providers {
+		a.providers[p.Type()] = p
+	}
+	return a
+}
+
+// NewDefaultAnalyzer returns an Analyzer with the Prometheus and web providers.
+func NewDefaultAnalyzer() *Analyzer {
+	return NewAnalyzer(NewPrometheusProvider(), NewWebProvider())
+}
+
+// Forget cleans up the measurements and the cached resources of the deleted workload.
+func (a *Analyzer) Forget(kind, namespace, name string) {
+	key := workloadKey{kind: kind, namespace: namespace, name: name}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if uid, ok := a.workloads[key]; ok {
+		delete(a.workloads, key)
+		a.forget(uid)
+	}
+}
+
+func (a *Analyzer) forget(uid types.UID) {
+	delete(a.measurements, uid)
+	for _, p := range a.providers {
+		if f, ok := p.(workloadForgetter); ok {
+			f.forget(uid)
+		}
+	}
+}
+
+// Sync maintains the analysis run of the current update batch. A new run starts when the partition
+// moves on and the previous run has passed, and the metrics are measured once all pods of the batch
+// are ready. The final batch that updates all pods is analyzed as well. A failed run will be restarted if the workload has been resumed after the failure.
+// The metrics are measured in background and the results will be recorded in the later syncs.
+// It returns the new run and the duration after which the run should be synced again.
+func (a *Analyzer) Sync(ctx context.Context, analysis *appspub.RolloutAnalysis, oldRun *appspub.AnalysisRunStatus,
+	rollout Rollout, now metav1.Time) (*appspub.AnalysisRunStatus, time.Duration) {
+	if analysis == nil || len(analysis.Metrics) == 0 {
+		return nil, 0
+	}
+
+	var run *appspub.AnalysisRunStatus
+	if oldRun != nil && oldRun.UpdateRevision == rollout.Revision {
+		run = oldRun.DeepCopy()
+	}
+	if run == nil && rollout.CurrentRevision == rollout.Revision {
+		// no rollout is in progress
+		return run, 0
+	}
+
+	batch := rollout.ExpectedUpdatedReplicas
+	newRun := func(updatedReplicas int32) *appspub.AnalysisRunStatus {
+		return &appspub.AnalysisRunStatus{
+			UpdateRevision:  rollout.Revision,
+			UpdatedReplicas: updatedReplicas,
+			Phase:           appspub.AnalysisPhasePending,
+		}
+	}
+	switch {
+	case run == nil || (run.Phase == appspub.AnalysisPhaseSuccessful && batch > run.UpdatedReplicas):
+		if batch <= 0 {
+			return run, 0
+		}
+		run = newRun(batch)
+	case run.Phase == appspub.AnalysisPhaseFailed:
+		if rollout.Paused || rollout.Generation == run.ObservedGeneration {
+			return run, 0
+		}
+		run = newRun(run.UpdatedReplicas)
+	case run.Phase == appspub.AnalysisPhasePending && batch > 0 && batch < run.UpdatedReplicas:
+		// the partition has been moved back before the batch is ready
+		run.UpdatedReplicas = batch
+	}
+
+	if run.Phase == appspub.AnalysisPhaseSuccessful || run.Phase == appspub.AnalysisPhaseFailed {
+		return run, 0
+	}
+	if run.StartTime == nil {
+		if rollout.UpdatedReadyReplicas < run.UpdatedReplicas {
+			return run, 0
+		}
+		run.StartTime = &now
+		run.Phase = appspub.AnalysisPhaseRunning
+	}
+
+	if remaining := run.StartTime.Add(time.Duration(analysis.InitialDelaySeconds) * time.Second).Sub(now.Time); remaining > 0 {
+		return run, remaining
+	}
+	interval := time.Duration(defaultIntervalSeconds) * time.Second
+	if analysis.IntervalSeconds != nil {
+		interval = time.Duration(*analysis.IntervalSeconds) * time.Second
+	}
+	if run.LastMeasureTime != nil {
+		if remaining := run.LastMeasureTime.Add(interval).Sub(now.Time); remaining > 0 {
+			return run, remaining
+		}
+	}
+
+	args := rollout.Args
+	args.UpdatedReplicas = run.UpdatedReplicas
+	results, finished := a.getMeasurements(analysis, run, args)
+	if !finished {
+		return run, measurePollInterval
+	}
+	a.measure(analysis, run, results, getRequiredCount(analysis))
+	run.LastMeasureTime = &now
+	if run.Phase == appspub.AnalysisPhaseSuccessful || run.Phase == appspub.AnalysisPhaseFailed {
+		run.ObservedGeneration = rollout.Generation
+		return run, 0
+	}
+	return run, interval
+}
+
+// getRequiredCount returns the number of successful measurements required for each metric to pass.
+func getRequiredCount(analysis *appspub.RolloutAnalysis) int32 {
+	if analysis.Count != nil && *analysis.Count > 0 {
+		return *analysis.Count
+	}
+	return defaultCount
+}
+
+// getMeasurements returns the results of the asynchronous measurement of the batch if it has finished,
+// otherwise it starts a new measurement of the metrics that have not finished in background.
+func (a *Analyzer) getMeasurements(analysis *appspub.RolloutAnalysis, run *appspub.AnalysisRunStatus, args Args) (map[string]measureResult, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	key := workloadKey{kind: args.Kind, namespace: args.Namespace, name: args.Name}
+	if uid, ok := a.workloads[key]; ok && uid != args.UID {
+		// the workload has been recreated with the same name
+		a.forget(uid)
+	}
+	a.workloads[key] = args.UID
+
+	if m, ok := a.measurements[args.UID]; ok && m.revision == run.UpdateRevision && m.updatedReplicas == run.UpdatedReplicas {
+		select {
+		case <-m.done:
+			delete(a.measurements, args.UID)
+			return m.results, true
+		default:
+			return nil, false
+		}
+	}
+
+	finished := sets.NewString()
+	for _, r := range run.MetricResults {
+		if r.Phase == appspub.AnalysisPhaseSuccessful || r.Phase == appspub.AnalysisPhaseFailed {
+			finished.Insert(r.Name)
+		}
+	}
+	var metrics []*appspub.AnalysisMetric
+	for i := range analysis.Metrics {
+		if !finished.Has(analysis.Metrics[i].Name) {
+			metrics = append(metrics, analysis.Metrics[i].DeepCopy())
+		}
+	}
+
+	// the measurement of another batch will be discarded after it finishes
+	m := &asyncMeasurement{
+		revision:        run.UpdateRevision,
+		updatedReplicas: run.UpdatedReplicas,
+		done:            make(chan struct{}),
+		results:         make(map[string]measureResult, len(metrics)),
+	}
+	a.measurements[args.UID] = m
+	go a.measureAsync(m, metrics, args)
+	return nil, false
+}
+
+// measureAsync measures the metrics concurrently within measureTimeout.
+func (a *Analyzer) measureAsync(m *asyncMeasurement, metrics []*appspub.AnalysisMetric, args Args) {
+	defer close(m.done)
+	ctx, cancel := context.WithTimeout(context.Background(), measureTimeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, metric := range metrics {
+		wg.Add(1)
+		go func(metric *appspub.AnalysisMetric) {
+			defer wg.Done()
+			measurement, err := a.measureMetric(ctx, metric, args)
+			mu.Lock()
+			defer mu.Unlock()
+			m.results[metric.Name] = measureResult{measurement: measurement, err: err}
+		}(metric)
+	}
+	wg.Wait()
+}
+
+// measure records the measurements of metrics that have not finished, and updates the phase of the run.
+func (a *Analyzer) measure(analysis *appspub.RolloutAnalysis, run *appspub.AnalysisRunStatus, measurements map[string]measureResult, count int32) {
+	oldResults := make(map[string]appspub.AnalysisMetricResult, len(run.MetricResults))
+	for _, r := range run.MetricResults {
+		oldResults[r.Name] = r
+	}
+	results := make([]appspub.AnalysisMetricResult, 0, len(analysis.Metrics))
+	for i := range analysis.Metrics {
+		metric := &analysis.Metrics[i]
+		result, ok := oldResults[metric.Name]
+		if !ok {
+			result = appspub.AnalysisMetricResult{Name: metric.Name, Phase: appspub.AnalysisPhaseRunning}
+		}
+		if result.Phase != appspub.AnalysisPhaseSuccessful && result.Phase != appspub.AnalysisPhaseFailed {
+			measured, ok := measurements[metric.Name]
+			if !ok {
+				measured.err = fmt.Errorf("not measured")
+			}
+			measurement, err := measured.measurement, measured.err
+			if err != nil {
+				result.Phase = appspub.AnalysisPhaseError
+				result.Value = ""
+				result.Message = err.Error()
+			} else {
+				result.Value = measurement.Value
+				result.Message = measurement.Message
+				if measurement.Phase == appspub.AnalysisPhaseFailed {
+					result.Phase = appspub.AnalysisPhaseFailed
+				} else if result.Successful++; result.Successful >= count {
+					result.Phase = appspub.AnalysisPhaseSuccessful
+				} else {
+					result.Phase = appspub.AnalysisPhaseRunning
+				}
+			}
+		}
+		results = append(results, result)
+	}
+	run.MetricResults = results
+
+	var failed, errored, running []string
+	for _, r := range results {
+		switch r.Phase {
+		case appspub.AnalysisPhaseFailed:
+			failed = append(failed, fmt.Sprintf("%s: %s", r.Name, r.Message))
+		case appspub.AnalysisPhaseError:
+			errored = append(errored, fmt.Sprintf("%s: %s", r.Name, r.Message))
+		case appspub.AnalysisPhaseRunning:
+			running = append(running, r.Name)
+		}
+	}
+	switch {
+	case len(failed) > 0:
+		run.Phase = appspub.AnalysisPhaseFailed
+		run.Message = fmt.Sprintf("metrics failed: %s", strings.Join(failed, "; "))
+	case len(errored) > 0:
+		run.Phase = appspub.AnalysisPhaseError
+		run.Message = fmt.Sprintf("metrics can not be measured: %s", strings.Join(errored, "; "))
+	case len(running) > 0:
+		run.Phase = appspub.AnalysisPhaseRunning
+		run.Message = fmt.Sprintf("metrics are being measured: %s", strings.Join(running, ", "))
+	default:
+		run.Phase = appspub.AnalysisPhaseSuccessful
+		run.Message = "all metrics passed"
+	}
+}
+
+func (a *Analyzer) measureMetric(ctx context.Context, metric *appspub.AnalysisMetric, args Args) (Measurement, error) {
+	providerType := GetProviderType(metric)
+	provider, ok := a.providers[providerType]
+	if !ok {
+		return Measurement{}, fmt.Errorf("no provider found for metric type %q", providerType)
+	}
+	return provider.Measure(ctx, metric, args)
+}
+
+// GetProviderType returns the provider type of the metric.
+func GetProviderType(metric *appspub.AnalysisMetric) string {
+	switch {
+	case metric.Prometheus != nil:
+		return ProviderTypePrometheus
+	case metric.Web != nil:
+		return ProviderTypeWeb
+	}
+	return ""
+}
+
+// GetHeldUpdatedReplicas returns the number of updated replicas that the rollout should be held at,
+// if the analysis of the current batch has not passed yet.
+func GetHeldUpdatedReplicas(analysis *appspub.RolloutAnalysis, run *appspub.AnalysisRunStatus, updateRevision string) (int32, bool) {
+	if analysis == nil || len(analysis.Metrics) == 0 || run == nil || run.UpdateRevision != updateRevision ||
+		run.Phase == appspub.AnalysisPhaseSuccessful {
+		return 0, false
+	}
+	return run.UpdatedReplicas, true
+}
+
+// IsBatchPassed returns true if the batch with the given number of updated replicas does not need
+// to be analyzed, or its analysis has passed.
+func IsBatchPassed(analysis *appspub.RolloutAnalysis, run *appspub.AnalysisRunStatus, updateRevision string, updatedReplicas int32) bool {
+	if analysis == nil || len(analysis.Metrics) == 0 || updatedReplicas <= 0 {
+		return true
+	}
+	return run != nil && run.UpdateRevision == updateRevision && run.UpdatedReplicas >= updatedReplicas &&
+		run.Phase == appspub.AnalysisPhaseSuccessful
+}
+
+// IsNewlyFailed returns true if the run has failed in the given generation of workload, which means
+// the workload should be paused.
+func IsNewlyFailed(run *appspub.AnalysisRunStatus, generation int64) bool {
+	return run != nil && run.Phase == appspub.AnalysisPhaseFailed && run.ObservedGeneration == generation
+}
+
+func renderTemplate(tmpl string, args Args) string {
+	return strings.NewReplacer(
+		"{{namespace}}", args.Namespace,
+		"{{name}}", args.Name,
+		"{{revision}}", args.Revision,
+	).Replace(tmpl)
+}
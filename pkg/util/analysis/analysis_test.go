@@ -0,0 +1,358 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilpointer "k8s.io/utils/pointer"
+)
+
+type fakeProvider struct {
+	phase appspub.AnalysisPhase
+	err   error
+	args  []Args
+}
+
+func (p *fakeProvider) Type() string {
+	return ProviderTypeWeb
+}
+
+func (p *fakeProvider) Measure(_ context.Context, _ *appspub.AnalysisMetric, args Args) (Measurement, error) {
+	p.args = append(p.args, args)
+	if p.err != nil {
+		return Measurement{}, p.err
+	}
+	return Measurement{Phase: p.phase, Value: "200"}, nil
+}
+
+func TestSync(t *testing.T) {
+	now := metav1.Now()
+	longAgo := metav1.NewTime(now.Add(-time.Hour))
+	recently := metav1.NewTime(now.Add(-10 * time.Second))
+	analysis := &appspub.RolloutAnalysis{
+		Metrics:         []appspub.AnalysisMetric{{Name: "success-rate", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}},
+		IntervalSeconds: utilpointer.Int32(30),
+		Count:           utilpointer.Int32(2),
+	}
+	rollout := func(expectedUpdated, updatedReady int32) Rollout {
+		return Rollout{
+			Args:                    Args{Namespace: "default", Name: "demo", Kind: "CloneSet", UID: "uid-1", Revision: "v2"},
+			Generation:              3,
+			CurrentRevision:         "v1",
+			Replicas:                10,
+			ExpectedUpdatedReplicas: expectedUpdated,
+			UpdatedReadyReplicas:    updatedReady,
+		}
+	}
+
+	completed := func(expectedUpdated, updatedReady int32) Rollout {
+		r := rollout(expectedUpdated, updatedReady)
+		r.CurrentRevision = r.Revision
+		return r
+	}
+
+	cases := []struct {
+		name            string
+		provider        *fakeProvider
+		oldRun          *appspub.AnalysisRunStatus
+		rollout         Rollout
+		expectedRun     *appspub.AnalysisRunStatus
+		expectedRequeue time.Duration
+		expectedMeasure int
+	}{
+		{
+			name:     "last batch is analyzed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			rollout:  rollout(10, 10),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 10, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &now, LastMeasureTime: &now,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseRunning, Value: "200", Successful: 1}}},
+			expectedRequeue: 30 * time.Second,
+			expectedMeasure: 1,
+		},
+		{
+			name:     "last batch is analyzed after all pods updated",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 5, Phase: appspub.AnalysisPhaseSuccessful,
+				ObservedGeneration: 3},
+			rollout:     completed(10, 8),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 10, Phase: appspub.AnalysisPhasePending},
+		},
+		{
+			name:     "last batch passed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 10, Phase: appspub.AnalysisPhaseSuccessful,
+				ObservedGeneration: 3},
+			rollout: completed(10, 10),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 10, Phase: appspub.AnalysisPhaseSuccessful,
+				ObservedGeneration: 3},
+		},
+		{
+			name:     "no rollout in progress",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			rollout:  completed(10, 10),
+		},
+		{
+			name:        "batch not ready",
+			provider:    &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			rollout:     rollout(2, 1),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending},
+		},
+		{
+			name:     "batch ready and measured",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun:   &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending},
+			rollout:  rollout(2, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &now, LastMeasureTime: &now,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseRunning, Value: "200", Successful: 1}}},
+			expectedRequeue: 30 * time.Second,
+			expectedMeasure: 1,
+		},
+		{
+			name:     "wait for interval",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &recently, LastMeasureTime: &recently},
+			rollout: rollout(5, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &recently, LastMeasureTime: &recently},
+			expectedRequeue: 20 * time.Second,
+		},
+		{
+			name:     "analysis passed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &longAgo, LastMeasureTime: &longAgo,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseRunning, Successful: 1}}},
+			rollout: rollout(2, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseSuccessful,
+				StartTime: &longAgo, LastMeasureTime: &now, ObservedGeneration: 3,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseSuccessful, Value: "200", Successful: 2}}},
+			expectedMeasure: 1,
+		},
+		{
+			name:     "analysis failed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseFailed},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &longAgo},
+			rollout: rollout(5, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseFailed,
+				StartTime: &longAgo, LastMeasureTime: &now, ObservedGeneration: 3,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseFailed, Value: "200"}}},
+			expectedMeasure: 1,
+		},
+		{
+			name:     "provider error",
+			provider: &fakeProvider{err: fmt.Errorf("connection refused")},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &longAgo},
+			rollout: rollout(2, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseError,
+				StartTime: &longAgo, LastMeasureTime: &now,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseError, Message: "connection refused"}}},
+			expectedRequeue: 30 * time.Second,
+			expectedMeasure: 1,
+		},
+		{
+			name:     "failed and not resumed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseFailed,
+				ObservedGeneration: 3},
+			rollout:     rollout(5, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseFailed, ObservedGeneration: 3},
+		},
+		{
+			name:     "failed and resumed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseFailed,
+				ObservedGeneration: 1},
+			rollout: rollout(5, 2),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning,
+				StartTime: &now, LastMeasureTime: &now,
+				MetricResults: []appspub.AnalysisMetricResult{{Name: "success-rate", Phase: appspub.AnalysisPhaseRunning, Value: "200", Successful: 1}}},
+			expectedRequeue: 30 * time.Second,
+			expectedMeasure: 1,
+		},
+		{
+			name:     "next batch after passed",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseSuccessful,
+				ObservedGeneration: 3},
+			rollout:     rollout(5, 3),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 5, Phase: appspub.AnalysisPhasePending},
+		},
+		{
+			name:     "new revision",
+			provider: &fakeProvider{phase: appspub.AnalysisPhaseSuccessful},
+			oldRun: &appspub.AnalysisRunStatus{UpdateRevision: "v1", UpdatedReplicas: 5, Phase: appspub.AnalysisPhaseFailed,
+				ObservedGeneration: 3},
+			rollout:     rollout(2, 0),
+			expectedRun: &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := NewAnalyzer(tc.provider)
+			run, requeue := a.Sync(context.TODO(), analysis, tc.oldRun, tc.rollout, now)
+			// wait for the asynchronous measurement to finish
+			for i := 0; requeue == measurePollInterval && i < 100; i++ {
+				time.Sleep(10 * time.Millisecond)
+				run, requeue = a.Sync(context.TODO(), analysis, tc.oldRun, tc.rollout, now)
+			}
+			if requeue != tc.expectedRequeue {
+				t.Fatalf("expected requeue %v, got %v", tc.expectedRequeue, requeue)
+			}
+			if len(tc.provider.args) != tc.expectedMeasure {
+				t.Fatalf("expected %d measurements, got %d", tc.expectedMeasure, len(tc.provider.args))
+			}
+			if run != nil {
+				// the message is only for humans
+				run.Message = ""
+			}
+			if fmt.Sprintf("%+v", run) != fmt.Sprintf("%+v", tc.expectedRun) {
+				t.Fatalf("expected run %+v, got %+v", tc.expectedRun, run)
+			}
+			for _, args := range tc.provider.args {
+				if args.Name != "demo" || args.Revision != "v2" || args.UpdatedReplicas != run.UpdatedReplicas {
+					t.Fatalf("unexpected args %+v", args)
+				}
+			}
+		})
+	}
+}
+
+func TestForget(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	a := NewAnalyzer(&blockingProvider{release: release})
+	analysis := &appspub.RolloutAnalysis{Metrics: []appspub.AnalysisMetric{{Name: "slow", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}}}
+	rollout := Rollout{
+		Args:                    Args{Namespace: "default", Name: "demo", Kind: "CloneSet", UID: "uid-1", Revision: "v2"},
+		CurrentRevision:         "v1",
+		Replicas:                10,
+		ExpectedUpdatedReplicas: 2,
+		UpdatedReadyReplicas:    2,
+	}
+	oldRun := &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending}
+	a.Sync(context.TODO(), analysis, oldRun, rollout, metav1.Now())
+
+	// the measurement of the deleted workload is discarded once it has been recreated with the same name
+	recreated := rollout
+	recreated.UID = "uid-2"
+	a.Sync(context.TODO(), analysis, oldRun, recreated, metav1.Now())
+	if _, ok := a.measurements["uid-1"]; ok {
+		t.Fatalf("expected measurement of the deleted workload to be discarded")
+	}
+	if _, ok := a.measurements["uid-2"]; !ok {
+		t.Fatalf("expected measurement of the recreated workload")
+	}
+
+	a.Forget("CloneSet", "default", "demo")
+	if len(a.measurements) != 0 || len(a.workloads) != 0 {
+		t.Fatalf("expected all cleaned up, got measurements %v and workloads %v", a.measurements, a.workloads)
+	}
+}
+
+func TestSyncNotBlocked(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	a := NewAnalyzer(&blockingProvider{release: release})
+	analysis := &appspub.RolloutAnalysis{Metrics: []appspub.AnalysisMetric{{Name: "slow", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}}}
+	rollout := Rollout{
+		Args:                    Args{Namespace: "default", Name: "demo", Kind: "CloneSet", Revision: "v2"},
+		CurrentRevision:         "v1",
+		Replicas:                10,
+		ExpectedUpdatedReplicas: 2,
+		UpdatedReadyReplicas:    2,
+	}
+	oldRun := &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending}
+
+	for i := 0; i < 2; i++ {
+		run, requeue := a.Sync(context.TODO(), analysis, oldRun, rollout, metav1.Now())
+		if requeue != measurePollInterval || run.Phase != appspub.AnalysisPhaseRunning || run.LastMeasureTime != nil {
+			t.Fatalf("expected measurement in progress, got run %+v and requeue %v", run, requeue)
+		}
+	}
+}
+
+type blockingProvider struct {
+	release chan struct{}
+}
+
+func (p *blockingProvider) Type() string {
+	return ProviderTypeWeb
+}
+
+func (p *blockingProvider) Measure(ctx context.Context, _ *appspub.AnalysisMetric, _ Args) (Measurement, error) {
+	select {
+	case <-p.release:
+	case <-ctx.Done():
+	}
+	return Measurement{}, fmt.Errorf("canceled")
+}
+
+func TestGetHeldUpdatedReplicas(t *testing.T) {
+	analysis := &appspub.RolloutAnalysis{Metrics: []appspub.AnalysisMetric{{Name: "m", Web: &appspub.WebAnalysisMetric{URL: "http://stub"}}}}
+	cases := []struct {
+		name         string
+		run          *appspub.AnalysisRunStatus
+		expectedHeld bool
+	}{
+		{
+			name: "no run",
+		},
+		{
+			name: "run of another revision",
+			run:  &appspub.AnalysisRunStatus{UpdateRevision: "v1", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseRunning},
+		},
+		{
+			name: "run passed",
+			run:  &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseSuccessful},
+		},
+		{
+			name:         "run pending",
+			run:          &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhasePending},
+			expectedHeld: true,
+		},
+		{
+			name:         "run failed",
+			run:          &appspub.AnalysisRunStatus{UpdateRevision: "v2", UpdatedReplicas: 2, Phase: appspub.AnalysisPhaseFailed},
+			expectedHeld: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			held, ok := GetHeldUpdatedReplicas(analysis, tc.run, "v2")
+			if ok != tc.expectedHeld {
+				t.Fatalf("expected held %v, got %v", tc.expectedHeld, ok)
+			}
+			if ok && held != tc.run.UpdatedReplicas {
+				t.Fatalf("expected held at %d, got %d", tc.run.UpdatedReplicas, held)
+			}
+		})
+	}
+}
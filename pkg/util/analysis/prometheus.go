@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	"github.com/openkruise/kruise/pkg/util/webhooktarget"
+
+	"github.com/prometheus/client_golang/api"
+	promv1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const defaultPrometheusQueryTimeout = 30 * time.Second
+
+type prometheusProvider struct {
+	roundTripper http.RoundTripper
+	// clients caches the API clients by workload UID and address
+	clients sync.Map
+}
+
+type prometheusClientKey struct {
+	uid     types.UID
+	address string
+}
+
+// NewPrometheusProvider returns a provider that measures metrics by Prometheus queries.
+func NewPrometheusProvider() AnalysisProvider {
+	return &prometheusProvider{roundTripper: webhooktarget.NewRestrictedTransport()}
+}
+
+func (p *prometheusProvider) Type() string {
+	return ProviderTypePrometheus
+}
+
+func (p *prometheusProvider) Measure(ctx context.Context, metric *appspub.AnalysisMetric, args Args) (Measurement, error) {
+	m := metric.Prometheus
+	if m == nil {
+		return Measurement{}, fmt.Errorf("no prometheus in metric %s", metric.Name)
+	}
+	client, err := p.getClient(args.UID, m.Address)
+	if err != nil {
+		return Measurement{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultPrometheusQueryTimeout)
+	defer cancel()
+	result, _, err := client.Query(ctx, renderTemplate(m.Query, args), time.Now())
+	if err != nil {
+		return Measurement{}, fmt.Errorf("failed to query prometheus: %v", err)
+	}
+
+	var values []float64
+	switch v := result.(type) {
+	case *model.Scalar:
+		values = append(values, float64(v.Value))
+	case model.Vector:
+		for _, sample := range v {
+			values = append(values, float64(sample.Value))
+		}
+	default:
+		return Measurement{}, fmt.Errorf("unsupported prometheus result type %s", result.Type())
+	}
+	if len(values) == 0 {
+		return Measurement{}, fmt.Errorf("empty prometheus result")
+	}
+	return evaluateThreshold(values, m.Min, m.Max)
+}
+
+func (p *prometheusProvider) getClient(uid types.UID, address string) (promv1.API, error) {
+	key := prometheusClientKey{uid: uid, address: address}
+	if c, ok := p.clients.Load(key); ok {
+		return c.(promv1.API), nil
+	}
+	client, err := api.NewClient(api.Config{Address: address, RoundTripper: p.roundTripper})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create prometheus client for %s: %v", address, err)
+	}
+	c, _ := p.clients.LoadOrStore(key, promv1.NewAPI(client))
+	return c.(promv1.API), nil
+}
+
+func (p *prometheusProvider) forget(uid types.UID) {
+	p.clients.Range(func(key, _ interface{}) bool {
+		if key.(prometheusClientKey).uid == uid {
+			p.clients.Delete(key)
+		}
+		return true
+	})
+}
+
+// evaluateThreshold checks if all values are within [min, max].
+func evaluateThreshold(values []float64, min, max *string) (Measurement, error) {
+	strValues := make([]string, 0, len(values))
+	for _, v := range values {
+		strValues = append(strValues, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	measurement := Measurement{Phase: appspub.AnalysisPhaseSuccessful, Value: strings.Join(strValues, ",")}
+
+	minValue, maxValue := math.Inf(-1), math.Inf(1)
+	var err error
+	if min != nil {
+		if minValue, err = strconv.ParseFloat(*min, 64); err != nil {
+			return Measurement{}, fmt.Errorf("invalid min %q: %v", *min, err)
+		}
+	}
+	if max != nil {
+		if maxValue, err = strconv.ParseFloat(*max, 64); err != nil {
+			return Measurement{}, fmt.Errorf("invalid max %q: %v", *max, err)
+		}
+	}
+
+	for i, v := range values {
+		switch {
+		case math.IsNaN(v):
+			return Measurement{}, fmt.Errorf("result is NaN")
+		case v < minValue:
+			measurement.Phase = appspub.AnalysisPhaseFailed
+			measurement.Message = fmt.Sprintf("value %s is less than min %s", strValues[i], *min)
+			return measurement, nil
+		case v > maxValue:
+			measurement.Phase = appspub.AnalysisPhaseFailed
+			measurement.Message = fmt.Sprintf("value %s is greater than max %s", strValues[i], *max)
+			return measurement, nil
+		}
+	}
+	return measurement, nil
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+
+	utilpointer "k8s.io/utils/pointer"
+)
+
+func TestPrometheusProvider(t *testing.T) {
+	cases := []struct {
+		name          string
+		response      string
+		min           *string
+		max           *string
+		expectedPhase appspub.AnalysisPhase
+		expectedValue string
+		expectedErr   bool
+	}{
+		{
+			name:          "vector within range",
+			response:      `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"0.995"]}]}}`,
+			min:           utilpointer.String("0.99"),
+			expectedPhase: appspub.AnalysisPhaseSuccessful,
+			expectedValue: "0.995",
+		},
+		{
+			name:          "vector exceeds max",
+			response:      `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"pod":"a"},"value":[1700000000,"0.01"]},{"metric":{"pod":"b"},"value":[1700000000,"0.2"]}]}}`,
+			max:           utilpointer.String("0.05"),
+			expectedPhase: appspub.AnalysisPhaseFailed,
+			expectedValue: "0.01,0.2",
+		},
+		{
+			name:          "scalar less than min",
+			response:      `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"3"]}}`,
+			min:           utilpointer.String("5"),
+			expectedPhase: appspub.AnalysisPhaseFailed,
+			expectedValue: "3",
+		},
+		{
+			name:        "empty result",
+			response:    `{"status":"success","data":{"resultType":"vector","result":[]}}`,
+			max:         utilpointer.String("1"),
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotQuery string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = r.ParseForm()
+				gotQuery = r.Form.Get("query")
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.response))
+			}))
+			defer server.Close()
+
+			metric := &appspub.AnalysisMetric{Name: "prom", Prometheus: &appspub.PrometheusAnalysisMetric{
+				Address: server.URL,
+				Query:   `rate(errors{namespace="{{namespace}}",workload="{{name}}",revision="{{revision}}"}[5m])`,
+				Min:     tc.min,
+				Max:     tc.max,
+			}}
+			args := Args{Namespace: "default", Name: "demo", UID: "uid-1", Revision: "v2"}
+			provider := &prometheusProvider{roundTripper: http.DefaultTransport}
+			measurement, err := provider.Measure(context.TODO(), metric, args)
+			if expectedQuery := `rate(errors{namespace="default",workload="demo",revision="v2"}[5m])`; gotQuery != expectedQuery {
+				t.Fatalf("expected query %s, got %s", expectedQuery, gotQuery)
+			}
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if err == nil && (measurement.Phase != tc.expectedPhase || measurement.Value != tc.expectedValue) {
+				t.Fatalf("unexpected measurement %+v", measurement)
+			}
+
+			provider.forget(args.UID)
+			provider.clients.Range(func(key, _ interface{}) bool {
+				t.Fatalf("expected clients of the workload to be forgotten, got %v", key)
+				return false
+			})
+		})
+	}
+}
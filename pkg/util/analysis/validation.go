@@ -0,0 +1,109 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	"github.com/openkruise/kruise/pkg/util/webhooktarget"
+
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ValidateRolloutAnalysis validates the analysis of a workload update strategy.
+func ValidateRolloutAnalysis(analysis *appspub.RolloutAnalysis, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if analysis == nil {
+		return allErrs
+	}
+
+	if len(analysis.Metrics) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("metrics"), "at least one metric should be set"))
+	}
+	names := sets.NewString()
+	for i := range analysis.Metrics {
+		metric := &analysis.Metrics[i]
+		metricPath := fldPath.Child("metrics").Index(i)
+		if metric.Name == "" {
+			allErrs = append(allErrs, field.Required(metricPath.Child("name"), ""))
+		} else if names.Has(metric.Name) {
+			allErrs = append(allErrs, field.Duplicate(metricPath.Child("name"), metric.Name))
+		}
+		names.Insert(metric.Name)
+
+		if (metric.Prometheus == nil) == (metric.Web == nil) {
+			allErrs = append(allErrs, field.Invalid(metricPath, metric.Name, "exactly one of prometheus and web should be set"))
+		}
+		if m := metric.Prometheus; m != nil {
+			allErrs = append(allErrs, validateURL(m.Address, metricPath.Child("prometheus", "address"))...)
+			if m.Query == "" {
+				allErrs = append(allErrs, field.Required(metricPath.Child("prometheus", "query"), ""))
+			}
+			if m.Min != nil {
+				if _, err := strconv.ParseFloat(*m.Min, 64); err != nil {
+					allErrs = append(allErrs, field.Invalid(metricPath.Child("prometheus", "min"), *m.Min, "must be a decimal number"))
+				}
+			}
+			if m.Max != nil {
+				if _, err := strconv.ParseFloat(*m.Max, 64); err != nil {
+					allErrs = append(allErrs, field.Invalid(metricPath.Child("prometheus", "max"), *m.Max, "must be a decimal number"))
+				}
+			}
+			if m.Min == nil && m.Max == nil {
+				allErrs = append(allErrs, field.Required(metricPath.Child("prometheus"), "at least one of min and max should be set"))
+			}
+		}
+		if m := metric.Web; m != nil {
+			allErrs = append(allErrs, validateURL(m.URL, metricPath.Child("web", "url"))...)
+			if m.TimeoutSeconds != nil && (*m.TimeoutSeconds <= 0 || *m.TimeoutSeconds > maxWebTimeoutSeconds) {
+				allErrs = append(allErrs, field.Invalid(metricPath.Child("web", "timeoutSeconds"), *m.TimeoutSeconds,
+					fmt.Sprintf("must be greater than 0 and no more than %d", maxWebTimeoutSeconds)))
+			}
+		}
+	}
+
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(analysis.InitialDelaySeconds), fldPath.Child("initialDelaySeconds"))...)
+	if analysis.IntervalSeconds != nil && *analysis.IntervalSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("intervalSeconds"), *analysis.IntervalSeconds, "must be greater than 0"))
+	}
+	if analysis.Count != nil && *analysis.Count <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("count"), *analysis.Count, "must be greater than 0"))
+	}
+	return allErrs
+}
+
+func validateURL(rawURL string, fldPath *field.Path) field.ErrorList {
+	if rawURL == "" {
+		return field.ErrorList{field.Required(fldPath, "")}
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, rawURL, err.Error())}
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return field.ErrorList{field.Invalid(fldPath, rawURL, "scheme must be http or https")}
+	}
+	if err := webhooktarget.CheckHost(u.Hostname()); err != nil {
+		return field.ErrorList{field.Invalid(fldPath, rawURL, err.Error())}
+	}
+	return nil
+}
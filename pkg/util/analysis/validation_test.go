@@ -0,0 +1,51 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"testing"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidateAnalysisTarget(t *testing.T) {
+	cases := []struct {
+		url         string
+		expectedErr bool
+	}{
+		{url: "http://prometheus.monitoring:9090"},
+		{url: "https://10.0.0.10/check", expectedErr: true},
+		{url: "http://localhost:8080", expectedErr: true},
+		{url: "http://127.0.0.1:10250", expectedErr: true},
+		{url: "http://[::1]/check", expectedErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", expectedErr: true},
+		{url: "http://0.0.0.0", expectedErr: true},
+		{url: "file:///etc/passwd", expectedErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.url, func(t *testing.T) {
+			analysis := &appspub.RolloutAnalysis{Metrics: []appspub.AnalysisMetric{{Name: "m", Web: &appspub.WebAnalysisMetric{URL: tc.url}}}}
+			errs := ValidateRolloutAnalysis(analysis, field.NewPath("analysis"))
+			if (len(errs) > 0) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	"github.com/openkruise/kruise/pkg/util/webhooktarget"
+)
+
+const (
+	defaultWebTimeoutSeconds = 10
+	// maxWebTimeoutSeconds should not be more than measureTimeout
+	maxWebTimeoutSeconds = 30
+	// maxWebMessageLength is the max length of response body recorded in the message
+	maxWebMessageLength = 256
+)
+
+type webProvider struct {
+	client *http.Client
+}
+
+// NewWebProvider returns a provider that measures metrics by posting the args to HTTP webhooks.
+func NewWebProvider() AnalysisProvider {
+	return &webProvider{client: &http.Client{
+		Transport: webhooktarget.NewRestrictedTransport(),
+		// the redirected targets are not checked by the validation
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}}
+}
+
+func (p *webProvider) Type() string {
+	return ProviderTypeWeb
+}
+
+func (p *webProvider) Measure(ctx context.Context, metric *appspub.AnalysisMetric, args Args) (Measurement, error) {
+	m := metric.Web
+	if m == nil {
+		return Measurement{}, fmt.Errorf("no web in metric %s", metric.Name)
+	}
+	timeout := time.Duration(defaultWebTimeoutSeconds) * time.Second
+	if m.TimeoutSeconds != nil {
+		timeout = time.Duration(*m.TimeoutSeconds) * time.Second
+	}
+
+	body, err := json.Marshal(args)
+	if err != nil {
+		return Measurement{}, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.URL, bytes.NewReader(body))
+	if err != nil {
+		return Measurement{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return Measurement{}, fmt.Errorf("failed to call webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxWebMessageLength))
+	message := strings.TrimSpace(string(respBody))
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return Measurement{}, fmt.Errorf("webhook returned %d: %s", resp.StatusCode, message)
+	case resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices:
+		return Measurement{Phase: appspub.AnalysisPhaseSuccessful, Value: strconv.Itoa(resp.StatusCode), Message: message}, nil
+	}
+	return Measurement{Phase: appspub.AnalysisPhaseFailed, Value: strconv.Itoa(resp.StatusCode), Message: message}, nil
+}
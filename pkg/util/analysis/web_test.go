@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+)
+
+func TestWebProvider(t *testing.T) {
+	cases := []struct {
+		name          string
+		statusCode    int
+		expectedPhase appspub.AnalysisPhase
+		expectedErr   bool
+	}{
+		{
+			name:          "passed",
+			statusCode:    http.StatusOK,
+			expectedPhase: appspub.AnalysisPhaseSuccessful,
+		},
+		{
+			name:          "failed",
+			statusCode:    http.StatusPreconditionFailed,
+			expectedPhase: appspub.AnalysisPhaseFailed,
+		},
+		{
+			name:        "server error",
+			statusCode:  http.StatusServiceUnavailable,
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotArgs Args
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&gotArgs); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				w.WriteHeader(tc.statusCode)
+				_, _ = w.Write([]byte("checked by stub"))
+			}))
+			defer server.Close()
+
+			args := Args{Namespace: "default", Name: "demo", Kind: "CloneSet", Revision: "v2", UpdatedReplicas: 2}
+			metric := &appspub.AnalysisMetric{Name: "stub", Web: &appspub.WebAnalysisMetric{URL: server.URL}}
+			provider := &webProvider{client: &http.Client{}}
+			measurement, err := provider.Measure(context.TODO(), metric, args)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if gotArgs != args {
+				t.Fatalf("expected args %+v, got %+v", args, gotArgs)
+			}
+			if err == nil && (measurement.Phase != tc.expectedPhase || measurement.Message != "checked by stub") {
+				t.Fatalf("unexpected measurement %+v", measurement)
+			}
+		})
+	}
+
+	metric := &appspub.AnalysisMetric{Name: "stub", Web: &appspub.WebAnalysisMetric{URL: "http://127.0.0.1:1"}}
+	if _, err := (&webProvider{client: &http.Client{}}).Measure(context.TODO(), metric, Args{}); err == nil {
+		t.Fatalf("expected error for unreachable webhook")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to loopback address")
+	}))
+	defer server.Close()
+	metric = &appspub.AnalysisMetric{Name: "stub", Web: &appspub.WebAnalysisMetric{URL: server.URL}}
+	if _, err := NewWebProvider().Measure(context.TODO(), metric, Args{}); err == nil || !strings.Contains(err.Error(), "not allowed") {
+		t.Fatalf("expected error for loopback webhook, got %v", err)
+	}
+}
@@ -36,3 +36,16 @@ func NewClientFromManager(mgr manager.Manager, name string) client.Client {
 	})
 	return delegatingClient
 }
+
+// NewNoCacheClientFromManager returns a client that reads objects from the apiserver directly, which is used for
+// the objects not watched by the manager, e.g., those whose CRDs may not be installed in the cluster.
+func NewNoCacheClientFromManager(mgr manager.Manager, name string) client.Client {
+	cfg := rest.CopyConfig(mgr.GetConfig())
+	cfg.UserAgent = fmt.Sprintf("kruise-manager/%s", name)
+
+	c, _ := client.New(cfg, client.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+	})
+	return c
+}
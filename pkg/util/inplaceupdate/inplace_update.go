@@ -40,8 +40,9 @@ import (
 )
 
 var (
-	containerImagePatchRexp = regexp.MustCompile("^/spec/containers/([0-9]+)/image$")
-	rfc6901Decoder          = strings.NewReplacer("~1", "/", "~0", "~")
+	containerImagePatchRexp     = regexp.MustCompile("^/spec/containers/([0-9]+)/image$")
+	containerResourcesPatchRexp = regexp.MustCompile("^/spec/containers/([0-9]+)/resources(/.*)?$")
+	rfc6901Decoder              = strings.NewReplacer("~1", "/", "~0", "~")
 
 	Clock clock.Clock = clock.RealClock{}
 )
@@ -49,6 +50,9 @@ var (
 type RefreshResult struct {
 	RefreshErr    error
 	DelayDuration time.Duration
+	// ResizeInfeasible indicates that the node can not fit the new resources of Pod,
+	// so the Pod should be recreated instead of waiting for in-place resize.
+	ResizeInfeasible bool
 }
 
 type UpdateResult struct {
@@ -60,6 +64,9 @@ type UpdateResult struct {
 
 type UpdateOptions struct {
 	IgnoreVolumeClaimTemplatesHashDiff bool
+	// IgnoreVolumeClaimTemplatesStorageDiff means the changes of storage requests in VolumeClaimTemplates
+	// do not prevent in-place update, because they will be resized onto the existing PVCs online.
+	IgnoreVolumeClaimTemplatesStorageDiff bool
 
 	GracePeriodSeconds int32
 	AdditionalFuncs    []func(*v1.Pod)
@@ -82,11 +89,12 @@ type Interface interface {
 type UpdateSpec struct {
 	Revision string `json:"revision"`
 
-	ContainerImages       map[string]string            `json:"containerImages,omitempty"`
-	ContainerRefMetadata  map[string]metav1.ObjectMeta `json:"containerRefMetadata,omitempty"`
-	MetaDataPatch         []byte                       `json:"metaDataPatch,omitempty"`
-	UpdateEnvFromMetadata bool                         `json:"updateEnvFromMetadata,omitempty"`
-	GraceSeconds          int32                        `json:"graceSeconds,omitempty"`
+	ContainerImages       map[string]string                  `json:"containerImages,omitempty"`
+	ContainerRefMetadata  map[string]metav1.ObjectMeta       `json:"containerRefMetadata,omitempty"`
+	ContainerResources    map[string]v1.ResourceRequirements `json:"containerResources,omitempty"`
+	MetaDataPatch         []byte                             `json:"metaDataPatch,omitempty"`
+	UpdateEnvFromMetadata bool                               `json:"updateEnvFromMetadata,omitempty"`
+	GraceSeconds          int32                              `json:"graceSeconds,omitempty"`
 
 	OldTemplate *v1.PodTemplateSpec `json:"oldTemplate,omitempty"`
 	NewTemplate *v1.PodTemplateSpec `json:"newTemplate,omitempty"`
@@ -130,11 +138,14 @@ func (c *realControl) Refresh(pod *v1.Pod, opts *UpdateOptions) RefreshResult {
 		// check in-place updating has not completed yet
 		if checkErr := opts.CheckContainersUpdateCompleted(pod, &state); checkErr != nil {
 			klog.V(6).ErrorS(checkErr, "Check Pod in-place update not completed yet", "namespace", pod.Namespace, "name", pod.Name)
+			if state.UpdateResources {
+				return c.refreshResizeStatus(pod, &state)
+			}
 			return RefreshResult{}
 		}
 
 		// check if there are containers with lower-priority that have to in-place update in next batch
-		if len(state.NextContainerImages) > 0 || len(state.NextContainerRefMetadata) > 0 {
+		if len(state.NextContainerImages) > 0 || len(state.NextContainerRefMetadata) > 0 || len(state.NextContainerResources) > 0 {
 
 			// pre-check the previous updated containers
 			if checkErr := doPreCheckBeforeNext(pod, state.PreCheckBeforeNext); checkErr != nil {
@@ -184,6 +195,44 @@ func (c *realControl) updateCondition(pod *v1.Pod, condition v1.PodCondition) er
 	})
 }
 
+// refreshResizeStatus records the latest resize status of Pod into in-place update state,
+// and reports whether the resize has been rejected by kubelet as infeasible.
+func (c *realControl) refreshResizeStatus(pod *v1.Pod, state *appspub.InPlaceUpdateState) RefreshResult {
+	if pod.Status.Resize != state.ResizeStatus {
+		err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			clone, err := c.podAdapter.GetPod(pod.Namespace, pod.Name)
+			if err != nil {
+				return err
+			}
+
+			cloneState := appspub.InPlaceUpdateState{}
+			if stateStr, ok := appspub.GetInPlaceUpdateState(clone); !ok {
+				return nil
+			} else if err := json.Unmarshal([]byte(stateStr), &cloneState); err != nil {
+				return err
+			}
+			if cloneState.ResizeStatus == clone.Status.Resize {
+				return nil
+			}
+
+			cloneState.ResizeStatus = clone.Status.Resize
+			cloneStateJSON, _ := json.Marshal(cloneState)
+			clone.Annotations[appspub.InPlaceUpdateStateKey] = string(cloneStateJSON)
+			_, err = c.podAdapter.UpdatePod(clone)
+			return err
+		})
+		if err != nil {
+			return RefreshResult{RefreshErr: err}
+		}
+	}
+
+	if pod.Status.Resize == v1.PodResizeStatusInfeasible {
+		klog.InfoS("Pod in-place resize is infeasible on node", "namespace", pod.Namespace, "name", pod.Name, "node", pod.Spec.NodeName)
+		return RefreshResult{ResizeInfeasible: true}
+	}
+	return RefreshResult{}
+}
+
 func (c *realControl) finishGracePeriod(pod *v1.Pod, opts *UpdateOptions) (time.Duration, error) {
 	var delayDuration time.Duration
 	err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
@@ -249,13 +298,14 @@ func (c *realControl) updateNextBatch(pod *v1.Pod, opts *UpdateOptions) (bool, e
 			return err
 		}
 
-		if len(state.NextContainerImages) == 0 && len(state.NextContainerRefMetadata) == 0 {
+		if len(state.NextContainerImages) == 0 && len(state.NextContainerRefMetadata) == 0 && len(state.NextContainerResources) == 0 {
 			return nil
 		}
 
 		spec := UpdateSpec{
 			ContainerImages:       state.NextContainerImages,
 			ContainerRefMetadata:  state.NextContainerRefMetadata,
+			ContainerResources:    state.NextContainerResources,
 			UpdateEnvFromMetadata: state.UpdateEnvFromMetadata,
 		}
 		if clone, err = opts.PatchSpecToPod(clone, &spec, &state); err != nil {
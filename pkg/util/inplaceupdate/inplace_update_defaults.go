@@ -72,6 +72,7 @@ func defaultPatchUpdateSpecToPod(pod *v1.Pod, spec *UpdateSpec, state *appspub.I
 
 	state.NextContainerImages = make(map[string]string)
 	state.NextContainerRefMetadata = make(map[string]metav1.ObjectMeta)
+	state.NextContainerResources = make(map[string]v1.ResourceRequirements)
 
 	if spec.MetaDataPatch != nil {
 		cloneBytes, _ := json.Marshal(pod)
@@ -100,7 +101,8 @@ func defaultPatchUpdateSpecToPod(pod *v1.Pod, spec *UpdateSpec, state *appspub.I
 		c := &pod.Spec.Containers[i]
 		_, existImage := spec.ContainerImages[c.Name]
 		_, existMetadata := spec.ContainerRefMetadata[c.Name]
-		if !existImage && !existMetadata {
+		_, existResources := spec.ContainerResources[c.Name]
+		if !existImage && !existMetadata && !existResources {
 			continue
 		}
 		priority := utilcontainerlaunchpriority.GetContainerPriority(c)
@@ -144,6 +146,33 @@ func defaultPatchUpdateSpecToPod(pod *v1.Pod, spec *UpdateSpec, state *appspub.I
 		}
 	}
 
+	// update resources and record current resources for the containers to update
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		newResources, exists := spec.ContainerResources[c.Name]
+		if !exists {
+			continue
+		}
+		if containersToUpdate.Has(c.Name) {
+			if state.LastContainerStatuses == nil {
+				state.LastContainerStatuses = map[string]appspub.InPlaceUpdateContainerStatus{}
+			}
+			lastStatus := state.LastContainerStatuses[c.Name]
+			lastStatus.Resources = c.Resources.DeepCopy()
+			for j := range pod.Status.ContainerStatuses {
+				if pod.Status.ContainerStatuses[j].Name == c.Name {
+					lastStatus.RestartCount = pod.Status.ContainerStatuses[j].RestartCount
+					break
+				}
+			}
+			state.LastContainerStatuses[c.Name] = lastStatus
+			pod.Spec.Containers[i].Resources = *newResources.DeepCopy()
+			state.UpdateResources = true
+		} else {
+			state.NextContainerResources[c.Name] = newResources
+		}
+	}
+
 	// update annotations and labels for the containers to update
 	for cName, objMeta := range spec.ContainerRefMetadata {
 		if containersToUpdate.Has(cName) {
@@ -161,7 +190,7 @@ func defaultPatchUpdateSpecToPod(pod *v1.Pod, spec *UpdateSpec, state *appspub.I
 	// add the containers that update this time into PreCheckBeforeNext, so that next containers can only
 	// start to update when these containers have updated ready
 	// TODO: currently we only support ContainersRequiredReady, not sure if we have to add ContainersPreferredReady in future
-	if len(state.NextContainerImages) > 0 || len(state.NextContainerRefMetadata) > 0 {
+	if len(state.NextContainerImages) > 0 || len(state.NextContainerRefMetadata) > 0 || len(state.NextContainerResources) > 0 {
 		state.PreCheckBeforeNext = &appspub.InPlaceUpdatePreCheckBeforeNext{ContainersRequiredReady: containersToUpdate.List()}
 	} else {
 		state.PreCheckBeforeNext = nil
@@ -225,6 +254,7 @@ func addMetadataSharedContainersToUpdate(pod *v1.Pod, containersToUpdate sets.St
 
 // defaultCalculateInPlaceUpdateSpec calculates diff between old and update revisions.
 // If the diff just contains replace operation of spec.containers[x].image, it will returns an UpdateSpec.
+// If InPlaceWorkloadVerticalScaling is enabled, changes of spec.containers[x].resources are also allowed.
 // Otherwise, it returns nil which means can not use in-place update.
 func defaultCalculateInPlaceUpdateSpec(oldRevision, newRevision *apps.ControllerRevision, opts *UpdateOptions) *UpdateSpec {
 	if oldRevision == nil || newRevision == nil {
@@ -241,6 +271,9 @@ func defaultCalculateInPlaceUpdateSpec(oldRevision, newRevision *apps.Controller
 	if utilfeature.DefaultFeatureGate.Enabled(features.RecreatePodWhenChangeVCTInCloneSetGate) {
 		if !opts.IgnoreVolumeClaimTemplatesHashDiff {
 			canInPlace := volumeclaimtemplate.CanVCTemplateInplaceUpdate(oldRevision, newRevision)
+			if opts.IgnoreVolumeClaimTemplatesStorageDiff {
+				canInPlace = volumeclaimtemplate.CanVCTemplateInplaceUpdateIgnoringStorage(oldRevision, newRevision)
+			}
 			if !canInPlace {
 				return nil
 			}
@@ -266,8 +299,9 @@ func defaultCalculateInPlaceUpdateSpec(oldRevision, newRevision *apps.Controller
 		updateSpec.Revision = opts.GetRevision(newRevision)
 	}
 
-	// all patches for podSpec can just update images in pod spec
+	// all patches for podSpec can just update images (and resources) in pod spec
 	var metadataPatches []jsonpatch.Operation
+	enableVerticalScaling := utilfeature.DefaultFeatureGate.Enabled(features.InPlaceWorkloadVerticalScaling)
 	for _, op := range patches {
 		op.Path = strings.Replace(op.Path, "/spec/template", "", 1)
 
@@ -278,6 +312,20 @@ func defaultCalculateInPlaceUpdateSpec(oldRevision, newRevision *apps.Controller
 			}
 			return nil
 		}
+		if enableVerticalScaling && containerResourcesPatchRexp.MatchString(op.Path) {
+			// for example: /spec/containers/0/resources/limits/cpu
+			words := strings.Split(op.Path, "/")
+			idx, _ := strconv.Atoi(words[3])
+			if len(oldTemp.Spec.Containers) <= idx || len(newTemp.Spec.Containers) <= idx ||
+				oldTemp.Spec.Containers[idx].Name != newTemp.Spec.Containers[idx].Name {
+				return nil
+			}
+			if updateSpec.ContainerResources == nil {
+				updateSpec.ContainerResources = make(map[string]v1.ResourceRequirements)
+			}
+			updateSpec.ContainerResources[newTemp.Spec.Containers[idx].Name] = newTemp.Spec.Containers[idx].Resources
+			continue
+		}
 		if op.Operation != "replace" || !containerImagePatchRexp.MatchString(op.Path) {
 			return nil
 		}
@@ -361,7 +409,7 @@ func DefaultCheckInPlaceUpdateCompleted(pod *v1.Pod) error {
 	} else if err := json.Unmarshal([]byte(stateStr), &inPlaceUpdateState); err != nil {
 		return err
 	}
-	if len(inPlaceUpdateState.NextContainerImages) > 0 || len(inPlaceUpdateState.NextContainerRefMetadata) > 0 {
+	if len(inPlaceUpdateState.NextContainerImages) > 0 || len(inPlaceUpdateState.NextContainerRefMetadata) > 0 || len(inPlaceUpdateState.NextContainerResources) > 0 {
 		return fmt.Errorf("existing containers to in-place update in next batches")
 	}
 
@@ -383,6 +431,12 @@ func defaultCheckContainersInPlaceUpdateCompleted(pod *v1.Pod, inPlaceUpdateStat
 		}
 	}
 
+	if inPlaceUpdateState.UpdateResources {
+		if err := checkContainersResourcesResized(pod, inPlaceUpdateState); err != nil {
+			return err
+		}
+	}
+
 	if runtimeContainerMetaSet != nil {
 		if checkAllContainersHashConsistent(pod, runtimeContainerMetaSet, plainHash) {
 			klog.V(5).InfoS("Check Pod in-place update completed for all container hash consistent", "namespace", pod.Namespace, "name", pod.Name)
@@ -401,25 +455,102 @@ func defaultCheckContainersInPlaceUpdateCompleted(pod *v1.Pod, inPlaceUpdateStat
 		}
 	}
 
+	// check on a copy, for the state may be read again by the caller
+	lastContainerStatuses := make(map[string]appspub.InPlaceUpdateContainerStatus, len(inPlaceUpdateState.LastContainerStatuses))
+	for name, status := range inPlaceUpdateState.LastContainerStatuses {
+		lastContainerStatuses[name] = status
+	}
 	for _, cs := range pod.Status.ContainerStatuses {
-		if oldStatus, ok := inPlaceUpdateState.LastContainerStatuses[cs.Name]; ok {
+		if oldStatus, ok := lastContainerStatuses[cs.Name]; ok {
 			// TODO: we assume that users should not update workload template with new image which actually has the same imageID as the old image
+			// the containers only resized have no imageID recorded, whose resources have been checked above
+			if oldStatus.ImageID == "" && oldStatus.Resources != nil {
+				delete(lastContainerStatuses, cs.Name)
+				continue
+			}
 			if oldStatus.ImageID == cs.ImageID {
 				if containerImages[cs.Name] != cs.Image {
 					return fmt.Errorf("container %s imageID not changed", cs.Name)
 				}
 			}
-			delete(inPlaceUpdateState.LastContainerStatuses, cs.Name)
+			delete(lastContainerStatuses, cs.Name)
 		}
 	}
 
-	if len(inPlaceUpdateState.LastContainerStatuses) > 0 {
-		return fmt.Errorf("not found statuses of containers %v", inPlaceUpdateState.LastContainerStatuses)
+	if len(lastContainerStatuses) > 0 {
+		return fmt.Errorf("not found statuses of containers %v", lastContainerStatuses)
+	}
+
+	return nil
+}
+
+// checkContainersResourcesResized checks whether kubelet has actuated the new resources of containers
+// that have been in-place resized, and whether the containers have been restarted if the resizePolicy
+// of any resized resource is RestartContainer.
+func checkContainersResourcesResized(pod *v1.Pod, inPlaceUpdateState *appspub.InPlaceUpdateState) error {
+	if pod.Status.Resize != "" {
+		return fmt.Errorf("waiting for pod resize %s", pod.Status.Resize)
 	}
 
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		oldStatus, ok := inPlaceUpdateState.LastContainerStatuses[c.Name]
+		if !ok || oldStatus.Resources == nil {
+			continue
+		}
+
+		var containerStatus *v1.ContainerStatus
+		for j := range pod.Status.ContainerStatuses {
+			if pod.Status.ContainerStatuses[j].Name == c.Name {
+				containerStatus = &pod.Status.ContainerStatuses[j]
+				break
+			}
+		}
+		if containerStatus == nil || containerStatus.Resources == nil {
+			return fmt.Errorf("waiting for resources of container %s reported in status", c.Name)
+		}
+		if !resizableResourcesEqual(c.Resources.Requests, containerStatus.Resources.Requests) ||
+			!resizableResourcesEqual(c.Resources.Limits, containerStatus.Resources.Limits) {
+			return fmt.Errorf("container %s resources not resized", c.Name)
+		}
+		if isRestartRequiredForResize(c, oldStatus.Resources) && containerStatus.RestartCount <= oldStatus.RestartCount {
+			return fmt.Errorf("waiting for container %s restarted for resize", c.Name)
+		}
+	}
 	return nil
 }
 
+// isRestartRequiredForResize returns true if any resource changed has the RestartContainer resizePolicy.
+// The resources with NotRequired or no resizePolicy are resized without restarting the container.
+func isRestartRequiredForResize(c *v1.Container, oldResources *v1.ResourceRequirements) bool {
+	for _, policy := range c.ResizePolicy {
+		if policy.RestartPolicy != v1.RestartContainer {
+			continue
+		}
+		if !resourceEqual(c.Resources.Requests, oldResources.Requests, policy.ResourceName) ||
+			!resourceEqual(c.Resources.Limits, oldResources.Limits, policy.ResourceName) {
+			return true
+		}
+	}
+	return false
+}
+
+// resizableResourcesEqual only compares cpu and memory, which are the resources that kubelet could resize.
+func resizableResourcesEqual(expected, actual v1.ResourceList) bool {
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		if !resourceEqual(expected, actual, name) {
+			return false
+		}
+	}
+	return true
+}
+
+func resourceEqual(expected, actual v1.ResourceList, name v1.ResourceName) bool {
+	e, eok := expected[name]
+	a, aok := actual[name]
+	return eok == aok && (!eok || e.Cmp(a) == 0)
+}
+
 type hashType string
 
 const (
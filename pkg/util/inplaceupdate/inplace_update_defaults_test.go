@@ -26,12 +26,14 @@ import (
 	appspub "github.com/openkruise/kruise/apis/apps/pub"
 	"github.com/openkruise/kruise/pkg/features"
 	"github.com/openkruise/kruise/pkg/util"
+	utilcontainerlaunchpriority "github.com/openkruise/kruise/pkg/util/containerlaunchpriority"
 	utilfeature "github.com/openkruise/kruise/pkg/util/feature"
 	"github.com/openkruise/kruise/pkg/util/volumeclaimtemplate"
 
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
@@ -920,3 +922,245 @@ func Test_defaultCalculateInPlaceUpdateSpec_VCTHash(t *testing.T) {
 	testWhenEnable(false)
 
 }
+
+func Test_defaultCalculateInPlaceUpdateSpec_Resources(t *testing.T) {
+	oldRevision := &apps.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: "old-revision"},
+		Data:       runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"c1","image":"foo1","resources":{"limits":{"cpu":"1","memory":"1Gi"}}}]}}}}`)},
+	}
+	cases := []struct {
+		name            string
+		newRevision     *apps.ControllerRevision
+		want            *UpdateSpec
+		wantWhenDisable *UpdateSpec
+	}{
+		{
+			name: "resize resources",
+			newRevision: &apps.ControllerRevision{
+				ObjectMeta: metav1.ObjectMeta{Name: "new-revision"},
+				Data:       runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"c1","image":"foo1","resources":{"limits":{"cpu":"2","memory":"1Gi"},"requests":{"cpu":"1"}}}]}}}}`)},
+			},
+			want: &UpdateSpec{
+				Revision:             "new-revision",
+				ContainerImages:      map[string]string{},
+				ContainerRefMetadata: map[string]metav1.ObjectMeta{},
+				ContainerResources: map[string]v1.ResourceRequirements{
+					"c1": {
+						Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceMemory: resource.MustParse("1Gi")},
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+					},
+				},
+			},
+		},
+		{
+			name: "resize resources and update image",
+			newRevision: &apps.ControllerRevision{
+				ObjectMeta: metav1.ObjectMeta{Name: "new-revision"},
+				Data:       runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"c1","image":"foo2","resources":{"limits":{"cpu":"1","memory":"2Gi"}}}]}}}}`)},
+			},
+			want: &UpdateSpec{
+				Revision:             "new-revision",
+				ContainerImages:      map[string]string{"c1": "foo2"},
+				ContainerRefMetadata: map[string]metav1.ObjectMeta{},
+				ContainerResources: map[string]v1.ResourceRequirements{
+					"c1": {Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("2Gi")}},
+				},
+			},
+		},
+		{
+			name: "change resize policy",
+			newRevision: &apps.ControllerRevision{
+				ObjectMeta: metav1.ObjectMeta{Name: "new-revision"},
+				Data:       runtime.RawExtension{Raw: []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"c1","image":"foo1","resources":{"limits":{"cpu":"2","memory":"1Gi"}},"resizePolicy":[{"resourceName":"cpu","restartPolicy":"RestartContainer"}]}]}}}}`)},
+			},
+		},
+	}
+
+	for _, enable := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enable-%v", enable), func(t *testing.T) {
+			defer utilfeature.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.InPlaceWorkloadVerticalScaling, enable)()
+			for _, tc := range cases {
+				t.Run(tc.name, func(t *testing.T) {
+					want := tc.want
+					if !enable {
+						want = tc.wantWhenDisable
+					}
+					got := defaultCalculateInPlaceUpdateSpec(oldRevision, tc.newRevision, nil)
+					if !apiequality.Semantic.DeepEqual(got, want) {
+						t.Fatalf("expected %v, got %v", util.DumpJSON(want), util.DumpJSON(got))
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestDefaultPatchUpdateSpecToPod_Resources(t *testing.T) {
+	now := time.Now()
+	Clock = testingclock.NewFakeClock(now)
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Name: "c1", Image: "foo1", Env: []v1.EnvVar{utilcontainerlaunchpriority.GeneratePriorityEnv(5, "p")},
+					Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}},
+				{Name: "c2", Image: "foo1", Env: []v1.EnvVar{utilcontainerlaunchpriority.GeneratePriorityEnv(10, "p")},
+					Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}},
+			},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{{Name: "c1"}, {Name: "c2", RestartCount: 3}},
+		},
+	}
+	spec := &UpdateSpec{
+		ContainerResources: map[string]v1.ResourceRequirements{
+			"c1": {Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}},
+			"c2": {Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}},
+		},
+	}
+	state := &appspub.InPlaceUpdateState{}
+
+	gotPod, err := defaultPatchUpdateSpecToPod(pod.DeepCopy(), spec, state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q := gotPod.Spec.Containers[1].Resources.Limits[v1.ResourceCPU]; q.Cmp(resource.MustParse("2")) != 0 {
+		t.Fatalf("expected c2 with higher priority resized first, got %v", q.String())
+	}
+	if q := gotPod.Spec.Containers[0].Resources.Limits[v1.ResourceCPU]; q.Cmp(resource.MustParse("1")) != 0 {
+		t.Fatalf("expected c1 not resized in first batch, got %v", q.String())
+	}
+	if !state.UpdateResources {
+		t.Fatalf("expected updateResources in state")
+	}
+	if _, ok := state.NextContainerResources["c1"]; !ok || len(state.NextContainerResources) != 1 {
+		t.Fatalf("expected c1 in next batch, got %v", util.DumpJSON(state.NextContainerResources))
+	}
+	lastStatus, ok := state.LastContainerStatuses["c2"]
+	if !ok || lastStatus.Resources == nil || !apiequality.Semantic.DeepEqual(*lastStatus.Resources, pod.Spec.Containers[1].Resources) {
+		t.Fatalf("expected last resources of c2 recorded, got %v", util.DumpJSON(state.LastContainerStatuses))
+	}
+	if lastStatus.RestartCount != 3 {
+		t.Fatalf("expected last restart count of c2 recorded, got %d", lastStatus.RestartCount)
+	}
+}
+
+func TestCheckContainersResourcesResized(t *testing.T) {
+	state := `{"revision":"new-revision","updateResources":true,"lastContainerStatuses":{"c1":{"resources":{"limits":{"cpu":"1"}}}}}`
+	newPod := func(resize v1.PodResizeStatus, statusResources *v1.ResourceRequirements) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "p",
+				Annotations: map[string]string{appspub.InPlaceUpdateStateKey: state},
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{Name: "c1", Image: "foo1", Resources: v1.ResourceRequirements{
+						Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2"), v1.ResourceEphemeralStorage: resource.MustParse("1Gi")},
+						Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+					}},
+				},
+			},
+			Status: v1.PodStatus{
+				Resize:            resize,
+				ContainerStatuses: []v1.ContainerStatus{{Name: "c1", Image: "foo1", ImageID: "img01", Resources: statusResources}},
+			},
+		}
+	}
+	resized := &v1.ResourceRequirements{
+		Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("2000m")},
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+	}
+	notResized := &v1.ResourceRequirements{
+		Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")},
+	}
+	withResizePolicy := func(pod *v1.Pod, resourceName v1.ResourceName, restartPolicy v1.ResourceResizeRestartPolicy, restartCount int32) *v1.Pod {
+		pod.Spec.Containers[0].ResizePolicy = []v1.ContainerResizePolicy{{ResourceName: resourceName, RestartPolicy: restartPolicy}}
+		pod.Status.ContainerStatuses[0].RestartCount = restartCount
+		return pod
+	}
+
+	cases := []struct {
+		name      string
+		pod       *v1.Pod
+		expectErr bool
+	}{
+		{
+			name: "resized",
+			pod:  newPod("", resized),
+		},
+		{
+			name:      "resize in progress",
+			pod:       newPod(v1.PodResizeStatusInProgress, resized),
+			expectErr: true,
+		},
+		{
+			name:      "resize infeasible",
+			pod:       newPod(v1.PodResizeStatusInfeasible, notResized),
+			expectErr: true,
+		},
+		{
+			name:      "resources not resized",
+			pod:       newPod("", notResized),
+			expectErr: true,
+		},
+		{
+			name:      "resources not reported",
+			pod:       newPod("", nil),
+			expectErr: true,
+		},
+		{
+			name: "resized without restart required",
+			pod:  withResizePolicy(newPod("", resized), v1.ResourceCPU, v1.NotRequired, 0),
+		},
+		{
+			name:      "waiting for container restarted",
+			pod:       withResizePolicy(newPod("", resized), v1.ResourceCPU, v1.RestartContainer, 0),
+			expectErr: true,
+		},
+		{
+			name: "container restarted for resize",
+			pod:  withResizePolicy(newPod("", resized), v1.ResourceCPU, v1.RestartContainer, 1),
+		},
+		{
+			name: "restart required for unchanged resource",
+			pod:  withResizePolicy(newPod("", resized), v1.ResourceMemory, v1.RestartContainer, 0),
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := DefaultCheckInPlaceUpdateCompleted(tc.pod)
+			if tc.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestCheckContainersInPlaceUpdateCompletedNotMutateState(t *testing.T) {
+	pod := &v1.Pod{
+		Spec: v1.PodSpec{Containers: []v1.Container{{Name: "c1", Image: "foo2"}, {Name: "c2", Image: "foo1",
+			Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}}}}},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{
+			{Name: "c1", Image: "foo2", ImageID: "img02"},
+			{Name: "c2", Image: "foo1", ImageID: "img01",
+				Resources: &v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")}}},
+		}},
+	}
+	state := &appspub.InPlaceUpdateState{
+		UpdateResources: true,
+		LastContainerStatuses: map[string]appspub.InPlaceUpdateContainerStatus{
+			"c1": {ImageID: "img01"},
+			"c2": {Resources: &v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceCPU: resource.MustParse("1")}}},
+		},
+	}
+	expected := state.DeepCopy()
+
+	if err := defaultCheckContainersInPlaceUpdateCompleted(pod, state); err != nil {
+		t.Fatalf("expected completed, got %v", err)
+	}
+	if !apiequality.Semantic.DeepEqual(state, expected) {
+		t.Fatalf("expected state not mutated, got %v", util.DumpJSON(state))
+	}
+}
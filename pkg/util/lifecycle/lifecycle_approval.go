@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	"github.com/openkruise/kruise/pkg/util/webhooktarget"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	defaultApprovalTimeoutSeconds = 10
+	// MaxApprovalTimeoutSeconds is the max timeout of each request to the web approval hook
+	MaxApprovalTimeoutSeconds = 30
+	// maxApprovalMessageLength is the max length of response body recorded in the error
+	maxApprovalMessageLength = 256
+	// approvalRetryInterval is the interval to request again after the web approval hook has rejected or failed
+	approvalRetryInterval = 10 * time.Second
+	// approvalResultExpiration is the duration to keep the results not consumed, such as of the deleted Pods
+	approvalResultExpiration = 10 * time.Minute
+)
+
+// UpdateApprovalRequest is the body posted to the web approval hook.
+type UpdateApprovalRequest struct {
+	Namespace string            `json:"namespace"`
+	Name      string            `json:"name"`
+	Revision  string            `json:"revision"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// IsPodUpdateApproved returns whether the pod has been approved to be updated to the revision.
+func IsPodUpdateApproved(pod *v1.Pod, revision string) bool {
+	return pod.Annotations[appspub.LifecycleUpdateApprovedKey] == revision
+}
+
+type approvalKey struct {
+	uid      types.UID
+	revision string
+}
+
+type approvalResult struct {
+	finished   bool
+	finishedAt time.Time
+	approved   bool
+	err        error
+}
+
+// UpdateApprover requests the web approval hooks asynchronously, so that the reconcile of workloads
+// will not be blocked by the slow or unreachable webhooks.
+type UpdateApprover struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	results map[approvalKey]*approvalResult
+}
+
+// NewUpdateApprover returns an UpdateApprover which requests the web approval hooks with the given client.
+func NewUpdateApprover(client *http.Client) *UpdateApprover {
+	return &UpdateApprover{client: client, results: map[approvalKey]*approvalResult{}}
+}
+
+// NewDefaultUpdateApprover returns an UpdateApprover which refuses to request the addresses not allowed.
+func NewDefaultUpdateApprover() *UpdateApprover {
+	return NewUpdateApprover(&http.Client{
+		Transport: webhooktarget.NewRestrictedTransport(),
+		// the redirected targets are not checked by the validation
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	})
+}
+
+// RequestPodUpdateApproval asks the approval hook whether the pod could be updated to the revision. The request is
+// sent in background, and false is returned until the webhook approves it, so the caller should check again later
+// and record the approval on the pod. The rejected or failed request will be retried after approvalRetryInterval,
+// and its error is returned only once. It returns false without error if the hook has no web approval, which means
+// the pod should be approved by an external controller.
+func (a *UpdateApprover) RequestPodUpdateApproval(hook *appspub.LifecycleApprovalHook, pod *v1.Pod, revision string) (bool, error) {
+	if hook == nil || hook.Web == nil {
+		return false, nil
+	}
+	key := approvalKey{uid: pod.UID, revision: revision}
+	now := time.Now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for k, result := range a.results {
+		if result.finished && now.Sub(result.finishedAt) > approvalResultExpiration {
+			delete(a.results, k)
+		}
+	}
+
+	result, ok := a.results[key]
+	if ok && !result.finished {
+		return false, nil
+	}
+	if ok && result.approved {
+		delete(a.results, key)
+		return true, nil
+	}
+	if ok && now.Sub(result.finishedAt) < approvalRetryInterval {
+		err := result.err
+		result.err = nil
+		return false, err
+	}
+
+	result = &approvalResult{}
+	a.results[key] = result
+	body := UpdateApprovalRequest{Namespace: pod.Namespace, Name: pod.Name, Revision: revision, Labels: pod.Labels}
+	web := hook.Web.DeepCopy()
+	go func() {
+		approved, err := a.requestWebApproval(web, body)
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		result.finished, result.finishedAt, result.approved, result.err = true, time.Now(), approved, err
+	}()
+	return false, nil
+}
+
+func (a *UpdateApprover) requestWebApproval(web *appspub.LifecycleWebApproval, approvalRequest UpdateApprovalRequest) (bool, error) {
+	timeoutSeconds := int32(defaultApprovalTimeoutSeconds)
+	if web.TimeoutSeconds != nil {
+		timeoutSeconds = *web.TimeoutSeconds
+	}
+	if timeoutSeconds > MaxApprovalTimeoutSeconds {
+		timeoutSeconds = MaxApprovalTimeoutSeconds
+	}
+
+	body, err := json.Marshal(approvalRequest)
+	if err != nil {
+		return false, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeoutSeconds)*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, web.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to call approval webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices {
+		return true, nil
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxApprovalMessageLength))
+		return false, fmt.Errorf("approval webhook returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return false, nil
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package lifecycle
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// waitForApprovalRequest waits for the request in background to finish.
+func waitForApprovalRequest(t *testing.T, approver *UpdateApprover, pod *v1.Pod, revision string) {
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		approver.mu.Lock()
+		defer approver.mu.Unlock()
+		result, ok := approver.results[approvalKey{uid: pod.UID, revision: revision}]
+		return !ok || result.finished, nil
+	})
+	if err != nil {
+		t.Fatalf("failed to wait for approval request: %v", err)
+	}
+}
+
+func TestRequestPodUpdateApproval(t *testing.T) {
+	cases := []struct {
+		name             string
+		statusCode       int
+		expectedApproved bool
+		expectedErr      bool
+	}{
+		{
+			name:             "approved",
+			statusCode:       http.StatusOK,
+			expectedApproved: true,
+		},
+		{
+			name:       "rejected",
+			statusCode: http.StatusConflict,
+		},
+		{
+			name:        "server error",
+			statusCode:  http.StatusServiceUnavailable,
+			expectedErr: true,
+		},
+	}
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "demo-0", UID: "uid-0", Labels: map[string]string{"role": "replica"}}}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got UpdateApprovalRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+					t.Errorf("failed to decode request: %v", err)
+				}
+				w.WriteHeader(tc.statusCode)
+			}))
+			defer server.Close()
+
+			approver := NewUpdateApprover(server.Client())
+			hook := &appspub.LifecycleApprovalHook{Web: &appspub.LifecycleWebApproval{URL: server.URL}}
+			if approved, err := approver.RequestPodUpdateApproval(hook, pod, "v2"); err != nil || approved {
+				t.Fatalf("expected request sent in background, got %v, %v", approved, err)
+			}
+			waitForApprovalRequest(t, approver, pod, "v2")
+			approved, err := approver.RequestPodUpdateApproval(hook, pod, "v2")
+			if (err != nil) != tc.expectedErr || approved != tc.expectedApproved {
+				t.Fatalf("expected approved %v with error %v, got %v, %v", tc.expectedApproved, tc.expectedErr, approved, err)
+			}
+			if got.Namespace != "default" || got.Name != "demo-0" || got.Revision != "v2" || got.Labels["role"] != "replica" {
+				t.Fatalf("unexpected request %+v", got)
+			}
+			// the rejected result is kept until retry, and the error is returned only once
+			if !tc.expectedApproved {
+				if approved, err := approver.RequestPodUpdateApproval(hook, pod, "v2"); err != nil || approved {
+					t.Fatalf("expected rejected result kept, got %v, %v", approved, err)
+				}
+			}
+		})
+	}
+
+	approver := NewUpdateApprover(http.DefaultClient)
+	if approved, err := approver.RequestPodUpdateApproval(&appspub.LifecycleApprovalHook{}, pod, "v2"); err != nil || approved {
+		t.Fatalf("expected not approved without web approval, got %v, %v", approved, err)
+	}
+	pod.Annotations = map[string]string{appspub.LifecycleUpdateApprovedKey: "v2"}
+	if !IsPodUpdateApproved(pod, "v2") || IsPodUpdateApproved(pod, "v3") {
+		t.Fatalf("expected pod approved only for revision v2")
+	}
+}
+
+func TestDefaultUpdateApproverRestrictsTarget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// the test server listens on the loopback address
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "demo-0", UID: "uid-0"}}
+	approver := NewDefaultUpdateApprover()
+	hook := &appspub.LifecycleApprovalHook{Web: &appspub.LifecycleWebApproval{URL: server.URL}}
+	if approved, err := approver.RequestPodUpdateApproval(hook, pod, "v2"); err != nil || approved {
+		t.Fatalf("expected request sent in background, got %v, %v", approved, err)
+	}
+	waitForApprovalRequest(t, approver, pod, "v2")
+	if approved, err := approver.RequestPodUpdateApproval(hook, pod, "v2"); err == nil || approved {
+		t.Fatalf("expected request to loopback address refused, got %v, %v", approved, err)
+	}
+}
@@ -134,6 +134,10 @@ func ContainsReadinessGate(pod *v1.Pod) bool {
 	return containsReadinessGate(pod, appspub.KruisePodReadyConditionType)
 }
 
+func ContainsNotReadyKey(pod *v1.Pod, msg Message) bool {
+	return alreadyHasKey(pod, msg, appspub.KruisePodReadyConditionType)
+}
+
 func getReadinessCondition(pod *v1.Pod, condType v1.PodConditionType) *v1.PodCondition {
 	if pod == nil {
 		return nil
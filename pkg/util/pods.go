@@ -209,15 +209,18 @@ func IsRunningAndReady(pod *v1.Pod) bool {
 }
 
 func GetPodContainerImageIDs(pod *v1.Pod) map[string]string {
-	cImageIDs := make(map[string]string, len(pod.Status.ContainerStatuses))
-	for i := range pod.Status.ContainerStatuses {
-		c := &pod.Status.ContainerStatuses[i]
-		//ImageID format: docker-pullable://busybox@sha256:a9286defaba7b3a519d585ba0e37d0b2cbee74ebfe590960b0b1d6a5e97d1e1d
-		imageID := c.ImageID
-		if strings.Contains(imageID, "://") {
-			imageID = strings.Split(imageID, "://")[1]
+	cImageIDs := make(map[string]string, len(pod.Status.InitContainerStatuses)+len(pod.Status.ContainerStatuses))
+	// the init containers are included for the native sidecar containers, whose names are unique among all containers
+	for _, statuses := range [][]v1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses} {
+		for i := range statuses {
+			c := &statuses[i]
+			//ImageID format: docker-pullable://busybox@sha256:a9286defaba7b3a519d585ba0e37d0b2cbee74ebfe590960b0b1d6a5e97d1e1d
+			imageID := c.ImageID
+			if strings.Contains(imageID, "://") {
+				imageID = strings.Split(imageID, "://")[1]
+			}
+			cImageIDs[c.Name] = imageID
 		}
-		cImageIDs[c.Name] = imageID
 	}
 	return cImageIDs
 }
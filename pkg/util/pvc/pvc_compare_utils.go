@@ -118,3 +118,33 @@ func IsPVCNeedExpand(claim, template *v1.PersistentVolumeClaim) bool {
 	}
 	return false
 }
+
+const (
+	// PersistentVolumeClaimControllerResizeError and PersistentVolumeClaimNodeResizeError are the conditions set on the
+	// PersistentVolumeClaim when its resize fails in the controller or on the node, which are not defined in the
+	// vendored k8s.io/api yet.
+	PersistentVolumeClaimControllerResizeError v1.PersistentVolumeClaimConditionType = "ControllerResizeError"
+	PersistentVolumeClaimNodeResizeError       v1.PersistentVolumeClaimConditionType = "NodeResizeError"
+)
+
+// GetPVCResizeFailure returns the allocated resource status of the given PersistentVolumeClaim (PVC)
+// if its storage resize has failed in the controller or on the node, which is reported either by the
+// allocated resource statuses or by the resize error conditions.
+func GetPVCResizeFailure(claim *v1.PersistentVolumeClaim) (v1.ClaimResourceStatus, bool) {
+	status := claim.Status.AllocatedResourceStatuses[v1.ResourceStorage]
+	if status == v1.PersistentVolumeClaimControllerResizeFailed || status == v1.PersistentVolumeClaimNodeResizeFailed {
+		return status, true
+	}
+	for _, condition := range claim.Status.Conditions {
+		if condition.Status != v1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case PersistentVolumeClaimControllerResizeError:
+			return v1.PersistentVolumeClaimControllerResizeFailed, true
+		case PersistentVolumeClaimNodeResizeError:
+			return v1.PersistentVolumeClaimNodeResizeFailed, true
+		}
+	}
+	return "", false
+}
@@ -526,3 +526,67 @@ func TestIsPatchPVCCompleted(t *testing.T) {
 		})
 	}
 }
+
+func TestGetPVCResizeFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     v1.ClaimResourceStatus
+		conditions []v1.PersistentVolumeClaimCondition
+		wantStatus v1.ClaimResourceStatus
+		wantFailed bool
+	}{
+		{
+			name: "No resize",
+		},
+		{
+			name:   "Resize in progress",
+			status: v1.PersistentVolumeClaimControllerResizeInProgress,
+		},
+		{
+			name:       "Controller resize failed",
+			status:     v1.PersistentVolumeClaimControllerResizeFailed,
+			wantStatus: v1.PersistentVolumeClaimControllerResizeFailed,
+			wantFailed: true,
+		},
+		{
+			name:       "Node resize failed",
+			status:     v1.PersistentVolumeClaimNodeResizeFailed,
+			wantStatus: v1.PersistentVolumeClaimNodeResizeFailed,
+			wantFailed: true,
+		},
+		{
+			name:       "Controller resize error condition",
+			conditions: []v1.PersistentVolumeClaimCondition{{Type: PersistentVolumeClaimControllerResizeError, Status: v1.ConditionTrue}},
+			wantStatus: v1.PersistentVolumeClaimControllerResizeFailed,
+			wantFailed: true,
+		},
+		{
+			name:       "Node resize error condition",
+			conditions: []v1.PersistentVolumeClaimCondition{{Type: PersistentVolumeClaimNodeResizeError, Status: v1.ConditionTrue}},
+			wantStatus: v1.PersistentVolumeClaimNodeResizeFailed,
+			wantFailed: true,
+		},
+		{
+			name:       "Resize error condition not true",
+			conditions: []v1.PersistentVolumeClaimCondition{{Type: PersistentVolumeClaimNodeResizeError, Status: v1.ConditionFalse}},
+		},
+		{
+			name:       "Resizing condition",
+			conditions: []v1.PersistentVolumeClaimCondition{{Type: v1.PersistentVolumeClaimResizing, Status: v1.ConditionTrue}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := &v1.PersistentVolumeClaim{}
+			if tt.status != "" {
+				claim.Status.AllocatedResourceStatuses = map[v1.ResourceName]v1.ClaimResourceStatus{v1.ResourceStorage: tt.status}
+			}
+			claim.Status.Conditions = tt.conditions
+			gotStatus, gotFailed := GetPVCResizeFailure(claim)
+			if gotStatus != tt.wantStatus || gotFailed != tt.wantFailed {
+				t.Errorf("GetPVCResizeFailure() = %v, %v, want %v, %v", gotStatus, gotFailed, tt.wantStatus, tt.wantFailed)
+			}
+		})
+	}
+}
@@ -31,6 +31,9 @@ import (
 const (
 	// HashAnnotation represents the specs of volumeclaimtemplates hash
 	HashAnnotation = "kruise.io/cloneset-volumeclaimtemplate-hash"
+	// StorageExcludedHashAnnotation represents the specs of volumeclaimtemplates hash excluding the storage requests,
+	// which can be resized onto the existing PVCs online
+	StorageExcludedHashAnnotation = "kruise.io/cloneset-volumeclaimtemplate-hash-excluding-storage"
 )
 
 var (
@@ -77,8 +80,12 @@ func hashVolumeClaimTemplate(templates []v1.PersistentVolumeClaim) uint64 {
 }
 
 func GetVCTemplatesHash(revision *apps.ControllerRevision) (string, bool) {
+	return getHash(revision, HashAnnotation)
+}
+
+func getHash(revision *apps.ControllerRevision, key string) (string, bool) {
 	if len(revision.Annotations) > 0 {
-		val, exist := revision.Annotations[HashAnnotation]
+		val, exist := revision.Annotations[key]
 		return val, exist
 	}
 	return "", false
@@ -92,9 +99,33 @@ func PatchVCTemplateHash(revision *apps.ControllerRevision, vcTemplates []v1.Per
 		// get hash of vct
 		vcTemplateHash := defaultHasher.getExpectHash(vcTemplates)
 		revision.Annotations[HashAnnotation] = strconv.FormatUint(vcTemplateHash, 10)
+		storageExcludedHash := defaultHasher.getExpectHash(withoutStorageRequests(vcTemplates))
+		revision.Annotations[StorageExcludedHashAnnotation] = strconv.FormatUint(storageExcludedHash, 10)
 	} else {
 		revision.Annotations[HashAnnotation] = ""
+		revision.Annotations[StorageExcludedHashAnnotation] = ""
+	}
+}
+
+// withoutStorageRequests returns a copy of the volume claim templates whose storage requests are removed.
+func withoutStorageRequests(templates []v1.PersistentVolumeClaim) []v1.PersistentVolumeClaim {
+	trimmed := make([]v1.PersistentVolumeClaim, len(templates))
+	for i := range templates {
+		templates[i].DeepCopyInto(&trimmed[i])
+		delete(trimmed[i].Spec.Resources.Requests, v1.ResourceStorage)
+	}
+	return trimmed
+}
+
+// CanVCTemplateInplaceUpdateIgnoringStorage is like CanVCTemplateInplaceUpdate, but ignores the changes of
+// storage requests, which are resized onto the existing PVCs online.
+func CanVCTemplateInplaceUpdateIgnoringStorage(oldRevision, newRevision *apps.ControllerRevision) bool {
+	newHash, newExist := getHash(newRevision, StorageExcludedHashAnnotation)
+	oldHash, oldExist := getHash(oldRevision, StorageExcludedHashAnnotation)
+	if !oldExist || !newExist {
+		return CanVCTemplateInplaceUpdate(oldRevision, newRevision)
 	}
+	return newHash == oldHash
 }
 
 // CanVCTemplateInplaceUpdate
@@ -406,3 +406,42 @@ func TestCanVCTemplateInplaceUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestCanVCTemplateInplaceUpdateIgnoringStorage(t *testing.T) {
+	newTemplates := func(storage string) []v1.PersistentVolumeClaim {
+		return []v1.PersistentVolumeClaim{{
+			ObjectMeta: metav1.ObjectMeta{Name: "data"},
+			Spec: v1.PersistentVolumeClaimSpec{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse(storage)}},
+			},
+		}}
+	}
+	newRevision := func(templates []v1.PersistentVolumeClaim) *apps.ControllerRevision {
+		revision := &apps.ControllerRevision{}
+		PatchVCTemplateHash(revision, templates)
+		return revision
+	}
+
+	oldRevision := newRevision(newTemplates("1Gi"))
+	resized := newRevision(newTemplates("2Gi"))
+	if CanVCTemplateInplaceUpdate(oldRevision, resized) {
+		t.Errorf("expected not in-place update with storage changed")
+	}
+	if !CanVCTemplateInplaceUpdateIgnoringStorage(oldRevision, resized) {
+		t.Errorf("expected in-place update ignoring storage changed")
+	}
+
+	renamed := newTemplates("1Gi")
+	renamed[0].Name = "log"
+	if CanVCTemplateInplaceUpdateIgnoringStorage(oldRevision, newRevision(renamed)) {
+		t.Errorf("expected not in-place update with templates changed")
+	}
+
+	// the revisions created before have no hash excluding storage
+	legacy := &apps.ControllerRevision{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{HashAnnotation: oldRevision.Annotations[HashAnnotation]},
+	}}
+	if CanVCTemplateInplaceUpdateIgnoringStorage(legacy, resized) {
+		t.Errorf("expected falling back to the hash with storage")
+	}
+}
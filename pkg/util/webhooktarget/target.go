@@ -0,0 +1,131 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooktarget
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// allowedPrivateNetworks are the private networks that the webhooks configured by workload owners are allowed to access
+var allowedPrivateNetworks cidrList
+
+// sharedAddressSpace is the carrier-grade NAT range defined in RFC 6598, in which some clouds serve their metadata
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+func init() {
+	flag.Var(&allowedPrivateNetworks, "webhook-target-allowed-cidrs", "Comma-separated CIDRs of the private networks "+
+		"that the rollout analysis and lifecycle approval webhooks are allowed to access, such as the service CIDR of the cluster.")
+}
+
+// cidrList is a flag value of comma-separated CIDRs.
+type cidrList []*net.IPNet
+
+func (l *cidrList) String() string {
+	cidrs := make([]string, 0, len(*l))
+	for _, n := range *l {
+		cidrs = append(cidrs, n.String())
+	}
+	return strings.Join(cidrs, ",")
+}
+
+func (l *cidrList) Set(value string) error {
+	var networks cidrList
+	for _, cidr := range strings.Split(value, ",") {
+		if cidr = strings.TrimSpace(cidr); cidr == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return err
+		}
+		networks = append(networks, n)
+	}
+	*l = networks
+	return nil
+}
+
+func (l cidrList) contains(ip net.IP) bool {
+	for _, n := range l {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTargetIP returns an error if the IP is not allowed to be accessed by the webhooks configured by workload owners,
+// such as the rollout analysis and lifecycle approval, such as the loopback and link-local addresses which expose the services of the node
+// and the cloud metadata to them. The private addresses (RFC 1918, RFC 4193 and RFC 6598) are only allowed if they are
+// in the allowed networks.
+func checkTargetIP(ip net.IP) error {
+	switch {
+	case ip.IsLoopback():
+		return fmt.Errorf("loopback address %s is not allowed", ip)
+	case ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast():
+		return fmt.Errorf("link-local address %s is not allowed", ip)
+	case ip.IsUnspecified(), ip.IsMulticast():
+		return fmt.Errorf("address %s is not allowed", ip)
+	case (ip.IsPrivate() || sharedAddressSpace.Contains(ip)) && !allowedPrivateNetworks.contains(ip):
+		return fmt.Errorf("private address %s is not in the allowed networks", ip)
+	}
+	return nil
+}
+
+// CheckHost returns an error if the host of URL is not allowed to be accessed by the webhooks configured by workload owners.
+// The hostnames are checked again after resolved when connecting.
+func CheckHost(host string) error {
+	if host == "" {
+		return fmt.Errorf("host must be set")
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("host %s is not allowed", host)
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return checkTargetIP(ip)
+	}
+	return nil
+}
+
+// NewRestrictedTransport returns an HTTP transport that refuses to connect to the IPs not allowed.
+func NewRestrictedTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return fmt.Errorf("invalid address %s", address)
+			}
+			return checkTargetIP(ip)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// the proxy would connect to the targets without the restriction
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
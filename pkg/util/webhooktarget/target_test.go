@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package webhooktarget
+
+import (
+	"testing"
+)
+
+func TestCheckHost(t *testing.T) {
+	defer func(networks cidrList) { allowedPrivateNetworks = networks }(allowedPrivateNetworks)
+	if err := allowedPrivateNetworks.Set("10.96.0.0/12, fd00:10:96::/112"); err != nil {
+		t.Fatalf("failed to set allowed networks: %v", err)
+	}
+
+	cases := []struct {
+		host        string
+		expectedErr bool
+	}{
+		{host: "prometheus.monitoring"},
+		{host: "10.96.0.10"},
+		{host: "fd00:10:96::a"},
+		{host: "10.0.0.10", expectedErr: true},
+		{host: "172.16.0.1", expectedErr: true},
+		{host: "192.168.1.1", expectedErr: true},
+		{host: "fd00::1", expectedErr: true},
+		{host: "100.100.100.200", expectedErr: true},
+		{host: "localhost", expectedErr: true},
+		{host: "api.localhost", expectedErr: true},
+		{host: "127.0.0.1", expectedErr: true},
+		{host: "::1", expectedErr: true},
+		{host: "169.254.169.254", expectedErr: true},
+		{host: "0.0.0.0", expectedErr: true},
+		{host: "", expectedErr: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.host, func(t *testing.T) {
+			err := CheckHost(tc.host)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestCIDRListSet(t *testing.T) {
+	var l cidrList
+	if err := l.Set("10.96.0.0/12,,192.168.0.0/16"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.String() != "10.96.0.0/12,192.168.0.0/16" {
+		t.Fatalf("unexpected networks %s", l.String())
+	}
+	if err := l.Set("10.96.0.10"); err == nil {
+		t.Fatalf("expected error for invalid CIDR")
+	}
+}
@@ -19,7 +19,9 @@ import (
 
 	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
 	clonesetcore "github.com/openkruise/kruise/pkg/controller/cloneset/core"
+	clonesetutils "github.com/openkruise/kruise/pkg/controller/cloneset/utils"
 	"github.com/openkruise/kruise/pkg/util"
+	"github.com/openkruise/kruise/pkg/util/analysis"
 	webhookutil "github.com/openkruise/kruise/pkg/webhook/util"
 	"github.com/openkruise/kruise/pkg/webhook/util/convertor"
 )
@@ -85,6 +87,20 @@ func (h *CloneSetCreateUpdateHandler) validateCloneSetSpec(spec, oldSpec *appsv1
 		allErrs = append(allErrs, field.Forbidden(fldPath.Child("template", "spec", "activeDeadlineSeconds"), "activeDeadlineSeconds in cloneset is not Supported"))
 	}
 
+	if spec.ProgressDeadlineSeconds != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*spec.ProgressDeadlineSeconds), fldPath.Child("progressDeadlineSeconds"))...)
+		if *spec.ProgressDeadlineSeconds <= spec.MinReadySeconds {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("progressDeadlineSeconds"), *spec.ProgressDeadlineSeconds, "must be greater than minReadySeconds"))
+		}
+	}
+
+	switch spec.VolumeClaimUpdateStrategy.Type {
+	case "", appsv1alpha1.OnDeleteCloneSetVolumeClaimUpdateStrategyType, appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("volumeClaimUpdateStrategy", "type"), spec.VolumeClaimUpdateStrategy.Type,
+			[]string{string(appsv1alpha1.OnDeleteCloneSetVolumeClaimUpdateStrategyType), string(appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType)}))
+	}
+
 	var oldScaleStrategy *appsv1alpha1.CloneSetScaleStrategy
 	if oldSpec != nil {
 		oldScaleStrategy = &oldSpec.ScaleStrategy
@@ -99,6 +115,34 @@ func (h *CloneSetCreateUpdateHandler) validateCloneSetSpec(spec, oldSpec *appsv1
 func (h *CloneSetCreateUpdateHandler) validateScaleStrategy(strategy, oldStrategy *appsv1alpha1.CloneSetScaleStrategy, metadata *metav1.ObjectMeta, fldPath *field.Path) field.ErrorList {
 	allErrs := field.ErrorList{}
 
+	if policy := strategy.ScaleDownPolicy; policy != nil {
+		policyPath := fldPath.Child("scaleDownPolicy")
+		if policy.MaxPodsPerInterval != nil {
+			maxPods, err := intstrutil.GetValueFromIntOrPercent(policy.MaxPodsPerInterval, 100, true)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(policyPath.Child("maxPodsPerInterval"), policy.MaxPodsPerInterval.String(),
+					fmt.Sprintf("failed getValueFromIntOrPercent for maxPodsPerInterval: %v", err)))
+			} else if maxPods <= 0 {
+				allErrs = append(allErrs, field.Invalid(policyPath.Child("maxPodsPerInterval"), policy.MaxPodsPerInterval.String(),
+					"must be greater than 0"))
+			}
+		}
+		if policy.IntervalSeconds != nil && *policy.IntervalSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(policyPath.Child("intervalSeconds"), *policy.IntervalSeconds,
+				"must be greater than 0"))
+		}
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(policy.StabilizationWindowSeconds), policyPath.Child("stabilizationWindowSeconds"))...)
+	}
+
+	switch strategy.InstanceIDPolicy {
+	case "", appsv1alpha1.RandomCloneSetInstanceIDPolicyType, appsv1alpha1.LowestAvailableOrdinalCloneSetInstanceIDPolicyType:
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("instanceIDPolicy"), strategy.InstanceIDPolicy, []string{
+			string(appsv1alpha1.RandomCloneSetInstanceIDPolicyType),
+			string(appsv1alpha1.LowestAvailableOrdinalCloneSetInstanceIDPolicyType),
+		}))
+	}
+
 	if list := util.CheckDuplicate(strategy.PodsToDelete); len(list) > 0 {
 		allErrs = append(allErrs, field.Invalid(fldPath.Child("podsToDelete"), strategy.PodsToDelete, fmt.Sprintf("duplicated items %v", list)))
 		return allErrs
@@ -131,12 +175,14 @@ func (h *CloneSetCreateUpdateHandler) validateUpdateStrategy(strategy *appsv1alp
 	switch strategy.Type {
 	case appsv1alpha1.RecreateCloneSetUpdateStrategyType,
 		appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
-		appsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType:
+		appsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType,
+		appsv1alpha1.BlueGreenCloneSetUpdateStrategyType:
 	default:
-		allErrs = append(allErrs, field.Invalid(fldPath.Child("type"), strategy.Type, fmt.Sprintf("must be '%s', %s or '%s'",
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("type"), strategy.Type, fmt.Sprintf("must be '%s', %s, '%s' or '%s'",
 			appsv1alpha1.RecreateCloneSetUpdateStrategyType,
 			appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
-			appsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType)))
+			appsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType,
+			appsv1alpha1.BlueGreenCloneSetUpdateStrategyType)))
 	}
 
 	partition, err := util.GetScaledValueFromIntOrPercent(strategy.Partition, replicas, true)
@@ -145,6 +191,15 @@ func (h *CloneSetCreateUpdateHandler) validateUpdateStrategy(strategy *appsv1alp
 			fmt.Sprintf("failed getValueFromIntOrPercent for partition: %v", err)))
 	}
 	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(partition), fldPath.Child("partition"))...)
+	if strategy.Type == appsv1alpha1.BlueGreenCloneSetUpdateStrategyType {
+		if partition > 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("partition"), strategy.Partition.String(),
+				"can not use partition with strategy type BlueGreen"))
+		}
+		if len(strategy.Steps) > 0 {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("steps"), "can not use steps with strategy type BlueGreen"))
+		}
+	}
 
 	if err := strategy.PriorityStrategy.FieldsValidation(); err != nil {
 		allErrs = append(allErrs, field.Required(fldPath.Child("priorityStrategy"), err.Error()))
@@ -181,6 +236,52 @@ func (h *CloneSetCreateUpdateHandler) validateUpdateStrategy(strategy *appsv1alp
 			"maxUnavailable and maxSurge should not both be less than 1"))
 	}
 
+	if policy := strategy.RollbackPolicy; policy != nil {
+		rollbackPath := fldPath.Child("rollbackPolicy")
+		if policy.MaxUnavailableUpdated == nil && policy.NotReadyTimeoutSeconds == nil {
+			allErrs = append(allErrs, field.Required(rollbackPath, "at least one of maxUnavailableUpdated and notReadyTimeoutSeconds should be set"))
+		}
+		if policy.MaxUnavailableUpdated != nil {
+			// the same rule as the controller applies, which allows at least one unavailable updated pod
+			if _, err := clonesetutils.GetMaxUnavailableUpdated(policy.MaxUnavailableUpdated, 100); err != nil {
+				allErrs = append(allErrs, field.Invalid(rollbackPath.Child("maxUnavailableUpdated"), policy.MaxUnavailableUpdated.String(),
+					fmt.Sprintf("invalid maxUnavailableUpdated: %v", err)))
+			}
+		}
+		if policy.NotReadyTimeoutSeconds != nil && *policy.NotReadyTimeoutSeconds <= 0 {
+			allErrs = append(allErrs, field.Invalid(rollbackPath.Child("notReadyTimeoutSeconds"), *policy.NotReadyTimeoutSeconds,
+				"must be greater than 0"))
+		}
+	}
+
+	var lastStepReplicas int
+	for i := range strategy.Steps {
+		step := &strategy.Steps[i]
+		stepPath := fldPath.Child("steps").Index(i)
+		stepReplicas, err := util.GetScaledValueFromIntOrPercent(&step.Replicas, replicas, true)
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("replicas"), step.Replicas.String(),
+				fmt.Sprintf("failed getValueFromIntOrPercent for replicas: %v", err)))
+		} else if stepReplicas < lastStepReplicas {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("replicas"), step.Replicas.String(),
+				"replicas of steps should not be decreasing"))
+		} else {
+			lastStepReplicas = stepReplicas
+		}
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(stepReplicas), stepPath.Child("replicas"))...)
+		if step.Pause != nil && step.Pause.Duration != nil {
+			allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*step.Pause.Duration), stepPath.Child("pause", "duration"))...)
+		}
+	}
+	if n := len(strategy.Steps); n > 0 {
+		if last := strategy.Steps[n-1].Replicas; last.Type != intstrutil.String || last.StrVal != "100%" {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("steps").Index(n-1).Child("replicas"), last.String(),
+				"replicas of the last step should be 100%"))
+		}
+	}
+
+	allErrs = append(allErrs, analysis.ValidateRolloutAnalysis(strategy.Analysis, fldPath.Child("analysis"))...)
+
 	return allErrs
 }
 
@@ -193,11 +294,13 @@ func (h *CloneSetCreateUpdateHandler) validateCloneSetUpdate(cloneSet, oldCloneS
 	clone.Spec.ScaleStrategy = oldCloneSet.Spec.ScaleStrategy
 	clone.Spec.UpdateStrategy = oldCloneSet.Spec.UpdateStrategy
 	clone.Spec.MinReadySeconds = oldCloneSet.Spec.MinReadySeconds
+	clone.Spec.ProgressDeadlineSeconds = oldCloneSet.Spec.ProgressDeadlineSeconds
 	clone.Spec.Lifecycle = oldCloneSet.Spec.Lifecycle
 	clone.Spec.RevisionHistoryLimit = oldCloneSet.Spec.RevisionHistoryLimit
 	clone.Spec.VolumeClaimTemplates = oldCloneSet.Spec.VolumeClaimTemplates
+	clone.Spec.VolumeClaimUpdateStrategy = oldCloneSet.Spec.VolumeClaimUpdateStrategy
 	if !apiequality.Semantic.DeepEqual(clone.Spec, oldCloneSet.Spec) {
-		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "updates to cloneset spec for fields other than 'replicas', 'template', 'lifecycle', 'scaleStrategy', 'updateStrategy', 'minReadySeconds', 'volumeClaimTemplates' and 'revisionHistoryLimit' are forbidden"))
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "updates to cloneset spec for fields other than 'replicas', 'template', 'lifecycle', 'scaleStrategy', 'updateStrategy', 'minReadySeconds', 'progressDeadlineSeconds', 'volumeClaimTemplates', 'volumeClaimUpdateStrategy' and 'revisionHistoryLimit' are forbidden"))
 	}
 
 	coreControl := clonesetcore.New(cloneSet)
@@ -243,6 +243,108 @@ func TestValidate(t *testing.T) {
 				},
 			},
 		},
+		{
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas:                &val1,
+				Selector:                &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:                validPodTemplate.Template,
+				ProgressDeadlineSeconds: utilpointer.Int32(600),
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:                            appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:                       util.GetIntOrStrPointer(intstr.FromInt(2)),
+					MaxUnavailable:                  &intOrStr1,
+					PauseOnProgressDeadlineExceeded: true,
+				},
+			},
+		},
+		{
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(2)),
+					MaxUnavailable: &intOrStr1,
+					RollbackPolicy: &appsv1alpha1.CloneSetRollbackPolicy{
+						MaxUnavailableUpdated:  util.GetIntOrStrPointer(intstr.FromString("50%")),
+						NotReadyTimeoutSeconds: utilpointer.Int32(300),
+					},
+				},
+			},
+		},
+		{
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+					Steps: []appsv1alpha1.CloneSetUpdateStep{
+						{Replicas: intstr.FromString("10%"), Pause: &appsv1alpha1.CloneSetUpdateStepPause{}},
+						{Replicas: intstr.FromString("50%"), Pause: &appsv1alpha1.CloneSetUpdateStepPause{Duration: utilpointer.Int32(60)}},
+						{Replicas: intstr.FromString("100%")},
+					},
+				},
+			},
+		},
+		{
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+					Analysis: &appspub.RolloutAnalysis{
+						Metrics: []appspub.AnalysisMetric{
+							{Name: "success-rate", Prometheus: &appspub.PrometheusAnalysisMetric{
+								Address: "http://prometheus.monitoring:9090", Query: "sum(up)", Min: utilpointer.String("0.99")}},
+							{Name: "smoke-test", Web: &appspub.WebAnalysisMetric{URL: "https://checker.default/check"}},
+						},
+						IntervalSeconds: utilpointer.Int32(30),
+					},
+				},
+			},
+		},
+		{
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{
+					ScaleDownPolicy: &appsv1alpha1.CloneSetScaleDownPolicy{
+						MaxPodsPerInterval:         util.GetIntOrStrPointer(intstr.FromString("10%")),
+						IntervalSeconds:            utilpointer.Int32(30),
+						StabilizationWindowSeconds: 300,
+					},
+					InstanceIDPolicy: appsv1alpha1.LowestAvailableOrdinalCloneSetInstanceIDPolicyType,
+				},
+				VolumeClaimUpdateStrategy: appsv1alpha1.CloneSetVolumeClaimUpdateStrategy{
+					Type: appsv1alpha1.OnlineResizeCloneSetVolumeClaimUpdateStrategyType,
+				},
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
+		{
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.BlueGreenCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
 	}
 
 	for i, successCase := range successCases {
@@ -274,6 +376,156 @@ func TestValidate(t *testing.T) {
 	}
 
 	errorCases := map[string]testCase{
+		"invalid-progress-deadline-seconds": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas:                &val1,
+				Selector:                &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:                validPodTemplate.Template,
+				MinReadySeconds:         30,
+				ProgressDeadlineSeconds: utilpointer.Int32(30),
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(2)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
+		"invalid-rollback-policy": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(2)),
+					MaxUnavailable: &intOrStr1,
+					RollbackPolicy: &appsv1alpha1.CloneSetRollbackPolicy{NotReadyTimeoutSeconds: utilpointer.Int32(0)},
+				},
+			},
+		},
+		"invalid-rollback-policy-zero-threshold": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val1,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(2)),
+					MaxUnavailable: &intOrStr1,
+					RollbackPolicy: &appsv1alpha1.CloneSetRollbackPolicy{MaxUnavailableUpdated: util.GetIntOrStrPointer(intstr.FromString("0%"))},
+				},
+			},
+		},
+		"invalid-update-steps": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+					Steps: []appsv1alpha1.CloneSetUpdateStep{
+						{Replicas: intstr.FromString("100%")},
+						{Replicas: intstr.FromInt(1)},
+					},
+				},
+			},
+		},
+		"update-steps-not-ending-with-all-pods": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+					Steps: []appsv1alpha1.CloneSetUpdateStep{
+						{Replicas: intstr.FromString("50%")},
+						{Replicas: intstr.FromInt(2)},
+					},
+				},
+			},
+		},
+		"invalid-analysis": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+					Analysis: &appspub.RolloutAnalysis{
+						Metrics: []appspub.AnalysisMetric{
+							{Name: "success-rate", Prometheus: &appspub.PrometheusAnalysisMetric{
+								Address: "prometheus.monitoring:9090", Query: "sum(up)", Min: utilpointer.String("high")}},
+						},
+					},
+				},
+			},
+		},
+		"invalid-scale-down-policy": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{
+					ScaleDownPolicy: &appsv1alpha1.CloneSetScaleDownPolicy{
+						MaxPodsPerInterval: util.GetIntOrStrPointer(intstr.FromInt(0)),
+						IntervalSeconds:    utilpointer.Int32(0),
+					},
+				},
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
+		"invalid-instance-id-policy": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				ScaleStrategy: appsv1alpha1.CloneSetScaleStrategy{
+					InstanceIDPolicy: "Ordered",
+				},
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
+		"invalid-blue-green-partition": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.BlueGreenCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(1)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
+		"invalid-volume-claim-update-strategy": {
+			spec: &appsv1alpha1.CloneSetSpec{
+				Replicas: &val2,
+				Selector: &metav1.LabelSelector{MatchLabels: validLabels},
+				Template: validPodTemplate.Template,
+				VolumeClaimUpdateStrategy: appsv1alpha1.CloneSetVolumeClaimUpdateStrategy{
+					Type: "OnPodRollingUpdate",
+				},
+				UpdateStrategy: appsv1alpha1.CloneSetUpdateStrategy{
+					Type:           appsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+					Partition:      util.GetIntOrStrPointer(intstr.FromInt(0)),
+					MaxUnavailable: &intOrStr1,
+				},
+			},
+		},
 		"invalid-replicas": {
 			spec: &appsv1alpha1.CloneSetSpec{
 				Replicas: &minus1,
@@ -11,6 +11,7 @@ import (
 	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	appsvalidation "k8s.io/kubernetes/pkg/apis/apps/validation"
@@ -78,12 +79,6 @@ func validateDaemonSetSpec(spec *appsv1alpha1.DaemonSetSpec, fldPath *field.Path
 		// zero is a valid RevisionHistoryLimit
 		allErrs = append(allErrs, corevalidation.ValidateNonnegativeField(int64(*spec.RevisionHistoryLimit), fldPath.Child("revisionHistoryLimit"))...)
 	}
-
-	if spec.Lifecycle != nil {
-		if spec.Lifecycle.InPlaceUpdate != nil {
-			allErrs = append(allErrs, field.Forbidden(fldPath.Child("lifecycle", "inPlaceUpdate"), "inPlaceUpdate hook has not supported yet"))
-		}
-	}
 	return allErrs
 }
 
@@ -127,11 +122,7 @@ func validateRollingUpdateDaemonSet(rollingUpdate *appsv1alpha1.RollingUpdateDae
 	}
 
 	switch rollingUpdate.Type {
-	case "", appsv1alpha1.StandardRollingUpdateType:
-	case appsv1alpha1.InplaceRollingUpdateType:
-		if hasSurge {
-			allErrs = append(allErrs, field.Required(fldPath.Child("maxSurge"), "must be 0 for InPlaceIfPossible type"))
-		}
+	case "", appsv1alpha1.StandardRollingUpdateType, appsv1alpha1.InplaceRollingUpdateType:
 	case appsv1alpha1.DeprecatedSurgingRollingUpdateType:
 		if hasUnavailable {
 			allErrs = append(allErrs, field.Required(fldPath.Child("maxUnavailable"), "must be 0 for Surging type"))
@@ -145,6 +136,71 @@ func validateRollingUpdateDaemonSet(rollingUpdate *appsv1alpha1.RollingUpdateDae
 		allErrs = append(allErrs, corevalidation.ValidateNonnegativeField(int64(*rollingUpdate.Partition), fldPath.Child("rollingUpdate").Child("partition"))...)
 	}
 
+	switch rollingUpdate.SurgeFallbackStrategy {
+	case "", appsv1alpha1.RecreateSurgeFallbackStrategyType, appsv1alpha1.InPlaceIfPossibleSurgeFallbackStrategyType:
+	default:
+		validValues := []string{string(appsv1alpha1.RecreateSurgeFallbackStrategyType), string(appsv1alpha1.InPlaceIfPossibleSurgeFallbackStrategyType)}
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("surgeFallbackStrategy"), rollingUpdate.SurgeFallbackStrategy, validValues))
+	}
+
+	if len(rollingUpdate.Waves) > 0 {
+		if rollingUpdate.Selector != nil {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("selector"), "may not be set together with waves"))
+		}
+		allErrs = append(allErrs, validateUpdateWaves(rollingUpdate.Waves, fldPath.Child("waves"))...)
+	}
+
+	if rollingUpdate.DrainingNodeStrategy != nil {
+		allErrs = append(allErrs, validateDrainingNodeStrategy(rollingUpdate.DrainingNodeStrategy, fldPath.Child("drainingNodeStrategy"))...)
+	}
+
+	if rollingUpdate.PinnedRevisionLabelKey != "" {
+		for _, msg := range validation.IsQualifiedName(rollingUpdate.PinnedRevisionLabelKey) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("pinnedRevisionLabelKey"), rollingUpdate.PinnedRevisionLabelKey, msg))
+		}
+	}
+
+	return allErrs
+}
+
+func validateDrainingNodeStrategy(strategy *appsv1alpha1.DaemonSetDrainingNodeStrategy, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, key := range strategy.TaintKeys {
+		for _, msg := range validation.IsQualifiedName(key) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("taintKeys").Index(i), key, msg))
+		}
+	}
+	if strategy.LabelKey != "" {
+		for _, msg := range validation.IsQualifiedName(strategy.LabelKey) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("labelKey"), strategy.LabelKey, msg))
+		}
+	}
+	return allErrs
+}
+
+func validateUpdateWaves(waves []appsv1alpha1.DaemonSetUpdateWave, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := sets.NewString()
+	for i, wave := range waves {
+		idxPath := fldPath.Index(i)
+		if wave.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), ""))
+		} else if names.Has(wave.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), wave.Name))
+		}
+		names.Insert(wave.Name)
+
+		if wave.Selector == nil {
+			allErrs = append(allErrs, field.Required(idxPath.Child("selector"), ""))
+		} else {
+			allErrs = append(allErrs, metavalidation.ValidateLabelSelector(wave.Selector, metavalidation.LabelSelectorValidationOptions{}, idxPath.Child("selector"))...)
+		}
+		if wave.MaxUnavailable != nil {
+			allErrs = append(allErrs, appsvalidation.ValidatePositiveIntOrPercent(*wave.MaxUnavailable, idxPath.Child("maxUnavailable"))...)
+			allErrs = append(allErrs, appsvalidation.IsNotMoreThan100Percent(*wave.MaxUnavailable, idxPath.Child("maxUnavailable"))...)
+		}
+		allErrs = append(allErrs, corevalidation.ValidateNonnegativeField(int64(wave.SoakSeconds), idxPath.Child("soakSeconds"))...)
+	}
 	return allErrs
 }
 
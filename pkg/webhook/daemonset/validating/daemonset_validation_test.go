@@ -28,6 +28,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/uuid"
 
+	appspub "github.com/openkruise/kruise/apis/apps/pub"
 	appsv1alpha1 "github.com/openkruise/kruise/apis/apps/v1alpha1"
 )
 
@@ -38,6 +39,24 @@ func newDaemonset(name string) *appsv1alpha1.DaemonSet {
 	return ds
 }
 
+func newRollingUpdateDaemonset(waves ...appsv1alpha1.DaemonSetUpdateWave) *appsv1alpha1.DaemonSet {
+	maxUnavailable := intstr.FromInt(1)
+	ds := newDaemonset("ds1")
+	ds.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"key1": "value1"}}
+	ds.Spec.Template = corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"key1": "value1"}},
+		Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "a", Image: "b"}}, RestartPolicy: corev1.RestartPolicyAlways},
+	}
+	ds.Spec.UpdateStrategy = appsv1alpha1.DaemonSetUpdateStrategy{
+		Type: appsv1alpha1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &appsv1alpha1.RollingUpdateDaemonSet{
+			MaxUnavailable: &maxUnavailable,
+			Waves:          waves,
+		},
+	}
+	return ds
+}
+
 func TestValidateDaemonSet(t *testing.T) {
 
 	for _, c := range []struct {
@@ -96,6 +115,98 @@ func TestValidateDaemonSet(t *testing.T) {
 			}(),
 			true,
 		},
+		{
+			"valid update waves",
+			newRollingUpdateDaemonset(
+				appsv1alpha1.DaemonSetUpdateWave{Name: "canary", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "canary"}}, SoakSeconds: 300},
+				appsv1alpha1.DaemonSetUpdateWave{Name: "general", Selector: &metav1.LabelSelector{}},
+			),
+			true,
+		},
+		{
+			"invalid update waves",
+			newRollingUpdateDaemonset(
+				appsv1alpha1.DaemonSetUpdateWave{Name: "canary", Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"pool": "canary"}}, SoakSeconds: -1},
+				appsv1alpha1.DaemonSetUpdateWave{Name: "canary"},
+			),
+			false,
+		},
+		{
+			"valid draining node strategy",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				ds.Spec.UpdateStrategy.RollingUpdate.DrainingNodeStrategy = &appsv1alpha1.DaemonSetDrainingNodeStrategy{
+					TaintKeys: []string{"ToBeDeletedByClusterAutoscaler"},
+					LabelKey:  "example.com/draining",
+				}
+				return ds
+			}(),
+			true,
+		},
+		{
+			"invalid draining node strategy",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				ds.Spec.UpdateStrategy.RollingUpdate.DrainingNodeStrategy = &appsv1alpha1.DaemonSetDrainingNodeStrategy{
+					TaintKeys: []string{"invalid key"},
+				}
+				return ds
+			}(),
+			false,
+		},
+		{
+			"valid surge with in-place update",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				maxUnavailable := intstr.FromInt(0)
+				maxSurge := intstr.FromString("10%")
+				ds.Spec.UpdateStrategy.RollingUpdate.Type = appsv1alpha1.InplaceRollingUpdateType
+				ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable = &maxUnavailable
+				ds.Spec.UpdateStrategy.RollingUpdate.MaxSurge = &maxSurge
+				ds.Spec.UpdateStrategy.RollingUpdate.SurgeFallbackStrategy = appsv1alpha1.InPlaceIfPossibleSurgeFallbackStrategyType
+				return ds
+			}(),
+			true,
+		},
+		{
+			"invalid surge fallback strategy",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				ds.Spec.UpdateStrategy.RollingUpdate.SurgeFallbackStrategy = "Unknown"
+				return ds
+			}(),
+			false,
+		},
+		{
+			"valid pinned revision label key",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				ds.Spec.UpdateStrategy.RollingUpdate.PinnedRevisionLabelKey = "example.com/pinned-revision"
+				return ds
+			}(),
+			true,
+		},
+		{
+			"invalid pinned revision label key",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				ds.Spec.UpdateStrategy.RollingUpdate.PinnedRevisionLabelKey = "invalid key"
+				return ds
+			}(),
+			false,
+		},
+		{
+			"valid lifecycle hooks",
+			func() *appsv1alpha1.DaemonSet {
+				ds := newRollingUpdateDaemonset()
+				ds.Spec.Lifecycle = &appspub.Lifecycle{
+					PreNormal:     &appspub.LifecycleHook{FinalizersHandler: []string{"example.com/pre-normal"}},
+					InPlaceUpdate: &appspub.LifecycleHook{LabelsHandler: map[string]string{"example.com/drained": "true"}, MarkPodNotReady: true},
+				}
+				return ds
+			}(),
+			true,
+		},
 	} {
 		result, _, err := validatingDaemonSetFn(context.TODO(), c.Ds)
 		if !reflect.DeepEqual(c.ExpectAllowResult, result) {
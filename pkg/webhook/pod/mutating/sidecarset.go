@@ -150,11 +150,11 @@ func (h *PodCreateHandler) sidecarsetMutatingPod(ctx context.Context, req admiss
 		"namespace", pod.Namespace, "name", pod.Name)
 	klog.V(4).InfoS("before mutating", "func", "sidecar inject", "pod", klog.KObj(pod))
 	// apply sidecar set info into pod
-	// 1. inject init containers, sort by their name, after the original init containers
+	// 1. inject init containers, sort by their name, at the position relative to the original init containers
 	sort.SliceStable(sidecarInitContainers, func(i, j int) bool {
 		return sidecarInitContainers[i].Name < sidecarInitContainers[j].Name
 	})
-	pod.Spec.InitContainers = mergeSidecarContainers(pod.Spec.InitContainers, sidecarInitContainers)
+	pod.Spec.InitContainers = mergeSidecarInitContainers(pod.Spec.InitContainers, sidecarInitContainers)
 	// 2. inject containers
 	pod.Spec.Containers = mergeSidecarContainers(pod.Spec.Containers, sidecarContainers)
 	// 3. inject volumes
@@ -309,6 +309,38 @@ func mergeSidecarContainers(origins []corev1.Container, injected []*appsv1alpha1
 	return origins
 }
 
+// mergeSidecarInitContainers injects the sidecar init containers right after the pod's own init containers
+// specified by their injectAfterInitContainer, and the others in front of or behind the pod's own init containers
+// according to their podInjectPolicy.
+func mergeSidecarInitContainers(origins []corev1.Container, injected []*appsv1alpha1.SidecarContainer) []corev1.Container {
+	initContainersInPod := sets.NewString()
+	for _, container := range origins {
+		initContainersInPod.Insert(container.Name)
+	}
+	//format: the name of pod's init container -> the sidecar init containers injected after it
+	afterInitContainers := make(map[string][]corev1.Container)
+	var others []*appsv1alpha1.SidecarContainer
+	for _, sidecar := range injected {
+		// sidecar init container already exist in pod is kept in its position
+		if name := sidecar.InjectAfterInitContainer; name != "" && initContainersInPod.Has(name) && !initContainersInPod.Has(sidecar.Name) {
+			afterInitContainers[name] = append(afterInitContainers[name], sidecar.Container)
+			continue
+		}
+		others = append(others, sidecar)
+	}
+
+	merged := mergeSidecarContainers(origins, others)
+	if len(afterInitContainers) == 0 {
+		return merged
+	}
+	initContainers := make([]corev1.Container, 0, len(merged)+len(injected)-len(others))
+	for _, container := range merged {
+		initContainers = append(initContainers, container)
+		initContainers = append(initContainers, afterInitContainers[container.Name]...)
+	}
+	return initContainers
+}
+
 func buildSidecars(isUpdated bool, pod *corev1.Pod, oldPod *corev1.Pod, matchedSidecarSets []sidecarcontrol.SidecarControl) (
 	sidecarContainers, sidecarInitContainers []*appsv1alpha1.SidecarContainer, sidecarSecrets []corev1.LocalObjectReference,
 	volumesInSidecars []corev1.Volume, injectedAnnotations map[string]string, err error) {
@@ -1502,6 +1502,109 @@ func TestInjectInitContainerSort(t *testing.T) {
 			expectContainerLen: 5,
 			expectedContainers: []string{"b-init", "c-init", "app1-init", "app2-init", "a-init"},
 		},
+		{
+			name: "origin init, inject containers(a, b, c) after app1-init",
+			getOrigins: func() []corev1.Container {
+				return []corev1.Container{
+					{
+						Name: "app1-init",
+					},
+					{
+						Name: "app2-init",
+					},
+				}
+			},
+			getInjected: func() []*appsv1alpha1.SidecarContainer {
+				return []*appsv1alpha1.SidecarContainer{
+					{
+						Container: corev1.Container{
+							Name: "a-init",
+						},
+						PodInjectPolicy:          appsv1alpha1.BeforeAppContainerType,
+						InjectAfterInitContainer: "app1-init",
+					},
+					{
+						Container: corev1.Container{
+							Name: "c-init",
+						},
+						PodInjectPolicy:          appsv1alpha1.AfterAppContainerType,
+						InjectAfterInitContainer: "app1-init",
+					},
+					{
+						Container: corev1.Container{
+							Name: "b-init",
+						},
+						PodInjectPolicy: appsv1alpha1.BeforeAppContainerType,
+					},
+				}
+			},
+			expectContainerLen: 5,
+			expectedContainers: []string{"b-init", "app1-init", "a-init", "c-init", "app2-init"},
+		},
+		{
+			name: "origin init, inject containers(a, b) after the init container not in pod",
+			getOrigins: func() []corev1.Container {
+				return []corev1.Container{
+					{
+						Name: "app1-init",
+					},
+				}
+			},
+			getInjected: func() []*appsv1alpha1.SidecarContainer {
+				return []*appsv1alpha1.SidecarContainer{
+					{
+						Container: corev1.Container{
+							Name: "a-init",
+						},
+						PodInjectPolicy:          appsv1alpha1.AfterAppContainerType,
+						InjectAfterInitContainer: "app2-init",
+					},
+					{
+						Container: corev1.Container{
+							Name: "b-init",
+						},
+						PodInjectPolicy:          appsv1alpha1.BeforeAppContainerType,
+						InjectAfterInitContainer: "app2-init",
+					},
+				}
+			},
+			expectContainerLen: 3,
+			expectedContainers: []string{"b-init", "app1-init", "a-init"},
+		},
+		{
+			name: "origin init with sidecar a, inject containers(a, b) after app2-init",
+			getOrigins: func() []corev1.Container {
+				return []corev1.Container{
+					{
+						Name: "a-init",
+					},
+					{
+						Name: "app1-init",
+					},
+					{
+						Name: "app2-init",
+					},
+				}
+			},
+			getInjected: func() []*appsv1alpha1.SidecarContainer {
+				return []*appsv1alpha1.SidecarContainer{
+					{
+						Container: corev1.Container{
+							Name: "a-init",
+						},
+						InjectAfterInitContainer: "app2-init",
+					},
+					{
+						Container: corev1.Container{
+							Name: "b-init",
+						},
+						InjectAfterInitContainer: "app2-init",
+					},
+				}
+			},
+			expectContainerLen: 4,
+			expectedContainers: []string{"a-init", "app1-init", "app2-init", "b-init"},
+		},
 	}
 
 	for _, cs := range cases {
@@ -1511,7 +1614,7 @@ func TestInjectInitContainerSort(t *testing.T) {
 			sort.SliceStable(injected, func(i, j int) bool {
 				return injected[i].Name < injected[j].Name
 			})
-			finals := mergeSidecarContainers(origins, injected)
+			finals := mergeSidecarInitContainers(origins, injected)
 			if len(finals) != cs.expectContainerLen {
 				t.Fatalf("expect %d containers but got %v", cs.expectContainerLen, len(finals))
 			}
@@ -34,6 +34,7 @@ import (
 	genericvalidation "k8s.io/apimachinery/pkg/api/validation"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	metavalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/sets"
 	validationutil "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -115,10 +116,11 @@ func validateSidecarSetName(name string, prefix bool) (allErrs []string) {
 func (h *SidecarSetCreateUpdateHandler) validateSidecarSetSpec(obj *appsv1alpha1.SidecarSet, fldPath *field.Path) field.ErrorList {
 	spec := &obj.Spec
 	allErrs := field.ErrorList{}
-	// currently when initContainer restartPolicy = Always, kruise don't support in-place update
-	for _, c := range obj.Spec.InitContainers {
-		if sidecarcontrol.IsSidecarContainer(c.Container) && obj.Spec.UpdateStrategy.Type == appsv1alpha1.RollingUpdateSidecarSetStrategyType {
-			allErrs = append(allErrs, field.Required(fldPath.Child("updateStrategy"), "The initContainer in-place upgrade is not currently supported."))
+	// only the native sidecar initContainers (restartPolicy = Always) keep running, and can be hot upgraded
+	for i, c := range obj.Spec.InitContainers {
+		if !sidecarcontrol.IsSidecarContainer(c.Container) && c.UpgradeStrategy.UpgradeType == appsv1alpha1.SidecarContainerHotUpgrade {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("initContainers").Index(i).Child("upgradeStrategy").Child("upgradeType"),
+				c.UpgradeStrategy.UpgradeType, "hot upgrade is only supported for the initContainer with restartPolicy Always"))
 		}
 	}
 
@@ -234,6 +236,47 @@ func validateSidecarSetUpdateStrategy(strategy *appsv1alpha1.SidecarSetUpdateStr
 				allErrs = append(allErrs, field.Required(fldPath.Child("scatterStrategy"), err.Error()))
 			}
 		}
+		if len(strategy.Stages) > 0 {
+			if strategy.Selector != nil {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("stages"), "stages and selector are mutually exclusive"))
+			}
+			if strategy.Partition != nil {
+				allErrs = append(allErrs, field.Forbidden(fldPath.Child("stages"), "stages and partition are mutually exclusive"))
+			}
+			allErrs = append(allErrs, validateSidecarSetUpdateStages(strategy.Stages, fldPath.Child("stages"))...)
+		}
+	}
+	return allErrs
+}
+
+func validateSidecarSetUpdateStages(stages []appsv1alpha1.SidecarSetUpdateStage, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	names := sets.NewString()
+	for i, stage := range stages {
+		idxPath := fldPath.Index(i)
+		if stage.Name == "" {
+			allErrs = append(allErrs, field.Required(idxPath.Child("name"), "stage name is required"))
+		} else if names.Has(stage.Name) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("name"), stage.Name))
+		}
+		names.Insert(stage.Name)
+		if len(stage.Namespaces) == 0 && stage.NamespaceSelector == nil && len(stage.Workloads) == 0 {
+			allErrs = append(allErrs, field.Required(idxPath, "one of namespaces, namespaceSelector and workloads is required"))
+		}
+		if stage.NamespaceSelector != nil {
+			allErrs = append(allErrs, validateSelector(stage.NamespaceSelector, idxPath.Child("namespaceSelector"))...)
+		}
+		for j, workload := range stage.Workloads {
+			workloadPath := idxPath.Child("workloads").Index(j)
+			if workload.APIVersion == "" || workload.Kind == "" || workload.Namespace == "" || workload.Name == "" {
+				allErrs = append(allErrs, field.Required(workloadPath, "apiVersion, kind, namespace and name of workload are required"))
+			} else if _, err := schema.ParseGroupVersion(workload.APIVersion); err != nil {
+				allErrs = append(allErrs, field.Invalid(workloadPath.Child("apiVersion"), workload.APIVersion, err.Error()))
+			}
+		}
+		if stage.SoakSeconds < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("soakSeconds"), stage.SoakSeconds, "soakSeconds must be non-negative"))
+		}
 	}
 	return allErrs
 }
@@ -246,6 +289,9 @@ func validateContainersForSidecarSet(
 	//validating initContainer
 	var coreInitContainers []core.Container
 	for _, container := range initContainers {
+		if container.PodInjectPolicy != "" && container.PodInjectPolicy != appsv1alpha1.BeforeAppContainerType && container.PodInjectPolicy != appsv1alpha1.AfterAppContainerType {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("initContainer").Child("podInjectPolicy"), container.PodInjectPolicy, "unsupported pod inject policy"))
+		}
 		coreContainer := core.Container{}
 		if err := corev1.Convert_v1_Container_To_core_Container(&container.Container, &coreContainer, nil); err != nil {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("initContainer"), container.Container, fmt.Sprintf("Convert_v1_Container_To_core_Container failed: %v", err)))
@@ -261,6 +307,9 @@ func validateContainersForSidecarSet(
 		if container.PodInjectPolicy != appsv1alpha1.BeforeAppContainerType && container.PodInjectPolicy != appsv1alpha1.AfterAppContainerType {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("container").Child("podInjectPolicy"), container.PodInjectPolicy, "unsupported pod inject policy"))
 		}
+		if container.InjectAfterInitContainer != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("container").Child("injectAfterInitContainer"), "only takes effect in initContainers"))
+		}
 		if container.ShareVolumePolicy.Type != appsv1alpha1.ShareVolumePolicyEnabled && container.ShareVolumePolicy.Type != appsv1alpha1.ShareVolumePolicyDisabled {
 			allErrs = append(allErrs, field.Invalid(fldPath.Child("container").Child("shareVolumePolicy"), container.ShareVolumePolicy, "unsupported share volume policy"))
 		}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
+	"net/url"
 	"regexp"
 
 	"github.com/appscode/jsonpatch"
@@ -22,13 +24,23 @@ import (
 
 	appspub "github.com/openkruise/kruise/apis/apps/pub"
 	appsv1beta1 "github.com/openkruise/kruise/apis/apps/v1beta1"
+	"github.com/openkruise/kruise/pkg/util/analysis"
+	"github.com/openkruise/kruise/pkg/util/lifecycle"
 	"github.com/openkruise/kruise/pkg/util/pvc"
+	"github.com/openkruise/kruise/pkg/util/webhooktarget"
 	webhookutil "github.com/openkruise/kruise/pkg/webhook/util"
 	"github.com/openkruise/kruise/pkg/webhook/util/convertor"
 )
 
 var inPlaceUpdateTemplateSpecPatchRexp = regexp.MustCompile("/containers/([0-9]+)/image")
 
+// maxOrdinalStatusLimit bounds the size of status.ordinalStatuses.
+const maxOrdinalStatusLimit = 500
+
+// maxOrdinalsSpan bounds the number of ordinals from the first to the last one that spec.ordinals allows,
+// so that the controller will not allocate for a huge range of ordinals.
+const maxOrdinalsSpan = 100000
+
 func validatePodManagementPolicy(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -59,6 +71,126 @@ func validateReserveOrdinals(spec *appsv1beta1.StatefulSetSpec, fldPath *field.P
 	return allErrs
 }
 
+func validateMaintenanceOrdinals(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	ordinals := sets.NewInt()
+	for i, maintenance := range spec.MaintenanceOrdinals {
+		idxPath := fldPath.Child("maintenanceOrdinals").Index(i)
+		if maintenance.Ordinal < 0 {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("ordinal"), maintenance.Ordinal, "must be greater than or equal to 0"))
+		} else if ordinals.Has(int(maintenance.Ordinal)) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("ordinal"), maintenance.Ordinal))
+		}
+		ordinals.Insert(int(maintenance.Ordinal))
+
+		switch maintenance.PodPolicy {
+		case "", appsv1beta1.KeepMaintenancePodPolicyType, appsv1beta1.DeleteMaintenancePodPolicyType:
+		default:
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("podPolicy"), maintenance.PodPolicy,
+				[]string{string(appsv1beta1.KeepMaintenancePodPolicyType), string(appsv1beta1.DeleteMaintenancePodPolicyType)}))
+		}
+	}
+	return allErrs
+}
+
+func validateOrdinalStatusLimit(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.OrdinalStatusLimit == nil {
+		return allErrs
+	}
+	limitPath := fldPath.Child("ordinalStatusLimit")
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*spec.OrdinalStatusLimit), limitPath)...)
+	if *spec.OrdinalStatusLimit > maxOrdinalStatusLimit {
+		allErrs = append(allErrs, field.Invalid(limitPath, *spec.OrdinalStatusLimit, fmt.Sprintf("must be less than or equal to %d", maxOrdinalStatusLimit)))
+	}
+	return allErrs
+}
+
+func validatePreUpdateApproval(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.PreUpdateApproval == nil || spec.PreUpdateApproval.Web == nil {
+		return allErrs
+	}
+	web := spec.PreUpdateApproval.Web
+	webPath := fldPath.Child("preUpdateApproval", "web")
+	if web.URL == "" {
+		allErrs = append(allErrs, field.Required(webPath.Child("url"), ""))
+	} else if u, err := url.Parse(web.URL); err != nil {
+		allErrs = append(allErrs, field.Invalid(webPath.Child("url"), web.URL, err.Error()))
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		allErrs = append(allErrs, field.Invalid(webPath.Child("url"), web.URL, "scheme must be http or https"))
+	} else if err := webhooktarget.CheckHost(u.Hostname()); err != nil {
+		allErrs = append(allErrs, field.Invalid(webPath.Child("url"), web.URL, err.Error()))
+	}
+	if web.TimeoutSeconds != nil && (*web.TimeoutSeconds <= 0 || *web.TimeoutSeconds > lifecycle.MaxApprovalTimeoutSeconds) {
+		allErrs = append(allErrs, field.Invalid(webPath.Child("timeoutSeconds"), *web.TimeoutSeconds,
+			fmt.Sprintf("must be greater than 0 and less than or equal to %d", lifecycle.MaxApprovalTimeoutSeconds)))
+	}
+	return allErrs
+}
+
+func validateOrdinals(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if spec.Ordinals == nil {
+		return allErrs
+	}
+	ordinalsPath := fldPath.Child("ordinals")
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(spec.Ordinals.Start), ordinalsPath.Child("start"))...)
+
+	// available is the number of ordinals this StatefulSet may own and not reserved, or -1 if the ordinals are unbounded
+	available := int64(-1)
+	if len(spec.Ordinals.List) > 0 {
+		if spec.Ordinals.Start != 0 || spec.Ordinals.End != nil {
+			allErrs = append(allErrs, field.Forbidden(ordinalsPath.Child("list"), "list can not be set together with start or end"))
+		}
+		if len(spec.Ordinals.List) > maxOrdinalsSpan {
+			allErrs = append(allErrs, field.TooMany(ordinalsPath.Child("list"), len(spec.Ordinals.List), maxOrdinalsSpan))
+			return allErrs
+		}
+		owned := sets.NewInt()
+		for i, ordinal := range spec.Ordinals.List {
+			if ordinal < 0 {
+				allErrs = append(allErrs, field.Invalid(ordinalsPath.Child("list").Index(i), ordinal, "must be greater than or equal to 0"))
+			} else if owned.Has(int(ordinal)) {
+				allErrs = append(allErrs, field.Duplicate(ordinalsPath.Child("list").Index(i), ordinal))
+			}
+			owned.Insert(int(ordinal))
+		}
+		if listed := owned.List(); int64(listed[len(listed)-1])-int64(listed[0]) >= maxOrdinalsSpan {
+			allErrs = append(allErrs, field.Invalid(ordinalsPath.Child("list"), spec.Ordinals.List,
+				fmt.Sprintf("must span less than or equal to %d ordinals from the smallest to the largest", maxOrdinalsSpan)))
+		}
+		available = int64(owned.Difference(sets.NewInt(spec.ReserveOrdinals...)).Len())
+	} else if spec.Ordinals.End != nil {
+		start, end := spec.Ordinals.Start, *spec.Ordinals.End
+		if end < start {
+			allErrs = append(allErrs, field.Invalid(ordinalsPath.Child("end"), end, "must be greater than or equal to start"))
+		} else if end == math.MaxInt32 {
+			// math.MaxInt32 is taken as unbounded by the controller
+			allErrs = append(allErrs, field.Invalid(ordinalsPath.Child("end"), end, fmt.Sprintf("must be less than %d", math.MaxInt32)))
+		} else if int64(end)-int64(start) >= maxOrdinalsSpan {
+			allErrs = append(allErrs, field.Invalid(ordinalsPath.Child("end"), end,
+				fmt.Sprintf("must span less than or equal to %d ordinals from start", maxOrdinalsSpan)))
+		} else {
+			// count the ordinals in range arithmetically rather than enumerating them, as the range may be huge
+			available = int64(end) - int64(start) + 1
+			for _, ordinal := range sets.NewInt(spec.ReserveOrdinals...).UnsortedList() {
+				if ordinal >= int(start) && ordinal <= int(end) {
+					available--
+				}
+			}
+		}
+	}
+
+	if available >= 0 && spec.Replicas != nil && int64(*spec.Replicas) > available {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("replicas"), *spec.Replicas,
+			fmt.Sprintf("must be less than or equal to the number of ordinals owned and not reserved, which is %d", available)))
+	}
+	return allErrs
+}
+
 func validateScaleStrategy(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 
@@ -81,11 +213,52 @@ func ValidatePersistentVolumeClaimRetentionPolicyType(policy appsv1beta1.Persist
 	return allErrs
 }
 
+// validateWhenScaledRetentionPolicyType validates the policy on scale-down, which also supports Snapshot.
+func validateWhenScaledRetentionPolicyType(policy appsv1beta1.PersistentVolumeClaimRetentionPolicyType, fldPath *field.Path) field.ErrorList {
+	if policy == appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType {
+		return nil
+	}
+	return ValidatePersistentVolumeClaimRetentionPolicyType(policy, fldPath)
+}
+
 func ValidatePersistentVolumeClaimRetentionPolicy(policy *appsv1beta1.StatefulSetPersistentVolumeClaimRetentionPolicy, fldPath *field.Path) field.ErrorList {
 	var allErrs field.ErrorList
 	if policy != nil {
 		allErrs = append(allErrs, ValidatePersistentVolumeClaimRetentionPolicyType(policy.WhenDeleted, fldPath.Child("whenDeleted"))...)
-		allErrs = append(allErrs, ValidatePersistentVolumeClaimRetentionPolicyType(policy.WhenScaled, fldPath.Child("whenScaled"))...)
+		allErrs = append(allErrs, validateWhenScaledRetentionPolicyType(policy.WhenScaled, fldPath.Child("whenScaled"))...)
+		for i, templatePolicy := range policy.Templates {
+			idxPath := fldPath.Child("templates").Index(i)
+			if templatePolicy.WhenDeleted != "" {
+				allErrs = append(allErrs, ValidatePersistentVolumeClaimRetentionPolicyType(templatePolicy.WhenDeleted, idxPath.Child("whenDeleted"))...)
+			}
+			if templatePolicy.WhenScaled != "" {
+				allErrs = append(allErrs, validateWhenScaledRetentionPolicyType(templatePolicy.WhenScaled, idxPath.Child("whenScaled"))...)
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateClaimTemplateRetentionPolicies checks that the retention policies of templates refer to the existing
+// VolumeClaimTemplates without duplicates.
+func validateClaimTemplateRetentionPolicies(spec *appsv1beta1.StatefulSetSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.PersistentVolumeClaimRetentionPolicy == nil {
+		return allErrs
+	}
+	templateNames := sets.NewString()
+	for i := range spec.VolumeClaimTemplates {
+		templateNames.Insert(spec.VolumeClaimTemplates[i].Name)
+	}
+	names := sets.NewString()
+	for i, templatePolicy := range spec.PersistentVolumeClaimRetentionPolicy.Templates {
+		namePath := fldPath.Child("persistentVolumeClaimRetentionPolicy", "templates").Index(i).Child("name")
+		if names.Has(templatePolicy.Name) {
+			allErrs = append(allErrs, field.Duplicate(namePath, templatePolicy.Name))
+		} else if !templateNames.Has(templatePolicy.Name) {
+			allErrs = append(allErrs, field.NotFound(namePath, templatePolicy.Name))
+		}
+		names.Insert(templatePolicy.Name)
 	}
 	return allErrs
 }
@@ -118,6 +291,26 @@ func validateRollingUpdateStatefulSetStrategyTypeUnorderedUpdate(spec *appsv1bet
 				Child("rollingUpdate").Child("unorderedUpdate").Child("priorityStrategy"),
 				err.Error()))
 		}
+		if rolePriority := spec.UpdateStrategy.RollingUpdate.UnorderedUpdate.RolePriority; rolePriority != nil {
+			rolePriorityPath := fldPath.Child("updateStrategy").Child("rollingUpdate").Child("unorderedUpdate").Child("rolePriority")
+			if rolePriority.RoleKey == "" {
+				allErrs = append(allErrs, field.Required(rolePriorityPath.Child("roleKey"), "roleKey is required for rolePriority"))
+			} else {
+				allErrs = append(allErrs, unversionedvalidation.ValidateLabelName(rolePriority.RoleKey, rolePriorityPath.Child("roleKey"))...)
+			}
+			if len(rolePriority.LeaderValues) == 0 {
+				allErrs = append(allErrs, field.Required(rolePriorityPath.Child("leaderValues"), "leaderValues is required for rolePriority"))
+			}
+		}
+		if topologyStrategy := spec.UpdateStrategy.RollingUpdate.UnorderedUpdate.TopologyDomainStrategy; topologyStrategy != nil {
+			topologyKeyPath := fldPath.Child("updateStrategy").Child("rollingUpdate").Child("unorderedUpdate").
+				Child("topologyDomainStrategy").Child("topologyKey")
+			if topologyStrategy.TopologyKey == "" {
+				allErrs = append(allErrs, field.Required(topologyKeyPath, "topologyKey is required for topologyDomainStrategy"))
+			} else {
+				allErrs = append(allErrs, unversionedvalidation.ValidateLabelName(topologyStrategy.TopologyKey, topologyKeyPath)...)
+			}
+		}
 	}
 	return allErrs
 }
@@ -154,6 +347,10 @@ func validateRollingUpdateStatefulSetStrategyType(spec *appsv1beta1.StatefulSetS
 		// validate the `spec.UpdateStrategy.RollingUpdate.UnorderedUpdate` related fields
 		allErrs = append(allErrs, validateRollingUpdateStatefulSetStrategyTypeUnorderedUpdate(spec, fldPath)...)
 
+		// validate the `spec.UpdateStrategy.RollingUpdate.Analysis` related fields
+		allErrs = append(allErrs, analysis.ValidateRolloutAnalysis(spec.UpdateStrategy.RollingUpdate.Analysis,
+			fldPath.Child("updateStrategy").Child("rollingUpdate").Child("analysis"))...)
+
 	}
 	return allErrs
 }
@@ -229,9 +426,14 @@ func validateStatefulSetSpec(spec *appsv1beta1.StatefulSetSpec, fldPath *field.P
 
 	allErrs = append(allErrs, validatePodManagementPolicy(spec, fldPath)...)
 	allErrs = append(allErrs, validateReserveOrdinals(spec, fldPath)...)
+	allErrs = append(allErrs, validateOrdinals(spec, fldPath)...)
+	allErrs = append(allErrs, validateMaintenanceOrdinals(spec, fldPath)...)
+	allErrs = append(allErrs, validateOrdinalStatusLimit(spec, fldPath)...)
+	allErrs = append(allErrs, validatePreUpdateApproval(spec, fldPath)...)
 	allErrs = append(allErrs, validateScaleStrategy(spec, fldPath)...)
 	allErrs = append(allErrs, validateUpdateStrategyType(spec, fldPath)...)
 	allErrs = append(allErrs, ValidatePersistentVolumeClaimRetentionPolicy(spec.PersistentVolumeClaimRetentionPolicy, fldPath.Child("persistentVolumeClaimRetentionPolicy"))...)
+	allErrs = append(allErrs, validateClaimTemplateRetentionPolicies(spec, fldPath)...)
 
 	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*spec.Replicas), fldPath.Child("replicas"))...)
 
@@ -331,14 +533,22 @@ func ValidateStatefulSetUpdate(statefulSet, oldStatefulSet *appsv1beta1.Stateful
 	statefulSet.Spec.RevisionHistoryLimit = oldStatefulSet.Spec.RevisionHistoryLimit
 	statefulSet.Spec.Ordinals = oldStatefulSet.Spec.Ordinals
 
+	restoreMaintenanceOrdinals := statefulSet.Spec.MaintenanceOrdinals
+	statefulSet.Spec.MaintenanceOrdinals = oldStatefulSet.Spec.MaintenanceOrdinals
+
+	restoreOrdinalStatusLimit := statefulSet.Spec.OrdinalStatusLimit
+	statefulSet.Spec.OrdinalStatusLimit = oldStatefulSet.Spec.OrdinalStatusLimit
+
 	if !apiequality.Semantic.DeepEqual(statefulSet.Spec, oldStatefulSet.Spec) {
-		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "updates to statefulset spec for fields other than 'replicas', 'ordinals', 'template', 'reserveOrdinals', 'lifecycle', 'revisionHistoryLimit', 'persistentVolumeClaimRetentionPolicy', `volumeClaimTemplates`, `VolumeClaimUpdateStrategy` and 'updateStrategy' are forbidden"))
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"), "updates to statefulset spec for fields other than 'replicas', 'ordinals', 'template', 'reserveOrdinals', 'maintenanceOrdinals', 'ordinalStatusLimit', 'lifecycle', 'revisionHistoryLimit', 'persistentVolumeClaimRetentionPolicy', `volumeClaimTemplates`, `VolumeClaimUpdateStrategy` and 'updateStrategy' are forbidden"))
 	}
 	statefulSet.Spec.Replicas = restoreReplicas
 	statefulSet.Spec.Template = restoreTemplate
 	statefulSet.Spec.UpdateStrategy = restoreStrategy
 	statefulSet.Spec.ScaleStrategy = restoreScaleStrategy
 	statefulSet.Spec.ReserveOrdinals = restoreReserveOrdinals
+	statefulSet.Spec.MaintenanceOrdinals = restoreMaintenanceOrdinals
+	statefulSet.Spec.OrdinalStatusLimit = restoreOrdinalStatusLimit
 	statefulSet.Spec.VolumeClaimTemplates = restorePVCTemplate
 	statefulSet.Spec.PersistentVolumeClaimRetentionPolicy = restorePersistentVolumeClaimRetentionPolicy
 
@@ -387,6 +597,16 @@ func ValidateVolumeClaimTemplateUpdate(c client.Client, sts, oldSts *appsv1beta1
 			return field.ErrorList{field.Forbidden(field.NewPath("spec", templateIdStr, "name"), "volumeClaimTemplate name can not be modified")}
 		}
 
+		if sts.Spec.VolumeClaimUpdateStrategy.Type == appsv1beta1.MigrateVolumeClaimUpdateStrategyType {
+			if err := validateVolumeClaimTemplateMigration(oldTemplate, &template); err != nil {
+				return field.ErrorList{field.Invalid(field.NewPath("spec", templateIdStr), template, err.Error())}
+			}
+			if err := validateVolumeClaimTemplateProvisioner(c, oldTemplate, &template); err != nil {
+				return field.ErrorList{field.Forbidden(field.NewPath("spec", templateIdStr, "spec", "storageClassName"), err.Error())}
+			}
+			continue
+		}
+
 		matched, resizeOnly := pvc.CompareWithCheckFn(oldTemplate, &template, isPVCResize)
 		if matched {
 			continue
@@ -443,6 +663,64 @@ func GetDefaultStorageClass(c client.Client) (*storagev1.StorageClass, error) {
 	return defaultSC, nil
 }
 
+// validateVolumeClaimTemplateMigration tests if only storage class, access modes and storage size are changed,
+// and the storage size is not shrunk because the migrated claim restores the data of the old one.
+func validateVolumeClaimTemplateMigration(oldTemplate, template *v1.PersistentVolumeClaim) error {
+	if template.Spec.Resources.Requests.Storage().Cmp(*oldTemplate.Spec.Resources.Requests.Storage()) < 0 {
+		return fmt.Errorf("volumeClaimTemplate storage requests can not be shrunk when Migrate")
+	}
+	spec := template.Spec.DeepCopy()
+	spec.StorageClassName = oldTemplate.Spec.StorageClassName
+	spec.AccessModes = oldTemplate.Spec.AccessModes
+	spec.Resources = oldTemplate.Spec.Resources
+	if !apiequality.Semantic.DeepEqual(spec, &oldTemplate.Spec) {
+		return fmt.Errorf("volumeClaimTemplate can only modify storageClassName, accessModes and resources when Migrate")
+	}
+	return nil
+}
+
+// validateVolumeClaimTemplateProvisioner tests if the storage class is changed to one of the same provisioner,
+// because a volume snapshot can only be restored by the CSI driver which took it.
+func validateVolumeClaimTemplateProvisioner(c client.Client, oldTemplate, template *v1.PersistentVolumeClaim) error {
+	if apiequality.Semantic.DeepEqual(oldTemplate.Spec.StorageClassName, template.Spec.StorageClassName) {
+		return nil
+	}
+	oldSC, err := getTemplateStorageClass(c, oldTemplate)
+	if err != nil {
+		return err
+	}
+	sc, err := getTemplateStorageClass(c, template)
+	if err != nil {
+		return err
+	}
+	// skip check if there is no default storage class
+	if oldSC == nil || sc == nil {
+		return nil
+	}
+	if oldSC.Provisioner != sc.Provisioner {
+		return fmt.Errorf("storage class %v of provisioner %v can not restore the volumes of provisioner %v when Migrate",
+			sc.Name, sc.Provisioner, oldSC.Provisioner)
+	}
+	return nil
+}
+
+// getTemplateStorageClass returns the storage class of the template, or the default one if not specified.
+func getTemplateStorageClass(c client.Client, template *v1.PersistentVolumeClaim) (*storagev1.StorageClass, error) {
+	scName := template.Spec.StorageClassName
+	if scName == nil {
+		sc, err := GetDefaultStorageClass(c)
+		if err != nil {
+			return nil, fmt.Errorf("can not list storage class")
+		}
+		return sc, nil
+	}
+	sc := &storagev1.StorageClass{}
+	if err := c.Get(context.TODO(), client.ObjectKey{Name: *scName}, sc); err != nil {
+		return nil, fmt.Errorf("can not get sc %v", *scName)
+	}
+	return sc, nil
+}
+
 func isPVCResize(claim, template *v1.PersistentVolumeClaim) bool {
 	if claim.Spec.Resources.Requests.Storage().Cmp(*template.Spec.Resources.Requests.Storage()) != 0 ||
 		claim.Spec.Resources.Limits.Storage().Cmp(*template.Spec.Resources.Limits.Storage()) != 0 {
@@ -17,6 +17,7 @@ limitations under the License.
 package validating
 
 import (
+	"math"
 	"strconv"
 	"strings"
 	"testing"
@@ -145,6 +146,87 @@ func TestValidateStatefulSet(t *testing.T) {
 					}()},
 			},
 		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.OrderedReadyPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{
+					Type: apps.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+						Partition:       &val2,
+						PodUpdatePolicy: appsv1beta1.RecreatePodUpdateStrategyType,
+						MaxUnavailable:  &maxUnavailable1,
+						MinReadySeconds: utilpointer.Int32Ptr(0),
+						Analysis: &appspub.RolloutAnalysis{
+							Metrics: []appspub.AnalysisMetric{
+								{Name: "error-rate", Prometheus: &appspub.PrometheusAnalysisMetric{
+									Address: "http://prometheus.monitoring:9090", Query: "sum(errors)", Max: utilpointer.String("0.05")}},
+							},
+							Count: utilpointer.Int32(3),
+						},
+					},
+				},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val2,
+				ReserveOrdinals:     []int{6},
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{Start: 5, End: utilpointer.Int32(7)},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{List: []int32{9, 2, 4}},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				ReserveOrdinals:     []int{0, 1},
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{Start: 1, End: utilpointer.Int32(maxOrdinalsSpan)},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+					{ObjectMeta: metav1.ObjectMeta{Name: "data"}},
+					{ObjectMeta: metav1.ObjectMeta{Name: "cache"}},
+				},
+				PersistentVolumeClaimRetentionPolicy: &appsv1beta1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+					WhenDeleted: appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType,
+					WhenScaled:  appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType,
+					Templates: []appsv1beta1.VolumeClaimTemplateRetentionPolicy{
+						{Name: "cache", WhenScaled: appsv1beta1.DeletePersistentVolumeClaimRetentionPolicyType},
+					},
+				},
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
 	}
 
 	for i, successCase := range successCases {
@@ -420,6 +502,30 @@ func TestValidateStatefulSet(t *testing.T) {
 				},
 			},
 		},
+		"invalid analysis": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.OrderedReadyPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+						Partition:       &val2,
+						PodUpdatePolicy: appsv1beta1.RecreatePodUpdateStrategyType,
+						MaxUnavailable:  &maxUnavailable1,
+						MinReadySeconds: utilpointer.Int32Ptr(0),
+						Analysis: &appspub.RolloutAnalysis{
+							Metrics: []appspub.AnalysisMetric{
+								{Name: "smoke-test", Web: &appspub.WebAnalysisMetric{URL: "checker.default/check"}},
+								{Name: "smoke-test"},
+							},
+							IntervalSeconds: utilpointer.Int32(0),
+						},
+					},
+				},
+			},
+		},
 		"empty pod management policy": {
 			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
 			Spec: appsv1beta1.StatefulSetSpec{
@@ -440,6 +546,176 @@ func TestValidateStatefulSet(t *testing.T) {
 				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
 			},
 		},
+		"replicas exceed ordinal range": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				ReserveOrdinals:     []int{6},
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{Start: 5, End: utilpointer.Int32(7)},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"replicas exceed ordinal range with reserved ordinals out of range": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				ReserveOrdinals:     []int{4, 6, 8},
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{Start: 5, End: utilpointer.Int32(7)},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"unbounded ordinal end": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val2,
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{End: utilpointer.Int32(math.MaxInt32)},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"invalid ordinal list": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val2,
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{End: utilpointer.Int32(7), List: []int32{3, 3, -1}},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"ordinal end spanning too many ordinals": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val2,
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{Start: 1, End: utilpointer.Int32(math.MaxInt32 - 1)},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"ordinal list spanning too many ordinals": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val2,
+				Ordinals:            &appsv1beta1.StatefulSetOrdinals{List: []int32{2, math.MaxInt32 - 1}},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"invalid maintenance ordinals": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				MaintenanceOrdinals: []appsv1beta1.MaintenanceOrdinal{{Ordinal: 1}, {Ordinal: 1, PodPolicy: "Stop"}},
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"invalid ordinal status limit": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				OrdinalStatusLimit:  utilpointer.Int32Ptr(1000),
+				UpdateStrategy:      appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"invalid pvc retention policy": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy:  apps.ParallelPodManagement,
+				Selector:             &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:             validPodTemplate.Template,
+				Replicas:             &val3,
+				VolumeClaimTemplates: []v1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+				PersistentVolumeClaimRetentionPolicy: &appsv1beta1.StatefulSetPersistentVolumeClaimRetentionPolicy{
+					WhenDeleted: appsv1beta1.SnapshotPersistentVolumeClaimRetentionPolicyType,
+					WhenScaled:  appsv1beta1.RetainPersistentVolumeClaimRetentionPolicyType,
+					Templates: []appsv1beta1.VolumeClaimTemplateRetentionPolicy{
+						{Name: "data", WhenScaled: "Stop"},
+						{Name: "data"},
+						{Name: "cache"},
+					},
+				},
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"invalid pre-update approval": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				PreUpdateApproval: &appspub.LifecycleApprovalHook{
+					Web: &appspub.LifecycleWebApproval{URL: "ftp://approver", TimeoutSeconds: utilpointer.Int32(0)},
+				},
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"pre-update approval to loopback address": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				PreUpdateApproval: &appspub.LifecycleApprovalHook{
+					Web: &appspub.LifecycleWebApproval{URL: "http://127.0.0.1:10250/approve"},
+				},
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"pre-update approval timeout too long": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				PreUpdateApproval: &appspub.LifecycleApprovalHook{
+					Web: &appspub.LifecycleWebApproval{URL: "http://approver.default", TimeoutSeconds: utilpointer.Int32(60)},
+				},
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{Type: apps.RollingUpdateStatefulSetStrategyType},
+			},
+		},
+		"role priority without leader values": {
+			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
+			Spec: appsv1beta1.StatefulSetSpec{
+				PodManagementPolicy: apps.ParallelPodManagement,
+				Selector:            &metav1.LabelSelector{MatchLabels: validLabels},
+				Template:            validPodTemplate.Template,
+				Replicas:            &val3,
+				UpdateStrategy: appsv1beta1.StatefulSetUpdateStrategy{
+					Type: apps.RollingUpdateStatefulSetStrategyType,
+					RollingUpdate: &appsv1beta1.RollingUpdateStatefulSetStrategy{
+						Partition:       &val2,
+						PodUpdatePolicy: appsv1beta1.RecreatePodUpdateStrategyType,
+						MaxUnavailable:  &maxUnavailable1,
+						MinReadySeconds: utilpointer.Int32Ptr(0),
+						UnorderedUpdate: &appsv1beta1.UnorderedUpdateStrategy{
+							RolePriority: &appsv1beta1.UpdatePriorityRoleTerm{RoleKey: "role"},
+						},
+					},
+				},
+			},
+		},
 		"set active deadline seconds": {
 			ObjectMeta: metav1.ObjectMeta{Name: "abc-123", Namespace: metav1.NamespaceDefault},
 			Spec: appsv1beta1.StatefulSetSpec{
@@ -463,12 +739,19 @@ func TestValidateStatefulSet(t *testing.T) {
 			for i := range errs {
 				field := errs[i].Field
 				if !strings.HasPrefix(field, "spec.template.") &&
+					!strings.HasPrefix(field, "spec.updateStrategy.rollingUpdate.analysis.") &&
+					!strings.HasPrefix(field, "spec.ordinals.") &&
+					!strings.HasPrefix(field, "spec.maintenanceOrdinals[") &&
+					!strings.HasPrefix(field, "spec.preUpdateApproval.") &&
+					!strings.HasPrefix(field, "spec.updateStrategy.rollingUpdate.unorderedUpdate.rolePriority.") &&
+					!strings.HasPrefix(field, "spec.persistentVolumeClaimRetentionPolicy.") &&
 					field != "metadata.name" &&
 					field != "metadata.namespace" &&
 					field != "spec.selector" &&
 					field != "spec.template" &&
 					field != "GCEPersistentDisk.ReadOnly" &&
 					field != "spec.replicas" &&
+					field != "spec.ordinalStatusLimit" &&
 					field != "spec.template.labels" &&
 					field != "metadata.annotations" &&
 					field != "metadata.labels" &&
@@ -754,7 +1037,10 @@ func newFakeStorageClass(name string, allowExpansion, isDefault bool) *storagev1
 func TestValidateVolumeClaimTemplateUpdate(t *testing.T) {
 	allowExpandSC := newFakeStorageClass("allowExpand", true, false)
 	disallowExpandSC := newFakeStorageClass("disallowExpand", false, false)
-	fakeClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(allowExpandSC, disallowExpandSC).Build()
+	otherProvisionerSC := newFakeStorageClass("otherProvisioner", true, false)
+	otherProvisionerSC.Provisioner = "other.csi.k8s.io"
+	fakeClient := fake.NewClientBuilder().WithScheme(testScheme).WithObjects(allowExpandSC, disallowExpandSC, otherProvisionerSC).Build()
+	blockVolumeMode := v1.PersistentVolumeBlock
 
 	tests := []struct {
 		name           string
@@ -1046,6 +1332,179 @@ func TestValidateVolumeClaimTemplateUpdate(t *testing.T) {
 			},
 			expectedErrors: true,
 		},
+		{
+			name: "migrate update strategy and change sc and access modes",
+			sts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimUpdateStrategy: appsv1beta1.VolumeClaimUpdateStrategy{
+						Type: appsv1beta1.MigrateVolumeClaimUpdateStrategyType,
+					},
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &allowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteMany},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("2Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			oldSts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &disallowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: false,
+		},
+		{
+			name: "migrate update strategy and shrink storage",
+			sts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimUpdateStrategy: appsv1beta1.VolumeClaimUpdateStrategy{
+						Type: appsv1beta1.MigrateVolumeClaimUpdateStrategyType,
+					},
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &allowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			oldSts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &disallowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("2Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: true,
+		},
+		{
+			name: "migrate update strategy and change volume mode",
+			sts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimUpdateStrategy: appsv1beta1.VolumeClaimUpdateStrategy{
+						Type: appsv1beta1.MigrateVolumeClaimUpdateStrategyType,
+					},
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &allowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("1Gi"),
+									},
+								},
+								VolumeMode: &blockVolumeMode,
+							},
+						},
+					},
+				},
+			},
+			oldSts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &disallowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: true,
+		},
+		{
+			name: "migrate update strategy and change sc to another provisioner",
+			sts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimUpdateStrategy: appsv1beta1.VolumeClaimUpdateStrategy{
+						Type: appsv1beta1.MigrateVolumeClaimUpdateStrategyType,
+					},
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &otherProvisionerSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			oldSts: &appsv1beta1.StatefulSet{
+				Spec: appsv1beta1.StatefulSetSpec{
+					VolumeClaimTemplates: []v1.PersistentVolumeClaim{
+						{
+							ObjectMeta: metav1.ObjectMeta{Name: "pvc-1"},
+							Spec: v1.PersistentVolumeClaimSpec{
+								StorageClassName: &disallowExpandSC.Name,
+								AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+								Resources: v1.ResourceRequirements{
+									Requests: map[v1.ResourceName]resource.Quantity{
+										v1.ResourceStorage: resource.MustParse("1Gi"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedErrors: true,
+		},
 		// Add more test cases here
 	}
 	for _, tt := range tests {